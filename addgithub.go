@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"lamp/internal/config"
+	"lamp/internal/core"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// runAddGithub suggests a github_release catalog entry for repo from its latest
+// release and, once confirmed, appends it to catalogFile (placed beside the
+// config at configPath when it is a bare file name)
+func runAddGithub(cfg *config.Config, configPath, repo, catalogFile string) int {
+	repo = normalizeGithubRepo(repo)
+
+	checker := core.NewChecker(nil, cfg.General.GitHubToken)
+	tag, assets, err := checker.GithubReleaseAssets(repo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to fetch latest release of %s: %v\n", repo, err)
+		return 1
+	}
+
+	fmt.Printf("Latest release of %s: %s\n", repo, tag)
+	for _, a := range assets {
+		fmt.Printf("  %s\n", a)
+	}
+	fmt.Println("")
+
+	imp, err := core.SuggestGithubSource(repo, tag, assets)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not suggest a catalog entry: %v\n", err)
+		return 1
+	}
+
+	targets := make([]string, 0, len(imp.Matched))
+	for t := range imp.Matched {
+		targets = append(targets, t)
+	}
+	sort.Strings(targets)
+	fmt.Println("The suggested asset_pattern selects:")
+	for _, t := range targets {
+		label := t
+		if label == "" {
+			label = "(any)"
+		}
+		fmt.Printf("  %-15s %s\n", label, imp.Matched[t])
+	}
+	if len(imp.Skipped) > 0 {
+		fmt.Printf("Excluded (no matching asset): %s\n", strings.Join(imp.Skipped, ", "))
+	}
+	fmt.Println("")
+
+	entry, err := config.MarshalCatalogEntry(imp.Source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode catalog entry: %v\n", err)
+		return 1
+	}
+	fmt.Print(entry)
+	fmt.Println("")
+
+	if configPath == "" {
+		configPath = config.DefaultConfigPath()
+	}
+	catalogPath := catalogFile
+	if filepath.Base(catalogPath) == catalogPath {
+		catalogPath = filepath.Join(config.CatalogsDir(configPath), catalogPath)
+	}
+
+	fmt.Printf("Append to %s? [y/N] ", catalogPath)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		fmt.Println("Not written.")
+		return 0
+	}
+
+	if err := config.AppendCatalogEntry(catalogPath, imp.Source); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to update %s: %v\n", catalogPath, err)
+		return 1
+	}
+	fmt.Printf("Added '%s'. Reference it from a category in config.yaml with:\n  - id: \"%s\"\n", imp.Source.ID, imp.Source.ID)
+	return 0
+}
+
+// normalizeGithubRepo accepts owner/repo or a github.com URL
+func normalizeGithubRepo(repo string) string {
+	repo = strings.TrimSpace(repo)
+	for _, prefix := range []string{"https://", "http://", "github.com/", "www.github.com/"} {
+		repo = strings.TrimPrefix(repo, prefix)
+	}
+	repo = strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git")
+	if parts := strings.Split(repo, "/"); len(parts) > 2 {
+		repo = parts[0] + "/" + parts[1]
+	}
+	return repo
+}
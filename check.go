@@ -0,0 +1,368 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"lamp/internal/config"
+	"lamp/internal/core"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+)
+
+// checkEntry is a single source's result in --check mode
+type checkEntry struct {
+	Category       string             `json:"category"`
+	Name           string             `json:"name"`
+	Strategy       string             `json:"strategy"`
+	Status         core.VersionStatus `json:"status"`
+	Current        string             `json:"current"`
+	Latest         string             `json:"latest"`
+	ResolvedURL    string             `json:"resolved_url"`
+	Message        string             `json:"message"`
+	Checksum       string             `json:"checksum,omitempty"`
+	ExtraURLs      []string           `json:"extra_urls,omitempty"`
+	LocalFilename  string             `json:"local_filename,omitempty"`
+	RemoteFilename string             `json:"remote_filename,omitempty"`
+	Cached         bool               `json:"cached,omitempty"`
+
+	source config.Source // Expanded source the entry was checked from
+}
+
+// checkFilter restricts which sources are checked. Empty fields match everything;
+// when both are set they must both match.
+type checkFilter struct {
+	Category string // Case-insensitive category name
+	Name     string // Case-insensitive substring of the source name
+}
+
+func (f checkFilter) matches(category string, src config.Source) bool {
+	if f.Category != "" && !strings.EqualFold(f.Category, category) {
+		return false
+	}
+	if f.Name != "" && !strings.Contains(strings.ToLower(src.Name), strings.ToLower(f.Name)) {
+		return false
+	}
+	return true
+}
+
+// runCheck checks every configured source and returns the process exit code
+func runCheck(cfg *config.Config, warnings []string, filter checkFilter, jsonOutput bool, failOn string, fetchSizes, verbose, group bool) int {
+	switch failOn {
+	case "error", "newer", "none":
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid --fail-on value '%s' (expected error, newer or none)\n", failOn)
+		return 2
+	}
+
+	if !jsonOutput {
+		if len(warnings) > 0 {
+			warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11")) // Yellow
+			fmt.Println(warnStyle.Render("Configuration Warning:"))
+			for _, w := range warnings {
+				fmt.Println(warnStyle.Render("- " + w))
+			}
+			fmt.Println("") // Spacer
+		}
+
+		if cfg.General.Offline {
+			fmt.Println("Offline: showing cached statuses from the last online check")
+		}
+		fmt.Println("Checking status of all monitored applications...")
+		fmt.Println("--------------------------------------------------")
+	} else {
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+	}
+
+	progress := newCheckProgress("Checking")
+	entries := collectChecks(cfg, filter, progress.update)
+	progress.clear()
+
+	if !jsonOutput {
+		lines := entries
+		if group {
+			lines = groupCheckEntries(entries)
+		}
+		for _, e := range lines {
+			printCheckEntry(e, verbose)
+		}
+		// Sizes come from HEAD requests, which offline mode does not make
+		printCheckSummary(os.Stdout, summarizeChecks(cfg, entries, fetchSizes && !cfg.General.Offline))
+	} else {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode results: %v\n", err)
+			return 1
+		}
+	}
+
+	notifyEntries(cfg, entries)
+
+	return checkExitCode(entries, failOn)
+}
+
+// notifyEntries runs the configured notify hooks for sources that need downloading
+func notifyEntries(cfg *config.Config, entries []checkEntry) {
+	notifier := core.NewNotifier(cfg.General.Notify)
+	if !notifier.Enabled() {
+		return
+	}
+	for _, e := range entries {
+		err := notifier.Notify(core.Notification{
+			Name:     e.Name,
+			Category: e.Category,
+			Status:   e.Status,
+			Current:  e.Current,
+			Latest:   e.Latest,
+			URL:      e.ResolvedURL,
+			Key:      e.source.Key(),
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Notify [%s] %s: %v\n", e.Category, e.Name, err)
+		}
+	}
+	if err := notifier.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save notification state: %v\n", err)
+	}
+}
+
+// collectChecks runs CheckVersion for every source matching filter using a bounded
+// worker pool, returning results in sorted category/source order.
+// onDone, if non-nil, is called once before the first check and after each source finishes.
+func collectChecks(cfg *config.Config, filter checkFilter, onDone func(done, total int)) []checkEntry {
+	tabs := make([]string, 0, len(cfg.Categories))
+	for name, cat := range cfg.Categories {
+		// Disabled categories are only checked when asked for by name
+		if cat.IsEnabled() || (filter.Category != "" && strings.EqualFold(filter.Category, name)) {
+			tabs = append(tabs, name)
+		}
+	}
+	sort.Strings(tabs)
+
+	type job struct {
+		index    int
+		category string
+		src      config.Source
+	}
+	var jobs []job
+	for _, catName := range tabs {
+		for _, src := range cfg.Categories[catName].Sources {
+			if src.IsEnabled() && filter.matches(catName, src) {
+				jobs = append(jobs, job{index: len(jobs), category: catName, src: src})
+			}
+		}
+	}
+
+	entries := make([]checkEntry, len(jobs))
+	checker := core.NewChecker(nil, cfg.General.GitHubToken)
+
+	workers := cfg.General.CheckConcurrency
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	var (
+		doneMu sync.Mutex
+		done   int
+	)
+	if onDone != nil {
+		onDone(0, len(jobs))
+	}
+
+	jobChan := make(chan job)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobChan {
+				target := cfg.GetTargetPath(j.category, j.src)
+				result := checker.CheckVersion(j.src, target)
+				// Each worker writes only its own slot, so no locking is needed
+				entries[j.index] = checkEntry{
+					Category:       j.category,
+					Name:           j.src.Name,
+					Strategy:       j.src.Strategy,
+					Status:         result.Status,
+					Current:        result.Current,
+					Latest:         result.Latest,
+					ResolvedURL:    result.ResolvedURL,
+					Message:        result.Message,
+					Checksum:       result.Checksum,
+					ExtraURLs:      result.ExtraURLs,
+					LocalFilename:  result.LocalFilename,
+					RemoteFilename: result.RemoteFilename,
+					Cached:         result.Cached,
+					source:         j.src,
+				}
+				if onDone != nil {
+					doneMu.Lock()
+					done++
+					onDone(done, len(jobs))
+					doneMu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, j := range jobs {
+		jobChan <- j
+	}
+	close(jobChan)
+	wg.Wait()
+
+	return entries
+}
+
+// groupCheckEntries coalesces the OS/arch variants of each source, those sharing a
+// category and catalog ID, into one line when they agree on status and versions.
+// Variants that disagree are all kept so the one that differs stays visible.
+func groupCheckEntries(entries []checkEntry) []checkEntry {
+	var order []string
+	variants := make(map[string][]checkEntry)
+	for _, e := range entries {
+		id := e.source.ID
+		if id == "" {
+			id = baseSourceName(e.Name)
+		}
+		key := e.Category + "\x00" + id
+		if _, ok := variants[key]; !ok {
+			order = append(order, key)
+		}
+		variants[key] = append(variants[key], e)
+	}
+
+	grouped := make([]checkEntry, 0, len(order))
+	for _, key := range order {
+		group := variants[key]
+		if len(group) == 1 || !variantsAgree(group) {
+			grouped = append(grouped, group...)
+			continue
+		}
+		e := group[0]
+		e.Name = fmt.Sprintf("%s [%d targets]", baseSourceName(e.Name), len(group))
+		// The files differ per target, so there is no single file change to show
+		e.LocalFilename, e.RemoteFilename = "", ""
+		for _, v := range group[1:] {
+			e.Cached = e.Cached || v.Cached
+		}
+		grouped = append(grouped, e)
+	}
+	return grouped
+}
+
+// variantsAgree reports whether every variant has the same status and versions
+func variantsAgree(group []checkEntry) bool {
+	for _, v := range group[1:] {
+		if v.Status != group[0].Status || v.Current != group[0].Current || v.Latest != group[0].Latest {
+			return false
+		}
+	}
+	return true
+}
+
+// baseSourceName strips the " [os/arch]" suffix expansion adds to a source name
+func baseSourceName(name string) string {
+	if i := strings.LastIndex(name, " ["); i > 0 && strings.HasSuffix(name, "]") {
+		return name[:i]
+	}
+	return name
+}
+
+// checkProgress writes an in-place "<label> n/total..." line to stderr.
+// It is a no-op unless both stdout and stderr are terminals.
+type checkProgress struct {
+	enabled bool
+	label   string
+}
+
+func newCheckProgress(label string) *checkProgress {
+	return &checkProgress{
+		enabled: term.IsTerminal(os.Stdout.Fd()) && term.IsTerminal(os.Stderr.Fd()),
+		label:   label,
+	}
+}
+
+// update redraws the progress line; callers serialize calls
+func (p *checkProgress) update(done, total int) {
+	if !p.enabled {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\r\033[K%s %d/%d...", p.label, done, total)
+}
+
+// clear erases the progress line so results print from column zero
+func (p *checkProgress) clear() {
+	if !p.enabled {
+		return
+	}
+	fmt.Fprint(os.Stderr, "\r\033[K")
+}
+
+// printCheckEntry prints one result line; verbose adds the local and remote filenames
+func printCheckEntry(e checkEntry, verbose bool) {
+	// Define CLI Styles
+	red := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	yellow := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	green := lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	gray := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+
+	statusStr := string(e.Status)
+	if sym := e.Status.Symbol(); sym != "" {
+		statusStr = sym + " " + statusStr
+	}
+	style := gray // Default
+
+	switch e.Status {
+	case core.StatusUpToDate:
+		statusStr = green.Render(statusStr)
+		style = green
+	case core.StatusNewer:
+		statusStr = yellow.Render(statusStr)
+		style = yellow
+	case core.StatusNotFound, core.StatusIncomplete:
+		statusStr = red.Render(statusStr)
+		style = red
+	case core.StatusError:
+		statusStr = red.Bold(true).Render(statusStr)
+		style = red
+	}
+
+	versionInfo := ""
+	if e.Current != "" && e.Latest != "" {
+		versionInfo = style.Render(fmt.Sprintf(" [%s -> %s]", e.Current, e.Latest))
+	} else if e.Latest != "" {
+		versionInfo = style.Render(fmt.Sprintf(" [Latest: %s]", e.Latest))
+	}
+
+	if e.Cached {
+		statusStr += gray.Render(" (cached)")
+	}
+
+	fmt.Printf("[%s] %s: %s%s\n", e.Category, e.Name, statusStr, versionInfo)
+	if verbose {
+		if files := core.FileChange(e.LocalFilename, e.RemoteFilename); files != "" {
+			fmt.Println(gray.Render("    " + files))
+		}
+	}
+}
+
+// checkExitCode maps the --fail-on policy to an exit code.
+// "newer" fails on errors as well as available updates.
+func checkExitCode(entries []checkEntry, failOn string) int {
+	for _, e := range entries {
+		switch {
+		case e.Status == core.StatusError && failOn != "none":
+			return 1
+		case (e.Status == core.StatusNewer || e.Status == core.StatusIncomplete) && failOn == "newer":
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"lamp/internal/config"
+	"os"
+	"sort"
+	"strings"
+)
+
+// completionShells are the shells -completion can print a script for
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// Flags whose values the completion scripts suggest. Categories and source names are
+// read from the config at completion time by running "lamp -completion category|name".
+var (
+	completionChoices = map[string][]string{
+		"completion":    completionShells,
+		"export-format": {"sh", "aria2"},
+		"fail-on":       {"error", "newer", "none"},
+	}
+	completionFromConfig = map[string]bool{"category": true, "name": true}
+	completionFiles      = map[string]bool{"config": true, "catalog": true}
+)
+
+// isCompletionShell reports whether arg names a shell rather than a word list
+func isCompletionShell(arg string) bool {
+	for _, s := range completionShells {
+		if arg == s {
+			return true
+		}
+	}
+	return false
+}
+
+// runCompletionScript prints the completion script for shell
+func runCompletionScript(shell string) int {
+	flags := completionFlags()
+	switch shell {
+	case "bash":
+		writeBashCompletion(os.Stdout, flags)
+	case "zsh":
+		writeZshCompletion(os.Stdout, flags)
+	case "fish":
+		writeFishCompletion(os.Stdout, flags)
+	}
+	return 0
+}
+
+// runCompletionWords prints the categories or source names of cfg, one per line,
+// for the completion scripts. kind is "category" or "name".
+func runCompletionWords(cfg *config.Config, kind string) int {
+	seen := make(map[string]bool)
+	var words []string
+	for catName, cat := range cfg.Categories {
+		if kind == "category" {
+			words = append(words, catName)
+			continue
+		}
+		for _, src := range cat.Sources {
+			if !seen[src.Name] {
+				seen[src.Name] = true
+				words = append(words, src.Name)
+			}
+		}
+	}
+	sort.Strings(words)
+	for _, w := range words {
+		fmt.Println(w)
+	}
+	return 0
+}
+
+// completionFlag is a command-line flag as the completion scripts see it
+type completionFlag struct {
+	Name    string
+	Usage   string
+	IsBool  bool
+	Choices []string
+}
+
+func completionFlags() []completionFlag {
+	var flags []completionFlag
+	flag.VisitAll(func(f *flag.Flag) {
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{
+			Name:    f.Name,
+			Usage:   f.Usage,
+			IsBool:  ok && b.IsBoolFlag(),
+			Choices: completionChoices[f.Name],
+		})
+	})
+	return flags
+}
+
+func writeBashCompletion(w io.Writer, flags []completionFlag) {
+	var names []string
+	for _, f := range flags {
+		names = append(names, f.Name)
+	}
+
+	fmt.Fprintln(w, "# bash completion for lamp. Load it with: source <(lamp -completion bash)")
+	fmt.Fprintln(w, "_lamp() {")
+	fmt.Fprintln(w, `	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w, "	local config=() i")
+	fmt.Fprintln(w, "	for ((i = 1; i < COMP_CWORD - 1; i++)); do")
+	fmt.Fprintln(w, `		case "${COMP_WORDS[i]}" in -config|--config) config=(-config "${COMP_WORDS[i+1]}") ;; esac`)
+	fmt.Fprintln(w, "	done")
+	fmt.Fprintln(w, `	prev="${prev#-}"; prev="${prev#-}"`)
+	fmt.Fprintln(w, `	case "$prev" in`)
+	for _, f := range flags {
+		switch {
+		case f.Choices != nil:
+			fmt.Fprintf(w, "	%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", f.Name, strings.Join(f.Choices, " "))
+		case completionFromConfig[f.Name]:
+			fmt.Fprintf(w, "	%s)\n", f.Name)
+			fmt.Fprintf(w, "		local IFS=$'\\n' words\n")
+			fmt.Fprintf(w, "		words=$(\"${COMP_WORDS[0]}\" \"${config[@]}\" -completion %s 2>/dev/null)\n", f.Name)
+			fmt.Fprintln(w, `		COMPREPLY=($(compgen -W "$words" -- "$cur"))`)
+			// Quote names with spaces; printf would turn an empty list into ''
+			io.WriteString(w, `		[[ ${#COMPREPLY[@]} -gt 0 ]] && COMPREPLY=($(printf '%q\n' "${COMPREPLY[@]}"))`+"\n")
+			fmt.Fprintln(w, "		return ;;")
+		case completionFiles[f.Name]:
+			fmt.Fprintf(w, "	%s) compopt -o filenames; COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", f.Name)
+		case !f.IsBool:
+			fmt.Fprintf(w, "	%s) return ;;\n", f.Name)
+		}
+	}
+	fmt.Fprintln(w, "	esac")
+	fmt.Fprintln(w, `	local dashes="-"`)
+	fmt.Fprintln(w, `	[[ "$cur" == --* ]] && dashes="--"`)
+	fmt.Fprintf(w, "	COMPREPLY=($(compgen -P \"$dashes\" -W %q -- \"${cur#$dashes}\"))\n", strings.Join(names, " "))
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -F _lamp lamp")
+}
+
+func writeZshCompletion(w io.Writer, flags []completionFlag) {
+	fmt.Fprintln(w, "#compdef lamp")
+	fmt.Fprintln(w, "# zsh completion for lamp. Load it with: source <(lamp -completion zsh)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "_lamp_values() {")
+	fmt.Fprintln(w, "	local -a config values")
+	fmt.Fprintln(w, "	local i")
+	fmt.Fprintln(w, "	for ((i = 2; i < CURRENT - 1; i++)); do")
+	fmt.Fprintln(w, `		[[ ${words[i]} == (-|--)config ]] && config=(-config ${words[i+1]})`)
+	fmt.Fprintln(w, "	done")
+	fmt.Fprintln(w, `	values=("${(@f)$(_call_program values ${words[1]} $config -completion $1 2>/dev/null)}")`)
+	fmt.Fprintln(w, "	compadd -a values")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "_lamp() {")
+	fmt.Fprintln(w, "	_arguments \\")
+	for _, f := range flags {
+		spec := fmt.Sprintf("-%s[%s]", f.Name, zshEscape(f.Usage))
+		switch {
+		case f.Choices != nil:
+			spec += fmt.Sprintf(":%s:(%s)", f.Name, strings.Join(f.Choices, " "))
+		case completionFromConfig[f.Name]:
+			spec += fmt.Sprintf(":%s:_lamp_values %s", f.Name, f.Name)
+		case completionFiles[f.Name]:
+			spec += fmt.Sprintf(":%s:_files", f.Name)
+		case !f.IsBool:
+			spec += fmt.Sprintf(":%s: ", f.Name)
+		}
+		fmt.Fprintf(w, "		'%s' \\\n", strings.ReplaceAll(spec, "'", `'\''`))
+	}
+	fmt.Fprintln(w, "		&& return 0")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `if [ "$funcstack[1]" = "_lamp" ]; then`)
+	fmt.Fprintln(w, `	_lamp "$@"`)
+	fmt.Fprintln(w, "else")
+	fmt.Fprintln(w, "	compdef _lamp lamp")
+	fmt.Fprintln(w, "fi")
+}
+
+// zshEscape protects the characters _arguments treats specially in a description
+func zshEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+func writeFishCompletion(w io.Writer, flags []completionFlag) {
+	fmt.Fprintln(w, "# fish completion for lamp. Load it with: lamp -completion fish | source")
+	fmt.Fprintln(w, "function __lamp_values")
+	fmt.Fprintln(w, "    set -l cmd (commandline -opc)")
+	fmt.Fprintln(w, "    set -l config")
+	fmt.Fprintln(w, "    for i in (seq 2 (math (count $cmd) - 1))")
+	fmt.Fprintln(w, "        if contains -- $cmd[$i] -config --config")
+	fmt.Fprintln(w, "            set config -config $cmd[(math $i + 1)]")
+	fmt.Fprintln(w, "        end")
+	fmt.Fprintln(w, "    end")
+	fmt.Fprintln(w, "    $cmd[1] $config -completion $argv[1] 2>/dev/null")
+	fmt.Fprintln(w, "end")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "complete -c lamp -f")
+	for _, f := range flags {
+		line := fmt.Sprintf("complete -c lamp -o %s -d %s", f.Name, fishQuote(f.Usage))
+		switch {
+		case f.Choices != nil:
+			line += fmt.Sprintf(" -x -a %s", fishQuote(strings.Join(f.Choices, " ")))
+		case completionFromConfig[f.Name]:
+			line += fmt.Sprintf(" -x -a '(__lamp_values %s)'", f.Name)
+		case completionFiles[f.Name]:
+			line += " -r -F"
+		case !f.IsBool:
+			line += " -x"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// fishQuote single-quotes s for fish, where only \ and ' are escaped
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"lamp/internal/config"
+	"os"
+	"sort"
+	"strings"
+)
+
+// runDebugExpand prints every category's sources as LoadConfig expanded them, one
+// entry per OS/arch combination with all template parameters substituted
+func runDebugExpand(cfg *config.Config, warnings []string, filter checkFilter) int {
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	writeExpandedSources(os.Stdout, cfg, filter)
+	return 0
+}
+
+func writeExpandedSources(w io.Writer, cfg *config.Config, filter checkFilter) {
+	fmt.Fprintf(w, "# os: %s  arch: %s\n", strings.Join(cfg.General.OS, ", "), strings.Join(cfg.General.Arch, ", "))
+
+	catNames := make([]string, 0, len(cfg.Categories))
+	for name := range cfg.Categories {
+		catNames = append(catNames, name)
+	}
+	sort.Strings(catNames)
+
+	for _, catName := range catNames {
+		cat := cfg.Categories[catName]
+		var sources []config.Source
+		for _, src := range cat.Sources {
+			if filter.matches(catName, src) {
+				sources = append(sources, src)
+			}
+		}
+		if len(sources) == 0 && (filter.Name != "" || filter.Category != "") {
+			continue
+		}
+
+		header := fmt.Sprintf("\n[%s] %d sources", catName, len(sources))
+		if !cat.IsEnabled() {
+			header += " (disabled)"
+		}
+		fmt.Fprintln(w, header)
+
+		for _, src := range sources {
+			line := "- " + src.Name
+			if !src.IsEnabled() {
+				line += " (disabled)"
+			}
+			fmt.Fprintln(w, line)
+			writeExpandedField(w, "id", src.ID)
+			writeExpandedField(w, "strategy", src.Strategy)
+			writeExpandedField(w, "os", src.OS)
+			writeExpandedField(w, "arch", src.Arch)
+			writeExpandedField(w, "url", src.URL)
+			writeExpandedField(w, "pin_version", src.PinVersion)
+			if len(src.Include) > 0 {
+				writeExpandedField(w, "include", strings.Join(src.Include, ", "))
+			}
+			if len(src.Exclude) > 0 {
+				writeExpandedField(w, "exclude", strings.Join(src.Exclude, ", "))
+			}
+			if src.Auth != nil {
+				writeExpandedField(w, "auth", src.Auth.String())
+			}
+			if len(src.Headers) > 0 {
+				// Only the names, as values such as cookies can be secret
+				names := make([]string, 0, len(src.Headers))
+				for name := range src.Headers {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				writeExpandedField(w, "headers", strings.Join(names, ", "))
+			}
+
+			keys := make([]string, 0, len(src.Params))
+			for k := range src.Params {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			if len(keys) > 0 {
+				fmt.Fprintln(w, "    params:")
+			}
+			for _, k := range keys {
+				fmt.Fprintf(w, "      %s: %q\n", k, src.Params[k])
+			}
+		}
+	}
+}
+
+// writeExpandedField prints one field of an expanded source, skipping empty values
+func writeExpandedField(w io.Writer, name, value string) {
+	if value != "" {
+		fmt.Fprintf(w, "    %s: %s\n", name, value)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"lamp/internal/config"
+	"lamp/internal/core"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// exportItem is a resolved download ready to be written out for another machine
+type exportItem struct {
+	Name     string
+	URL      string
+	Path     string
+	Algo     string // Checksum algorithm (sha256, sha1, md5), empty if unknown
+	Checksum string
+	// GitHubAsset is a private release asset fetched through the GitHub API,
+	// which needs the token and an octet-stream Accept header
+	GitHubAsset bool
+}
+
+// runExport resolves every source and prints a download plan in the given format
+// ("sh" or "aria2") so the files can be fetched on a machine without LAMP
+func runExport(cfg *config.Config, filter checkFilter, format string) int {
+	if format != "sh" && format != "aria2" {
+		fmt.Fprintf(os.Stderr, "Invalid --export-format '%s' (expected sh or aria2)\n", format)
+		return 2
+	}
+
+	var items []exportItem
+	for _, e := range collectChecks(cfg, filter, nil) {
+		item, err := exportItemFor(cfg, e)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping [%s] %s: %v\n", e.Category, e.Name, err)
+			continue
+		}
+		for _, it := range append([]exportItem{item}, companionItems(e, filepath.Dir(item.Path))...) {
+			// aria2 input files can't read the token from the environment, and
+			// writing it out would leave it in the file
+			if it.GitHubAsset && format == "aria2" {
+				fmt.Fprintf(os.Stderr, "Skipping %s: private GitHub release assets need a token, which aria2 input can't take from the environment; use -export-format sh\n", it.Name)
+				continue
+			}
+			items = append(items, it)
+		}
+	}
+
+	if format == "aria2" {
+		writeAria2Input(os.Stdout, items)
+	} else {
+		writeShellScript(os.Stdout, items)
+	}
+	return 0
+}
+
+// exportItemFor picks the URL, local path and checksum for a checked source,
+// naming the file the same way the TUI download does
+func exportItemFor(cfg *config.Config, e checkEntry) (exportItem, error) {
+	src := e.source
+	downloadURL := e.ResolvedURL
+	if downloadURL == "" {
+		downloadURL = src.URL
+	}
+	if downloadURL == "" {
+		if e.Message != "" {
+			return exportItem{}, fmt.Errorf("could not resolve download URL: %s", e.Message)
+		}
+		return exportItem{}, fmt.Errorf("could not resolve download URL")
+	}
+
+	target := cfg.GetTargetPath(e.Category, src)
+	remoteFilename := core.RemoteFilename(downloadURL, nil)
+	if src.StandardizeName {
+		target = filepath.Join(filepath.Dir(target), src.GetStandardizedFilename(e.Latest, path.Ext(remoteFilename)))
+	} else if filepath.Base(target) == src.Name || strings.Contains(filepath.Base(target), "[") {
+		sanitized, err := core.SanitizeFilename(remoteFilename)
+		if err != nil {
+			return exportItem{}, fmt.Errorf("invalid filename from URL: %w", err)
+		}
+		target = filepath.Join(filepath.Dir(target), sanitized)
+	}
+
+	checksum := src.Checksum
+	if checksum == "" {
+		checksum = e.Checksum
+	}
+	algo, sum := splitChecksum(checksum)
+
+	return exportItem{Name: e.Name, URL: downloadURL, Path: target, Algo: algo, Checksum: sum, GitHubAsset: core.IsGitHubAssetURL(downloadURL)}, nil
+}
+
+// companionItems lists the extra release assets of a checked source, saved next
+// to its primary file under their remote names
+func companionItems(e checkEntry, dir string) []exportItem {
+	var items []exportItem
+	for _, u := range e.ExtraURLs {
+		name, err := core.SanitizeFilename(core.RemoteFilename(u, nil))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping companion of [%s] %s: %v\n", e.Category, e.Name, err)
+			continue
+		}
+		items = append(items, exportItem{Name: e.Name + " (" + name + ")", URL: u, Path: filepath.Join(dir, name), GitHubAsset: core.IsGitHubAssetURL(u)})
+	}
+	return items
+}
+
+// splitChecksum separates an "algo:hex" checksum, guessing the algorithm from
+// the length when there is no prefix (as downloader.VerifyFile does)
+func splitChecksum(checksum string) (string, string) {
+	if checksum == "" {
+		return "", ""
+	}
+	if algo, sum, ok := strings.Cut(checksum, ":"); ok {
+		return strings.ToLower(algo), sum
+	}
+	switch len(checksum) {
+	case 32:
+		return "md5", checksum
+	case 40:
+		return "sha1", checksum
+	case 128:
+		return "sha512", checksum
+	}
+	return "sha256", checksum
+}
+
+func writeShellScript(w io.Writer, items []exportItem) {
+	fmt.Fprintln(w, "#!/bin/sh")
+	fmt.Fprintf(w, "# Download plan exported by lamp on %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintln(w, "set -e")
+	for _, it := range items {
+		if it.GitHubAsset {
+			// The token is read when the script runs rather than written into it
+			fmt.Fprintln(w, `: "${GITHUB_TOKEN:?set GITHUB_TOKEN to download private GitHub release assets}"`)
+			break
+		}
+	}
+	for _, it := range items {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "# %s\n", it.Name)
+		fmt.Fprintf(w, "mkdir -p %s\n", shellQuote(filepath.Dir(it.Path)))
+		headers := ""
+		if it.GitHubAsset {
+			// curl doesn't pass Authorization on to the storage host the API redirects to
+			headers = `-H 'Accept: application/octet-stream' -H "Authorization: Bearer $GITHUB_TOKEN" `
+		}
+		fmt.Fprintf(w, "curl -fL --retry 3 -C - %s-o %s %s\n", headers, shellQuote(it.Path), shellQuote(it.URL))
+		if tool := checksumTool(it.Algo); tool != "" {
+			fmt.Fprintf(w, "echo %s | %s -c -\n", shellQuote(it.Checksum+"  "+it.Path), tool)
+		}
+	}
+}
+
+func writeAria2Input(w io.Writer, items []exportItem) {
+	for _, it := range items {
+		fmt.Fprintf(w, "# %s\n", it.Name)
+		fmt.Fprintln(w, it.URL)
+		fmt.Fprintf(w, "  dir=%s\n", filepath.Dir(it.Path))
+		fmt.Fprintf(w, "  out=%s\n", filepath.Base(it.Path))
+		if name := aria2ChecksumName(it.Algo); name != "" {
+			fmt.Fprintf(w, "  checksum=%s=%s\n", name, it.Checksum)
+		}
+	}
+}
+
+func checksumTool(algo string) string {
+	switch algo {
+	case "sha256":
+		return "sha256sum"
+	case "sha512":
+		return "sha512sum"
+	case "blake2b":
+		return "b2sum"
+	case "sha1":
+		return "sha1sum"
+	case "md5":
+		return "md5sum"
+	}
+	return ""
+}
+
+func aria2ChecksumName(algo string) string {
+	switch algo {
+	case "sha256":
+		return "sha-256"
+	case "sha512":
+		return "sha-512"
+	case "sha1":
+		return "sha-1"
+	case "md5":
+		return "md5"
+	}
+	return ""
+}
+
+// shellQuote single-quotes s for POSIX sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"lamp/internal/config"
+	"lamp/internal/core"
+	"os"
+	"text/tabwriter"
+
+	"github.com/dustin/go-humanize"
+)
+
+// historyLimit is how many recent downloads -history shows
+const historyLimit = 50
+
+// runHistory prints the most recent completed downloads matching filter, oldest first
+func runHistory(filter checkFilter) int {
+	entries, err := core.ReadHistory(0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read the download history: %v\n", err)
+		return 1
+	}
+
+	var matched []core.HistoryEntry
+	for _, e := range entries {
+		if filter.matches(e.Category, config.Source{Name: e.Name}) {
+			matched = append(matched, e)
+		}
+	}
+	if len(matched) > historyLimit {
+		matched = matched[len(matched)-historyLimit:]
+	}
+	if len(matched) == 0 {
+		fmt.Println("No downloads recorded yet")
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tCATEGORY\tNAME\tVERSION\tSIZE\tCHECKSUM\tPATH")
+	for _, e := range matched {
+		checksum := e.Checksum
+		if e.Signature {
+			checksum += ", signed"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Time.Local().Format("2006-01-02 15:04"), e.Category, e.Name, e.Version,
+			humanize.Bytes(uint64(e.Size)), checksum, e.Path)
+	}
+	w.Flush()
+	return 0
+}
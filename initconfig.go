@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"lamp/internal/config"
+	"os"
+)
+
+// runInit writes the default config and catalogs to the config directory and
+// lists every file it created or left in place
+func runInit(force bool) int {
+	result, err := config.InitConfig(defaultConfig, embeddedFiles, force)
+	for _, path := range result.Created {
+		fmt.Printf("Created %s\n", path)
+	}
+	for _, path := range result.Skipped {
+		fmt.Printf("Skipped %s (already exists)\n", path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize %s: %v\n", result.Dir, err)
+		return 1
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Printf("Kept %d existing file(s) in %s; run with -force to replace them\n", len(result.Skipped), result.Dir)
+	} else {
+		fmt.Printf("Initialized %s\n", result.Dir)
+	}
+	return 0
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Auth holds the credentials a source's server requires. Each secret can be
+// written inline or, to keep it out of the YAML, named by an environment variable
+// (which may come from .env); the inline value wins when both are set, like
+// general.github_token over GITHUB_TOKEN.
+type Auth struct {
+	Type        string   `yaml:"type"` // basic or bearer
+	Username    string   `yaml:"username,omitempty"`
+	UsernameEnv string   `yaml:"username_env,omitempty"`
+	Password    string   `yaml:"password,omitempty"`
+	PasswordEnv string   `yaml:"password_env,omitempty"`
+	Token       string   `yaml:"token,omitempty"`
+	TokenEnv    string   `yaml:"token_env,omitempty"`
+	Hosts       []string `yaml:"hosts,omitempty"` // Other hosts to send the credentials to, besides those of the source's URLs
+}
+
+// secret returns the inline value, or else the named environment variable
+func secret(value, env string) string {
+	if value != "" || env == "" {
+		return value
+	}
+	return os.Getenv(env)
+}
+
+// Header returns the Authorization header value for the credentials
+func (a *Auth) Header() (string, error) {
+	switch strings.ToLower(a.Type) {
+	case "basic":
+		user := secret(a.Username, a.UsernameEnv)
+		pass := secret(a.Password, a.PasswordEnv)
+		if user == "" {
+			return "", fmt.Errorf("basic auth has no username (set username or username_env)")
+		}
+		if pass == "" && a.PasswordEnv != "" {
+			return "", fmt.Errorf("basic auth password: $%s is not set", a.PasswordEnv)
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass)), nil
+	case "bearer":
+		token := secret(a.Token, a.TokenEnv)
+		if token == "" {
+			if a.TokenEnv != "" {
+				return "", fmt.Errorf("bearer token: $%s is not set", a.TokenEnv)
+			}
+			return "", fmt.Errorf("bearer auth has no token (set token or token_env)")
+		}
+		return "Bearer " + token, nil
+	}
+	return "", fmt.Errorf("unknown auth type '%s' (expected basic or bearer)", a.Type)
+}
+
+// String describes the credentials without revealing them, for logs and listings
+func (a *Auth) String() string {
+	from := func(value, env string) string {
+		if value != "" {
+			return "inline"
+		}
+		if env != "" {
+			return "$" + env
+		}
+		return "unset"
+	}
+	switch strings.ToLower(a.Type) {
+	case "basic":
+		return fmt.Sprintf("basic (username %s, password %s)", from(a.Username, a.UsernameEnv), from(a.Password, a.PasswordEnv))
+	case "bearer":
+		return fmt.Sprintf("bearer (token %s)", from(a.Token, a.TokenEnv))
+	}
+	return a.Type
+}
+
+// authURLParams are the params whose URLs resolution requests go to, and so
+// receive a source's auth and headers
+var authURLParams = []string{"url", "base_url", "feed_url"}
+
+// urlHosts returns the hosts of the source's url and URL params, which its
+// resolution requests go to
+func (s Source) urlHosts() []string {
+	urls := []string{s.URL}
+	for _, p := range authURLParams {
+		urls = append(urls, s.Params[p])
+	}
+	var hosts []string
+	for _, raw := range urls {
+		if u, err := url.Parse(raw); err == nil && u.Host != "" {
+			hosts = append(hosts, u.Host)
+		}
+	}
+	return hosts
+}
+
+// AuthHosts returns the hosts the source's credentials are sent to: those of its
+// url and URL params, plus auth.hosts. Downloads redirected elsewhere, e.g. to a
+// CDN, are fetched without them.
+func (s Source) AuthHosts() []string {
+	if s.Auth == nil {
+		return nil
+	}
+	return uniqueHosts(append(s.urlHosts(), s.Auth.Hosts...))
+}
+
+// HeaderHosts returns the hosts the source's headers are sent to: those of its
+// url and URL params
+func (s Source) HeaderHosts() []string {
+	if len(s.Headers) == 0 {
+		return nil
+	}
+	return uniqueHosts(s.urlHosts())
+}
+
+// uniqueHosts lowercases hosts and drops empty and repeated ones
+func uniqueHosts(hosts []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host != "" && !seen[host] {
+			seen[host] = true
+			unique = append(unique, host)
+		}
+	}
+	return unique
+}
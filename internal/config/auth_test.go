@@ -0,0 +1,94 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAuthHeader(t *testing.T) {
+	t.Setenv("LAMP_TEST_PASSWORD", "from-env")
+	t.Setenv("LAMP_TEST_TOKEN", "env-token")
+
+	tests := []struct {
+		name    string
+		auth    Auth
+		want    string
+		wantErr string
+	}{
+		{"basic inline", Auth{Type: "basic", Username: "user", Password: "pass"}, "Basic dXNlcjpwYXNz", ""},
+		{"basic from env", Auth{Type: "basic", Username: "user", PasswordEnv: "LAMP_TEST_PASSWORD"}, "Basic dXNlcjpmcm9tLWVudg==", ""},
+		{"inline wins over env", Auth{Type: "Bearer", Token: "inline", TokenEnv: "LAMP_TEST_TOKEN"}, "Bearer inline", ""},
+		{"bearer from env", Auth{Type: "bearer", TokenEnv: "LAMP_TEST_TOKEN"}, "Bearer env-token", ""},
+		{"unset env", Auth{Type: "bearer", TokenEnv: "LAMP_TEST_UNSET"}, "", "LAMP_TEST_UNSET is not set"},
+		{"no username", Auth{Type: "basic", Password: "pass"}, "", "no username"},
+		{"unknown type", Auth{Type: "digest"}, "", "unknown auth type"},
+	}
+	for _, tt := range tests {
+		got, err := tt.auth.Header()
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: expected an error containing %q, got %q, %v", tt.name, tt.wantErr, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: expected %q, got %q, %v", tt.name, tt.want, got, err)
+		}
+	}
+}
+
+func TestAuthStringHidesSecrets(t *testing.T) {
+	a := &Auth{Type: "basic", Username: "user", Password: "hunter2"}
+	if s := a.String(); strings.Contains(s, "hunter2") || strings.Contains(s, "user,") {
+		t.Errorf("Expected the description to leave out the credentials, got %q", s)
+	}
+	b := &Auth{Type: "bearer", TokenEnv: "MIRROR_TOKEN"}
+	if s := b.String(); s != "bearer (token $MIRROR_TOKEN)" {
+		t.Errorf("Unexpected description %q", s)
+	}
+}
+
+func TestAuthHosts(t *testing.T) {
+	src := Source{
+		URL:    "https://Mirror.example.org/files/latest.iso",
+		Params: map[string]string{"feed_url": "https://feeds.example.org/releases.rss", "base_url": "https://mirror.example.org/"},
+		Auth:   &Auth{Type: "bearer", Token: "t", Hosts: []string{"cdn.example.org"}},
+	}
+	want := []string{"mirror.example.org", "feeds.example.org", "cdn.example.org"}
+	if got := src.AuthHosts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	src.Auth = nil
+	if got := src.AuthHosts(); got != nil {
+		t.Errorf("Expected no hosts without auth, got %v", got)
+	}
+}
+
+func TestCheckHeaders(t *testing.T) {
+	headers, problems := CheckHeaders(map[string]string{
+		"referer":     "https://example.org/downloads",
+		"Accept":      "application/octet-stream",
+		"Range":       "bytes=0-",
+		"Bad Name":    "x",
+		"X-Injected":  "a\r\nHost: evil",
+		"user-agent ": "Mozilla/5.0",
+	})
+	want := map[string]string{
+		"Referer":    "https://example.org/downloads",
+		"Accept":     "application/octet-stream",
+		"User-Agent": "Mozilla/5.0",
+	}
+	if !reflect.DeepEqual(headers, want) {
+		t.Errorf("CheckHeaders kept %v, want %v", headers, want)
+	}
+	if len(problems) != 3 {
+		t.Errorf("Expected 3 problems, got %v", problems)
+	}
+
+	src := Source{URL: "https://Mirror.example.org/app.iso", Params: map[string]string{"base_url": "https://mirror.example.org/"}, Headers: want}
+	if hosts := src.HeaderHosts(); !reflect.DeepEqual(hosts, []string{"mirror.example.org"}) {
+		t.Errorf("HeaderHosts() = %v", hosts)
+	}
+}
@@ -1,21 +1,30 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Storage    Storage             `yaml:"storage"`
-	General    GeneralConfig       `yaml:"general"`
-	Categories map[string]Category `yaml:"categories"`
+	Storage     Storage             `yaml:"storage"`
+	General     GeneralConfig       `yaml:"general"`
+	Categories  map[string]Category `yaml:"categories"`
+	CatalogURLs []string            `yaml:"catalog_urls,omitempty"` // Remote catalogs merged beneath local ones
+
+	Warnings []string `yaml:"-"` // Non-fatal problems found while loading
 }
 
 type GeneralConfig struct {
@@ -25,17 +34,60 @@ type GeneralConfig struct {
 	Threads      int      `yaml:"threads"`        // Number of parallel download segments
 	ApiRateLimit float64  `yaml:"api_rate_limit"` // Requests per second
 	ApiBurst     int      `yaml:"api_burst"`      // Maximum burst of requests
+
+	CheckConcurrency int  `yaml:"check_concurrency"` // Parallel version checks in --check mode
+	BackupOnUpdate   bool `yaml:"backup_on_update"`  // Keep the previous file as <name>.bak when a direct URL is re-downloaded
+
+	MaxDownloadSize ByteSize `yaml:"max_download_size"` // Abort downloads larger than this; 0 allows any size
+
+	BlockPrivateAddresses bool `yaml:"block_private_addresses"` // Reject download URLs resolving to private/internal addresses
+
+	Notify NotifyConfig `yaml:"notify"` // Hooks run by --check when a source needs downloading
+
+	HideForeign bool `yaml:"hide_foreign"` // Start the TUI with sources for other OS/arch combinations hidden
+
+	Offline  bool          `yaml:"offline"`   // Serve cached results only, with no network requests or downloads
+	CacheTTL time.Duration `yaml:"cache_ttl"` // How long cached Gutenberg and Kiwix catalogs are used, e.g. "12h"
+
+	GitHubCacheTTL time.Duration `yaml:"github_cache_ttl"` // How long a cached GitHub release is used before asking the API again (default 1h)
+
+	Theme string `yaml:"theme"` // TUI palette: earthy (default), mono, dracula or the path to a palette file
+
+	AllowHooks bool `yaml:"allow_hooks"` // Run the post_download commands of sources; off by default
+
+	StrictCatalogs bool `yaml:"strict_catalogs"` // Fail to load when catalog files in one directory define the same id
+
+	IPVersion string `yaml:"ip_version"` // Connect over auto (default), 4 (IPv4 only) or 6 (IPv6 only)
+	DNSServer string `yaml:"dns_server"` // Resolve host names with this server (host or host:port) instead of the system resolver
+}
+
+// NotifyConfig configures how new versions found by --check are announced
+type NotifyConfig struct {
+	Command string `yaml:"notify_cmd"`     // Shell command run once per source, with LAMP_* env vars set
+	Webhook string `yaml:"notify_webhook"` // URL that receives a JSON POST per source
 }
 
 // Category defines a group of download sources
 type Category struct {
 	Path     string   `yaml:"path"`
-	Language string   `yaml:"language,omitempty"` // Default language for dynamic catalogs in this category
+	Language string   `yaml:"language,omitempty"`  // Default language for dynamic catalogs in this category
+	Enabled  *bool    `yaml:"enabled,omitempty"`   // Set to false to skip the category without removing it
+	MaxBytes ByteSize `yaml:"max_bytes,omitempty"` // Refuse downloads that would take the category's folders past this size
 	Sources  []Source `yaml:"sources"`
+
+	PlatformFiltered bool `yaml:"-"` // Every source was left out by general.os/arch and the sources' include/exclude
+}
+
+// IsEnabled reports whether the category is checked and shown; categories are
+// enabled unless they set enabled: false
+func (c Category) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
 }
 
 type Storage struct {
-	DefaultRoot string `yaml:"default_root"`
+	DefaultRoot string   `yaml:"default_root"`
+	MaxBytes    ByteSize `yaml:"max_bytes"`    // Refuse downloads that would take all category folders past this size
+	EvictOldest bool     `yaml:"evict_oldest"` // Delete the version a download replaces when that keeps it under quota
 }
 
 type Source struct {
@@ -46,9 +98,23 @@ type Source struct {
 	OS              string            `yaml:"os,omitempty"`
 	Arch            string            `yaml:"arch,omitempty"` // Added to track specific arch of expanded source
 	Exclude         []string          `yaml:"exclude,omitempty"`
-	Checksum        string            `yaml:"checksum,omitempty"` // Checksum for integrity verification (e.g. sha256:...)
+	ExcludeMode     string            `yaml:"exclude_mode,omitempty"`          // How a config entry's exclude merges with its catalog source's: append (default) or replace
+	Include         []string          `yaml:"include,omitempty"`               // Only expand these os, arch or os/arch combos (globs allowed); empty allows all
+	Checksum        string            `yaml:"checksum,omitempty"`              // Checksum for integrity verification (e.g. sha256:...)
+	Signature       string            `yaml:"signature,omitempty"`             // Detached signature URL, or a suffix like ".asc" appended to the download URL
+	SignatureKey    string            `yaml:"signature_key,omitempty"`         // Path to the armored public key used to verify Signature
+	SignatureFpr    string            `yaml:"signature_fingerprint,omitempty"` // Trusted signing key fingerprint (optional)
 	URL             string            `yaml:"url,omitempty"`
 	StandardizeName bool              `yaml:"standardize_name,omitempty"` // Renames downloaded file to AppName_OS_Arch_Version.ext
+	Enabled         *bool             `yaml:"enabled,omitempty"`          // Set to false to skip the source without removing it
+	PinVersion      string            `yaml:"pin_version,omitempty"`      // Stay on this version/tag instead of the newest release
+	Threads         int               `yaml:"threads,omitempty"`          // Parallel download segments for this source, overriding general.threads
+	PostDownload    string            `yaml:"post_download,omitempty"`    // Shell command run after a verified download; needs general.allow_hooks
+	ExpectExt       []string          `yaml:"expect_ext,omitempty"`       // Allowed extensions of the downloaded file, e.g. [iso, tar.gz]; empty allows any
+	KeepVersions    int               `yaml:"keep_versions,omitempty"`    // After a download, delete older versions beyond this many; 0 keeps all
+	Auth            *Auth             `yaml:"auth,omitempty"`             // Credentials for servers behind HTTP auth
+	MaxSize         ByteSize          `yaml:"max_size,omitempty"`         // Abort downloads larger than this, overriding general.max_download_size
+	Headers         map[string]string `yaml:"headers,omitempty"`          // Extra request headers, e.g. Referer, for the hosts of the source's URLs
 
 	// Configuration Maps
 	OSMap   map[string]string `yaml:"os_map,omitempty"`
@@ -56,11 +122,47 @@ type Source struct {
 	ExtMap  map[string]string `yaml:"ext_map,omitempty"`
 }
 
+// IsEnabled reports whether the source is checked and shown; sources are
+// enabled unless they set enabled: false
+func (s Source) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// Key returns a stable identity for an expanded source, derived from its catalog
+// ID (or name when it has none), OS, arch and params. It does not change when
+// display names do, so persistent state such as the status cache and announced
+// notifications is keyed by it.
+func (s Source) Key() string {
+	id := s.ID
+	if id == "" {
+		id = s.Name
+	}
+	keys := make([]string, 0, len(s.Params))
+	for k := range s.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, field := range []string{id, s.OS, s.Arch} {
+		fmt.Fprintf(h, "%s\x00", field)
+	}
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\x00", k, s.Params[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
 type Catalog struct {
 	Sources []Source `yaml:"sources"`
 }
 
+// GetConfigDir returns the global lamp directory: $XDG_CONFIG_HOME/lamp when
+// XDG_CONFIG_HOME is set (on every platform), otherwise os.UserConfigDir()/lamp
 func GetConfigDir() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, "lamp"), nil
+	}
 	configDir, err := os.UserConfigDir()
 	if err != nil {
 		return "", err
@@ -68,6 +170,25 @@ func GetConfigDir() (string, error) {
 	return filepath.Join(configDir, "lamp"), nil
 }
 
+// DefaultConfigPath returns the config used when no --config is given:
+// ./config.yaml if it exists, otherwise config.yaml in the global directory
+func DefaultConfigPath() string {
+	if _, err := os.Stat("config.yaml"); err == nil {
+		return "config.yaml"
+	}
+	dir, err := GetConfigDir()
+	if err != nil {
+		// Fallback to local
+		return "config.yaml"
+	}
+	return filepath.Join(dir, "config.yaml")
+}
+
+// CatalogsDir returns the catalogs directory that sits beside configPath
+func CatalogsDir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "catalogs")
+}
+
 func EnsureConfigExists(defaultConfig []byte, catalogFS fs.FS) error {
 	configPath, err := GetConfigDir()
 	if err != nil {
@@ -122,37 +243,88 @@ func EnsureConfigExists(defaultConfig []byte, catalogFS fs.FS) error {
 	return nil
 }
 
-func LoadConfig(configPath string, defaultConfig []byte, catalogFS fs.FS) (*Config, error) {
-	// If configPath is empty, try to resolve it from the global directory
-	// If configPath is empty, check local then global
-	if configPath == "" {
-		if _, err := os.Stat("config.yaml"); err == nil {
-			configPath = "config.yaml"
-		} else {
-			dir, err := GetConfigDir()
-			if err == nil {
-				configPath = filepath.Join(dir, "config.yaml")
-			} else {
-				// Fallback to local
-				configPath = "config.yaml"
+// InitResult lists what InitConfig wrote and what it left alone
+type InitResult struct {
+	Dir     string
+	Created []string
+	Skipped []string // Existing files kept because force was not set
+}
+
+// InitConfig writes the default config.yaml and the embedded catalogs into the
+// global config directory. Unlike EnsureConfigExists it never overwrites a file
+// unless force is set, and it reports every path it wrote or skipped.
+func InitConfig(defaultConfig []byte, catalogFS fs.FS, force bool) (InitResult, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return InitResult{}, err
+	}
+	result := InitResult{Dir: dir}
+
+	entries, err := fs.ReadDir(catalogFS, "catalogs")
+	if err != nil {
+		return result, fmt.Errorf("failed to read embedded catalogs: %w", err)
+	}
+
+	// Embedded catalog names, with "" standing for the default config
+	names := []string{""}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	for _, name := range names {
+		path := filepath.Join(dir, "config.yaml")
+		data := defaultConfig
+		if name != "" {
+			path = filepath.Join(dir, "catalogs", name)
+			if data, err = fs.ReadFile(catalogFS, "catalogs/"+name); err != nil {
+				return result, fmt.Errorf("failed to read embedded catalog %s: %w", name, err)
 			}
 		}
+		if _, err := os.Stat(path); err == nil && !force {
+			result.Skipped = append(result.Skipped, path)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return result, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return result, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		result.Created = append(result.Created, path)
 	}
+	return result, nil
+}
 
-	// 1. Load Config
-	// Check if file exists, if not and we are in local mode, fallback to default behavior (error)
-	// But since we run EnsureConfigExists before this in main, it should exist if we are using global.
-	// We allow overriding by passing a specific path (e.g. from flag)
+// forceOffline is set by the --offline flag so reloaded configs stay offline
+var forceOffline bool
 
-	// If the user manually provided a path (not implementable yet in main but good for future)
-	// Or if we resolved it to global config.
+// ForceOffline makes every later LoadConfig behave as if general.offline were set
+func ForceOffline() {
+	forceOffline = true
+}
 
+func LoadConfig(configPath string, defaultConfig []byte, catalogFS fs.FS) (*Config, error) {
+	// An explicit path (--config) must exist; otherwise check local then global
+	explicit := configPath != ""
+	if !explicit {
+		configPath = DefaultConfigPath()
+	}
+
+	// 1. Load Config
+	usingDefault := false
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		// Try local config.yaml as fallback if not absolute path
-		if !filepath.IsAbs(configPath) {
+		// Try local config.yaml as fallback if the global one is missing
+		if !explicit {
 			data, err = os.ReadFile("config.yaml")
 		}
+		// Then the embedded default, e.g. when the config directory is not writable
+		if err != nil && !explicit && len(defaultConfig) > 0 {
+			data, err = defaultConfig, nil
+			usingDefault = true
+		}
 
 		if err != nil {
 			return nil, fmt.Errorf("failed to read config file: %w", err)
@@ -171,6 +343,10 @@ func LoadConfig(configPath string, defaultConfig []byte, catalogFS fs.FS) (*Conf
 	if len(cfg.General.Arch) == 0 {
 		cfg.General.Arch = []string{runtime.GOARCH}
 	}
+	// Aliases like darwin or x86_64 are stored under their canonical names so
+	// exclusions and templates only have to handle one spelling
+	cfg.General.OS = normalizeList(cfg.General.OS, normalizeOS)
+	cfg.General.Arch = normalizeList(cfg.General.Arch, normalizeArch)
 	if cfg.General.Threads <= 0 {
 		cfg.General.Threads = 4
 	}
@@ -180,6 +356,12 @@ func LoadConfig(configPath string, defaultConfig []byte, catalogFS fs.FS) (*Conf
 	if cfg.General.ApiBurst <= 0 {
 		cfg.General.ApiBurst = 5
 	}
+	if cfg.General.CheckConcurrency <= 0 {
+		cfg.General.CheckConcurrency = 8
+	}
+	if forceOffline {
+		cfg.General.Offline = true
+	}
 
 	// 1.5. Priority: Config > .env > Environment
 	loadEnv()
@@ -187,33 +369,63 @@ func LoadConfig(configPath string, defaultConfig []byte, catalogFS fs.FS) (*Conf
 		cfg.General.GitHubToken = os.Getenv("GITHUB_TOKEN")
 	}
 
+	if usingDefault {
+		cfg.Warnings = append(cfg.Warnings, fmt.Sprintf("No config found at %s; using the built-in defaults", configPath))
+	}
+
 	// 2. Load Catalogs
 	catalogMap := make(map[string]Source)
 
-	// Determine where to look for catalogs.
-	// If configPath is in global dir, look in global catalogs dir.
-	// If configPath is local, look in local catalogs dir.
-	catalogsDir := filepath.Join(filepath.Dir(configPath), "catalogs")
-
-	entries, err := os.ReadDir(catalogsDir)
-	if err == nil {
-		for _, entry := range entries {
-			if !entry.IsDir() && (strings.HasSuffix(entry.Name(), ".yaml") || strings.HasSuffix(entry.Name(), ".yml")) {
-				catalogPath := filepath.Join(catalogsDir, entry.Name())
-				data, err := os.ReadFile(catalogPath)
-				if err != nil {
-					continue
-				}
-				var catalog Catalog
-				if err := yaml.Unmarshal(data, &catalog); err != nil {
-					return nil, fmt.Errorf("failed to unmarshal catalog %s: %w", entry.Name(), err)
-				}
-				for _, s := range catalog.Sources {
-					catalogMap[s.ID] = s
-				}
+	// The embedded catalogs are the base layer, so the defaults are available
+	// even before (or without) the config directory being seeded
+	if catalogFS != nil {
+		if err := loadEmbeddedCatalogs(catalogFS, catalogMap); err != nil {
+			return nil, err
+		}
+	}
+
+	// Remote catalogs are merged first so local catalog files override them
+	if len(cfg.CatalogURLs) > 0 {
+		cacheDir := ""
+		if dir, err := GetConfigDir(); err == nil {
+			cacheDir = filepath.Join(dir, "catalog_cache")
+		}
+		remote, warnings := loadRemoteCatalogs(cfg.CatalogURLs, cacheDir, cfg.General.Offline)
+		for _, s := range remote {
+			catalogMap[s.ID] = s
+		}
+		cfg.Warnings = append(cfg.Warnings, warnings...)
+	}
+
+	// Catalogs in the global directory are loaded first, then those next to
+	// the config in use (e.g. ./catalogs for a local config.yaml) override them
+	// Overriding across directories is intended, but two files in one directory
+	// defining the same id usually means one of them is being ignored by mistake
+	var duplicates []catalogDuplicate
+	catalogsDir := CatalogsDir(configPath)
+	if dir, err := GetConfigDir(); err == nil {
+		globalDir := filepath.Join(dir, "catalogs")
+		if abs, err := filepath.Abs(catalogsDir); err != nil || abs != globalDir {
+			_, dups, err := loadCatalogDir(globalDir, catalogMap)
+			if err != nil {
+				return nil, err
 			}
+			duplicates = append(duplicates, dups...)
 		}
-	} else {
+	}
+
+	found, dups, err := loadCatalogDir(catalogsDir, catalogMap)
+	if err != nil {
+		return nil, err
+	}
+	duplicates = append(duplicates, dups...)
+	for _, d := range duplicates {
+		if cfg.General.StrictCatalogs {
+			return nil, fmt.Errorf("duplicate %s (general.strict_catalogs is set)", d)
+		}
+		cfg.Warnings = append(cfg.Warnings, "Duplicate "+d.String())
+	}
+	if !found {
 		// Fallback to legacy catalog.yaml for backward compatibility
 		catalogPath := filepath.Join(filepath.Dir(configPath), "catalog.yaml")
 		data, err := os.ReadFile(catalogPath)
@@ -243,9 +455,55 @@ func LoadConfig(configPath string, defaultConfig []byte, catalogFS fs.FS) (*Conf
 						if src.StandardizeName {
 							merged.StandardizeName = true
 						}
-						if len(src.Exclude) > 0 {
+						if src.Enabled != nil {
+							merged.Enabled = src.Enabled
+						}
+						switch src.ExcludeMode {
+						case "replace":
+							// Lets the config bring back a platform the catalog excludes
+							merged.Exclude = src.Exclude
+						case "", "append":
+							if len(src.Exclude) > 0 {
+								merged.Exclude = append(merged.Exclude, src.Exclude...)
+							}
+						default:
+							cfg.Warnings = append(cfg.Warnings, fmt.Sprintf("Unknown exclude_mode '%s' for '%s' (expected append or replace); appending", src.ExcludeMode, src.ID))
 							merged.Exclude = append(merged.Exclude, src.Exclude...)
 						}
+						if len(src.Include) > 0 {
+							// Adding to an allow-list would widen it, so an override replaces it
+							merged.Include = src.Include
+						}
+						if src.SignatureKey != "" {
+							merged.SignatureKey = src.SignatureKey
+						}
+						if src.SignatureFpr != "" {
+							merged.SignatureFpr = src.SignatureFpr
+						}
+						if src.PinVersion != "" {
+							merged.PinVersion = src.PinVersion
+						}
+						if src.Threads > 0 {
+							merged.Threads = src.Threads
+						}
+						if src.PostDownload != "" {
+							merged.PostDownload = src.PostDownload
+						}
+						if len(src.ExpectExt) > 0 {
+							merged.ExpectExt = src.ExpectExt
+						}
+						if src.KeepVersions > 0 {
+							merged.KeepVersions = src.KeepVersions
+						}
+						if src.Auth != nil {
+							merged.Auth = src.Auth
+						}
+						if src.MaxSize > 0 {
+							merged.MaxSize = src.MaxSize
+						}
+						if len(src.Headers) > 0 {
+							merged.Headers = src.Headers
+						}
 						cat.Sources[i] = merged
 					}
 				}
@@ -254,20 +512,130 @@ func LoadConfig(configPath string, defaultConfig []byte, catalogFS fs.FS) (*Conf
 		}
 	}
 
+	// Drop headers that can't be sent before expansion copies them
+	for catName, cat := range cfg.Categories {
+		for i, src := range cat.Sources {
+			headers, problems := CheckHeaders(src.Headers)
+			name := src.Name
+			if name == "" {
+				name = src.ID
+			}
+			for _, problem := range problems {
+				cfg.Warnings = append(cfg.Warnings, fmt.Sprintf("Ignoring a header of '%s': %s", name, problem))
+			}
+			cat.Sources[i].Headers = headers
+		}
+		cfg.Categories[catName] = cat
+	}
+
 	// 4. Expand Sources based on General OS/Arch
 	expandSources(&cfg)
 
-	// Expand tilde in paths
-	cfg.Storage.DefaultRoot = expandTilde(cfg.Storage.DefaultRoot)
+	// Expand environment variables and tilde in paths, and variables in params
+	cfg.Storage.DefaultRoot = expandPath(cfg.Storage.DefaultRoot)
 	for name, cat := range cfg.Categories {
-		cat.Path = expandTilde(cat.Path)
+		cat.Path = expandPath(cat.Path)
+		for i := range cat.Sources {
+			cat.Sources[i].SignatureKey = expandPath(cat.Sources[i].SignatureKey)
+			cat.Sources[i].Params = expandParams(cat.Sources[i].Params)
+		}
 		cfg.Categories[name] = cat
 	}
 
 	return &cfg, nil
 }
 
+// loadCatalogDir merges every .yaml/.yml catalog in dir and its subdirectories into
+// catalogMap, in sorted path order so later files consistently override earlier ones.
+// It reports false if dir does not exist, and returns the ids defined more than once.
+func loadCatalogDir(dir string, catalogMap map[string]Source) (bool, []catalogDuplicate, error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return false, nil, nil
+	}
+
+	var paths []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable folders rather than failing the whole load
+			return nil
+		}
+		if !d.IsDir() && (strings.HasSuffix(d.Name(), ".yaml") || strings.HasSuffix(d.Name(), ".yml")) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	sort.Strings(paths)
+
+	// Files defining each id, in merge order
+	definedIn := make(map[string][]string)
+	var ids []string
+	for _, catalogPath := range paths {
+		name, _ := filepath.Rel(dir, catalogPath)
+		data, err := os.ReadFile(catalogPath)
+		if err != nil {
+			continue
+		}
+		var catalog Catalog
+		if err := yaml.Unmarshal(data, &catalog); err != nil {
+			return true, nil, fmt.Errorf("failed to unmarshal catalog %s: %w", name, err)
+		}
+		for _, s := range catalog.Sources {
+			catalogMap[s.ID] = s
+			if definedIn[s.ID] == nil {
+				ids = append(ids, s.ID)
+			}
+			definedIn[s.ID] = append(definedIn[s.ID], name)
+		}
+	}
+
+	var duplicates []catalogDuplicate
+	for _, id := range ids {
+		if files := definedIn[id]; len(files) > 1 {
+			duplicates = append(duplicates, catalogDuplicate{Dir: dir, ID: id, Files: files})
+		}
+	}
+	return true, duplicates, nil
+}
+
+// catalogDuplicate is a catalog id defined more than once in one catalogs directory
+type catalogDuplicate struct {
+	Dir   string
+	ID    string
+	Files []string // Files defining ID relative to Dir, in merge order; the last one wins
+}
+
+func (d catalogDuplicate) String() string {
+	return fmt.Sprintf("catalog id %q is defined in %s (under %s); the definition in %s is used",
+		d.ID, strings.Join(d.Files, ", "), d.Dir, d.Files[len(d.Files)-1])
+}
+
+// loadEmbeddedCatalogs merges the catalogs/ directory of catalogFS into catalogMap
+func loadEmbeddedCatalogs(catalogFS fs.FS, catalogMap map[string]Source) error {
+	entries, err := fs.ReadDir(catalogFS, "catalogs")
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := fs.ReadFile(catalogFS, "catalogs/"+entry.Name())
+		if err != nil {
+			continue
+		}
+		var catalog Catalog
+		if err := yaml.Unmarshal(data, &catalog); err != nil {
+			return fmt.Errorf("failed to unmarshal embedded catalog %s: %w", entry.Name(), err)
+		}
+		for _, s := range catalog.Sources {
+			catalogMap[s.ID] = s
+		}
+	}
+	return nil
+}
+
 func expandSources(cfg *Config) {
+	var emptied []string
 	for catName, cat := range cfg.Categories {
 		var expandedSources []Source
 		for _, src := range cat.Sources {
@@ -306,13 +674,25 @@ func expandSources(cfg *Config) {
 					needsOSIteration = true
 					needsArchIteration = true
 				} else if !usesArch && !usesOS {
-					if ex == "linux" || ex == "macos" || ex == "darwin" || ex == "windows" {
+					if knownOS[normalizeOS(ex)] {
 						needsOSIteration = true
 					} else {
 						needsArchIteration = true
 					}
 				}
 			}
+			// An include entry can only match once its part of the combo is iterated
+			for _, in := range src.Include {
+				switch {
+				case strings.Contains(in, "/"):
+					needsOSIteration = true
+					needsArchIteration = true
+				case knownOS[normalizeOS(in)]:
+					needsOSIteration = true
+				default:
+					needsArchIteration = true
+				}
+			}
 
 			if !needsOSIteration && !needsArchIteration {
 				expandedSources = append(expandedSources, src)
@@ -340,6 +720,10 @@ func expandSources(cfg *Config) {
 
 			for _, osName := range osList {
 				for _, archName := range archList {
+					// Include narrows the combos first, then exclude removes from what is left
+					if len(src.Include) > 0 && !matchesPlatform(src.Include, osName, archName) {
+						continue
+					}
 					if isExcluded(src.Exclude, osName, archName) {
 						continue
 					}
@@ -350,24 +734,10 @@ func expandSources(cfg *Config) {
 						newSrc.Params[k] = v
 					}
 
-					if src.OSMap != nil {
-						newSrc.OSMap = make(map[string]string)
-						for k, v := range src.OSMap {
-							newSrc.OSMap[k] = v
-						}
-					}
-					if src.ArchMap != nil {
-						newSrc.ArchMap = make(map[string]string)
-						for k, v := range src.ArchMap {
-							newSrc.ArchMap[k] = v
-						}
-					}
-					if src.ExtMap != nil {
-						newSrc.ExtMap = make(map[string]string)
-						for k, v := range src.ExtMap {
-							newSrc.ExtMap[k] = v
-						}
-					}
+					// Maps are looked up with canonical names, so catalogs may key them by alias
+					newSrc.OSMap = normalizeKeys(src.OSMap)
+					newSrc.ArchMap = normalizeKeys(src.ArchMap)
+					newSrc.ExtMap = normalizeKeys(src.ExtMap)
 
 					substituteParams(&newSrc, osName, archName)
 
@@ -381,6 +751,13 @@ func expandSources(cfg *Config) {
 					// Key now uses effectiveArch to allow merging Universal binaries (via arch_override)
 					// while keeping separate downloads distinct.
 					key := expandedKey{os: osName, arch: effectiveArch, params: paramStr}
+					// Combos iterated only to apply include/exclude collapse into one source
+					if !usesOS {
+						key.os = ""
+					}
+					if !usesArch {
+						key.arch = ""
+					}
 
 					if _, ok := seen[key]; ok {
 						continue
@@ -423,9 +800,49 @@ func expandSources(cfg *Config) {
 				expandedSources = append(expandedSources, *seen[k])
 			}
 		}
+		if len(cat.Sources) > 0 && len(expandedSources) == 0 {
+			cat.PlatformFiltered = true
+			emptied = append(emptied, emptyExpansionWarning(catName, cat.Sources, cfg.General))
+		}
 		cat.Sources = expandedSources
 		cfg.Categories[catName] = cat
 	}
+	sort.Strings(emptied)
+	cfg.Warnings = append(cfg.Warnings, emptied...)
+}
+
+// emptyExpansionWarning explains why none of a category's sources expanded for
+// the configured platforms, listing the include and exclude of each source
+func emptyExpansionWarning(catName string, sources []Source, general GeneralConfig) string {
+	list := func(values []string) string {
+		if len(values) == 0 {
+			return "none"
+		}
+		return strings.Join(values, ", ")
+	}
+	var filters []string
+	for _, src := range sources {
+		var parts []string
+		if len(src.Include) > 0 {
+			parts = append(parts, "include "+strings.Join(src.Include, ", "))
+		}
+		if len(src.Exclude) > 0 {
+			parts = append(parts, "exclude "+strings.Join(src.Exclude, ", "))
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		name := src.Name
+		if name == "" {
+			name = src.ID
+		}
+		filters = append(filters, fmt.Sprintf("'%s' has %s", name, strings.Join(parts, " and ")))
+	}
+	msg := fmt.Sprintf("No sources of category '%s' match os %s and arch %s", catName, list(general.OS), list(general.Arch))
+	if len(filters) > 0 {
+		msg += ": " + strings.Join(filters, "; ")
+	}
+	return msg
 }
 
 func substituteParams(src *Source, osName, archName string) {
@@ -490,16 +907,63 @@ func substituteParams(src *Source, osName, archName string) {
 	}
 }
 
+// isExcluded reports whether the exclude list rules out osName/archName
 func isExcluded(excludeList []string, osName, archName string) bool {
+	return matchesPlatform(excludeList, osName, archName)
+}
+
+// matchesPlatform reports whether any entry of an exclude or include list names
+// osName/archName: an os, an arch or an os/arch combo. Entries may use aliases
+// (darwin, x86_64, ...), which match their canonical names, and glob patterns as
+// understood by path.Match, such as "windows/*" or "*/arm64".
+func matchesPlatform(list []string, osName, archName string) bool {
+	osName, archName = normalizeOS(osName), normalizeArch(archName)
 	combo := fmt.Sprintf("%s/%s", osName, archName)
-	for _, ex := range excludeList {
-		if ex == combo || ex == osName || ex == archName {
-			return true
+	for _, ex := range list {
+		ex = normalizePlatform(ex)
+		for _, name := range []string{combo, osName, archName} {
+			// A malformed pattern still excludes its exact spelling
+			if matched, err := path.Match(ex, name); matched || (err != nil && ex == name) {
+				return true
+			}
 		}
 	}
 	return false
 }
 
+// envRef matches $VAR and ${VAR}. A $ not followed by a variable name, such as a
+// regex anchor or the {{os}} in ${{os}}, is not a reference.
+var envRef = regexp.MustCompile(`\$(?:\{[A-Za-z_][A-Za-z0-9_]*\}|[A-Za-z_][A-Za-z0-9_]*)`)
+
+// expandEnv replaces $VAR and ${VAR} with the variable's value, or nothing when it
+// is unset, like os.ExpandEnv
+func expandEnv(s string) string {
+	if !strings.Contains(s, "$") {
+		return s
+	}
+	return envRef.ReplaceAllStringFunc(s, func(ref string) string {
+		return os.Getenv(strings.Trim(ref, "${}"))
+	})
+}
+
+// expandPath expands environment variables and then a leading ~
+func expandPath(path string) string {
+	return expandTilde(expandEnv(path))
+}
+
+// expandParams returns params with environment variables expanded in every value.
+// Expanded sources can share a params map, so a copy is returned.
+func expandParams(params map[string]string) map[string]string {
+	if params == nil {
+		return nil
+	}
+	expanded := make(map[string]string, len(params))
+	for k, v := range params {
+		expanded[k] = expandEnv(v)
+	}
+	return expanded
+}
+
 func expandTilde(path string) string {
 	if len(path) > 0 && path[0] == '~' {
 		home, err := os.UserHomeDir()
@@ -511,6 +975,24 @@ func expandTilde(path string) string {
 	return path
 }
 
+// ThreadsFor returns how many parallel segments downloads of src may use: the
+// source's own threads setting, or general.threads
+func (c *Config) ThreadsFor(src Source) int {
+	if src.Threads > 0 {
+		return src.Threads
+	}
+	return c.General.Threads
+}
+
+// MaxSizeFor returns the largest download of src allowed: the source's own
+// max_size, or general.max_download_size. 0 means no limit.
+func (c *Config) MaxSizeFor(src Source) int64 {
+	if src.MaxSize > 0 {
+		return int64(src.MaxSize)
+	}
+	return int64(c.General.MaxDownloadSize)
+}
+
 func (c *Config) GetTargetPath(categoryName string, src Source) string {
 	cat, ok := c.Categories[categoryName]
 	if !ok {
@@ -522,6 +1004,10 @@ func (c *Config) GetTargetPath(categoryName string, src Source) string {
 		basePath = c.Storage.DefaultRoot
 	}
 
+	// An explicit {{os}} in the template replaces the implicit OS subfolder
+	osSubfolder := !strings.Contains(basePath, "{{os}}")
+	basePath = expandTilde(expandPathTemplate(basePath, categoryName, src))
+
 	filename := filepath.Base(src.URL)
 	if src.URL == "" {
 		filename = src.Name
@@ -529,13 +1015,75 @@ func (c *Config) GetTargetPath(categoryName string, src Source) string {
 
 	filename = strings.ReplaceAll(filename, "/", "_")
 
-	if src.OS != "" {
+	if src.OS != "" && osSubfolder {
 		return filepath.Join(basePath, src.OS, filename)
 	}
 
 	return filepath.Join(basePath, filename)
 }
 
+// expandPathTemplate substitutes {{category}}, {{os}}, {{arch}}, {{name}} and {{date}}
+// in a storage path. Empty values collapse when the path is joined.
+func expandPathTemplate(path, categoryName string, src Source) string {
+	if !strings.Contains(path, "{{") {
+		return path
+	}
+
+	name := src.Name
+	if idx := strings.Index(name, " ["); idx != -1 {
+		name = name[:idx]
+	}
+
+	r := strings.NewReplacer(
+		"{{category}}", strings.ReplaceAll(categoryName, "/", "_"),
+		"{{os}}", src.OS,
+		"{{arch}}", src.Arch,
+		"{{name}}", strings.ReplaceAll(name, "/", "_"),
+		"{{date}}", time.Now().Format("2006-01-02"),
+	)
+	return r.Replace(path)
+}
+
+// IsForeign reports whether an expanded source targets a different OS or
+// architecture than the machine LAMP is running on. Sources without an OS/arch
+// and universal builds match every machine of their OS.
+func (s Source) IsForeign() bool {
+	if s.OS != "" {
+		osName := s.OS
+		if osName == "macos" {
+			osName = "darwin"
+		}
+		if osName != runtime.GOOS {
+			return true
+		}
+	}
+	if s.Arch != "" && s.Arch != "universal" {
+		arch := s.Arch
+		if alias, ok := archAliases[arch]; ok {
+			arch = alias
+		}
+		if arch != runtime.GOARCH {
+			return true
+		}
+	}
+	return false
+}
+
+// SignatureURL returns the detached signature location for a resolved download URL.
+// A Signature starting with "." is treated as a suffix of the download URL.
+func (s Source) SignatureURL(downloadURL string) string {
+	if s.Signature == "" {
+		return ""
+	}
+	if strings.HasPrefix(s.Signature, ".") {
+		if downloadURL == "" {
+			return ""
+		}
+		return downloadURL + s.Signature
+	}
+	return s.Signature
+}
+
 func (s Source) GetStandardizedFilename(version, originalExt string) string {
 	// Format: AppName_OS_Arch_Version.ext
 	name := strings.ReplaceAll(s.Name, " ", "")
@@ -633,5 +1181,171 @@ func CheckSystemCompatibility(cfg *Config) []string {
 		warnings = append(warnings, fmt.Sprintf("Current Architecture '%s' is not in config.general.arch. Add it to receive updates for this machine.", currentArch))
 	}
 
+	for _, osName := range cfg.General.OS {
+		if !knownOS[osName] {
+			warnings = append(warnings, fmt.Sprintf("Unknown OS '%s' in config.general.os (expected linux, macos or windows).", osName))
+		}
+	}
+	switch cfg.General.IPVersion {
+	case "", "auto", "4", "6":
+	default:
+		warnings = append(warnings, fmt.Sprintf("Unknown ip_version '%s' in config.general (expected auto, 4 or 6); using both IPv4 and IPv6.", cfg.General.IPVersion))
+	}
+	for _, archName := range cfg.General.Arch {
+		if !knownArch[archName] {
+			hint := ""
+			if alias, ok := archAliases[archName]; ok {
+				hint = fmt.Sprintf(" Did you mean '%s'?", alias)
+			}
+			warnings = append(warnings, fmt.Sprintf("Unknown architecture '%s' in config.general.arch (expected amd64, arm64, 386 or arm).%s", archName, hint))
+		}
+	}
+
+	// Storage locations must exist or be creatable
+	if cfg.Storage.DefaultRoot != "" {
+		if err := checkWritable(cfg.Storage.DefaultRoot); err != nil {
+			warnings = append(warnings, fmt.Sprintf("storage.default_root '%s' is not usable: %v", cfg.Storage.DefaultRoot, err))
+		}
+	}
+	catNames := make([]string, 0, len(cfg.Categories))
+	for name := range cfg.Categories {
+		catNames = append(catNames, name)
+	}
+	sort.Strings(catNames)
+	usesGitHub := false
+	authChecked := make(map[*Auth]bool) // Expanded sources share their Auth
+	for _, name := range catNames {
+		cat := cfg.Categories[name]
+		for _, src := range cat.Sources {
+			if src.Strategy == "github_release" {
+				usesGitHub = true
+			}
+			if src.Auth != nil && !authChecked[src.Auth] {
+				authChecked[src.Auth] = true
+				if _, err := src.Auth.Header(); err != nil {
+					warnings = append(warnings, fmt.Sprintf("Auth of source '%s' is not usable: %v", src.Name, err))
+				}
+			}
+		}
+		if cat.Path == "" {
+			continue
+		}
+		// Only the part before any {{template}} is known ahead of time
+		path := cat.Path
+		if i := strings.Index(path, "{{"); i != -1 {
+			path = filepath.Dir(path[:i] + "x")
+		}
+		if err := checkWritable(path); err != nil {
+			warnings = append(warnings, fmt.Sprintf("Path of category '%s' ('%s') is not usable: %v", name, cat.Path, err))
+		}
+	}
+
+	if usesGitHub && cfg.General.GitHubToken == "" {
+		warnings = append(warnings, "No GitHub token configured; anonymous API access is limited to 60 requests per hour. Set general.github_token or GITHUB_TOKEN.")
+	}
+
 	return warnings
 }
+
+var (
+	knownOS     = map[string]bool{"linux": true, "macos": true, "darwin": true, "windows": true}
+	knownArch   = map[string]bool{"amd64": true, "arm64": true, "386": true, "arm": true}
+	archAliases = map[string]string{"x86_64": "amd64", "x64": "amd64", "aarch64": "arm64", "x86": "386", "i386": "386"}
+	osAliases   = map[string]string{"darwin": "macos", "osx": "macos"}
+)
+
+// normalizeOS maps OS aliases such as darwin to the canonical name used in templates
+func normalizeOS(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := osAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// normalizeArch maps architecture aliases such as x86_64 to the canonical Go name
+func normalizeArch(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := archAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// normalizePlatform normalizes an exclude entry or map key, which is an OS, an
+// architecture, or an "os/arch" pair
+func normalizePlatform(key string) string {
+	if osName, archName, ok := strings.Cut(key, "/"); ok {
+		return normalizeOS(osName) + "/" + normalizeArch(archName)
+	}
+	if n := normalizeOS(key); n != strings.ToLower(strings.TrimSpace(key)) || knownOS[n] {
+		return n
+	}
+	return normalizeArch(key)
+}
+
+// normalizeList normalizes names and drops the duplicates aliases produce, keeping order
+func normalizeList(names []string, normalize func(string) string) []string {
+	seen := make(map[string]bool, len(names))
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		n := normalize(name)
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// normalizeKeys copies a platform-keyed map with its keys normalized. An entry
+// written with the canonical name wins over one written with an alias.
+func normalizeKeys(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		n := normalizePlatform(k)
+		if _, taken := out[n]; taken && n != k {
+			continue
+		}
+		out[n] = v
+	}
+	return out
+}
+
+// checkWritable reports whether files can be written under dir. A directory that
+// does not exist yet is fine as long as its nearest existing parent is writable,
+// since downloads create it.
+func checkWritable(dir string) error {
+	existing := filepath.Clean(dir)
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", existing)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return fmt.Errorf("no existing parent directory")
+		}
+		existing = parent
+	}
+
+	f, err := os.CreateTemp(existing, ".lamp-write-test-*")
+	if err != nil {
+		if existing != filepath.Clean(dir) {
+			return fmt.Errorf("does not exist and %s is not writable", existing)
+		}
+		return fmt.Errorf("not writable")
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
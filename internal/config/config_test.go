@@ -1,7 +1,16 @@
 package config
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func TestExpandSources(t *testing.T) {
@@ -157,6 +166,244 @@ func TestExpandSourcesWithMaps(t *testing.T) {
 	}
 }
 
+func TestPlatformAliases(t *testing.T) {
+	tests := []struct {
+		in, want  string
+		normalize func(string) string
+	}{
+		{"x86_64", "amd64", normalizeArch},
+		{"x64", "amd64", normalizeArch},
+		{"aarch64", "arm64", normalizeArch},
+		{"AARCH64", "arm64", normalizeArch},
+		{"i386", "386", normalizeArch},
+		{"x86", "386", normalizeArch},
+		{"amd64", "amd64", normalizeArch},
+		{"darwin", "macos", normalizeOS},
+		{"osx", "macos", normalizeOS},
+		{"macOS", "macos", normalizeOS},
+		{"linux", "linux", normalizeOS},
+		{"darwin/aarch64", "macos/arm64", normalizePlatform},
+		{"darwin", "macos", normalizePlatform},
+		{"x64", "amd64", normalizePlatform},
+		{"universal", "universal", normalizePlatform},
+	}
+	for _, tt := range tests {
+		if got := tt.normalize(tt.in); got != tt.want {
+			t.Errorf("normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	// Exclusions written with aliases match the canonical names they stand for
+	for _, ex := range []string{"darwin", "x86_64", "x64", "darwin/x86_64", "macos/x64"} {
+		if !isExcluded([]string{ex}, "macos", "amd64") {
+			t.Errorf("Expected exclude %q to match macos/amd64", ex)
+		}
+	}
+	if isExcluded([]string{"aarch64", "linux"}, "macos", "amd64") {
+		t.Error("Expected aarch64 and linux not to exclude macos/amd64")
+	}
+}
+
+func TestExcludeGlobs(t *testing.T) {
+	exclude := []string{"windows/*", "*/arm64", "linux/amd64", "darwin/*"}
+	tests := []struct {
+		os, arch string
+		excluded bool
+	}{
+		{"windows", "amd64", true},   // windows/*
+		{"windows", "arm64", true},   // windows/* and */arm64
+		{"linux", "arm64", true},     // */arm64
+		{"linux", "amd64", true},     // exact
+		{"macos", "universal", true}, // darwin/* through its alias
+		{"linux", "386", false},
+		{"freebsd", "amd64", false},
+	}
+	for _, tt := range tests {
+		if got := isExcluded(exclude, tt.os, tt.arch); got != tt.excluded {
+			t.Errorf("isExcluded(%s/%s) = %v, want %v", tt.os, tt.arch, got, tt.excluded)
+		}
+	}
+	if !isExcluded([]string{"[bad"}, "[bad", "") {
+		t.Error("Expected a malformed pattern to still match its exact spelling")
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	local := t.TempDir()
+	configPath := filepath.Join(local, "config.yaml")
+	writeFile(t, configPath,
+		"general:\n  os: [macos, linux, windows]\n  arch: [amd64, arm64]\ncategories:\n  Apps:\n    sources:\n      - id: app\n")
+	writeFile(t, filepath.Join(local, "catalogs", "apps.yaml"), `sources:
+  - id: app
+    name: App
+    params:
+      file: "app-{{os}}-{{arch}}.zip"
+    exclude: ["windows/*", "*/arm64", "linux/amd64"]
+`)
+	cfg, err := LoadConfig(configPath, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	var targets []string
+	for _, src := range cfg.Categories["Apps"].Sources {
+		targets = append(targets, src.OS+"/"+src.Arch)
+	}
+	if strings.Join(targets, ",") != "macos/amd64" {
+		t.Errorf("Expected only macos/amd64 to remain, got %v", targets)
+	}
+}
+
+func TestIncludeList(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	local := t.TempDir()
+	configPath := filepath.Join(local, "config.yaml")
+	writeFile(t, configPath, `general:
+  os: [macos, linux, windows]
+  arch: [amd64, arm64]
+categories:
+  Apps:
+    sources:
+      - id: app
+      - id: tool
+      - id: cli
+        include: [linux]
+`)
+	writeFile(t, filepath.Join(local, "catalogs", "apps.yaml"), `sources:
+  - id: app
+    name: App
+    params:
+      file: "app-{{os}}-{{arch}}.zip"
+    include: ["linux/amd64", "darwin/arm64", "windows/*"]
+    exclude: ["windows/arm64"]
+  - id: tool
+    name: Tool
+    params:
+      file: "tool-{{arch}}.tar.gz"
+    include: ["*/arm64"]
+  - id: cli
+    name: CLI
+    params:
+      file: "cli-{{os}}-{{arch}}.zip"
+    include: [macos]
+`)
+	cfg, err := LoadConfig(configPath, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	targets := make(map[string][]string)
+	for _, src := range cfg.Categories["Apps"].Sources {
+		targets[src.ID] = append(targets[src.ID], src.OS+"/"+src.Arch)
+	}
+
+	// Include picks the combos, then exclude removes windows/arm64 from them
+	if got := strings.Join(targets["app"], ","); got != "macos/arm64,linux/amd64,windows/amd64" {
+		t.Errorf("Expected app on macos/arm64, linux/amd64 and windows/amd64, got %s", got)
+	}
+	if got := strings.Join(targets["tool"], ","); got != "/arm64" {
+		t.Errorf("Expected tool only for arm64, got %s", got)
+	}
+	// The category's include replaces the catalog's
+	if got := strings.Join(targets["cli"], ","); got != "linux/amd64,linux/arm64" {
+		t.Errorf("Expected cli only on linux, got %s", got)
+	}
+}
+
+func TestEmptyExpansionWarning(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	local := t.TempDir()
+	configPath := filepath.Join(local, "config.yaml")
+	writeFile(t, configPath, `general:
+  os: [linux]
+  arch: [amd64]
+categories:
+  Desktop:
+    path: /tmp/desktop
+    sources:
+      - name: App
+        url: "https://example.org/app-{{os}}.zip"
+        include: [macos]
+      - name: Tool
+        url: "https://example.org/tool-{{arch}}.zip"
+        exclude: ["linux/*"]
+  Other:
+    path: /tmp/other
+    sources:
+      - name: CLI
+        url: "https://example.org/cli-{{os}}.zip"
+        exclude: [windows]
+`)
+	cfg, err := LoadConfig(configPath, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	desktop := cfg.Categories["Desktop"]
+	if len(desktop.Sources) != 0 || !desktop.PlatformFiltered {
+		t.Errorf("Expected Desktop to be emptied by its filters, got %d sources (filtered %v)", len(desktop.Sources), desktop.PlatformFiltered)
+	}
+	if other := cfg.Categories["Other"]; len(other.Sources) != 1 || other.PlatformFiltered {
+		t.Errorf("Expected Other to keep CLI, got %d sources (filtered %v)", len(other.Sources), other.PlatformFiltered)
+	}
+
+	want := "No sources of category 'Desktop' match os linux and arch amd64: 'App' has include macos; 'Tool' has exclude linux/*"
+	var found []string
+	for _, w := range cfg.Warnings {
+		if strings.HasPrefix(w, "No sources of category") {
+			found = append(found, w)
+		}
+	}
+	if len(found) != 1 || found[0] != want {
+		t.Errorf("Expected the warning %q, got %q", want, found)
+	}
+}
+
+func TestExpandSourcesAliases(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	local := t.TempDir()
+	configPath := filepath.Join(local, "config.yaml")
+	writeFile(t, configPath,
+		"general:\n  os: [darwin, macos, linux]\n  arch: [x86_64, aarch64]\ncategories:\n  Apps:\n    sources:\n      - id: app\n")
+	writeFile(t, filepath.Join(local, "catalogs", "apps.yaml"), `sources:
+  - id: app
+    name: App
+    params:
+      file: "{{os_map}}-{{arch_map}}.{{ext}}"
+    os_map: {darwin: mac, linux: linux}
+    arch_map: {x86_64: x64, "darwin/aarch64": universal, arm64: arm}
+    ext_map: {darwin: pkg}
+    exclude: [linux/aarch64]
+`)
+
+	cfg, err := LoadConfig(configPath, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := strings.Join(cfg.General.OS, ","); got != "macos,linux" {
+		t.Errorf("Expected OS list macos,linux, got %s", got)
+	}
+	if got := strings.Join(cfg.General.Arch, ","); got != "amd64,arm64" {
+		t.Errorf("Expected arch list amd64,arm64, got %s", got)
+	}
+
+	files := make(map[string]string)
+	for _, src := range cfg.Categories["Apps"].Sources {
+		files[src.OS+"/"+src.Arch] = src.Params["file"]
+	}
+	want := map[string]string{
+		"macos/amd64": "mac-x64.pkg",
+		"macos/arm64": "mac-universal.pkg",
+		"linux/amd64": "linux-x64.zip",
+	}
+	if len(files) != len(want) {
+		t.Errorf("Expected %d expansions, got %v", len(want), files)
+	}
+	for k, v := range want {
+		if files[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, files[k])
+		}
+	}
+}
+
 func TestGetStandardizedFilename(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -209,3 +456,582 @@ func TestGetStandardizedFilename(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTargetPathTemplate(t *testing.T) {
+	cfg := &Config{
+		Storage: Storage{DefaultRoot: "/data/{{category}}"},
+		Categories: map[string]Category{
+			"ISOs":  {Path: "/isos/{{category}}/{{os}}/{{arch}}/{{name}}"},
+			"Apps":  {Path: "/apps"},
+			"Games": {},
+		},
+	}
+
+	src := Source{Name: "Ubuntu [linux/amd64]", OS: "linux", Arch: "amd64"}
+
+	tests := []struct {
+		category string
+		want     string
+	}{
+		// {{os}} in the template replaces the implicit OS subfolder
+		{"ISOs", "/isos/ISOs/linux/amd64/Ubuntu/Ubuntu [linux_amd64]"},
+		{"Apps", "/apps/linux/Ubuntu [linux_amd64]"},
+		{"Games", "/data/Games/linux/Ubuntu [linux_amd64]"},
+	}
+
+	for _, tt := range tests {
+		got := cfg.GetTargetPath(tt.category, src)
+		if got != filepath.FromSlash(tt.want) {
+			t.Errorf("GetTargetPath(%s) = %s, want %s", tt.category, got, tt.want)
+		}
+	}
+
+	// Unexpanded sources collapse the empty os/arch segments
+	plain := Source{Name: "Static"}
+	if got := cfg.GetTargetPath("ISOs", plain); got != filepath.FromSlash("/isos/ISOs/Static/Static") {
+		t.Errorf("GetTargetPath(ISOs, plain) = %s", got)
+	}
+}
+
+func TestLoadConfigXDGCatalogs(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	dir, err := GetConfigDir()
+	if err != nil || dir != filepath.Join(xdg, "lamp") {
+		t.Fatalf("Expected config dir under XDG_CONFIG_HOME, got %q (%v)", dir, err)
+	}
+
+	// Global catalogs provide "shared"; the catalog beside the config overrides "app"
+	writeFile(t, filepath.Join(dir, "catalogs", "main.yaml"),
+		"sources:\n  - id: shared\n    name: Shared\n  - id: app\n    name: Global App\n")
+
+	local := t.TempDir()
+	configPath := filepath.Join(local, "my.yaml")
+	writeFile(t, configPath,
+		"general:\n  os: [linux]\n  arch: [amd64]\ncategories:\n  Apps:\n    sources:\n      - id: shared\n      - id: app\n")
+	writeFile(t, filepath.Join(local, "catalogs", "custom.yaml"),
+		"sources:\n  - id: app\n    name: Local App\n")
+
+	cfg, err := LoadConfig(configPath, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	sources := cfg.Categories["Apps"].Sources
+	if len(sources) != 2 || sources[0].Name != "Shared" || sources[1].Name != "Local App" {
+		t.Errorf("Unexpected sources: %+v", sources)
+	}
+
+	// An explicit path that does not exist is an error, not a fallback
+	if _, err := LoadConfig(filepath.Join(local, "missing.yaml"), nil, nil); err == nil {
+		t.Error("Expected an error for a missing explicit config")
+	}
+}
+
+func TestLoadConfigNestedCatalogs(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	local := t.TempDir()
+	configPath := filepath.Join(local, "config.yaml")
+	writeFile(t, configPath,
+		"general:\n  os: [linux]\n  arch: [amd64]\ncategories:\n  Apps:\n    sources:\n      - id: top\n      - id: distro\n      - id: player\n")
+	writeFile(t, filepath.Join(local, "catalogs", "base.yaml"),
+		"sources:\n  - id: top\n    name: Top\n  - id: player\n    name: Base Player\n")
+	writeFile(t, filepath.Join(local, "catalogs", "linux", "distros.yml"),
+		"sources:\n  - id: distro\n    name: Distro\n")
+	// Paths are merged in sorted order, so media/deep/video.yaml overrides base.yaml
+	writeFile(t, filepath.Join(local, "catalogs", "media", "deep", "video.yaml"),
+		"sources:\n  - id: player\n    name: Nested Player\n")
+	writeFile(t, filepath.Join(local, "catalogs", "media", "notes.txt"), "not a catalog")
+
+	cfg, err := LoadConfig(configPath, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	sources := cfg.Categories["Apps"].Sources
+	if len(sources) != 3 || sources[0].Name != "Top" || sources[1].Name != "Distro" || sources[2].Name != "Nested Player" {
+		t.Errorf("Unexpected sources: %+v", sources)
+	}
+
+	writeFile(t, filepath.Join(local, "catalogs", "linux", "broken.yaml"), "sources: [")
+	if _, err := LoadConfig(configPath, nil, nil); err == nil || !strings.Contains(err.Error(), filepath.Join("linux", "broken.yaml")) {
+		t.Errorf("Expected an error naming the nested catalog, got %v", err)
+	}
+}
+
+func TestLoadConfigDuplicateCatalogIDs(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	local := t.TempDir()
+	configPath := filepath.Join(local, "config.yaml")
+	writeFile(t, configPath,
+		"general:\n  os: [linux]\n  arch: [amd64]\ncategories:\n  Apps:\n    sources:\n      - id: app\n")
+	writeFile(t, filepath.Join(local, "catalogs", "apps.yaml"),
+		"sources:\n  - id: app\n    name: Old App\n  - id: other\n")
+	writeFile(t, filepath.Join(local, "catalogs", "linux", "apps.yaml"),
+		"sources:\n  - id: app\n    name: New App\n")
+
+	cfg, err := LoadConfig(configPath, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if name := cfg.Categories["Apps"].Sources[0].Name; name != "New App" {
+		t.Errorf("Expected the last definition to win, got %q", name)
+	}
+	want := fmt.Sprintf(`Duplicate catalog id "app" is defined in apps.yaml, %s (under %s); the definition in %s is used`,
+		filepath.Join("linux", "apps.yaml"), filepath.Join(local, "catalogs"), filepath.Join("linux", "apps.yaml"))
+	if len(cfg.Warnings) != 1 || cfg.Warnings[0] != want {
+		t.Errorf("Expected warning %q, got %q", want, cfg.Warnings)
+	}
+
+	writeFile(t, configPath,
+		"general:\n  strict_catalogs: true\ncategories:\n  Apps:\n    sources:\n      - id: app\n")
+	if _, err := LoadConfig(configPath, nil, nil); err == nil || !strings.Contains(err.Error(), `duplicate catalog id "app"`) {
+		t.Errorf("Expected strict mode to reject the duplicate, got %v", err)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadConfigEmbeddedDefaults(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+
+	defaultConfig := []byte("general:\n  os: [linux]\n  arch: [amd64]\ncategories:\n  Apps:\n    sources:\n      - id: builtin\n")
+	catalogFS := fstest.MapFS{
+		"catalogs/apps.yaml": {Data: []byte("sources:\n  - id: builtin\n    name: Built-in App\n    url: https://example.com/app.zip\n")},
+	}
+
+	// No config or catalogs on disk: everything comes from the embedded defaults
+	cfg, err := LoadConfig("", defaultConfig, catalogFS)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	sources := cfg.Categories["Apps"].Sources
+	if len(sources) != 1 || sources[0].Name != "Built-in App" {
+		t.Errorf("Expected the embedded catalog entry, got %+v", sources)
+	}
+	if len(cfg.Warnings) == 0 {
+		t.Error("Expected a warning about using the built-in config")
+	}
+
+	// Once seeded, the files on disk are used
+	if err := EnsureConfigExists(defaultConfig, catalogFS); err != nil {
+		t.Fatalf("EnsureConfigExists failed: %v", err)
+	}
+	dir, _ := GetConfigDir()
+	if _, err := os.Stat(filepath.Join(dir, "catalogs", "apps.yaml")); err != nil {
+		t.Errorf("Expected catalogs to be seeded: %v", err)
+	}
+	cfg, err = LoadConfig("", defaultConfig, catalogFS)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if len(cfg.Warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", cfg.Warnings)
+	}
+}
+
+func TestCheckSystemCompatibility(t *testing.T) {
+	tmp := t.TempDir()
+	blocker := filepath.Join(tmp, "file")
+	writeFile(t, blocker, "not a directory")
+
+	currentOS := runtime.GOOS
+	if currentOS == "darwin" {
+		currentOS = "macos"
+	}
+
+	cfg := &Config{
+		General: GeneralConfig{
+			OS:   []string{currentOS, "freebsd"},
+			Arch: []string{runtime.GOARCH, "x86_64"},
+		},
+		Storage: Storage{DefaultRoot: filepath.Join(blocker, "downloads")},
+		Categories: map[string]Category{
+			"Apps": {
+				Path:    filepath.Join(blocker, "apps", "{{os}}"),
+				Sources: []Source{{Name: "Tool", Strategy: "github_release"}},
+			},
+			"New": {Path: filepath.Join(tmp, "not", "yet", "created")},
+		},
+	}
+
+	warnings := CheckSystemCompatibility(cfg)
+	expect := []string{
+		"Unknown OS 'freebsd'",
+		"Unknown architecture 'x86_64'",
+		"Did you mean 'amd64'?",
+		"storage.default_root",
+		"Path of category 'Apps'",
+		"No GitHub token configured",
+	}
+	for _, want := range expect {
+		found := false
+		for _, w := range warnings {
+			if strings.Contains(w, want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected a warning containing %q, got %v", want, warnings)
+		}
+	}
+	if len(warnings) != 5 {
+		t.Errorf("Expected 5 warnings, got %d: %v", len(warnings), warnings)
+	}
+
+	// A clean config produces no warnings
+	cfg = &Config{
+		General:    GeneralConfig{OS: []string{currentOS}, Arch: []string{runtime.GOARCH}, GitHubToken: "token"},
+		Storage:    Storage{DefaultRoot: tmp},
+		Categories: map[string]Category{"Apps": {Sources: []Source{{Strategy: "github_release"}}}},
+	}
+	if warnings := CheckSystemCompatibility(cfg); len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", warnings)
+	}
+}
+
+func TestSourceIsForeign(t *testing.T) {
+	hostOS := runtime.GOOS
+	if hostOS == "darwin" {
+		hostOS = "macos"
+	}
+	otherOS := "windows"
+	if hostOS == "windows" {
+		otherOS = "linux"
+	}
+	otherArch := "arm64"
+	if runtime.GOARCH == "arm64" {
+		otherArch = "amd64"
+	}
+
+	tests := []struct {
+		src  Source
+		want bool
+	}{
+		{Source{}, false},
+		{Source{OS: hostOS, Arch: runtime.GOARCH}, false},
+		{Source{OS: hostOS, Arch: "universal"}, false},
+		{Source{OS: otherOS, Arch: runtime.GOARCH}, true},
+		{Source{OS: hostOS, Arch: otherArch}, true},
+		{Source{Arch: otherArch}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.src.IsForeign(); got != tt.want {
+			t.Errorf("IsForeign(%s/%s) = %v, want %v", tt.src.OS, tt.src.Arch, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigEnabled(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	local := t.TempDir()
+	configPath := filepath.Join(local, "config.yaml")
+	writeFile(t, configPath, "general:\n  os: [linux]\n  arch: [amd64]\ncategories:\n"+
+		"  Apps:\n    sources:\n      - id: app\n        enabled: false\n      - name: Plain\n        url: https://example.com/plain.zip\n"+
+		"  Seasonal:\n    enabled: false\n    sources:\n      - name: Holiday\n        url: https://example.com/holiday.zip\n")
+	writeFile(t, filepath.Join(local, "catalogs", "apps.yaml"),
+		"sources:\n  - id: app\n    name: App\n    url: https://example.com/app.zip\n")
+
+	cfg, err := LoadConfig(configPath, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !cfg.Categories["Apps"].IsEnabled() || cfg.Categories["Seasonal"].IsEnabled() {
+		t.Errorf("Unexpected category enabled state: Apps=%v Seasonal=%v",
+			cfg.Categories["Apps"].IsEnabled(), cfg.Categories["Seasonal"].IsEnabled())
+	}
+	sources := cfg.Categories["Apps"].Sources
+	if len(sources) != 2 || sources[0].IsEnabled() || !sources[1].IsEnabled() {
+		t.Errorf("Expected the catalog source to stay disabled after merging, got %+v", sources)
+	}
+}
+
+func TestLoadConfigCacheTTL(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, configPath, "general:\n  cache_ttl: 90m\n")
+
+	cfg, err := LoadConfig(configPath, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.General.CacheTTL != 90*time.Minute {
+		t.Errorf("Expected cache_ttl of 90m, got %v", cfg.General.CacheTTL)
+	}
+}
+
+func TestLoadConfigPinVersion(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	local := t.TempDir()
+	configPath := filepath.Join(local, "config.yaml")
+	writeFile(t, configPath, "general:\n  os: [linux]\n  arch: [amd64]\ncategories:\n"+
+		"  Apps:\n    sources:\n      - id: app\n        pin_version: v1.2.0\n")
+	writeFile(t, filepath.Join(local, "catalogs", "apps.yaml"),
+		"sources:\n  - id: app\n    name: App\n    strategy: github_release\n")
+
+	cfg, err := LoadConfig(configPath, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	sources := cfg.Categories["Apps"].Sources
+	if len(sources) != 1 || sources[0].PinVersion != "v1.2.0" || sources[0].Strategy != "github_release" {
+		t.Errorf("Expected the pin to be merged into the catalog source, got %+v", sources)
+	}
+}
+
+func TestLoadConfigExcludeMode(t *testing.T) {
+	catalog := `sources:
+  - id: app
+    name: App
+    params:
+      file: "app-{{os}}-{{arch}}.zip"
+    exclude: ["*/arm64"]
+`
+	tests := []struct {
+		name    string
+		entry   string
+		targets string
+	}{
+		{"append by default", "      - id: app\n        exclude: [\"windows/*\"]\n", "linux/amd64"},
+		{"append explicitly", "      - id: app\n        exclude_mode: append\n        exclude: [\"windows/*\"]\n", "linux/amd64"},
+		{"replace", "      - id: app\n        exclude_mode: replace\n        exclude: [\"windows/*\"]\n", "linux/amd64,linux/arm64"},
+		{"replace with nothing", "      - id: app\n        exclude_mode: replace\n", "linux/amd64,linux/arm64,windows/amd64,windows/arm64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			local := t.TempDir()
+			configPath := filepath.Join(local, "config.yaml")
+			writeFile(t, configPath, "general:\n  os: [linux, windows]\n  arch: [amd64, arm64]\ncategories:\n  Apps:\n    sources:\n"+tt.entry)
+			writeFile(t, filepath.Join(local, "catalogs", "apps.yaml"), catalog)
+
+			cfg, err := LoadConfig(configPath, nil, nil)
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			var targets []string
+			for _, src := range cfg.Categories["Apps"].Sources {
+				targets = append(targets, src.OS+"/"+src.Arch)
+			}
+			sort.Strings(targets)
+			if got := strings.Join(targets, ","); got != tt.targets {
+				t.Errorf("Expected %s, got %s", tt.targets, got)
+			}
+		})
+	}
+}
+
+func TestConfigThreadsFor(t *testing.T) {
+	cfg := &Config{General: GeneralConfig{Threads: 4}}
+	if got := cfg.ThreadsFor(Source{}); got != 4 {
+		t.Errorf("Expected general threads 4, got %d", got)
+	}
+	if got := cfg.ThreadsFor(Source{Threads: 1}); got != 1 {
+		t.Errorf("Expected the source override 1, got %d", got)
+	}
+}
+
+func TestConfigMaxSizeFor(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.MaxSizeFor(Source{}); got != 0 {
+		t.Errorf("Expected no limit by default, got %d", got)
+	}
+	cfg.General.MaxDownloadSize = 10 << 30
+	if got := cfg.MaxSizeFor(Source{}); got != 10<<30 {
+		t.Errorf("Expected the general limit, got %d", got)
+	}
+	if got := cfg.MaxSizeFor(Source{MaxSize: 60 << 30}); got != 60<<30 {
+		t.Errorf("Expected the source override, got %d", got)
+	}
+}
+
+func TestLoadConfigExpandEnv(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("LAMP_TEST_DATA", "/data")
+	t.Setenv("LAMP_TEST_MIRROR", "https://mirror.example.com")
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, configPath, "general:\n  os: [linux]\n  arch: [amd64]\n"+
+		"storage:\n  default_root: $LAMP_TEST_DATA/isos\n"+
+		"categories:\n  Apps:\n    path: \"${LAMP_TEST_DATA}/{{os}}\"\n    sources:\n"+
+		"      - name: Tool\n        strategy: web_scrape\n        params:\n"+
+		"          base_url: $LAMP_TEST_MIRROR/pub/\n          version_pattern: 'tool-(\\d+)\\.zip$'\n")
+
+	cfg, err := LoadConfig(configPath, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Storage.DefaultRoot != "/data/isos" {
+		t.Errorf("Expected default_root /data/isos, got %q", cfg.Storage.DefaultRoot)
+	}
+	if got := cfg.Categories["Apps"].Path; got != "/data/{{os}}" {
+		t.Errorf("Expected the template token to survive expansion, got %q", got)
+	}
+	params := cfg.Categories["Apps"].Sources[0].Params
+	if params["base_url"] != "https://mirror.example.com/pub/" {
+		t.Errorf("Expected base_url to be expanded, got %q", params["base_url"])
+	}
+	if params["version_pattern"] != `tool-(\d+)\.zip$` {
+		t.Errorf("Expected the regex anchor to be left alone, got %q", params["version_pattern"])
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("LAMP_TEST_VAR", "value")
+	os.Unsetenv("LAMP_TEST_UNSET")
+
+	tests := []struct{ in, want string }{
+		{"$LAMP_TEST_VAR/x", "value/x"},
+		{"${LAMP_TEST_VAR}x", "valuex"},
+		{"a$LAMP_TEST_UNSET/b", "a/b"},
+		{"${{os}}/$1/^v$", "${{os}}/$1/^v$"},
+		{"{{arch}}", "{{arch}}"},
+	}
+	for _, tt := range tests {
+		if got := expandEnv(tt.in); got != tt.want {
+			t.Errorf("expandEnv(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigQuotas(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, configPath, "general:\n  os: [linux]\n  arch: [amd64]\n"+
+		"storage:\n  default_root: "+root+"\n  max_bytes: 2GB\n  evict_oldest: true\n"+
+		"categories:\n"+
+		"  ISOs:\n    path: "+filepath.Join(root, "isos")+"\n    max_bytes: 1.5 GiB\n    sources:\n      - name: Distro\n        url: https://example.com/distro.iso\n"+
+		"  Nested:\n    path: "+filepath.Join(root, "isos", "extra")+"\n    sources:\n      - name: Extra\n        url: https://example.com/extra.iso\n"+
+		"  Apps:\n    path: "+filepath.Join(root, "apps")+"\n    sources:\n      - name: Tool\n        url: https://example.com/tool.zip\n")
+
+	cfg, err := LoadConfig(configPath, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Storage.MaxBytes != 2_000_000_000 || !cfg.Storage.EvictOldest {
+		t.Errorf("Unexpected storage quota %+v", cfg.Storage)
+	}
+
+	quotas := cfg.QuotasFor("ISOs")
+	if len(quotas) != 2 {
+		t.Fatalf("Expected category and storage quotas, got %+v", quotas)
+	}
+	if quotas[0].Name != "ISOs" || quotas[0].MaxBytes != 1.5*1024*1024*1024 {
+		t.Errorf("Unexpected category quota %+v", quotas[0])
+	}
+	// The nested category's folder is already counted through its parent
+	wantDirs := []string{filepath.Join(root, "apps"), filepath.Join(root, "isos")}
+	if strings.Join(quotas[1].Dirs, ",") != strings.Join(wantDirs, ",") {
+		t.Errorf("Expected storage quota over %v, got %v", wantDirs, quotas[1].Dirs)
+	}
+
+	if quotas := cfg.QuotasFor("Apps"); len(quotas) != 1 || quotas[0].Name != "storage" {
+		t.Errorf("Expected only the storage quota for Apps, got %+v", quotas)
+	}
+
+	writeFile(t, configPath, "storage:\n  max_bytes: lots\n")
+	if _, err := LoadConfig(configPath, nil, nil); err == nil || !strings.Contains(err.Error(), "invalid size") {
+		t.Errorf("Expected an invalid size error, got %v", err)
+	}
+}
+
+func TestSourceKey(t *testing.T) {
+	src := Source{ID: "app", Name: "App [linux/amd64]", OS: "linux", Arch: "amd64", Params: map[string]string{"repo": "example/app", "asset_pattern": "app-linux"}}
+
+	// The key is persisted, so it must not depend on anything that varies between runs
+	if got, want := src.Key(), "761106a9652b5b3b"; got != want {
+		t.Errorf("Key() = %s, want %s", got, want)
+	}
+	renamed := src
+	renamed.Name = "App (Linux x64)"
+	if renamed.Key() != src.Key() {
+		t.Error("Expected a display name change to keep the key")
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	local := t.TempDir()
+	configPath := filepath.Join(local, "config.yaml")
+	writeFile(t, configPath,
+		"general:\n  os: [macos, linux, windows]\n  arch: [amd64, arm64]\ncategories:\n  Apps:\n    sources:\n      - id: app\n")
+	writeFile(t, filepath.Join(local, "catalogs", "apps.yaml"), `sources:
+  - id: app
+    name: App
+    params:
+      file: "app-{{os}}-{{arch}}.zip"
+`)
+	cfg, err := LoadConfig(configPath, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	keys := make(map[string]string)
+	for _, s := range cfg.Categories["Apps"].Sources {
+		if other, ok := keys[s.Key()]; ok {
+			t.Errorf("%s and %s share key %s", s.Name, other, s.Key())
+		}
+		keys[s.Key()] = s.Name
+	}
+	if len(keys) != 6 {
+		t.Errorf("Expected 6 distinct keys, got %v", keys)
+	}
+}
+
+func TestInitConfig(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir, _ := GetConfigDir()
+
+	defaultConfig := []byte("general:\n  os: [linux]\n")
+	catalogFS := fstest.MapFS{
+		"catalogs/apps.yaml": {Data: []byte("sources: []\n")},
+		"catalogs/isos.yaml": {Data: []byte("sources: []\n")},
+	}
+	configFile := filepath.Join(dir, "config.yaml")
+	apps := filepath.Join(dir, "catalogs", "apps.yaml")
+	isos := filepath.Join(dir, "catalogs", "isos.yaml")
+
+	result, err := InitConfig(defaultConfig, catalogFS, false)
+	if err != nil {
+		t.Fatalf("InitConfig failed: %v", err)
+	}
+	if result.Dir != dir || len(result.Created) != 3 || len(result.Skipped) != 0 {
+		t.Fatalf("Expected 3 created files in %s, got %+v", dir, result)
+	}
+
+	// Edited files are kept unless forced
+	writeFile(t, apps, "sources:\n  - id: mine\n")
+	os.Remove(isos)
+	result, err = InitConfig(defaultConfig, catalogFS, false)
+	if err != nil {
+		t.Fatalf("InitConfig failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Created, []string{isos}) || !reflect.DeepEqual(result.Skipped, []string{configFile, apps}) {
+		t.Errorf("Unexpected result without force: %+v", result)
+	}
+	if data, _ := os.ReadFile(apps); string(data) != "sources:\n  - id: mine\n" {
+		t.Errorf("Existing catalog was overwritten: %q", data)
+	}
+
+	result, err = InitConfig(defaultConfig, catalogFS, true)
+	if err != nil {
+		t.Fatalf("InitConfig failed: %v", err)
+	}
+	if len(result.Created) != 3 || len(result.Skipped) != 0 {
+		t.Errorf("Expected every file rewritten with force, got %+v", result)
+	}
+	if data, _ := os.ReadFile(apps); string(data) != "sources: []\n" {
+		t.Errorf("Expected the catalog to be replaced, got %q", data)
+	}
+}
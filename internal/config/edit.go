@@ -0,0 +1,193 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AddSource appends src to the sources of category in the config file at
+// configPath. The entry is inserted as text after the category's last source, so
+// the rest of the file, comments included, is kept as-is; the file is only
+// written if it still parses with the new source in place.
+func AddSource(configPath, category string, src Source) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("config is invalid: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("config has no categories")
+	}
+	categories := mappingValue(doc.Content[0], "categories")
+	if categories == nil || categories.Kind != yaml.MappingNode {
+		return fmt.Errorf("config has no categories")
+	}
+	catKey, cat := mappingEntry(categories, category)
+	if cat == nil {
+		return fmt.Errorf("category '%s' is not in the config", category)
+	}
+
+	var (
+		after  int // Line the entry goes after
+		indent int // Column of the entry's "- "
+		header string
+	)
+	sources := mappingValue(cat, "sources")
+	switch {
+	case sources != nil && sources.Kind == yaml.SequenceNode && sources.Style&yaml.FlowStyle == 0 && len(sources.Content) > 0:
+		after = lastLine(sources)
+		// Items are reported at the column of their content, just past the "- "
+		indent = sources.Content[0].Column - 3
+	case sources == nil && cat.Kind == yaml.MappingNode && cat.Style&yaml.FlowStyle == 0 && len(cat.Content) > 0:
+		after = lastLine(cat)
+		keyIndent := cat.Content[0].Column - 1
+		header = strings.Repeat(" ", keyIndent) + "sources:\n"
+		indent = keyIndent + 2
+	case sources == nil && cat.Kind == yaml.ScalarNode && cat.Value == "":
+		// "Name:" with nothing under it yet
+		after = catKey.Line
+		header = strings.Repeat(" ", catKey.Column+1) + "sources:\n"
+		indent = catKey.Column + 3
+	default:
+		return fmt.Errorf("the sources of '%s' are not a block list; add the source by hand", category)
+	}
+
+	entry, err := marshalSourceEntry(src, indent)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	if after > len(lines) {
+		after = len(lines)
+	}
+	var b strings.Builder
+	for _, l := range lines[:after] {
+		b.WriteString(l)
+	}
+	if after > 0 && !strings.HasSuffix(lines[after-1], "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(header)
+	b.WriteString(entry)
+	for _, l := range lines[after:] {
+		b.WriteString(l)
+	}
+	content := b.String()
+
+	var before, updated Config
+	yaml.Unmarshal(data, &before)
+	if err := yaml.Unmarshal([]byte(content), &updated); err != nil ||
+		len(updated.Categories[category].Sources) != len(before.Categories[category].Sources)+1 {
+		return fmt.Errorf("could not place the source in '%s'; add it by hand", category)
+	}
+
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(configPath, []byte(content), info.Mode().Perm())
+}
+
+// MarshalCatalogEntry renders src as a "sources:" list item indented to sit
+// under the key of a catalog file
+func MarshalCatalogEntry(src Source) (string, error) {
+	return marshalSourceEntry(src, 2)
+}
+
+// AppendCatalogEntry adds src to the end of the sources list of the catalog file
+// at catalogPath, creating the file if needed. Existing text (and comments) are
+// kept as-is.
+func AppendCatalogEntry(catalogPath string, src Source) error {
+	entry, err := MarshalCatalogEntry(src)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(catalogPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var existing Catalog
+	if err := yaml.Unmarshal(data, &existing); err != nil {
+		return fmt.Errorf("existing catalog is invalid: %w", err)
+	}
+	for _, s := range existing.Sources {
+		if s.ID == src.ID {
+			return fmt.Errorf("an entry with id '%s' already exists", src.ID)
+		}
+	}
+
+	content := string(data)
+	if len(existing.Sources) == 0 && !strings.Contains(content, "sources:") {
+		content += "sources:\n"
+	} else if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += entry
+
+	// Only write the result if it still parses with the new entry at the end
+	var updated Catalog
+	if err := yaml.Unmarshal([]byte(content), &updated); err != nil || len(updated.Sources) != len(existing.Sources)+1 {
+		return fmt.Errorf("sources is not the last section of the catalog; add the entry by hand")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(catalogPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(catalogPath, []byte(content), 0644)
+}
+
+// marshalSourceEntry renders src as a list item whose "- " starts at column indent
+func marshalSourceEntry(src Source, indent int) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode([]Source{src}); err != nil {
+		return "", err
+	}
+	enc.Close()
+
+	pad := strings.Repeat(" ", indent)
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		b.WriteString(pad + line + "\n")
+	}
+	return b.String(), nil
+}
+
+// mappingEntry returns the key and value nodes of key in a mapping node
+func mappingEntry(m *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i], m.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	_, v := mappingEntry(m, key)
+	return v
+}
+
+// lastLine is the last line a block node's content reaches
+func lastLine(n *yaml.Node) int {
+	last := n.Line
+	for _, c := range n.Content {
+		if l := lastLine(c); l > last {
+			last = l
+		}
+	}
+	return last
+}
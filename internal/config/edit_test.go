@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAddSource(t *testing.T) {
+	original := `# My config
+storage:
+  default_root: "./Downloads"
+
+categories:
+  Apps:
+    path: "./Apps" # where apps go
+    sources:
+      - id: "firefox"
+      - id: "vlc"
+        name: "VLC"
+  # Emulators and the like
+  Games:
+    path: "./Games"
+  Empty:
+`
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	src := Source{Name: "Tool", Strategy: "github_release", Params: map[string]string{"repo": "a/tool", "asset_pattern": `tool-.*\.zip`}}
+	for _, cat := range []string{"Apps", "Games", "Empty"} {
+		if err := AddSource(path, cat, src); err != nil {
+			t.Fatalf("AddSource(%s): %v", cat, err)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	for _, keep := range []string{"# My config", "# where apps go", "# Emulators and the like"} {
+		if !strings.Contains(string(data), keep) {
+			t.Errorf("Expected %q to be kept, got:\n%s", keep, data)
+		}
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	apps := cfg.Categories["Apps"].Sources
+	if len(apps) != 3 || apps[1].Name != "VLC" || apps[2].Name != "Tool" || apps[2].Params["asset_pattern"] != `tool-.*\.zip` {
+		t.Errorf("Expected the source after the existing two, got %+v", apps)
+	}
+	if cfg.Categories["Games"].Path != "./Games" || len(cfg.Categories["Games"].Sources) != 1 {
+		t.Errorf("Expected a sources list to be added to Games, got %+v", cfg.Categories["Games"])
+	}
+	if len(cfg.Categories["Empty"].Sources) != 1 {
+		t.Errorf("Expected a sources list to be added to Empty, got %+v", cfg.Categories["Empty"])
+	}
+
+	if err := AddSource(path, "Missing", src); err == nil {
+		t.Error("Expected an error for an unknown category")
+	}
+	os.WriteFile(path, []byte("categories:\n  Apps: {sources: []}\n"), 0600)
+	if err := AddSource(path, "Apps", src); err == nil {
+		t.Error("Expected flow-style sources to be refused")
+	}
+}
+
+func TestAppendCatalogEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalogs", "tracked.yaml")
+	first := Source{ID: "kiwix-wikipedia_en_100", Name: "Wikipedia 100", Strategy: "kiwix_feed", Params: map[string]string{"series": "wikipedia_en_100"}}
+	second := Source{ID: "gutenberg-84", Name: "Frankenstein", Strategy: "direct", Params: map[string]string{"url": "https://www.gutenberg.org/ebooks/84.epub3.images"}}
+
+	// The file and its folder are created for the first entry
+	if err := AppendCatalogEntry(path, first); err != nil {
+		t.Fatalf("AppendCatalogEntry: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, append([]byte("# Tracked from the library tabs\n"), data...), 0644)
+	if err := AppendCatalogEntry(path, second); err != nil {
+		t.Fatalf("AppendCatalogEntry: %v", err)
+	}
+	if err := AppendCatalogEntry(path, first); err == nil {
+		t.Error("Expected an error for an id that is already in the catalog")
+	}
+
+	data, _ = os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "# Tracked from the library tabs") {
+		t.Errorf("Expected the comment to be kept, got:\n%s", data)
+	}
+	var catalog Catalog
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
+		t.Fatal(err)
+	}
+	if len(catalog.Sources) != 2 || catalog.Sources[0].ID != first.ID || catalog.Sources[1].Params["url"] != second.Params["url"] {
+		t.Errorf("Expected both entries in order, got %+v", catalog.Sources)
+	}
+}
@@ -0,0 +1,6 @@
+package config
+
+// Exported for the tests in package config_test, which can import core
+var LoadRemoteCatalogs = loadRemoteCatalogs
+
+const RemoteCatalogTTL = remoteCatalogTTL
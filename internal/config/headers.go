@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// reservedHeaders are managed by LAMP or the HTTP client; setting them from a
+// source would break segmented downloads, caching or auth
+var reservedHeaders = map[string]bool{
+	"Accept-Encoding":   true,
+	"Authorization":     true,
+	"Connection":        true,
+	"Content-Length":    true,
+	"Host":              true,
+	"If-Modified-Since": true,
+	"If-None-Match":     true,
+	"If-Range":          true,
+	"Range":             true,
+	"Te":                true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+}
+
+// validHeaderName reports whether name is an HTTP token (RFC 9110 section 5.6.2)
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r > 0x7e || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHeaders returns the usable headers of a source, with canonical names, and
+// a reason for each one it drops
+func CheckHeaders(headers map[string]string) (map[string]string, []string) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	usable := make(map[string]string, len(headers))
+	var problems []string
+	for _, name := range names {
+		value := headers[name]
+		canonical := http.CanonicalHeaderKey(strings.TrimSpace(name))
+		switch {
+		case !validHeaderName(canonical):
+			problems = append(problems, fmt.Sprintf("'%s' is not a valid header name", name))
+		case reservedHeaders[canonical]:
+			problems = append(problems, fmt.Sprintf("%s cannot be overridden", canonical))
+		case strings.ContainsAny(value, "\r\n"):
+			problems = append(problems, fmt.Sprintf("the value of %s contains a line break", canonical))
+		default:
+			usable[canonical] = value
+		}
+	}
+	return usable, problems
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"gopkg.in/yaml.v3"
+)
+
+// ByteSize is a size in bytes, written in the config as a number or with a
+// unit such as "500MB" or "1.5 TiB"
+type ByteSize int64
+
+func (b *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	n, err := humanize.ParseBytes(value.Value)
+	if err != nil {
+		return fmt.Errorf("invalid size %q: %w", value.Value, err)
+	}
+	*b = ByteSize(n)
+	return nil
+}
+
+// Quota is a storage limit over a set of directories
+type Quota struct {
+	Name     string // "storage" or the category name, for messages
+	MaxBytes int64
+	Dirs     []string
+}
+
+// QuotasFor returns the quotas a download into category must stay within: the
+// category's max_bytes over its folders and storage.max_bytes over the folders of
+// every category
+func (c *Config) QuotasFor(category string) []Quota {
+	var quotas []Quota
+	if cat, ok := c.Categories[category]; ok && cat.MaxBytes > 0 {
+		quotas = append(quotas, Quota{Name: category, MaxBytes: int64(cat.MaxBytes), Dirs: c.categoryDirs(category)})
+	}
+	if c.Storage.MaxBytes > 0 {
+		all := make(map[string]bool)
+		for name := range c.Categories {
+			for _, dir := range c.categoryDirs(name) {
+				all[dir] = true
+			}
+		}
+		quotas = append(quotas, Quota{Name: "storage", MaxBytes: int64(c.Storage.MaxBytes), Dirs: OutermostDirs(all)})
+	}
+	return quotas
+}
+
+// categoryDirs returns the folders the sources of category download into
+func (c *Config) categoryDirs(category string) []string {
+	dirs := make(map[string]bool)
+	for _, src := range c.Categories[category].Sources {
+		if target := c.GetTargetPath(category, src); target != "" {
+			dirs[filepath.Dir(target)] = true
+		}
+	}
+	return OutermostDirs(dirs)
+}
+
+// OutermostDirs drops directories that are nested inside another directory of
+// the set, so that nothing is counted twice, and sorts the rest
+func OutermostDirs(set map[string]bool) []string {
+	var out []string
+	for dir := range set {
+		nested := false
+		for other := range set {
+			if other != dir && strings.HasPrefix(dir, other+string(filepath.Separator)) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			out = append(out, dir)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const remoteCatalogTTL = 24 * time.Hour
+
+var (
+	validateURL   func(rawURL string) error
+	checkRedirect func(req *http.Request, via []*http.Request) error
+)
+
+// SetURLPolicy sets the checks remote catalog URLs and their redirects must pass,
+// so catalogs are held to the same rules as downloads. config cannot import core,
+// which installs ValidateDownloadURL and ValidateRedirect; without a policy no
+// catalog is fetched.
+func SetURLPolicy(validate func(rawURL string) error, redirect func(req *http.Request, via []*http.Request) error) {
+	validateURL, checkRedirect = validate, redirect
+}
+
+// loadRemoteCatalogs fetches the catalogs at urls, caching each under cacheDir.
+// A cached copy younger than the TTL is used without fetching, and an older cached
+// copy is used (with a warning) when the fetch fails so offline launches still work.
+// In offline mode only cached copies are used, whatever their age.
+func loadRemoteCatalogs(urls []string, cacheDir string, offline bool) ([]Source, []string) {
+	var sources []Source
+	var warnings []string
+
+	client := &http.Client{Timeout: 30 * time.Second, CheckRedirect: checkRedirect}
+
+	for _, catalogURL := range urls {
+		cachePath := ""
+		if cacheDir != "" {
+			sum := sha256.Sum256([]byte(catalogURL))
+			cachePath = filepath.Join(cacheDir, hex.EncodeToString(sum[:8])+".yaml")
+		}
+
+		var data []byte
+		if cachePath != "" {
+			if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) < remoteCatalogTTL {
+				data, _ = os.ReadFile(cachePath)
+			}
+		}
+
+		if data == nil && offline {
+			if cached, err := os.ReadFile(cachePath); cachePath != "" && err == nil {
+				data = cached
+			} else {
+				warnings = append(warnings, fmt.Sprintf("Offline: no cached copy of catalog %s", catalogURL))
+				continue
+			}
+		}
+
+		if data == nil {
+			fetched, err := fetchRemoteCatalog(client, catalogURL)
+			if err == nil {
+				data = fetched
+				if cachePath != "" {
+					if err := os.MkdirAll(cacheDir, 0755); err == nil {
+						os.WriteFile(cachePath, data, 0644)
+					}
+				}
+			} else if cached, cacheErr := os.ReadFile(cachePath); cachePath != "" && cacheErr == nil {
+				data = cached
+				warnings = append(warnings, fmt.Sprintf("Failed to fetch catalog %s (%v); using cached copy", catalogURL, err))
+			} else {
+				warnings = append(warnings, fmt.Sprintf("Failed to fetch catalog %s: %v", catalogURL, err))
+				continue
+			}
+		}
+
+		var catalog Catalog
+		if err := yaml.Unmarshal(data, &catalog); err != nil {
+			warnings = append(warnings, fmt.Sprintf("Failed to parse catalog %s: %v", catalogURL, err))
+			continue
+		}
+		for _, src := range catalog.Sources {
+			// Commands are only taken from files on this machine
+			if src.PostDownload != "" {
+				warnings = append(warnings, fmt.Sprintf("Ignoring post_download of %q from catalog %s", src.ID, catalogURL))
+				src.PostDownload = ""
+			}
+			// So are settings that delete files
+			if src.KeepVersions != 0 {
+				warnings = append(warnings, fmt.Sprintf("Ignoring keep_versions of %q from catalog %s", src.ID, catalogURL))
+				src.KeepVersions = 0
+			}
+			// A remote catalog could otherwise send local secrets to a server of its choosing
+			if src.Auth != nil {
+				warnings = append(warnings, fmt.Sprintf("Ignoring auth of %q from catalog %s", src.ID, catalogURL))
+				src.Auth = nil
+			}
+			// Headers apply to every request for a host, so they could reach other sources' requests
+			if len(src.Headers) > 0 {
+				warnings = append(warnings, fmt.Sprintf("Ignoring headers of %q from catalog %s", src.ID, catalogURL))
+				src.Headers = nil
+			}
+			sources = append(sources, src)
+		}
+	}
+
+	return sources, warnings
+}
+
+func fetchRemoteCatalog(client *http.Client, catalogURL string) ([]byte, error) {
+	if validateURL == nil || checkRedirect == nil {
+		return nil, fmt.Errorf("no URL policy set for remote catalogs")
+	}
+	if err := validateURL(catalogURL); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", catalogURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "lamp/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
+}
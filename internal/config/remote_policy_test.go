@@ -0,0 +1,54 @@
+package config_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"lamp/internal/config"
+	_ "lamp/internal/core" // Installs the URL policy
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadRemoteCatalogsRejectsInsecureRedirect(t *testing.T) {
+	var plainRequests int
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		plainRequests++
+		w.Write([]byte("sources:\n  - id: injected-app\n"))
+	}))
+	defer plain.Close()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL+"/catalog.yaml", http.StatusFound)
+	}))
+	defer server.Close()
+
+	// Trust the test server's certificate
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = server.Client().Transport
+	t.Cleanup(func() { http.DefaultTransport = defaultTransport })
+
+	// A stale cached copy stands in when the fetch fails
+	cacheDir := t.TempDir()
+	sum := sha256.Sum256([]byte(server.URL))
+	cachePath := filepath.Join(cacheDir, hex.EncodeToString(sum[:8])+".yaml")
+	if err := os.WriteFile(cachePath, []byte("sources:\n  - id: cached-app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * config.RemoteCatalogTTL)
+	os.Chtimes(cachePath, old, old)
+
+	sources, warnings := config.LoadRemoteCatalogs([]string{server.URL}, cacheDir, false)
+	if len(sources) != 1 || sources[0].ID != "cached-app" {
+		t.Errorf("Expected the cached catalog, got %v", sources)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "insecure scheme") || !strings.Contains(warnings[0], "cached copy") {
+		t.Errorf("Expected a warning about the insecure redirect, got %v", warnings)
+	}
+	if plainRequests != 0 {
+		t.Errorf("Expected the plain HTTP server not to be asked, got %d requests", plainRequests)
+	}
+}
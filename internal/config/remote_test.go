@@ -0,0 +1,111 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadRemoteCatalogs(t *testing.T) {
+	catalogYAML := `
+sources:
+  - id: "remote-app"
+    name: "Remote App"
+    strategy: "github_release"
+`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(catalogYAML))
+	}))
+
+	cacheDir := t.TempDir()
+
+	sources, warnings := loadRemoteCatalogs([]string{server.URL}, cacheDir, false)
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", warnings)
+	}
+	if len(sources) != 1 || sources[0].ID != "remote-app" {
+		t.Fatalf("Expected remote-app source, got %v", sources)
+	}
+
+	cached, _ := filepath.Glob(filepath.Join(cacheDir, "*.yaml"))
+	if len(cached) != 1 {
+		t.Fatalf("Expected 1 cached catalog, got %d", len(cached))
+	}
+
+	// Expire the cache and take the server down: the stale copy should be used
+	old := time.Now().Add(-2 * remoteCatalogTTL)
+	if err := os.Chtimes(cached[0], old, old); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	sources, warnings = loadRemoteCatalogs([]string{server.URL}, cacheDir, false)
+	if len(sources) != 1 {
+		t.Errorf("Expected cached source after fetch failure, got %v", sources)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "cached copy") {
+		t.Errorf("Expected cached-copy warning, got %v", warnings)
+	}
+}
+
+func TestLoadRemoteCatalogsDropsHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("sources:\n  - id: remote-app\n    post_download: \"rm -rf ~\"\n    keep_versions: 1\n    auth:\n      type: bearer\n      token_env: HOME\n    headers:\n      Cookie: session=stolen\n"))
+	}))
+	defer server.Close()
+
+	sources, warnings := loadRemoteCatalogs([]string{server.URL}, "", false)
+	if len(sources) != 1 || sources[0].PostDownload != "" || sources[0].KeepVersions != 0 || sources[0].Auth != nil || sources[0].Headers != nil {
+		t.Fatalf("Expected the remote post_download, keep_versions, auth and headers to be dropped, got %+v", sources)
+	}
+	if len(warnings) != 4 || !strings.Contains(warnings[0], "post_download") || !strings.Contains(warnings[1], "keep_versions") || !strings.Contains(warnings[2], "auth") || !strings.Contains(warnings[3], "headers") {
+		t.Errorf("Expected warnings about the dropped settings, got %v", warnings)
+	}
+}
+
+func TestLoadRemoteCatalogsRejectsHTTP(t *testing.T) {
+	sources, warnings := loadRemoteCatalogs([]string{"http://example.com/catalog.yaml"}, t.TempDir(), false)
+	if len(sources) != 0 {
+		t.Errorf("Expected no sources, got %v", sources)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "insecure") {
+		t.Errorf("Expected insecure URL warning, got %v", warnings)
+	}
+}
+
+func TestLoadRemoteCatalogsOffline(t *testing.T) {
+	var requests int
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	sources, warnings := loadRemoteCatalogs([]string{server.URL}, cacheDir, true)
+	if len(sources) != 0 || len(warnings) != 1 || !strings.Contains(warnings[0], "Offline") {
+		t.Errorf("Expected an offline warning without a cache, got %v %v", sources, warnings)
+	}
+
+	// A stale cached copy is used as-is
+	sum := sha256.Sum256([]byte(server.URL))
+	cachePath := filepath.Join(cacheDir, hex.EncodeToString(sum[:8])+".yaml")
+	if err := os.WriteFile(cachePath, []byte("sources:\n  - id: cached-app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * remoteCatalogTTL)
+	os.Chtimes(cachePath, old, old)
+
+	sources, warnings = loadRemoteCatalogs([]string{server.URL}, cacheDir, true)
+	if len(sources) != 1 || sources[0].ID != "cached-app" || len(warnings) != 0 {
+		t.Errorf("Expected the cached catalog, got %v %v", sources, warnings)
+	}
+	if requests != 0 {
+		t.Errorf("Expected no requests in offline mode, got %d", requests)
+	}
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"fmt"
+	"lamp/internal/config"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// credentials maps a lowercase host to the auth of the source that names it
+var credentials atomic.Pointer[map[string]*config.Auth]
+
+// SetCredentials makes requests to the hosts of sources with auth carry their
+// credentials, replacing those set before. It returns a warning for each host two
+// sources give different credentials for; the first source's are used.
+func SetCredentials(cfg *config.Config) []string {
+	hosts := make(map[string]*config.Auth)
+	owner := make(map[string]string)
+	var warnings []string
+	names := make([]string, 0, len(cfg.Categories))
+	for name := range cfg.Categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, src := range cfg.Categories[name].Sources {
+			for _, host := range src.AuthHosts() {
+				prev, ok := hosts[host]
+				if !ok {
+					hosts[host] = src.Auth
+					owner[host] = src.Name
+					continue
+				}
+				if prev != src.Auth && !sameCredentials(prev, src.Auth) {
+					warnings = append(warnings, fmt.Sprintf("Sources '%s' and '%s' set different auth for %s; using the first", owner[host], src.Name, host))
+				}
+			}
+		}
+	}
+	credentials.Store(&hosts)
+	return warnings
+}
+
+// sameCredentials reports whether a and b send the same header
+func sameCredentials(a, b *config.Auth) bool {
+	ha, errA := a.Header()
+	hb, errB := b.Header()
+	return errA == nil && errB == nil && ha == hb
+}
+
+// authTransport adds the registered credentials to HTTPS requests for their
+// hosts, and the registered headers to any request for theirs. Release assets
+// fetched through the GitHub API get the GitHub token. A header set by the
+// caller, such as go-github's token, is left alone.
+type authTransport struct {
+	base http.RoundTripper // nil sends through the transport from SetTransport
+}
+
+// AuthTransport wraps base, or http.DefaultTransport when it is nil, to add the
+// credentials from SetCredentials and the headers from SetHeaders. Clients built
+// outside core, such as the downloader's, use it so they apply to every request
+// for a host.
+func AuthTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return authTransport{base: base}
+}
+
+func (t authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = transport
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	host := strings.ToLower(req.URL.Host)
+	if headers := headersFor(host); len(headers) > 0 {
+		req = req.Clone(req.Context())
+		for name, value := range headers {
+			// Source headers replace defaults such as lamp's User-Agent
+			req.Header.Set(name, value)
+		}
+	}
+	if isGitHubAssetRequest(req) {
+		req = applyGitHubAssetHeaders(req)
+	}
+	if req.URL.Scheme != "https" || req.Header.Get("Authorization") != "" {
+		return base.RoundTrip(req)
+	}
+	hosts := credentials.Load()
+	if hosts == nil {
+		return base.RoundTrip(req)
+	}
+	auth, ok := (*hosts)[host]
+	if !ok {
+		return base.RoundTrip(req)
+	}
+	header, err := auth.Header()
+	if err != nil {
+		// Reported as a config warning; the server's 401 says the rest
+		return base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", header)
+	return base.RoundTrip(req)
+}
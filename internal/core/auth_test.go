@@ -0,0 +1,70 @@
+package core
+
+import (
+	"lamp/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordingTransport answers every request with 200 and keeps the Authorization header
+type recordingTransport struct {
+	seen map[string]string // URL to Authorization header
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.seen[req.URL.String()] = req.Header.Get("Authorization")
+	rec := httptest.NewRecorder()
+	return rec.Result(), nil
+}
+
+func TestAuthTransport(t *testing.T) {
+	defer credentials.Store(nil)
+	cfg := &config.Config{Categories: map[string]config.Category{
+		"Mirrors": {Sources: []config.Source{
+			{Name: "Private", URL: "https://mirror.example.org/app.iso", Auth: &config.Auth{Type: "bearer", Token: "secret"}},
+			{Name: "Other", URL: "https://mirror.example.org/other.iso", Auth: &config.Auth{Type: "bearer", Token: "different"}},
+		}},
+	}}
+	warnings := SetCredentials(cfg)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "mirror.example.org") {
+		t.Errorf("Expected a warning about the conflicting credentials, got %v", warnings)
+	}
+
+	rt := &recordingTransport{seen: make(map[string]string)}
+	client := &http.Client{Transport: AuthTransport(rt)}
+	for _, u := range []string{
+		"https://mirror.example.org/app.iso",
+		"https://cdn.example.org/app.iso",
+		"http://mirror.example.org/app.iso",
+	} {
+		resp, err := client.Get(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if got := rt.seen["https://mirror.example.org/app.iso"]; got != "Bearer secret" {
+		t.Errorf("Expected the first source's token for its host, got %q", got)
+	}
+	if got := rt.seen["https://cdn.example.org/app.iso"]; got != "" {
+		t.Errorf("Expected no credentials for another host, got %q", got)
+	}
+	if got := rt.seen["http://mirror.example.org/app.iso"]; got != "" {
+		t.Errorf("Expected no credentials over plain HTTP, got %q", got)
+	}
+
+	// A header the caller set, such as the GitHub token, is kept
+	req, _ := http.NewRequest("GET", "https://mirror.example.org/app.iso", nil)
+	req.Header.Set("Authorization", "token gh")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := rt.seen["https://mirror.example.org/app.iso"]; got != "token gh" {
+		t.Errorf("Expected the caller's header to be kept, got %q", got)
+	}
+}
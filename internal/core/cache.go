@@ -0,0 +1,66 @@
+package core
+
+import (
+	"errors"
+	"lamp/internal/config"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCacheTTL is how long cached Gutenberg and Kiwix catalogs are used
+// before they are fetched again
+const DefaultCacheTTL = 24 * time.Hour
+
+var catalogCacheTTL atomic.Int64
+
+func init() {
+	catalogCacheTTL.Store(int64(DefaultCacheTTL))
+}
+
+// SetCacheTTL sets the lifetime of the Gutenberg and Kiwix catalog caches.
+// A non-positive ttl restores the default.
+func SetCacheTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	catalogCacheTTL.Store(int64(ttl))
+}
+
+func cacheTTL() time.Duration {
+	return time.Duration(catalogCacheTTL.Load())
+}
+
+// cacheFiles are the cache files kept in the lamp config directory
+var cacheFiles = []string{"gutenberg_cache.json", "kiwix_cache.json", "status_cache.json", "github_cache.json"}
+
+// ClearCaches deletes the Gutenberg, Kiwix, status and GitHub release caches from
+// the lamp config directory, returning the paths that were removed
+func ClearCaches() ([]string, error) {
+	lampDir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return clearCacheFiles(lampDir)
+}
+
+func clearCacheFiles(dir string) ([]string, error) {
+	var removed []string
+	var errs []error
+	for _, name := range cacheFiles {
+		path := filepath.Join(dir, name)
+		if err := os.Remove(path); err == nil {
+			removed = append(removed, path)
+		} else if !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	if sc := statusCache.Load(); sc != nil {
+		sc.reset()
+	}
+	if rc := releaseCache.Load(); rc != nil {
+		rc.reset()
+	}
+	return removed, errors.Join(errs...)
+}
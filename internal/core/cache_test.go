@@ -0,0 +1,41 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSetCacheTTL(t *testing.T) {
+	t.Cleanup(func() { SetCacheTTL(0) })
+
+	SetCacheTTL(2 * time.Hour)
+	if got := cacheTTL(); got != 2*time.Hour {
+		t.Errorf("Expected 2h, got %v", got)
+	}
+	SetCacheTTL(0)
+	if got := cacheTTL(); got != DefaultCacheTTL {
+		t.Errorf("Expected the default TTL, got %v", got)
+	}
+}
+
+func TestClearCacheFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"gutenberg_cache.json", "status_cache.json", "notified.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := clearCacheFiles(dir)
+	if err != nil {
+		t.Fatalf("clearCacheFiles failed: %v", err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("Expected 2 removed files, got %v", removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notified.json")); err != nil {
+		t.Errorf("Expected notification state to be kept: %v", err)
+	}
+}
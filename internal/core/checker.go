@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"html"
 	"io"
 	"lamp/internal/config"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -22,9 +24,20 @@ import (
 
 var (
 	githubCache sync.Map // map[string]*github.RepositoryRelease
-	webCache    sync.Map // map[string]string (URL:Body)
+	webCache    sync.Map // map[string]webListing (URL:Listing)
+	cacheLocks  sync.Map // map[string]*sync.Mutex, serializes fills of the caches above
 )
 
+// lockCacheKey serializes cache fills for a key so concurrent checks of sources
+// sharing a repo or page make one request instead of racing on a cache miss.
+// Callers must re-check the cache after acquiring the lock.
+func lockCacheKey(key string) func() {
+	v, _ := cacheLocks.LoadOrStore(key, &sync.Mutex{})
+	mu := v.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
 // HTTPClient interface for dependency injection
 type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
@@ -36,12 +49,13 @@ type HTTPClient interface {
 type Checker struct {
 	client      HTTPClient
 	githubToken string
+	github      *github.Client // Built from client and githubToken on first use unless set
 }
 
 // NewChecker creates a new Checker with a default or custom HTTP client
 func NewChecker(client HTTPClient, githubToken string) *Checker {
 	if client == nil {
-		client = &http.Client{Timeout: 30 * time.Second}
+		client = newHTTPClient()
 	}
 	return &Checker{
 		client:      client,
@@ -49,6 +63,45 @@ func NewChecker(client HTTPClient, githubToken string) *Checker {
 	}
 }
 
+// SetGithubClient makes the checker query GitHub through gc, e.g. one pointed at a
+// GitHub Enterprise or test server, instead of a client built from its HTTP client
+// and token
+func (c *Checker) SetGithubClient(gc *github.Client) {
+	c.github = gc
+}
+
+// githubClient returns the injected GitHub client, or one that sends its requests
+// through the checker's HTTP client
+func (c *Checker) githubClient() *github.Client {
+	if c.github != nil {
+		return c.github
+	}
+	client := github.NewClient(asHTTPClient(c.client))
+	if c.githubToken != "" {
+		client = client.WithAuthToken(c.githubToken)
+	} else if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		client = client.WithAuthToken(token)
+	}
+	return client
+}
+
+// asHTTPClient adapts an HTTPClient for libraries that need an *http.Client
+func asHTTPClient(client HTTPClient) *http.Client {
+	if hc, ok := client.(*http.Client); ok {
+		return hc
+	}
+	return &http.Client{Transport: clientTransport{client}}
+}
+
+// clientTransport sends requests through an HTTPClient
+type clientTransport struct {
+	client HTTPClient
+}
+
+func (t clientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.client.Do(req)
+}
+
 func parseRepo(repo string) (string, string, error) {
 	parts := strings.Split(repo, "/")
 	if len(parts) != 2 {
@@ -65,14 +118,55 @@ const (
 	StatusNotFound   VersionStatus = "Local File Not Found"
 	StatusDownloaded VersionStatus = "Downloaded"
 	StatusError      VersionStatus = "Error Checking"
+	StatusIncomplete VersionStatus = "Incomplete Download"
 )
 
+// Symbol returns a marker for the status that reads without color: ✓ up to date,
+// ↑ newer version, ✗ missing or failed, ? found locally but not compared.
+// Statuses outside the list above have no symbol.
+func (s VersionStatus) Symbol() string {
+	switch s {
+	case StatusUpToDate:
+		return "✓"
+	case StatusNewer:
+		return "↑"
+	case StatusNotFound, StatusError, StatusIncomplete:
+		return "✗"
+	case StatusDownloaded:
+		return "?"
+	}
+	return ""
+}
+
+// FileChange describes what a download would do to the local files, e.g.
+// "app-1.0.zip → app-1.1.zip", "→ app-1.1.zip" when nothing is local yet, or just
+// the filename when local and remote are the same file. It is empty when neither is known.
+func FileChange(local, remote string) string {
+	switch {
+	case local == "" && remote == "":
+		return ""
+	case local == "":
+		return "→ " + remote
+	case remote == "" || local == remote:
+		return local
+	}
+	return local + " → " + remote
+}
+
 type CheckResult struct {
 	Status      VersionStatus
 	Current     string // Local version found
 	Latest      string // Latest version available
 	Message     string
-	ResolvedURL string // The dynamic URL found during checking
+	ResolvedURL string   // The dynamic URL found during checking
+	Checksum    string   // Checksum of ResolvedURL published by the source, if any
+	ExtraURLs   []string // Companion files downloaded next to ResolvedURL, e.g. signatures or SHA256SUMS
+	Size        int64    // Size of ResolvedURL in bytes as published by the source, 0 if unknown
+	// LocalFilename is the local file the status was read from; RemoteFilename is
+	// the file ResolvedURL downloads. For a newer release the download supersedes LocalFilename.
+	LocalFilename  string
+	RemoteFilename string
+	Cached         bool // Served from the status cache in offline mode
 }
 
 // Fedora CoreOS Metadata
@@ -92,21 +186,10 @@ type FedoraArtifact struct {
 type FedoraImage struct {
 	Disk struct {
 		Location string `json:"location"`
+		Sha256   string `json:"sha256"`
 	} `json:"disk"`
 }
 
-// Kiwix XML Feed Structures (Atom)
-type Feed struct {
-	XMLName xml.Name `xml:"feed"`
-	Entries []Entry  `xml:"entry"`
-}
-
-type Entry struct {
-	Name    string `xml:"name"`
-	Flavour string `xml:"flavour"`
-	Issued  string `xml:"issued"` // Format: 2025-10-16T00:00:00Z
-}
-
 // RSS 2.0 Structures
 type RSS struct {
 	XMLName xml.Name   `xml:"rss"`
@@ -133,24 +216,10 @@ type CommonPrefix struct {
 	Prefix string `xml:"Prefix"`
 }
 
-func ScanLocalStatus(src config.Source, localPath string) CheckResult {
-	targetDir := filepath.Dir(localPath)
-
-	// Check if the specific file matches exact path (if filename is static)
-	if _, err := os.Stat(localPath); err == nil {
-		// Only valid if localPath doesn't point to a directory or generic name
-		if !strings.HasSuffix(localPath, src.Name) {
-			return CheckResult{Status: StatusDownloaded, Current: "installed"}
-		}
-	}
-
-	// Check if target directory exists
-	entries, err := os.ReadDir(targetDir)
-	if err != nil {
-		return CheckResult{Status: StatusNotFound}
-	}
-
-	// Strategy-agnostic pattern matching based on Params
+// localFilePatterns returns the regexes, strategy-agnostic and built from Params,
+// that the local files of src are recognised by; a capture group, when present,
+// holds the version
+func localFilePatterns(src config.Source) []string {
 	var patterns []string
 
 	// 1. Explicit Filename
@@ -202,23 +271,49 @@ func ScanLocalStatus(src config.Source, localPath string) CheckResult {
 		pat := fmt.Sprintf("^%s_%s_%s_(.*?)\\..*$", regexp.QuoteMeta(name), osName, archName)
 		patterns = append(patterns, pat)
 	}
+	return patterns
+}
+
+// compileLocalPattern anchors a pattern from localFilePatterns and adds a version
+// capture group if it has none
+func compileLocalPattern(pat string) (*regexp.Regexp, error) {
+	// Ensure capture group for version if missing
+	if !strings.Contains(pat, "(") {
+		pat = strings.Replace(pat, ".*", "(.*?)", 1)
+	}
+	// Ensure anchors
+	if !strings.HasPrefix(pat, "^") {
+		pat = "^" + pat
+	}
+	if !strings.HasSuffix(pat, "$") {
+		pat = pat + "$"
+	}
+	return SafeCompileRegex(pat)
+}
+
+func ScanLocalStatus(src config.Source, localPath string) CheckResult {
+	targetDir := filepath.Dir(localPath)
+
+	// Check if the specific file matches exact path (if filename is static)
+	if _, err := os.Stat(localPath); err == nil {
+		// Only valid if localPath doesn't point to a directory or generic name
+		if !strings.HasSuffix(localPath, src.Name) {
+			return CheckResult{Status: StatusDownloaded, Current: "installed"}
+		}
+	}
+
+	// Check if target directory exists
+	entries, err := os.ReadDir(targetDir)
+	if err != nil {
+		return CheckResult{Status: StatusNotFound}
+	}
+
+	patterns := localFilePatterns(src)
 
 	// Helper to scan with specific patterns
 	scanWithPatterns := func(regexStrs []string) CheckResult {
 		for _, pat := range regexStrs {
-			// Ensure capture group for version if missing
-			if !strings.Contains(pat, "(") {
-				pat = strings.Replace(pat, ".*", "(.*?)", 1)
-			}
-			// Ensure anchors
-			if !strings.HasPrefix(pat, "^") {
-				pat = "^" + pat
-			}
-			if !strings.HasSuffix(pat, "$") {
-				pat = pat + "$"
-			}
-
-			re, err := SafeCompileRegex(pat)
+			re, err := compileLocalPattern(pat)
 			if err != nil {
 				// Skip invalid or unsafe patterns
 				continue
@@ -305,7 +400,58 @@ func ScanLocalStatus(src config.Source, localPath string) CheckResult {
 	return CheckResult{Status: StatusNotFound}
 }
 
+// CheckVersion compares the local copy of src against the latest release. Results
+// are recorded in the status cache, which is all that is consulted in offline mode.
 func (c *Checker) CheckVersion(src config.Source, localPath string) CheckResult {
+	cache := statusCache.Load()
+	key := statusCacheKey(src, localPath)
+
+	if IsOffline() {
+		if cache != nil {
+			if entry, ok := cache.get(key); ok {
+				result := entry.Result
+				result.Cached = true
+				return result
+			}
+		}
+		return CheckResult{Status: StatusError, Message: "offline: no cached status"}
+	}
+
+	result := c.checkVersion(src, localPath)
+	if result.RemoteFilename == "" && result.ResolvedURL != "" {
+		result.RemoteFilename = RemoteFilename(result.ResolvedURL, nil)
+	}
+	if result.LocalFilename == "" && result.Status == StatusUpToDate {
+		// Up to date means the remote filename already exists locally
+		result.LocalFilename = result.RemoteFilename
+	}
+	if result.Status == StatusUpToDate {
+		local := localPath
+		if result.LocalFilename != "" {
+			local = filepath.Join(filepath.Dir(localPath), result.LocalFilename)
+		}
+		if reason := incompleteReason(local, result.Size); reason != "" {
+			result.Status, result.Message = StatusIncomplete, reason
+		}
+	}
+	if src.PinVersion != "" && result.Status == StatusNewer {
+		result.Message = "Pinned to " + src.PinVersion
+	}
+	if cache != nil && result.Status != StatusError {
+		cache.put(key, result)
+	}
+	return result
+}
+
+// pinnableStrategies can resolve a specific release for Source.PinVersion
+var pinnableStrategies = map[string]bool{
+	"github_release": true,
+	"web_scrape":     true,
+	"deb_repo":       true,
+	"hashicorp":      true,
+}
+
+func (c *Checker) checkVersion(src config.Source, localPath string) CheckResult {
 	info, err := os.Stat(localPath)
 	if os.IsNotExist(err) && src.Strategy == "" {
 		// Only return NotFound if we have no strategy to verify against (legacy/direct file)
@@ -314,6 +460,10 @@ func (c *Checker) CheckVersion(src config.Source, localPath string) CheckResult
 		return CheckResult{Status: StatusError, Message: err.Error()}
 	}
 
+	if src.PinVersion != "" && !pinnableStrategies[src.Strategy] {
+		return CheckResult{Status: StatusError, Message: fmt.Sprintf("pin_version is not supported by the '%s' strategy", src.Strategy)}
+	}
+
 	// Dynamic Resolution Strategies
 	switch src.Strategy {
 	case "web_scrape":
@@ -328,12 +478,21 @@ func (c *Checker) CheckVersion(src config.Source, localPath string) CheckResult
 		return c.resolveRSSFeed(src, localPath)
 	case "http_redirect":
 		return c.resolveHTTPRedirect(src, localPath)
+	case "redirect":
+		return c.resolveRedirect(src, localPath)
+	case "direct":
+		return c.resolveDirect(src, localPath)
 	case "chromium_rss":
 		return c.resolveChromiumRSS(src, localPath)
 	case "chromium_gcs":
 		return c.resolveChromiumGCS(src, localPath)
+	case "deb_repo":
+		return c.resolveDebRepo(src, localPath)
+	case "hashicorp":
+		return c.resolveHashicorp(src, localPath)
 	default:
-		// Fallback for direct URLs (legacy behavior)
+		// Sources with only a url predate the direct strategy, which does the same
+		// and more; they keep working
 		if src.URL != "" {
 			return c.checkHTTPHeader(src.URL, info)
 		}
@@ -354,30 +513,9 @@ func (c *Checker) resolveGithubRelease(src config.Source, localPath string) Chec
 		return CheckResult{Status: StatusError, Message: err.Error()}
 	}
 
-	var release *github.RepositoryRelease
-	if val, ok := githubCache.Load(repo); ok {
-		release = val.(*github.RepositoryRelease)
-	} else {
-		// Use the injected client for GitHub interactions if possible
-		// The github library requires an *http.Client. We can type assert or fallback.
-		var httpClient *http.Client
-		if hc, ok := c.client.(*http.Client); ok {
-			httpClient = hc
-		}
-
-		client := github.NewClient(httpClient)
-		if c.githubToken != "" {
-			client = client.WithAuthToken(c.githubToken)
-		} else if token := os.Getenv("GITHUB_TOKEN"); token != "" {
-			client = client.WithAuthToken(token)
-		}
-
-		var err error
-		release, _, err = client.Repositories.GetLatestRelease(context.Background(), owner, repoName)
-		if err != nil {
-			return CheckResult{Status: StatusError, Message: "GitHub API error: " + err.Error()}
-		}
-		githubCache.Store(repo, release)
+	release, err := c.githubRelease(owner, repoName, src.PinVersion)
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: "GitHub API error: " + err.Error()}
 	}
 
 	tagName := release.GetTagName()
@@ -388,10 +526,13 @@ func (c *Checker) resolveGithubRelease(src config.Source, localPath string) Chec
 		return CheckResult{Status: StatusError, Message: "Invalid or unsafe asset_pattern regex: " + err.Error()}
 	}
 
+	private := c.repoIsPrivate(owner, repoName)
 	var downloadURL string
+	var size int64
 	for _, asset := range release.Assets {
 		if re.MatchString(asset.GetName()) {
-			downloadURL = asset.GetBrowserDownloadURL()
+			downloadURL = githubAssetURL(asset, private)
+			size = int64(asset.GetSize())
 			break
 		}
 	}
@@ -404,30 +545,39 @@ func (c *Checker) resolveGithubRelease(src config.Source, localPath string) Chec
 		}
 	}
 
+	extraURLs, err := matchExtraAssets(release.Assets, src.Params["extra_assets"], downloadURL, private)
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: "Invalid extra_assets: " + err.Error(), Latest: tagName}
+	}
+
 	targetDir := filepath.Dir(localPath)
-	remoteFilename := filepath.Base(downloadURL)
+	remoteFilename := RemoteFilename(downloadURL, nil)
 	fullLocalPath := filepath.Join(targetDir, remoteFilename)
 
-	var currentVersion string
+	var currentVersion, localFilename string
 	entries, _ := os.ReadDir(targetDir)
 	for _, entry := range entries {
 		if !entry.IsDir() && re.MatchString(entry.Name()) {
 			currentVersion = tagName // Best guess
+			localFilename = entry.Name()
 			break
 		}
 	}
 
 	if _, err := os.Stat(fullLocalPath); err == nil {
-		return CheckResult{Status: StatusUpToDate, Current: tagName, Latest: tagName, ResolvedURL: downloadURL}
+		return CheckResult{Status: StatusUpToDate, Current: tagName, Latest: tagName, ResolvedURL: downloadURL, ExtraURLs: extraURLs, Size: size}
 	}
 
 	if currentVersion != "" {
 		return CheckResult{
-			Status:      StatusNewer,
-			Current:     currentVersion,
-			Latest:      tagName,
-			Message:     fmt.Sprintf("New release: %s", tagName),
-			ResolvedURL: downloadURL,
+			Status:        StatusNewer,
+			Current:       currentVersion,
+			Latest:        tagName,
+			Message:       fmt.Sprintf("New release: %s", tagName),
+			ResolvedURL:   downloadURL,
+			ExtraURLs:     extraURLs,
+			Size:          size,
+			LocalFilename: localFilename,
 		}
 	}
 
@@ -435,7 +585,98 @@ func (c *Checker) resolveGithubRelease(src config.Source, localPath string) Chec
 		Status:      StatusNotFound,
 		Latest:      tagName,
 		ResolvedURL: downloadURL,
+		ExtraURLs:   extraURLs,
+		Size:        size,
+	}
+}
+
+// matchExtraAssets resolves the comma-separated extra_assets patterns to download
+// URLs. Each pattern takes its first matching asset other than the primary one.
+func matchExtraAssets(assets []*github.ReleaseAsset, patterns, primaryURL string, private bool) ([]string, error) {
+	var urls []string
+	seen := map[string]bool{primaryURL: true}
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		re, err := SafeCompileRegex(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("unsafe regex %q: %w", pattern, err)
+		}
+		found := false
+		for _, asset := range assets {
+			if !re.MatchString(asset.GetName()) {
+				continue
+			}
+			u := githubAssetURL(asset, private)
+			if !seen[u] {
+				urls = append(urls, u)
+				seen[u] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no asset found matching '%s'", pattern)
+		}
+	}
+	return urls, nil
+}
+
+// githubRelease returns the release of owner/repo tagged tag, or the latest release
+// when tag is empty, cached for the process lifetime
+func (c *Checker) githubRelease(owner, repoName, tag string) (*github.RepositoryRelease, error) {
+	repo := owner + "/" + repoName
+	if tag != "" {
+		repo += "@" + tag
+	}
+	unlock := lockCacheKey("github:" + repo)
+	defer unlock()
+
+	if val, ok := githubCache.Load(repo); ok {
+		return val.(*github.RepositoryRelease), nil
+	}
+
+	disk := releaseCache.Load()
+	var cached cachedRelease
+	hasCached := false
+	if disk != nil {
+		if cached, hasCached = disk.get(repo); hasCached && cached.fresh() {
+			githubCache.Store(repo, cached.Release)
+			return cached.Release, nil
+		}
+	}
+
+	client := c.githubClient()
+
+	apiPath := fmt.Sprintf("repos/%s/%s/releases/latest", owner, repoName)
+	if tag != "" {
+		apiPath = fmt.Sprintf("repos/%s/%s/releases/tags/%s", owner, repoName, tag)
+	}
+	req, err := client.NewRequest("GET", apiPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	if hasCached && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	release := new(github.RepositoryRelease)
+	resp, err := client.Do(context.Background(), req, release)
+	if hasCached && resp != nil && resp.StatusCode == http.StatusNotModified {
+		// Unchanged, and the 304 didn't count against the rate limit
+		disk.put(repo, cached.Release, cached.ETag)
+		githubCache.Store(repo, cached.Release)
+		return cached.Release, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if disk != nil {
+		disk.put(repo, release, resp.Header.Get("ETag"))
 	}
+	githubCache.Store(repo, release)
+	return release, nil
 }
 
 func (c *Checker) resolveWebScrape(src config.Source, localPath string) CheckResult {
@@ -447,60 +688,61 @@ func (c *Checker) resolveWebScrape(src config.Source, localPath string) CheckRes
 		return CheckResult{Status: StatusError, Message: "Missing web_scrape params"}
 	}
 
-	// Scrape the directory
-	var body []byte
-	if val, ok := webCache.Load(baseURL); ok {
-		body = val.([]byte)
-	} else {
-		resp, err := c.client.Get(baseURL)
+	// The same version shape is required of scraped versions and local filenames
+	versionRegex := src.Params["version_regex"]
+	if versionRegex == "" {
+		versionRegex = defaultWebScrapeVersionRegex
+	}
+	reVersion, err := regexp.Compile(`^(?:` + versionRegex + `)$`)
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: "Invalid version_regex: " + err.Error()}
+	}
+	// Regex for file pattern (to extract version from local files too)
+	reFile, err := webScrapeFilePattern(filepath.Base(fileTemplate), versionRegex)
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: "Invalid version_regex: " + err.Error()}
+	}
+
+	// A pinned version needs no listing, only the file check below
+	versions := []string{src.PinVersion}
+	if src.PinVersion == "" {
+		scraped, pages, err := c.scrapeVersions(baseURL, versionPattern, src.Params["page_pattern"])
 		if err != nil {
 			return CheckResult{Status: StatusError, Message: "Failed to scrape: " + err.Error()}
 		}
-		defer resp.Body.Close()
-
-		body, _ = io.ReadAll(resp.Body)
-		webCache.Store(baseURL, body)
-	}
-	reDir := regexp.MustCompile(versionPattern)
-
-	matches := reDir.FindAllStringSubmatch(string(body), -1)
-	var versions []string
-	for _, m := range matches {
-		if len(m) > 1 {
-			versions = append(versions, m[1])
+		if src.Params["follow_latest"] == "true" {
+			// Appended last, a version only the latest link reveals is tried first
+			if v := c.followLatestLink(pages, versionPattern, reFile); v != "" {
+				scraped = appendVersion(scraped, v)
+			}
+		}
+		versions = nil
+		for _, v := range scraped {
+			if reVersion.MatchString(v) {
+				versions = append(versions, v)
+			}
+		}
+		if len(versions) == 0 && len(scraped) > 0 {
+			return CheckResult{Status: StatusError, Message: fmt.Sprintf("None of the %d scraped versions match version_regex %s", len(scraped), versionRegex)}
 		}
 	}
-	sort.Strings(versions)
-
-	// Step 2: Iterate backwards and verify remote file existence
-	var latestVersion string
-	var remoteFullURL string
-	var remotePath string
-
-	// Regex for file pattern (to extract version from local files too)
-	templateFilenamePattern := filepath.Base(fileTemplate)
-	regexPattern := strings.ReplaceAll(templateFilenamePattern, "{{version}}", `(\d+\.\d+)`)
-	reFile := regexp.MustCompile(regexPattern)
 
+	// Step 2: Probe from the last version, the newest, down for one whose file exists
+	var urls []string
 	for i := len(versions) - 1; i >= 0; i-- {
-		v := versions[i]
-		rPath := strings.ReplaceAll(fileTemplate, "{{version}}", v)
-		rURL := baseURL + rPath
-
-		resp, err := c.client.Head(rURL)
-		if err == nil && resp.StatusCode == http.StatusOK {
-			latestVersion = v
-			remoteFullURL = rURL
-			remotePath = rPath
-			resp.Body.Close()
-			break
-		}
-		if resp != nil {
-			resp.Body.Close()
-		}
+		urls = append(urls, baseURL+strings.ReplaceAll(fileTemplate, "{{version}}", versions[i]))
+	}
+	var latestVersion, remoteFullURL, remotePath string
+	if found := c.probeNewest(urls); found >= 0 {
+		latestVersion = versions[len(versions)-1-found]
+		remoteFullURL = urls[found]
+		remotePath = strings.ReplaceAll(fileTemplate, "{{version}}", latestVersion)
 	}
 
 	if latestVersion == "" {
+		if src.PinVersion != "" {
+			return CheckResult{Status: StatusError, Message: fmt.Sprintf("No remote file found for pinned version %s", src.PinVersion)}
+		}
 		return CheckResult{Status: StatusError, Message: "No valid remote files found for any version"}
 	}
 
@@ -509,7 +751,7 @@ func (c *Checker) resolveWebScrape(src config.Source, localPath string) CheckRes
 	fullLocalPath := filepath.Join(targetDir, expectedFilename)
 
 	// Local version detection
-	var currentVersion string
+	var currentVersion, localFilename string
 	entries, _ := os.ReadDir(targetDir)
 	for _, entry := range entries {
 		if entry.IsDir() {
@@ -519,6 +761,7 @@ func (c *Checker) resolveWebScrape(src config.Source, localPath string) CheckRes
 			m := reFile.FindStringSubmatch(entry.Name())
 			if len(m) > 1 {
 				currentVersion = strings.Trim(m[1], "-_ .")
+				localFilename = entry.Name()
 			}
 			break
 		}
@@ -530,10 +773,11 @@ func (c *Checker) resolveWebScrape(src config.Source, localPath string) CheckRes
 
 	if currentVersion != "" {
 		return CheckResult{
-			Status:      StatusNewer,
-			Current:     currentVersion,
-			Latest:      latestVersion,
-			ResolvedURL: remoteFullURL,
+			Status:        StatusNewer,
+			Current:       currentVersion,
+			Latest:        latestVersion,
+			ResolvedURL:   remoteFullURL,
+			LocalFilename: localFilename,
 		}
 	}
 
@@ -544,6 +788,238 @@ func (c *Checker) resolveWebScrape(src config.Source, localPath string) CheckRes
 	}
 }
 
+// defaultWebScrapeVersionRegex is the version shape web_scrape expects unless a source
+// sets version_regex: dot, dash or underscore separated numbers such as 3.0.21,
+// 24.04.1 or 2024-03, optionally prefixed with v
+const defaultWebScrapeVersionRegex = `v?\d+(?:[._-]\d+)*`
+
+// webScrapeFilePattern turns a file_template filename into a regex matching it with
+// any version that versionRegex allows. The first {{version}} is captured.
+func webScrapeFilePattern(filenameTemplate, versionRegex string) (*regexp.Regexp, error) {
+	parts := strings.Split(filenameTemplate, "{{version}}")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	pattern := parts[0]
+	for i, part := range parts[1:] {
+		if i == 0 {
+			pattern += "(" + versionRegex + ")"
+		} else {
+			pattern += "(?:" + versionRegex + ")"
+		}
+		pattern += part
+	}
+	return regexp.Compile("^" + pattern + "$")
+}
+
+const (
+	probeBatch   = 4               // Candidate files HEAD-probed at once
+	probeTimeout = 5 * time.Second // Wait per probe before counting the file as missing
+)
+
+// probeNewest HEAD-probes urls, ordered newest first, probeBatch at a time and
+// returns the index of the first one that exists, or -1. A batch waits for all
+// its probes so an older file never wins over a newer one that is slower to
+// answer; later batches are skipped once one succeeds.
+func (c *Checker) probeNewest(urls []string) int {
+	for start := 0; start < len(urls); start += probeBatch {
+		end := min(start+probeBatch, len(urls))
+		found := make([]bool, end-start)
+		var wg sync.WaitGroup
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				found[i-start] = c.probe(urls[i])
+			}(i)
+		}
+		wg.Wait()
+		for i, ok := range found {
+			if ok {
+				return start + i
+			}
+		}
+	}
+	return -1
+}
+
+// probe reports whether a HEAD of rawURL answers 200 within probeTimeout
+func (c *Checker) probe(rawURL string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := c.client.Do(req)
+	if err != nil || resp == nil {
+		return false
+	}
+	if resp.Body != nil {
+		resp.Body.Close()
+	}
+	return resp.StatusCode == http.StatusOK
+}
+
+// webListing is a fetched directory listing and the URL it was served from after redirects
+type webListing struct {
+	Body []byte
+	URL  string
+}
+
+// fetchListing GETs a listing page, caching it by the requested URL
+func (c *Checker) fetchListing(listingURL string) (webListing, error) {
+	unlock := lockCacheKey("web:" + listingURL)
+	defer unlock()
+	if val, ok := webCache.Load(listingURL); ok {
+		return val.(webListing), nil
+	}
+	resp, err := c.client.Get(listingURL)
+	if err != nil {
+		return webListing{}, err
+	}
+	defer resp.Body.Close()
+	r, err := readMaybeCompressed(resp)
+	if err != nil {
+		return webListing{}, err
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return webListing{}, fmt.Errorf("failed to read %s: %w", listingURL, err)
+	}
+	listing := webListing{Body: body, URL: listingURL}
+	if resp.Request != nil && resp.Request.URL != nil {
+		listing.URL = resp.Request.URL.String()
+	}
+	webCache.Store(listingURL, listing)
+	return listing, nil
+}
+
+// maxListingPages bounds how many pages page_pattern can pull into one scrape
+const maxListingPages = 20
+
+// scrapeVersions lists the versions versionPattern captures in the baseURL directory
+// listing, sorted ascending. With pagePattern, the pages it links to (its first
+// group, or the whole match, resolved against the page) are scraped as well. The
+// fetched pages are returned for follow-up scans.
+func (c *Checker) scrapeVersions(baseURL, versionPattern, pagePattern string) ([]string, []webListing, error) {
+	reDir, err := regexp.Compile(versionPattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid version_pattern: %w", err)
+	}
+	var rePage *regexp.Regexp
+	if pagePattern != "" {
+		if rePage, err = regexp.Compile(pagePattern); err != nil {
+			return nil, nil, fmt.Errorf("invalid page_pattern: %w", err)
+		}
+	}
+
+	var versions []string
+	var pages []webListing
+	queue := []string{baseURL}
+	seen := map[string]bool{baseURL: true}
+	for len(queue) > 0 && len(pages) < maxListingPages {
+		pageURL := queue[0]
+		queue = queue[1:]
+		listing, err := c.fetchListing(pageURL)
+		if err != nil {
+			if len(pages) == 0 {
+				return nil, nil, err
+			}
+			// A later page failing still leaves the versions found so far
+			continue
+		}
+		pages = append(pages, listing)
+
+		for _, m := range reDir.FindAllStringSubmatch(string(listing.Body), -1) {
+			if len(m) > 1 {
+				versions = appendVersion(versions, m[1])
+			}
+		}
+		if rePage == nil {
+			continue
+		}
+		for _, m := range rePage.FindAllStringSubmatch(string(listing.Body), -1) {
+			link := m[0]
+			if len(m) > 1 {
+				link = m[1]
+			}
+			next := resolveLink(listing.URL, link)
+			if next != "" && !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return CompareVersions(versions[i], versions[j]) < 0 })
+	return versions, pages, nil
+}
+
+// latestLink matches links to the "latest" or "current" alias many mirrors keep
+var latestLink = regexp.MustCompile(`(?i)href="((?:\./)?(?:latest|current)/?)"`)
+
+// followLatestLink follows a latest/current link in the scraped pages and returns
+// the version it stands for: taken from the URL it redirects to with versionPattern,
+// or else from a file it lists that matches the file_template filename.
+func (c *Checker) followLatestLink(pages []webListing, versionPattern string, reFile *regexp.Regexp) string {
+	reDir, err := regexp.Compile(versionPattern)
+	if err != nil {
+		return ""
+	}
+	for _, page := range pages {
+		m := latestLink.FindStringSubmatch(string(page.Body))
+		if m == nil {
+			continue
+		}
+		latestURL := resolveLink(page.URL, m[1])
+		if latestURL == "" {
+			continue
+		}
+		listing, err := c.fetchListing(latestURL)
+		if err != nil {
+			continue
+		}
+		if listing.URL != latestURL {
+			// Listings put the version before a slash, so give the URL a trailing one
+			if vm := reDir.FindStringSubmatch(strings.TrimSuffix(listing.URL, "/") + "/"); len(vm) > 1 {
+				return vm[1]
+			}
+		}
+		for _, href := range hrefLink.FindAllStringSubmatch(string(listing.Body), -1) {
+			if fm := reFile.FindStringSubmatch(path.Base(href[1])); len(fm) > 1 {
+				return fm[1]
+			}
+		}
+	}
+	return ""
+}
+
+var hrefLink = regexp.MustCompile(`href="([^"?#]+)"`)
+
+// resolveLink resolves a link found on the page at pageURL, returning "" if either
+// cannot be parsed
+func resolveLink(pageURL, link string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	ref, err := url.Parse(html.UnescapeString(link))
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// appendVersion adds v to versions unless it is already there
+func appendVersion(versions []string, v string) []string {
+	for _, existing := range versions {
+		if existing == v {
+			return versions
+		}
+	}
+	return append(versions, v)
+}
+
 func (c *Checker) resolveFedoraCoreOS(src config.Source, localPath string) CheckResult {
 	stream := src.Params["stream"]
 	arch := src.Params["arch"]
@@ -564,7 +1040,11 @@ func (c *Checker) resolveFedoraCoreOS(src config.Source, localPath string) Check
 	defer resp.Body.Close()
 
 	var streams FedoraCoreOSStreams
-	if err := json.NewDecoder(resp.Body).Decode(&streams); err != nil {
+	body, err := readMaybeCompressed(resp)
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: "Failed to read Fedora metadata: " + err.Error()}
+	}
+	if err := json.NewDecoder(body).Decode(&streams); err != nil {
 		return CheckResult{Status: StatusError, Message: "Failed to parse Fedora metadata"}
 	}
 
@@ -573,24 +1053,37 @@ func (c *Checker) resolveFedoraCoreOS(src config.Source, localPath string) Check
 		return CheckResult{Status: StatusError, Message: "Arch not found: " + arch}
 	}
 
-	// Usually 'metal' artifact contains the ISO
-	// For metal, we might look for 'iso' or 'raw.xz' format?
-	// Commonly 'iso' format exists for metal.
-	metal, ok := archData.Artifacts["metal"]
+	// The 'metal' artifact's ISO is the default; other artifacts (qemu, aws, ...)
+	// and formats (raw.xz, qcow2.xz, ...) can be selected via params
+	artifactName := src.Params["artifact"]
+	if artifactName == "" {
+		artifactName = "metal"
+	}
+	artifact, ok := archData.Artifacts[artifactName]
 	if !ok {
-		// Fallback to "live" if "metal" missing? But FCOS usually has metal.
-		return CheckResult{Status: StatusError, Message: "Metal artifact not found"}
+		return CheckResult{Status: StatusError, Message: fmt.Sprintf("Artifact '%s' not found (available: %s)",
+			artifactName, strings.Join(sortedKeys(archData.Artifacts), ", "))}
 	}
 
-	// Find the ISO location
-	var downloadURL string
-	if isoImg, ok := metal.Formats["iso"]; ok {
-		downloadURL = isoImg.Disk.Location
-	} else if liveIso, ok := metal.Formats["live-iso"]; ok {
-		// Sometimes it's called live-iso in formats? Need to check specific stream data.
-		// The example log showed "vhd.xz" for azure.
-		// For metal, it's typically "iso" or "raw.xz".
-		downloadURL = liveIso.Disk.Location
+	formatName := src.Params["format"]
+	var image FedoraImage
+	if formatName != "" {
+		image, ok = artifact.Formats[formatName]
+	} else if image, ok = artifact.Formats["iso"]; !ok {
+		// Some streams have published the metal ISO as live-iso
+		image, ok = artifact.Formats["live-iso"]
+	}
+	if !ok {
+		if formatName == "" {
+			formatName = "iso"
+		}
+		return CheckResult{Status: StatusError, Message: fmt.Sprintf("Format '%s' not found for artifact '%s' (available: %s)",
+			formatName, artifactName, strings.Join(sortedKeys(artifact.Formats), ", "))}
+	}
+	downloadURL := image.Disk.Location
+	checksum := ""
+	if image.Disk.Sha256 != "" {
+		checksum = "sha256:" + image.Disk.Sha256
 	}
 
 	targetDir := filepath.Dir(localPath)
@@ -598,7 +1091,7 @@ func (c *Checker) resolveFedoraCoreOS(src config.Source, localPath string) Check
 	fullLocalPath := filepath.Join(targetDir, remoteFilename)
 
 	// Local version detection
-	var currentVersion string
+	var currentVersion, localFilename string
 	reVer := regexp.MustCompile(`fedora-coreos-(\d+\.\d+\.\d+\.\d+)`)
 	entries, _ := os.ReadDir(targetDir)
 	for _, entry := range entries {
@@ -606,61 +1099,133 @@ func (c *Checker) resolveFedoraCoreOS(src config.Source, localPath string) Check
 			m := reVer.FindStringSubmatch(entry.Name())
 			if len(m) > 1 {
 				currentVersion = m[1]
+				localFilename = entry.Name()
 			}
 			break
 		}
 	}
 
 	if _, err := os.Stat(fullLocalPath); err == nil {
-		return CheckResult{Status: StatusUpToDate, Current: metal.Release, Latest: metal.Release, ResolvedURL: downloadURL}
+		return CheckResult{Status: StatusUpToDate, Current: artifact.Release, Latest: artifact.Release, ResolvedURL: downloadURL, Checksum: checksum}
 	}
 
 	if currentVersion != "" {
 		return CheckResult{
-			Status:      StatusNewer,
-			Current:     currentVersion,
-			Latest:      metal.Release,
-			ResolvedURL: downloadURL,
+			Status:        StatusNewer,
+			Current:       currentVersion,
+			Latest:        artifact.Release,
+			ResolvedURL:   downloadURL,
+			Checksum:      checksum,
+			LocalFilename: localFilename,
 		}
 	}
 
 	return CheckResult{
 		Status:      StatusNotFound,
-		Latest:      metal.Release,
+		Latest:      artifact.Release,
 		ResolvedURL: downloadURL,
+		Checksum:    checksum,
+	}
+}
+
+// sortedKeys returns the keys of m in sorted order, for stable error messages
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	return keys
+}
+
+// searchKiwixFeed fetches and parses one Kiwix OPDS search. The body is closed
+// before returning so each search in a loop releases its connection.
+func (c *Checker) searchKiwixFeed(searchURL string) (KiwixFeed, error) {
+	var feed KiwixFeed
+	resp, err := c.client.Get(searchURL)
+	if err != nil {
+		return feed, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return feed, fmt.Errorf("Kiwix feed returned HTTP %d", resp.StatusCode)
+	}
+	body, err := readMaybeCompressed(resp)
+	if err != nil {
+		return feed, err
+	}
+	if err := xml.NewDecoder(body).Decode(&feed); err != nil {
+		return feed, fmt.Errorf("Failed to parse Kiwix feed: %w", err)
+	}
+	return feed, nil
+}
+
+// kiwixChecksum fetches the entry's metalink for the sha256 of its ZIM. Kiwix only
+// publishes checksums there, so any failure just leaves the download unverified.
+func (c *Checker) kiwixChecksum(entry KiwixEntry) string {
+	metaURL := entry.GetMetalinkURL()
+	if metaURL == "" {
+		return ""
+	}
+	resp, err := c.client.Get(metaURL)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	body, err := readMaybeCompressed(resp)
+	if err != nil {
+		return ""
+	}
+	var m metalink
+	if err := xml.NewDecoder(body).Decode(&m); err != nil {
+		return ""
+	}
+	if sum := m.sha256(); sum != "" {
+		return "sha256:" + sum
+	}
+	return ""
 }
 
 func (c *Checker) resolveKiwixFeed(src config.Source, localPath string) CheckResult {
 	series := src.Params["series"]
 	feedURL := src.Params["feed_url"]
+	flavour := src.Params["flavour"]
+	language := src.Params["language"]
+	dateFormat := src.Params["date_format"]
 
 	if series == "" || feedURL == "" {
 		return CheckResult{Status: StatusError, Message: "Missing series or feed_url params"}
 	}
+	granularity := src.Params["version_granularity"]
+	if granularity == "" {
+		granularity = "month"
+	}
+	versionLayout, ok := kiwixVersionLayouts[granularity]
+	if !ok {
+		return CheckResult{Status: StatusError, Message: fmt.Sprintf("Unknown version_granularity '%s', expected month or day", granularity)}
+	}
 
 	// Use existing loop fallback logic adapted for params
 	// Search API with 'q'
 	// Recursive search strategy: strip last segment if not found
 
-	var feed Feed
+	var feed KiwixFeed
 
 	searchQuery := series
 	found := false
 
 	for {
 		searchURL := fmt.Sprintf("%s?q=%s", feedURL, url.QueryEscape(searchQuery))
-		resp, err := c.client.Get(searchURL)
+		var err error
+		feed, err = c.searchKiwixFeed(searchURL)
 		if err != nil {
 			return CheckResult{Status: StatusError, Message: err.Error()}
 		}
 
-		if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
-			resp.Body.Close()
-			return CheckResult{Status: StatusError, Message: "Failed to parse Kiwix feed: " + err.Error()}
-		}
-		resp.Body.Close()
-
 		if len(feed.Entries) > 0 {
 			found = true
 			break
@@ -679,73 +1244,116 @@ func (c *Checker) resolveKiwixFeed(src config.Source, localPath string) CheckRes
 	}
 
 	// Find the latest entry for the specified series
-	var latestEntry *Entry
+	var latestEntry *KiwixEntry
 	var latestDate time.Time
 
 	for i := range feed.Entries {
 		entry := &feed.Entries[i]
 		// Check if Name contains series (Entry.Name is typically the ID/Series name)
 		// Or contains the *original* series name requested
-		if strings.Contains(entry.Name, series) || (searchQuery != series && strings.Contains(entry.Name, searchQuery)) {
-
-			// Parse Issued date
-			issuedDate, err := time.Parse(time.RFC3339, entry.Issued)
-			if err != nil {
-				// Try simplified YYYY-MM-DD
-				issuedDate, err = time.Parse("2006-01-02", entry.Issued)
-				if err != nil {
-					continue
-				}
-			}
+		if !strings.Contains(entry.Name, series) && (searchQuery == series || !strings.Contains(entry.Name, searchQuery)) {
+			continue
+		}
+		// A series is often published in several flavours (nopic, maxi, ...) and languages
+		if flavour != "" && !strings.EqualFold(entry.Flavour, flavour) {
+			continue
+		}
+		if language != "" && !entry.matchesLanguage(language) {
+			continue
+		}
 
-			if latestEntry == nil || issuedDate.After(latestDate) {
-				latestDate = issuedDate
-				latestEntry = entry
-			}
+		issuedDate := entry.issuedDate(dateFormat)
+		if issuedDate.IsZero() {
+			continue
+		}
+		if latestEntry == nil || issuedDate.After(latestDate) {
+			latestDate = issuedDate
+			latestEntry = entry
 		}
 	}
 
 	if latestEntry == nil {
-		return CheckResult{Status: StatusError, Message: fmt.Sprintf("No entry found for series '%s'", series)}
+		return CheckResult{Status: StatusError, Message: fmt.Sprintf("No entry found for series '%s'%s", series, kiwixFilterSuffix(flavour, language))}
 	}
 
-	remoteDateShort := latestDate.Format("2006-01")
+	remoteDateShort := latestDate.Format(versionLayout)
+	downloadURL := latestEntry.GetDownloadURL()
 
+	// Expected name pattern: series[_flavour]_remoteDateShort.zim, as GetExpectedKiwixPath names them
+	prefix := series + "_"
+	if flavour != "" {
+		prefix += flavour + "_"
+	}
 	targetDir := filepath.Dir(localPath)
-	// Expected name pattern: series_remoteDateShort.zim
-	expectedFilename := fmt.Sprintf("%s_%s.zim", series, remoteDateShort)
+	expectedFilename := prefix + remoteDateShort + ".zim"
 	fullLocalPath := filepath.Join(targetDir, expectedFilename)
 
 	// Local version detection
-	var currentVersion string
-	reDate := regexp.MustCompile(`_(\d{4}-\d{2})\.zim`)
+	var currentVersion, localFilename string
+	reDate := regexp.MustCompile(`_(\d{4}-\d{2}(?:-\d{2})?)\.zim$`)
 	entries, _ := os.ReadDir(targetDir)
 	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasPrefix(entry.Name(), series+"_") {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), prefix) {
 			m := reDate.FindStringSubmatch(entry.Name())
 			if len(m) > 1 {
 				currentVersion = m[1]
+				localFilename = entry.Name()
 			}
 			break
 		}
 	}
 
 	if _, err := os.Stat(fullLocalPath); err == nil {
-		return CheckResult{Status: StatusUpToDate, Current: remoteDateShort, Latest: remoteDateShort}
+		return CheckResult{Status: StatusUpToDate, Current: remoteDateShort, Latest: remoteDateShort, ResolvedURL: downloadURL, RemoteFilename: expectedFilename, Size: latestEntry.GetFileSize()}
 	}
 
+	// Only looked up when there is something to download
+	checksum := c.kiwixChecksum(*latestEntry)
+
 	if currentVersion != "" {
 		return CheckResult{
-			Status:  StatusNewer,
-			Current: currentVersion,
-			Latest:  remoteDateShort,
+			Status:         StatusNewer,
+			Current:        currentVersion,
+			Latest:         remoteDateShort,
+			ResolvedURL:    downloadURL,
+			Checksum:       checksum,
+			Size:           latestEntry.GetFileSize(),
+			LocalFilename:  localFilename,
+			RemoteFilename: expectedFilename,
 		}
 	}
 
 	return CheckResult{
-		Status: StatusNotFound,
-		Latest: remoteDateShort,
+		Status:         StatusNotFound,
+		Latest:         remoteDateShort,
+		ResolvedURL:    downloadURL,
+		Checksum:       checksum,
+		Size:           latestEntry.GetFileSize(),
+		RemoteFilename: expectedFilename,
+	}
+}
+
+// kiwixVersionLayouts maps a kiwix_feed version_granularity to the layout of the
+// date that versions and names its ZIMs. Series updated more than once a month
+// need day, or two releases in a month share a name.
+var kiwixVersionLayouts = map[string]string{
+	"month": "2006-01",
+	"day":   "2006-01-02",
+}
+
+// kiwixFilterSuffix describes the flavour and language filters for error messages
+func kiwixFilterSuffix(flavour, language string) string {
+	var filters []string
+	if flavour != "" {
+		filters = append(filters, "flavour "+flavour)
+	}
+	if language != "" {
+		filters = append(filters, "language "+language)
+	}
+	if len(filters) == 0 {
+		return ""
 	}
+	return " with " + strings.Join(filters, " and ")
 }
 
 func (c *Checker) resolveRSSFeed(src config.Source, localPath string) CheckResult {
@@ -764,7 +1372,11 @@ func (c *Checker) resolveRSSFeed(src config.Source, localPath string) CheckResul
 	defer resp.Body.Close()
 
 	var rss RSS
-	if err := xml.NewDecoder(resp.Body).Decode(&rss); err != nil {
+	body, err := readMaybeCompressed(resp)
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: "Failed to read RSS: " + err.Error()}
+	}
+	if err := xml.NewDecoder(body).Decode(&rss); err != nil {
 		return CheckResult{Status: StatusError, Message: "Failed to parse RSS: " + err.Error()}
 	}
 
@@ -809,13 +1421,14 @@ func (c *Checker) resolveRSSFeed(src config.Source, localPath string) CheckResul
 	fullLocalPath := filepath.Join(targetDir, remoteFilename)
 
 	// Local version detection
-	var currentVersion string
+	var currentVersion, localFilename string
 	entries, _ := os.ReadDir(targetDir)
 	for _, entry := range entries {
 		if !entry.IsDir() && reItem.MatchString(entry.Name()) {
 			m := reVersion.FindStringSubmatch(entry.Name())
 			if len(m) > 1 {
 				currentVersion = strings.Trim(m[1], "-_ .")
+				localFilename = entry.Name()
 				break
 			}
 		}
@@ -827,10 +1440,11 @@ func (c *Checker) resolveRSSFeed(src config.Source, localPath string) CheckResul
 
 	if currentVersion != "" {
 		return CheckResult{
-			Status:      StatusNewer,
-			Current:     currentVersion,
-			Latest:      latestVersion,
-			ResolvedURL: downloadURL,
+			Status:        StatusNewer,
+			Current:       currentVersion,
+			Latest:        latestVersion,
+			ResolvedURL:   downloadURL,
+			LocalFilename: localFilename,
 		}
 	}
 
@@ -865,12 +1479,72 @@ func (c *Checker) resolveHTTPRedirect(src config.Source, localPath string) Check
 	}
 	defer resp.Body.Close()
 
-	resolvedURL := resp.Request.URL.String()
+	var reVer *regexp.Regexp
+	if versionPattern != "" {
+		reVer = regexp.MustCompile(versionPattern)
+	}
+	return redirectResult(resp.Request.URL.String(), reVer, localPath)
+}
+
+// resolveRedirect reads the version from the Location a "latest" URL redirects
+// to. Unlike http_redirect it stops at the first hop, so the version is found
+// even when the versioned URL redirects again, e.g. to an unversioned CDN link.
+func (c *Checker) resolveRedirect(src config.Source, localPath string) CheckResult {
+	targetURL := src.Params["url"]
+	versionPattern := src.Params["version_pattern"]
+
+	if targetURL == "" || versionPattern == "" {
+		return CheckResult{Status: StatusError, Message: "Missing url or version_pattern params for redirect"}
+	}
+	reVer, err := SafeCompileRegex(versionPattern)
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: "Invalid version_pattern: " + err.Error()}
+	}
+
+	req, err := http.NewRequest("GET", targetURL, nil)
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: err.Error()}
+	}
+	req.Header.Set("User-Agent", "lamp/1.0")
+	resp, err := noRedirects(c.client).Do(req)
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: "Redirect check failed: " + err.Error()}
+	}
+	resp.Body.Close()
+
+	location, err := resp.Location()
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: fmt.Sprintf("%s did not redirect (HTTP %d)", targetURL, resp.StatusCode)}
+	}
+	resolvedURL := location.String()
+	if m := reVer.FindStringSubmatch(resolvedURL); len(m) < 2 || strings.Trim(m[1], "-_ .") == "" {
+		return CheckResult{Status: StatusError, Message: "version_pattern found no version in " + resolvedURL}
+	}
+	return redirectResult(resolvedURL, reVer, localPath)
+}
+
+// noRedirects returns a client that hands back redirect responses instead of
+// following them. Injected clients other than *http.Client are used as they are.
+func noRedirects(client HTTPClient) HTTPClient {
+	hc, ok := client.(*http.Client)
+	if !ok {
+		return client
+	}
+	stopped := *hc
+	stopped.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &stopped
+}
+
+// redirectResult compares the file a redirect resolved to with the local copy.
+// The version is read from resolvedURL and local filenames with reVer; without
+// one the remote version is "latest" and only the exact filename counts.
+func redirectResult(resolvedURL string, reVer *regexp.Regexp, localPath string) CheckResult {
 	remoteFilename := filepath.Base(resolvedURL)
 
 	var latestVersion string
-	if versionPattern != "" {
-		reVer := regexp.MustCompile(versionPattern)
+	if reVer != nil {
 		m := reVer.FindStringSubmatch(resolvedURL)
 		if len(m) > 1 {
 			latestVersion = strings.Trim(m[1], "-_ .")
@@ -883,15 +1557,15 @@ func (c *Checker) resolveHTTPRedirect(src config.Source, localPath string) Check
 	fullLocalPath := filepath.Join(targetDir, remoteFilename)
 
 	// Local version detection
-	var currentVersion string
-	if versionPattern != "" {
-		reVer := regexp.MustCompile(versionPattern)
+	var currentVersion, localFilename string
+	if reVer != nil {
 		entries, _ := os.ReadDir(targetDir)
 		for _, entry := range entries {
 			if !entry.IsDir() {
 				m := reVer.FindStringSubmatch(entry.Name())
 				if len(m) > 1 {
 					currentVersion = m[1]
+					localFilename = entry.Name()
 					break
 				}
 			}
@@ -904,10 +1578,11 @@ func (c *Checker) resolveHTTPRedirect(src config.Source, localPath string) Check
 
 	if currentVersion != "" {
 		return CheckResult{
-			Status:      StatusNewer,
-			Current:     currentVersion,
-			Latest:      latestVersion,
-			ResolvedURL: resolvedURL,
+			Status:        StatusNewer,
+			Current:       currentVersion,
+			Latest:        latestVersion,
+			ResolvedURL:   resolvedURL,
+			LocalFilename: localFilename,
 		}
 	}
 
@@ -928,8 +1603,12 @@ func (c *Checker) checkHTTPHeader(url string, localInfo os.FileInfo) CheckResult
 	if resp.StatusCode != http.StatusOK {
 		return CheckResult{Status: StatusError, Message: fmt.Sprintf("HTTP Status: %d", resp.StatusCode)}
 	}
+	return lastModifiedResult(resp, localInfo)
+}
 
-	// Check Last-Modified
+// lastModifiedResult compares the Last-Modified of resp with the local file's
+// modification time, for files whose URL carries no version
+func lastModifiedResult(resp *http.Response, localInfo os.FileInfo) CheckResult {
 	remoteLastModStr := resp.Header.Get("Last-Modified")
 	if remoteLastModStr != "" {
 		remoteLastMod, err := http.ParseTime(remoteLastModStr)
@@ -951,6 +1630,76 @@ func (c *Checker) checkHTTPHeader(url string, localInfo os.FileInfo) CheckResult
 	return CheckResult{Status: StatusUpToDate, Message: "No specific version changes detected via headers"}
 }
 
+// resolveDirect checks a file at a fixed URL. The version is read from the
+// version_from_header response header when set, and compared with the version
+// the local file was downloaded as; otherwise Last-Modified decides, as for a
+// source with only a url.
+func (c *Checker) resolveDirect(src config.Source, localPath string) CheckResult {
+	targetURL := src.Params["url"]
+	versionHeader := src.Params["version_from_header"]
+
+	if targetURL == "" {
+		return CheckResult{Status: StatusError, Message: "Missing url param for direct"}
+	}
+
+	resp, err := c.client.Head(targetURL)
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return CheckResult{Status: StatusError, Message: fmt.Sprintf("HTTP Status: %d", resp.StatusCode)}
+	}
+
+	// Named as the download will be: by the URL, Content-Disposition or redirect
+	filename, err := SanitizeFilename(RemoteFilename(targetURL, resp))
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: "Invalid filename from URL: " + err.Error()}
+	}
+
+	var latest string
+	if versionHeader != "" {
+		latest = strings.TrimSpace(resp.Header.Get(versionHeader))
+		if latest == "" {
+			return CheckResult{Status: StatusError, Message: fmt.Sprintf("Response has no %s header", versionHeader)}
+		}
+	}
+
+	result := CheckResult{Status: StatusNotFound, Latest: latest, ResolvedURL: targetURL, RemoteFilename: filename}
+	if resp.ContentLength > 0 {
+		result.Size = resp.ContentLength
+	}
+
+	fullLocalPath := filepath.Join(filepath.Dir(localPath), filename)
+	info, err := os.Stat(fullLocalPath)
+	if os.IsNotExist(err) {
+		return result
+	} else if err != nil {
+		return CheckResult{Status: StatusError, Message: err.Error()}
+	}
+	result.LocalFilename = filename
+
+	if latest != "" {
+		if current := downloadedVersion(src.Key(), fullLocalPath); current != "" {
+			result.Current = current
+			result.Status = StatusUpToDate
+			if current != latest {
+				result.Status = StatusNewer
+			}
+			return result
+		}
+	}
+
+	// No version to compare: the local file may predate the history
+	lm := lastModifiedResult(resp, info)
+	result.Status, result.Message = lm.Status, lm.Message
+	if latest == "" {
+		result.Latest = lm.Latest
+	}
+	return result
+}
+
 func (c *Checker) resolveChromiumRSS(src config.Source, localPath string) CheckResult {
 	feedURL := src.Params["feed_url"]
 	assetPattern := src.Params["asset_pattern"]
@@ -974,7 +1723,11 @@ func (c *Checker) resolveChromiumRSS(src config.Source, localPath string) CheckR
 	defer resp.Body.Close()
 
 	var rss RSS
-	if err := xml.NewDecoder(resp.Body).Decode(&rss); err != nil {
+	body, err := readMaybeCompressed(resp)
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: "Failed to read RSS: " + err.Error()}
+	}
+	if err := xml.NewDecoder(body).Decode(&rss); err != nil {
 		return CheckResult{Status: StatusError, Message: "Failed to parse RSS: " + err.Error()}
 	}
 
@@ -1080,7 +1833,11 @@ func (c *Checker) resolveChromiumGCS(src config.Source, localPath string) CheckR
 	defer resp.Body.Close()
 
 	var result ListBucketResult
-	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+	body, err := readMaybeCompressed(resp)
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: "Failed to read GCS list: " + err.Error()}
+	}
+	if err := xml.NewDecoder(body).Decode(&result); err != nil {
 		return CheckResult{Status: StatusError, Message: "Failed to parse GCS XML: " + err.Error()}
 	}
 
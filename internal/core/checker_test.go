@@ -2,13 +2,22 @@ package core
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"lamp/internal/config"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/google/go-github/v69/github"
 )
 
 // MockHTTPClient allows mocking HTTP responses
@@ -36,6 +45,10 @@ func (m *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
 	if m.DoFunc != nil {
 		return m.DoFunc(req)
 	}
+	// HEAD requests built for their context, such as probes, fall back to HeadFunc
+	if req.Method == http.MethodHead && m.HeadFunc != nil {
+		return m.HeadFunc(req.URL.String())
+	}
 	return nil, nil
 }
 
@@ -89,6 +102,250 @@ func TestCheckKiwixVersion(t *testing.T) {
 	}
 }
 
+func TestKiwixFeedFlavours(t *testing.T) {
+	mockXML := `
+<feed xmlns="http://www.w3.org/2005/Atom">
+    <entry>
+        <name>wikipedia_en_all</name>
+        <flavour>nopic</flavour>
+        <language>eng</language>
+        <issued>2024-06-01T00:00:00Z</issued>
+        <link rel="http://opds-spec.org/acquisition/open-access" type="application/x-zim" length="1024"
+              href="https://download.kiwix.org/zim/wikipedia/wikipedia_en_all_nopic_2024-06.zim.meta4"/>
+    </entry>
+    <entry>
+        <name>wikipedia_en_all</name>
+        <flavour>maxi</flavour>
+        <language>eng</language>
+        <issued>2024-01-01T00:00:00Z</issued>
+    </entry>
+    <entry>
+        <name>wikipedia_en_all</name>
+        <flavour>maxi</flavour>
+        <language>fra</language>
+        <issued>2024-09-01T00:00:00Z</issued>
+    </entry>
+</feed>`
+	client := &MockHTTPClient{
+		GetFunc: func(url string) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(mockXML))}, nil
+		},
+	}
+
+	tmpDir := t.TempDir()
+	// An older maxi download must not count as the local copy of nopic
+	if err := os.WriteFile(filepath.Join(tmpDir, "wikipedia_en_all_maxi_2023-06.zim"), []byte("zim"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(flavour, language string) CheckResult {
+		src := config.Source{
+			Name:     "Wikipedia " + flavour,
+			Strategy: "kiwix_feed",
+			Params: map[string]string{
+				"series":   "wikipedia_en_all",
+				"feed_url": "https://library.kiwix.org/catalog/v2/entries",
+				"flavour":  flavour,
+				"language": language,
+			},
+		}
+		return NewChecker(client, "").CheckVersion(src, filepath.Join(tmpDir, "wikipedia.zim"))
+	}
+
+	nopic := check("nopic", "eng")
+	if nopic.Status != StatusNotFound || nopic.Latest != "2024-06" {
+		t.Errorf("Expected nopic 2024-06 to be missing, got %+v", nopic)
+	}
+	if nopic.RemoteFilename != "wikipedia_en_all_nopic_2024-06.zim" {
+		t.Errorf("Expected the flavour in the nopic filename, got %s", nopic.RemoteFilename)
+	}
+	if want := "https://download.kiwix.org/zim/wikipedia/wikipedia_en_all_nopic_2024-06.zim"; nopic.ResolvedURL != want {
+		t.Errorf("Expected the acquisition link %s, got %q", want, nopic.ResolvedURL)
+	}
+
+	maxi := check("maxi", "eng")
+	if maxi.Status != StatusNewer || maxi.Current != "2023-06" || maxi.Latest != "2024-01" {
+		t.Errorf("Expected maxi 2023-06 -> 2024-01, got %+v", maxi)
+	}
+	if maxi.LocalFilename != "wikipedia_en_all_maxi_2023-06.zim" {
+		t.Errorf("Expected the local maxi file, got %s", maxi.LocalFilename)
+	}
+
+	if french := check("maxi", "fra"); french.Latest != "2024-09" {
+		t.Errorf("Expected the French maxi entry, got %+v", french)
+	}
+	if missing := check("mini", ""); missing.Status != StatusError {
+		t.Errorf("Expected an error for a flavour the feed lacks, got %+v", missing)
+	}
+}
+
+func TestKiwixFeedDayGranularity(t *testing.T) {
+	// Two releases in one month, with dates in a format Kiwix itself doesn't use
+	mockXML := `
+<feed xmlns="http://www.w3.org/2005/Atom">
+    <entry>
+        <name>wikinews_en_all</name>
+        <flavour>maxi</flavour>
+        <issued>01 Jun 2024</issued>
+    </entry>
+    <entry>
+        <name>wikinews_en_all</name>
+        <flavour>maxi</flavour>
+        <issued>15 Jun 2024</issued>
+    </entry>
+</feed>`
+	client := &MockHTTPClient{
+		GetFunc: func(url string) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(mockXML))}, nil
+		},
+	}
+	tmpDir := t.TempDir()
+	check := func(granularity string) CheckResult {
+		src := config.Source{
+			Name:     "Wikinews",
+			Strategy: "kiwix_feed",
+			Params: map[string]string{
+				"series":              "wikinews_en_all",
+				"feed_url":            "https://library.kiwix.org/catalog/v2/entries",
+				"flavour":             "maxi",
+				"date_format":         "02 Jan 2006",
+				"version_granularity": granularity,
+			},
+		}
+		return NewChecker(client, "").CheckVersion(src, filepath.Join(tmpDir, "wikinews.zim"))
+	}
+	write := func(name string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, name), make([]byte, 4096), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result := check("day")
+	if result.Status != StatusNotFound || result.Latest != "2024-06-15" || result.RemoteFilename != "wikinews_en_all_maxi_2024-06-15.zim" {
+		t.Errorf("Expected the 2024-06-15 ZIM to be missing, got %+v", result)
+	}
+
+	// The release from earlier in the month is not the latest one
+	write("wikinews_en_all_maxi_2024-06-01.zim")
+	result = check("day")
+	if result.Status != StatusNewer || result.Current != "2024-06-01" || result.Latest != "2024-06-15" {
+		t.Errorf("Expected 2024-06-01 -> 2024-06-15, got %+v", result)
+	}
+	if result.LocalFilename != "wikinews_en_all_maxi_2024-06-01.zim" {
+		t.Errorf("Expected the local ZIM from June 1st, got %s", result.LocalFilename)
+	}
+
+	write("wikinews_en_all_maxi_2024-06-15.zim")
+	if result = check("day"); result.Status != StatusUpToDate || result.Current != "2024-06-15" {
+		t.Errorf("Expected 2024-06-15 to be up to date, got %+v", result)
+	}
+
+	// Month granularity stays the default
+	if result = check(""); result.Latest != "2024-06" || result.RemoteFilename != "wikinews_en_all_maxi_2024-06.zim" {
+		t.Errorf("Expected a monthly version by default, got %+v", result)
+	}
+	if result = check("week"); result.Status != StatusError {
+		t.Errorf("Expected an error for an unknown granularity, got %+v", result)
+	}
+}
+
+func TestKiwixFeedResolvesDownload(t *testing.T) {
+	// Recorded from a library.kiwix.org search and its mirror's metalink
+	feedXML, err := os.ReadFile(filepath.Join("testdata", "kiwix_wikipedia_en_100.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta4, err := os.ReadFile(filepath.Join("testdata", "kiwix_wikipedia_en_100_mini.meta4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &MockHTTPClient{
+		GetFunc: func(url string) (*http.Response, error) {
+			switch {
+			case strings.HasSuffix(url, "wikipedia_en_100_mini_2024-06.zim.meta4"):
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(meta4))}, nil
+			case strings.HasSuffix(url, ".meta4"):
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(feedXML))}, nil
+		},
+	}
+	checker := NewChecker(client, "")
+	localPath := filepath.Join(t.TempDir(), "wikipedia.zim")
+
+	src := config.Source{
+		Name:     "Wikipedia 100",
+		Strategy: "kiwix_feed",
+		Params: map[string]string{
+			"series":   "wikipedia_en_100",
+			"feed_url": "https://library.kiwix.org/catalog/v2/entries",
+			"flavour":  "mini",
+		},
+	}
+	result := checker.CheckVersion(src, localPath)
+	if want := "https://download.kiwix.org/zim/wikipedia/wikipedia_en_100_mini_2024-06.zim"; result.ResolvedURL != want {
+		t.Errorf("Expected ResolvedURL %s, got %q (Message: %s)", want, result.ResolvedURL, result.Message)
+	}
+	if want := "sha256:4b7e1a9d3c6f0e2b8a5d7c1f4e9b3a6d0c8f2e5b7a1d4c9e6f3b0a8d2c5e7f1b"; result.Checksum != want {
+		t.Errorf("Expected checksum %s from the metalink, got %q", want, result.Checksum)
+	}
+
+	// A missing metalink still resolves, only without a checksum
+	src.Params["flavour"] = "maxi"
+	result = checker.CheckVersion(src, localPath)
+	if !strings.HasSuffix(result.ResolvedURL, "wikipedia_en_100_maxi_2024-06.zim") || result.Checksum != "" {
+		t.Errorf("Expected the maxi ZIM without a checksum, got %q / %q", result.ResolvedURL, result.Checksum)
+	}
+}
+
+func TestKiwixFeedSearchReusesConnection(t *testing.T) {
+	// Every search but the last is empty, with trailing data the XML decoder never
+	// reads. With a single connection allowed, a response left open would stall
+	// the next search until the client times out.
+	const segments = 40
+	parts := make([]string, segments)
+	for i := range parts {
+		parts[i] = fmt.Sprintf("s%d", i)
+	}
+	series := strings.Join(parts, "_")
+	padding := strings.Repeat(" ", 16*1024)
+
+	var newConns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") == "s0" {
+			fmt.Fprint(w, `<feed><entry><name>s0_2024-01</name><issued>2024-01-01T00:00:00Z</issued></entry></feed>`)
+			return
+		}
+		fmt.Fprint(w, "<feed></feed>"+padding)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			newConns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	client := &http.Client{
+		Timeout:   2 * time.Second,
+		Transport: &http.Transport{MaxConnsPerHost: 1, MaxIdleConnsPerHost: 1},
+	}
+	src := config.Source{
+		Name:     "Deep Kiwix Search",
+		Strategy: "kiwix_feed",
+		Params:   map[string]string{"series": series, "feed_url": srv.URL},
+	}
+
+	result := NewChecker(client, "").CheckVersion(src, filepath.Join(t.TempDir(), "deep.zim"))
+	if result.Latest != "2024-01" {
+		t.Fatalf("Expected the s0 entry after %d searches, got %+v", segments, result)
+	}
+	if got := newConns.Load(); got != 1 {
+		t.Errorf("Expected all searches to share one connection, opened %d", got)
+	}
+}
+
 func TestCheckUbuntuVersion(t *testing.T) {
 	tmpDir := t.TempDir()
 	filename := "ubuntu-mate-24.04-desktop-amd64.iso"
@@ -201,12 +458,104 @@ func TestCheckFedoraCoreOSVersion(t *testing.T) {
 	}
 }
 
+func TestCheckFedoraCoreOSArtifactFormat(t *testing.T) {
+	mockJSON := `{
+		"architectures": {
+			"x86_64": {
+				"artifacts": {
+					"metal": {
+						"release": "39.20231001.3.0",
+						"formats": {
+							"iso": {
+								"disk": { "location": "https://example.com/fedora-coreos-39.iso" }
+							}
+						}
+					},
+					"qemu": {
+						"release": "39.20231001.3.0",
+						"formats": {
+							"qcow2.xz": {
+								"disk": { "location": "https://example.com/fedora-coreos-39-qemu.qcow2.xz" }
+							}
+						}
+					}
+				}
+			}
+		}
+	}`
+	client := &MockHTTPClient{
+		GetFunc: func(url string) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(mockJSON)),
+			}, nil
+		},
+	}
+	checker := NewChecker(client, "")
+	localPath := filepath.Join(t.TempDir(), "fcos")
+
+	src := config.Source{
+		Name:     "Fedora Test",
+		Strategy: "fedora_coreos",
+		Params: map[string]string{
+			"arch":     "x86_64",
+			"artifact": "qemu",
+			"format":   "qcow2.xz",
+		},
+	}
+	result := checker.CheckVersion(src, localPath)
+	if result.ResolvedURL != "https://example.com/fedora-coreos-39-qemu.qcow2.xz" {
+		t.Errorf("Expected qemu image URL, got %q (Message: %s)", result.ResolvedURL, result.Message)
+	}
+
+	src.Params["format"] = "raw.xz"
+	result = checker.CheckVersion(src, localPath)
+	if result.Status != StatusError || !strings.Contains(result.Message, "qcow2.xz") {
+		t.Errorf("Expected error listing available formats, got %v: %s", result.Status, result.Message)
+	}
+}
+
+func TestCheckFedoraCoreOSChecksum(t *testing.T) {
+	streamJSON, err := os.ReadFile(filepath.Join("testdata", "fcos_stable.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &MockHTTPClient{
+		GetFunc: func(url string) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(streamJSON)),
+			}, nil
+		},
+	}
+	checker := NewChecker(client, "")
+	localPath := filepath.Join(t.TempDir(), "fcos")
+
+	src := config.Source{
+		Name:     "Fedora Test",
+		Strategy: "fedora_coreos",
+		Params:   map[string]string{"arch": "x86_64"},
+	}
+	result := checker.CheckVersion(src, localPath)
+	expected := "sha256:a3c5d6e9b0f18c2e7d4a1b6f3e8c9d0a2b5e7f1c4d8a6b3e9f0c2d5a7b1e4f86"
+	if result.Checksum != expected {
+		t.Errorf("Expected ISO checksum %s, got %q (Message: %s)", expected, result.Checksum, result.Message)
+	}
+
+	src.Params["format"] = "raw.xz"
+	result = checker.CheckVersion(src, localPath)
+	expected = "sha256:7e2b4f1d9c3a6e8b0d5f2a7c4e1b9d6a3f8c0e5b2d7a4f1c6e9b3d0a5f8c2e71"
+	if result.Checksum != expected {
+		t.Errorf("Expected raw.xz checksum %s, got %q", expected, result.Checksum)
+	}
+}
+
 func TestCheckUpToDate(t *testing.T) {
 	tmpDir := t.TempDir()
 	latestFilename := "fedora-coreos-39.iso"
 	latestPath := filepath.Join(tmpDir, latestFilename)
 
-	if err := os.WriteFile(latestPath, []byte("latest"), 0644); err != nil {
+	if err := os.WriteFile(latestPath, make([]byte, 4096), 0644); err != nil {
 		t.Fatalf("Failed to create latest file: %v", err)
 	}
 
@@ -252,6 +601,128 @@ func TestCheckUpToDate(t *testing.T) {
 	}
 }
 
+func TestGithubReleaseExtraAssets(t *testing.T) {
+	base := "https://github.com/example/tool/releases/download/v2.0.0/"
+	useFixtures(t, map[string]string{
+		"api.github.com/repos/example/extras/releases/latest": "github_extras_latest.json",
+	})
+	t.Cleanup(func() { githubCache.Delete("example/extras") })
+
+	src := config.Source{
+		Name:     "Tool",
+		Strategy: "github_release",
+		Params: map[string]string{
+			"repo":          "example/extras",
+			"asset_pattern": `tool-.*\.tar\.gz`,
+			"extra_assets":  `tool-.*\.tar\.gz, ^SHA256SUMS$`,
+		},
+	}
+	result := NewChecker(nil, "").CheckVersion(src, filepath.Join(t.TempDir(), "tool"))
+
+	if result.Status != StatusNotFound || result.ResolvedURL != base+"tool-2.0.0.tar.gz" {
+		t.Fatalf("Expected the primary asset to drive the result, got %+v", result)
+	}
+	// The first extra pattern also matches the primary, so it falls through to the signature
+	want := []string{base + "tool-2.0.0.tar.gz.asc", base + "SHA256SUMS"}
+	if strings.Join(result.ExtraURLs, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected extras %v, got %v", want, result.ExtraURLs)
+	}
+
+	src.Params["extra_assets"] = "missing"
+	if result := NewChecker(nil, "").CheckVersion(src, filepath.Join(t.TempDir(), "tool")); result.Status != StatusError {
+		t.Errorf("Expected an error for an unmatched extra asset, got %+v", result)
+	}
+}
+
+func TestGithubReleasePinVersion(t *testing.T) {
+	fixtures := useFixtures(t, map[string]string{
+		"api.github.com/repos/example/pinned/releases/tags/v1.0.0": "github_pinned_v1.0.0.json",
+	})
+	t.Cleanup(func() {
+		githubCache.Delete("example/pinned")
+		githubCache.Delete("example/pinned@v1.0.0")
+	})
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "tool-v2.0.0.tar.gz"), []byte("new"), 0644)
+	src := config.Source{
+		Name:       "Tool",
+		Strategy:   "github_release",
+		PinVersion: "v1.0.0",
+		Params:     map[string]string{"repo": "example/pinned", "asset_pattern": `tool-.*\.tar\.gz`},
+	}
+
+	result := NewChecker(nil, "").CheckVersion(src, filepath.Join(dir, "tool"))
+	if result.Status != StatusNewer || result.Latest != "v1.0.0" || !strings.HasSuffix(result.ResolvedURL, "/v1.0.0/tool-v1.0.0.tar.gz") {
+		t.Errorf("Expected the pinned release to be offered, got %+v", result)
+	}
+
+	os.WriteFile(filepath.Join(dir, "tool-v1.0.0.tar.gz"), make([]byte, 4096), 0644)
+	if result := NewChecker(nil, "").CheckVersion(src, filepath.Join(dir, "tool")); result.Status != StatusUpToDate {
+		t.Errorf("Expected up to date once the pinned release is present, got %+v", result)
+	}
+
+	// The pinned release is fetched by tag once and then served from the cache
+	if got := fixtures.Requests(); len(got) != 1 || got[0] != "api.github.com/repos/example/pinned/releases/tags/v1.0.0" {
+		t.Errorf("Expected a single request for the pinned tag, got %v", got)
+	}
+
+	src = config.Source{Name: "Feed", Strategy: "rss_feed", PinVersion: "1.0"}
+	if result := NewChecker(nil, "").CheckVersion(src, filepath.Join(dir, "feed")); result.Status != StatusError {
+		t.Errorf("Expected an error for a strategy that cannot pin, got %+v", result)
+	}
+}
+
+func TestGithubReleaseInjectedClients(t *testing.T) {
+	release, err := os.ReadFile(filepath.Join("testdata", "github_extras_latest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		githubCache.Delete("example/mocked")
+		githubCache.Delete("example/enterprise")
+	})
+	src := func(repo string) config.Source {
+		return config.Source{
+			Name:     "Tool",
+			Strategy: "github_release",
+			Params:   map[string]string{"repo": repo, "asset_pattern": `tool-.*\.tar\.gz$`},
+		}
+	}
+
+	// An HTTPClient that is not an *http.Client still carries the GitHub API calls
+	var requested string
+	client := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requested = req.URL.String()
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(release))}, nil
+		},
+	}
+	result := NewChecker(client, "").CheckVersion(src("example/mocked"), filepath.Join(t.TempDir(), "tool"))
+	if result.Latest != "v2.0.0" || requested != "https://api.github.com/repos/example/mocked/releases/latest" {
+		t.Errorf("Expected the release through the mock client, got %+v (requested %q)", result, requested)
+	}
+
+	// An injected GitHub client takes precedence
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/example/enterprise/releases/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(release)
+	}))
+	defer srv.Close()
+	gc := github.NewClient(srv.Client())
+	gc.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	checker := NewChecker(&MockHTTPClient{}, "")
+	checker.SetGithubClient(gc)
+	result = checker.CheckVersion(src("example/enterprise"), filepath.Join(t.TempDir(), "tool"))
+	if result.Latest != "v2.0.0" || !strings.HasSuffix(result.ResolvedURL, "/tool-2.0.0.tar.gz") {
+		t.Errorf("Expected the release from the injected GitHub client, got %+v", result)
+	}
+}
+
 func TestCheckRSSVersion(t *testing.T) {
 	tmpDir := t.TempDir()
 	filename := "kiwix-desktop_x86_64_2.4.0.appimage"
@@ -299,3 +770,299 @@ func TestCheckRSSVersion(t *testing.T) {
 		t.Errorf("Expected latest 2.4.1, got %s", result.Latest)
 	}
 }
+
+func TestWebScrapeVersionShapes(t *testing.T) {
+	tests := []struct {
+		name         string
+		listing      string
+		pattern      string
+		template     string
+		versionRegex string
+		local        string
+		current      string
+		latest       string
+	}{
+		{
+			name:     "three-part",
+			listing:  `<a href="3.0.20/">3.0.20/</a><a href="3.0.21/">3.0.21/</a>`,
+			pattern:  `(\d+\.\d+\.\d+)/`,
+			template: "{{version}}/win64/vlc-{{version}}-win64.exe",
+			local:    "vlc-3.0.20-win64.exe",
+			current:  "3.0.20",
+			latest:   "3.0.21",
+		},
+		{
+			name:     "point release",
+			listing:  `<a href="24.04.1/">24.04.1/</a><a href="24.04.2/">24.04.2/</a>`,
+			pattern:  `(\d+\.\d+\.\d+)/`,
+			template: "{{version}}/distro-{{version}}-desktop-amd64.iso",
+			local:    "distro-24.04.1-desktop-amd64.iso",
+			current:  "24.04.1",
+			latest:   "24.04.2",
+		},
+		{
+			name:     "date-based",
+			listing:  `<a href="2024-03/">2024-03/</a><a href="2024-09/">2024-09/</a>`,
+			pattern:  `(\d{4}-\d{2})/`,
+			template: "{{version}}/archive_{{version}}.zip",
+			local:    "archive_2024-03.zip",
+			current:  "2024-03",
+			latest:   "2024-09",
+		},
+		{
+			name:         "custom regex",
+			listing:      `<a href="v2/">v2/</a><a href="v3/">v3/</a><a href="nightly/">nightly/</a>`,
+			pattern:      `(v\d+|nightly)/`,
+			template:     "{{version}}/tool-{{version}}.tar.gz",
+			versionRegex: `v\d+`,
+			local:        "tool-v2.tar.gz",
+			current:      "v2",
+			latest:       "v3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(tmpDir, tt.local), []byte("old"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			src := config.Source{
+				Name:     tt.name,
+				Strategy: "web_scrape",
+				Params: map[string]string{
+					// Listings are cached by URL, so each case gets its own
+					"base_url":        "https://" + strings.ReplaceAll(tt.name, " ", "-") + ".example.com/",
+					"version_pattern": tt.pattern,
+					"file_template":   tt.template,
+				},
+			}
+			if tt.versionRegex != "" {
+				src.Params["version_regex"] = tt.versionRegex
+			}
+			client := &MockHTTPClient{
+				GetFunc: func(url string) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(tt.listing))}, nil
+				},
+				HeadFunc: func(url string) (*http.Response, error) {
+					// "nightly" sorts last but is not a version under version_regex
+					if strings.Contains(url, "nightly") {
+						t.Errorf("Unexpected HEAD for %s", url)
+					}
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+				},
+			}
+
+			result := NewChecker(client, "").CheckVersion(src, filepath.Join(tmpDir, "target"))
+			if result.Status != StatusNewer || result.Current != tt.current || result.Latest != tt.latest {
+				t.Errorf("Expected %s -> %s (newer), got %s -> %s (%s: %s)", tt.current, tt.latest, result.Current, result.Latest, result.Status, result.Message)
+			}
+			if result.LocalFilename != tt.local {
+				t.Errorf("Expected local file %s, got %s", tt.local, result.LocalFilename)
+			}
+		})
+	}
+}
+
+func TestWebScrapePagesAndLatest(t *testing.T) {
+	pages := map[string]string{
+		// Paginated index: versions are split across ?page=N
+		"https://paged.example.com/":        `<a href="1.0/">1.0/</a><a href="?page=2">Next</a>`,
+		"https://paged.example.com/?page=2": `<a href="1.1/">1.1/</a><a href="?page=3">Next</a>`,
+		"https://paged.example.com/?page=3": `<a href="1.2/">1.2/</a><a href="?page=2">Prev</a>`,
+		// "latest" redirects to the release directory
+		"https://redirect.example.com/":        `<a href="1.0/">1.0/</a><a href="latest/">latest/</a>`,
+		"https://redirect.example.com/latest/": `<a href="app-2.0.iso">app-2.0.iso</a>`,
+		// "current" is a symlink; only the files inside name the version
+		"https://symlink.example.com/":         `<a href="1.0/">1.0/</a><a href="current/">current/</a>`,
+		"https://symlink.example.com/current/": `<a href="SHA256SUMS">SHA256SUMS</a><a href="app-3.0.iso">app-3.0.iso</a>`,
+	}
+	client := &MockHTTPClient{
+		GetFunc: func(rawURL string) (*http.Response, error) {
+			body, ok := pages[rawURL]
+			if !ok {
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil
+			}
+			final := rawURL
+			if rawURL == "https://redirect.example.com/latest/" {
+				final = "https://redirect.example.com/2.0/"
+			}
+			u, _ := url.Parse(final)
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: &http.Request{URL: u}}, nil
+		},
+		HeadFunc: func(rawURL string) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+		},
+	}
+	checker := NewChecker(client, "")
+
+	tests := []struct {
+		name, base string
+		params     map[string]string
+		latest     string
+	}{
+		{"single page by default", "https://paged.example.com/", nil, "1.0"},
+		{"page_pattern", "https://paged.example.com/", map[string]string{"page_pattern": `href="(\?page=\d+)"`}, "1.2"},
+		{"latest without follow_latest", "https://redirect.example.com/", nil, "1.0"},
+		{"latest redirect", "https://redirect.example.com/", map[string]string{"follow_latest": "true"}, "2.0"},
+		{"current symlink", "https://symlink.example.com/", map[string]string{"follow_latest": "true"}, "3.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := config.Source{
+				Name:     tt.name,
+				Strategy: "web_scrape",
+				Params: map[string]string{
+					"base_url":        tt.base,
+					"version_pattern": `href="(\d+\.\d+)/"`,
+					"file_template":   "{{version}}/app-{{version}}.iso",
+				},
+			}
+			for k, v := range tt.params {
+				src.Params[k] = v
+			}
+			result := checker.CheckVersion(src, filepath.Join(t.TempDir(), "app.iso"))
+			if result.Latest != tt.latest {
+				t.Errorf("Expected latest %s, got %q (%s)", tt.latest, result.Latest, result.Message)
+			}
+			if want := tt.base + tt.latest + "/app-" + tt.latest + ".iso"; result.ResolvedURL != want {
+				t.Errorf("Expected URL %s, got %s", want, result.ResolvedURL)
+			}
+		})
+	}
+}
+
+func TestWebScrapeProbesConcurrently(t *testing.T) {
+	// 9.0 and 10.0 sort wrongly as strings; 10.0 to 12.0 are listed but not uploaded yet
+	var listing strings.Builder
+	for _, v := range []string{"1.0", "2.0", "3.0", "4.0", "5.0", "6.0", "7.0", "8.0", "9.0", "10.0", "11.0", "12.0"} {
+		fmt.Fprintf(&listing, `<a href="%s/">%s/</a>`, v, v)
+	}
+
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	var probed []string
+	client := &MockHTTPClient{
+		GetFunc: func(url string) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(listing.String()))}, nil
+		},
+		HeadFunc: func(url string) (*http.Response, error) {
+			mu.Lock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			probed = append(probed, url)
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+
+			status := http.StatusNotFound
+			if strings.Contains(url, "/9.0/") || strings.Contains(url, "/8.0/") {
+				status = http.StatusOK
+			}
+			return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+		},
+	}
+
+	src := config.Source{
+		Name:     "Probe Test",
+		Strategy: "web_scrape",
+		Params: map[string]string{
+			"base_url":        "https://probe.example.com/",
+			"version_pattern": `href="(\d+\.\d+)/"`,
+			"file_template":   "{{version}}/app-{{version}}.iso",
+		},
+	}
+	result := NewChecker(client, "").CheckVersion(src, filepath.Join(t.TempDir(), "app.iso"))
+	if result.Latest != "9.0" {
+		t.Errorf("Expected the newest uploaded version 9.0, got %q (%s)", result.Latest, result.Message)
+	}
+	if maxInFlight < 2 || maxInFlight > probeBatch {
+		t.Errorf("Expected between 2 and %d concurrent probes, got %d", probeBatch, maxInFlight)
+	}
+	// 12.0 to 9.0 form the first batch, so nothing older is probed
+	if len(probed) != probeBatch {
+		t.Errorf("Expected %d probes, got %v", probeBatch, probed)
+	}
+}
+
+func TestWebScrapeConcurrentCacheFill(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	src := config.Source{
+		Name:     "Concurrent Test",
+		Strategy: "web_scrape",
+		Params: map[string]string{
+			"base_url":        "https://concurrent.example.com/releases/",
+			"version_pattern": `(\d+\.\d+)/`,
+			"file_template":   "{{version}}/app-{{version}}.iso",
+		},
+	}
+
+	var gets int32
+	client := &MockHTTPClient{
+		GetFunc: func(url string) (*http.Response, error) {
+			atomic.AddInt32(&gets, 1)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`<a href="1.0/">1.0/</a>`)),
+			}, nil
+		},
+		HeadFunc: func(url string) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+		},
+	}
+
+	checker := NewChecker(client, "")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := checker.CheckVersion(src, filepath.Join(tmpDir, "app.iso"))
+			if result.Latest != "1.0" {
+				t.Errorf("Expected latest 1.0, got %s (%s)", result.Latest, result.Message)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if gets != 1 {
+		t.Errorf("Expected 1 listing fetch for concurrent checks, got %d", gets)
+	}
+}
+
+func TestVersionStatusSymbol(t *testing.T) {
+	tests := map[VersionStatus]string{
+		StatusUpToDate:   "✓",
+		StatusNewer:      "↑",
+		StatusNotFound:   "✗",
+		StatusError:      "✗",
+		StatusIncomplete: "✗",
+		StatusDownloaded: "?",
+		"Queued":         "",
+	}
+	for status, want := range tests {
+		if got := status.Symbol(); got != want {
+			t.Errorf("%q.Symbol() = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestFileChange(t *testing.T) {
+	tests := []struct {
+		local, remote, want string
+	}{
+		{"", "", ""},
+		{"", "ubuntu-25.10.iso", "→ ubuntu-25.10.iso"},
+		{"ubuntu-24.04.iso", "ubuntu-25.10.iso", "ubuntu-24.04.iso → ubuntu-25.10.iso"},
+		{"ubuntu-25.10.iso", "ubuntu-25.10.iso", "ubuntu-25.10.iso"},
+	}
+	for _, tt := range tests {
+		if got := FileChange(tt.local, tt.remote); got != tt.want {
+			t.Errorf("FileChange(%q, %q) = %q, want %q", tt.local, tt.remote, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ulikunitz/xz"
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	xzMagic    = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+)
+
+// maxMetadataSize bounds how much a listing, feed or index may expand to, so a
+// small compressed response can't exhaust memory. Debian's largest Packages
+// files are well under it. A variable so tests can lower it.
+var maxMetadataSize int64 = 512 << 20
+
+var (
+	// ErrUnsupportedCompression is returned for metadata compressed in a format LAMP
+	// cannot read
+	ErrUnsupportedCompression = errors.New("unsupported compression")
+	// ErrMetadataTooLarge is returned once metadata grows past maxMetadataSize
+	ErrMetadataTooLarge = errors.New("metadata too large")
+)
+
+// readMaybeCompressed returns resp's body, decompressed when it holds gzip, bzip2
+// or xz data. Mirrors label compressed metadata inconsistently (Content-Encoding, a
+// .gz suffix, or neither, and Go's transport may already have removed the encoding),
+// so the format is recognised by its magic bytes; the header and URL only name the
+// format in errors. Reading fails with ErrMetadataTooLarge past maxMetadataSize.
+// The caller still closes resp.Body.
+func readMaybeCompressed(resp *http.Response) (io.Reader, error) {
+	br := bufio.NewReader(resp.Body)
+	head, _ := br.Peek(len(xzMagic))
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip data from %s: %w", responseURL(resp), err)
+		}
+		return limitMetadata(gz, resp), nil
+	case bytes.HasPrefix(head, bzip2Magic) && len(head) > 3 && head[3] >= '1' && head[3] <= '9':
+		return limitMetadata(bzip2.NewReader(br), resp), nil
+	case bytes.HasPrefix(head, xzMagic):
+		xr, err := xz.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xz data from %s: %w", responseURL(resp), err)
+		}
+		return limitMetadata(xr, resp), nil
+	}
+
+	if enc := resp.Header.Get("Content-Encoding"); enc != "" && !resp.Uncompressed && !strings.EqualFold(enc, "identity") {
+		switch strings.ToLower(enc) {
+		case "gzip", "x-gzip", "bzip2", "xz":
+			// Labelled but not actually compressed; read it as it is
+		default:
+			return nil, fmt.Errorf("%w: %s is served with Content-Encoding %s", ErrUnsupportedCompression, responseURL(resp), enc)
+		}
+	}
+	return limitMetadata(br, resp), nil
+}
+
+// limitMetadata reads up to maxMetadataSize bytes of r, and fails with
+// ErrMetadataTooLarge instead of ending there if r has more
+func limitMetadata(r io.Reader, resp *http.Response) io.Reader {
+	return io.MultiReader(io.LimitReader(r, maxMetadataSize), overflowReader{r, resp})
+}
+
+// overflowReader follows a reader's first maxMetadataSize bytes, reporting
+// whether anything is left
+type overflowReader struct {
+	r    io.Reader
+	resp *http.Response
+}
+
+func (o overflowReader) Read([]byte) (int, error) {
+	var b [1]byte
+	n, err := io.ReadFull(o.r, b[:])
+	if n > 0 {
+		return 0, fmt.Errorf("%w: %s expands past %d MB", ErrMetadataTooLarge, responseURL(o.resp), maxMetadataSize>>20)
+	}
+	return 0, err
+}
+
+// responseURL names the resource a response belongs to for error messages
+func responseURL(resp *http.Response) string {
+	if resp.Request != nil && resp.Request.URL != nil {
+		return resp.Request.URL.String()
+	}
+	return "response"
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"lamp/internal/config"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadMaybeCompressed(t *testing.T) {
+	plain := "Package: tool\n"
+	tests := []struct {
+		name     string
+		fixture  string // File under testdata; plain is used when empty
+		encoding string
+		contains string
+		wantErr  error
+	}{
+		{name: "plain", contains: "Package: tool"},
+		{name: "gzip", fixture: "web_listing.html.gz", contains: "Index of /tool/"},
+		{name: "bzip2", fixture: "deb_Packages.bz2", contains: "Version: 2.0.0-1"},
+		{name: "labelled gzip but plain", encoding: "gzip", contains: "Package: tool"},
+		{name: "xz", fixture: "deb_Packages.xz", contains: "Version: 2.0.0-1"},
+		{name: "brotli", encoding: "br", wantErr: ErrUnsupportedCompression},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(plain)
+			if tt.fixture != "" {
+				var err error
+				if body, err = os.ReadFile(filepath.Join("testdata", tt.fixture)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(string(body))),
+			}
+			if tt.encoding != "" {
+				resp.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			r, err := readMaybeCompressed(resp)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readMaybeCompressed failed: %v", err)
+			}
+			data, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("Reading the body failed: %v", err)
+			}
+			if !strings.Contains(string(data), tt.contains) {
+				t.Errorf("Expected the body to contain %q, got %q", tt.contains, data)
+			}
+		})
+	}
+}
+
+func TestReadMaybeCompressedLimit(t *testing.T) {
+	defer func(limit int64) { maxMetadataSize = limit }(maxMetadataSize)
+	body, err := os.ReadFile(filepath.Join("testdata", "deb_Packages.bz2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := func() ([]byte, error) {
+		r, err := readMaybeCompressed(&http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(string(body)))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return io.ReadAll(r)
+	}
+
+	data, err := read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Exactly at the limit is fine; a byte more is refused rather than cut off
+	maxMetadataSize = int64(len(data))
+	if _, err := read(); err != nil {
+		t.Errorf("Expected metadata at the limit to be read, got %v", err)
+	}
+	maxMetadataSize--
+	if _, err := read(); !errors.Is(err, ErrMetadataTooLarge) {
+		t.Errorf("Expected ErrMetadataTooLarge past the limit, got %v", err)
+	}
+}
+
+func TestWebScrapeGzippedListing(t *testing.T) {
+	useFixtures(t, map[string]string{
+		"gzipped.example.com/tool/":                   "web_listing.html.gz",
+		"gzipped.example.com/tool/tool-1.10.0.tar.gz": "web_listing.html.gz",
+	})
+
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "tool-1.9.2.tar.gz"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := config.Source{
+		Name:     "Tool",
+		Strategy: "web_scrape",
+		Params: map[string]string{
+			"base_url":        "https://gzipped.example.com/tool/",
+			"version_pattern": `tool-(\d+\.\d+\.\d+)\.tar\.gz`,
+			"file_template":   "tool-{{version}}.tar.gz",
+		},
+	}
+
+	result := NewChecker(nil, "").CheckVersion(src, filepath.Join(tmpDir, "tool"))
+	if result.Status != StatusNewer || result.Current != "1.9.2" || result.Latest != "1.10.0" {
+		t.Errorf("Expected 1.9.2 -> 1.10.0 from the gzipped listing, got %+v", result)
+	}
+}
@@ -0,0 +1,293 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"lamp/internal/config"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// debPackage is one stanza of an apt Packages index
+type debPackage struct {
+	Package      string
+	Version      string
+	Architecture string
+	Filename     string
+	SHA256       string
+}
+
+func (c *Checker) resolveDebRepo(src config.Source, localPath string) CheckResult {
+	baseURL := strings.TrimSuffix(src.Params["base_url"], "/")
+	suite := src.Params["suite"]
+	component := src.Params["component"]
+	arch := src.Params["arch"]
+	pkgName := src.Params["package"]
+
+	if baseURL == "" || suite == "" || pkgName == "" {
+		return CheckResult{Status: StatusError, Message: "Missing base_url, suite or package for deb_repo"}
+	}
+	if component == "" {
+		component = "main"
+	}
+	if arch == "" {
+		arch = "amd64"
+	}
+
+	indexURL := fmt.Sprintf("%s/dists/%s/%s/binary-%s/Packages", baseURL, suite, component, arch)
+	packages, err := c.fetchDebPackages(indexURL)
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: "Failed to fetch Packages index: " + err.Error()}
+	}
+
+	var latest *debPackage
+	for i, p := range packages {
+		if p.Package != pkgName || (p.Architecture != arch && p.Architecture != "all") {
+			continue
+		}
+		if src.PinVersion != "" && p.Version != src.PinVersion {
+			continue
+		}
+		if latest == nil || CompareDebVersions(p.Version, latest.Version) > 0 {
+			latest = &packages[i]
+		}
+	}
+	if latest == nil && src.PinVersion != "" {
+		return CheckResult{Status: StatusError, Message: fmt.Sprintf("Package '%s' version %s not found for %s", pkgName, src.PinVersion, arch)}
+	}
+	if latest == nil {
+		return CheckResult{Status: StatusError, Message: fmt.Sprintf("Package '%s' not found for %s", pkgName, arch)}
+	}
+
+	resolvedURL := baseURL + "/" + strings.TrimPrefix(latest.Filename, "/")
+	checksum := ""
+	if latest.SHA256 != "" {
+		checksum = "sha256:" + latest.SHA256
+	}
+
+	targetDir := filepath.Dir(localPath)
+	if _, err := os.Stat(filepath.Join(targetDir, path.Base(latest.Filename))); err == nil {
+		return CheckResult{Status: StatusUpToDate, Current: latest.Version, Latest: latest.Version, ResolvedURL: resolvedURL, Checksum: checksum}
+	}
+
+	// Local version detection from <package>_<version>_<arch>.deb filenames
+	var currentVersion, localFilename string
+	entries, _ := os.ReadDir(targetDir)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, pkgName+"_") || !strings.HasSuffix(name, ".deb") {
+			continue
+		}
+		parts := strings.Split(strings.TrimSuffix(name, ".deb"), "_")
+		if len(parts) == 3 {
+			// Epochs are escaped in pool filenames
+			version := strings.ReplaceAll(parts[1], "%3a", ":")
+			if currentVersion == "" || CompareDebVersions(version, currentVersion) > 0 {
+				currentVersion = version
+				localFilename = name
+			}
+		}
+	}
+
+	if currentVersion != "" {
+		return CheckResult{
+			Status:        StatusNewer,
+			Current:       currentVersion,
+			Latest:        latest.Version,
+			ResolvedURL:   resolvedURL,
+			Checksum:      checksum,
+			LocalFilename: localFilename,
+		}
+	}
+
+	return CheckResult{
+		Status:      StatusNotFound,
+		Latest:      latest.Version,
+		ResolvedURL: resolvedURL,
+		Checksum:    checksum,
+	}
+}
+
+// fetchDebPackages downloads and parses a Packages index, preferring the
+// compressed Packages.gz or Packages.bz2 and falling back to the plain file
+func (c *Checker) fetchDebPackages(indexURL string) ([]debPackage, error) {
+	var lastErr error
+	for _, suffix := range []string{".gz", ".bz2", ""} {
+		resp, err := c.client.Get(indexURL + suffix)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("HTTP %d", resp.StatusCode)
+			continue
+		}
+		packages, err := readDebPackages(resp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid Packages%s: %w", suffix, err)
+		}
+		return packages, nil
+	}
+	return nil, lastErr
+}
+
+func readDebPackages(resp *http.Response) ([]debPackage, error) {
+	body, err := readMaybeCompressed(resp)
+	if err != nil {
+		return nil, err
+	}
+	return parseDebPackages(body)
+}
+
+// parseDebPackages parses the RFC 822 style stanzas of a Packages index
+func parseDebPackages(r io.Reader) ([]debPackage, error) {
+	var packages []debPackage
+	var cur debPackage
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // Long Description/Depends lines
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if cur.Package != "" {
+				packages = append(packages, cur)
+			}
+			cur = debPackage{}
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			continue // Continuation of a multi-line field
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Package":
+			cur.Package = value
+		case "Version":
+			cur.Version = value
+		case "Architecture":
+			cur.Architecture = value
+		case "Filename":
+			cur.Filename = value
+		case "SHA256":
+			cur.SHA256 = value
+		}
+	}
+	if cur.Package != "" {
+		packages = append(packages, cur)
+	}
+	return packages, scanner.Err()
+}
+
+// CompareDebVersions compares two Debian package versions ([epoch:]upstream[-revision])
+// following dpkg's rules, including '~' sorting before everything else.
+// It returns -1, 0 or 1.
+func CompareDebVersions(a, b string) int {
+	epochA, upA, revA := splitDebVersion(a)
+	epochB, upB, revB := splitDebVersion(b)
+
+	if epochA != epochB {
+		if epochA < epochB {
+			return -1
+		}
+		return 1
+	}
+	if c := debVerRevCmp(upA, upB); c != 0 {
+		return c
+	}
+	return debVerRevCmp(revA, revB)
+}
+
+func splitDebVersion(v string) (int, string, string) {
+	epoch := 0
+	if e, rest, ok := strings.Cut(v, ":"); ok {
+		epoch, _ = strconv.Atoi(e)
+		v = rest
+	}
+	revision := ""
+	if i := strings.LastIndex(v, "-"); i != -1 {
+		revision = v[i+1:]
+		v = v[:i]
+	}
+	return epoch, v, revision
+}
+
+// debOrder ranks a character for the non-digit comparison: '~' sorts before the
+// end of the string, which sorts before letters, which sort before other symbols
+func debOrder(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+	c := s[i]
+	switch {
+	case c >= '0' && c <= '9':
+		return 0
+	case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		return int(c)
+	case c == '~':
+		return -1
+	default:
+		return int(c) + 256
+	}
+}
+
+func isDigitAt(s string, i int) bool {
+	return i < len(s) && s[i] >= '0' && s[i] <= '9'
+}
+
+// debVerRevCmp is dpkg's verrevcmp: alternating non-digit and numeric runs
+func debVerRevCmp(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigitAt(a, i)) || (j < len(b) && !isDigitAt(b, j)) {
+			ac, bc := debOrder(a, i), debOrder(b, j)
+			if ac != bc {
+				if ac < bc {
+					return -1
+				}
+				return 1
+			}
+			i++
+			j++
+		}
+
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+
+		firstDiff := 0
+		for isDigitAt(a, i) && isDigitAt(b, j) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if isDigitAt(a, i) {
+			return 1
+		}
+		if isDigitAt(b, j) {
+			return -1
+		}
+		if firstDiff != 0 {
+			if firstDiff < 0 {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
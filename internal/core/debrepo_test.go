@@ -0,0 +1,146 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"lamp/internal/config"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareDebVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.1", -1},
+		{"1.10", "1.9", 1},
+		{"1.0-1", "1.0-2", -1},
+		{"1:0.9", "2.0", 1},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0~rc1", "1.0~rc2", -1},
+		{"1.0~~", "1.0~", -1},
+		{"1.0a", "1.0", 1},
+		{"1.0+b1", "1.0a", 1},
+		{"2.30.2-1ubuntu1", "2.30.2-1", 1},
+		{"007", "7", 0},
+	}
+
+	for _, tt := range tests {
+		if got := CompareDebVersions(tt.a, tt.b); got != tt.expected {
+			t.Errorf("CompareDebVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+		if got := CompareDebVersions(tt.b, tt.a); got != -tt.expected {
+			t.Errorf("CompareDebVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.expected)
+		}
+	}
+}
+
+func TestCheckDebRepo(t *testing.T) {
+	index := `Package: other
+Version: 9.9
+Architecture: amd64
+Filename: pool/main/o/other/other_9.9_amd64.deb
+
+Package: tool
+Version: 1.2.0-1
+Architecture: amd64
+Filename: pool/main/t/tool/tool_1.2.0-1_amd64.deb
+SHA256: aaaa
+Description: A tool
+ with a long description
+
+Package: tool
+Version: 1.10.0~rc1-1
+Architecture: amd64
+Filename: pool/main/t/tool/tool_1.10.0~rc1-1_amd64.deb
+SHA256: bbbb
+
+Package: tool
+Version: 1.9.0-1
+Architecture: arm64
+Filename: pool/main/t/tool/tool_1.9.0-1_arm64.deb
+SHA256: cccc
+`
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(index))
+	w.Close()
+
+	var requested string
+	client := &MockHTTPClient{
+		GetFunc: func(url string) (*http.Response, error) {
+			requested = url
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(gz.Bytes())),
+			}, nil
+		},
+	}
+
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "tool_1.2.0-1_amd64.deb"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := config.Source{
+		Name:     "Tool",
+		Strategy: "deb_repo",
+		Params: map[string]string{
+			"base_url": "https://apt.example.com/",
+			"suite":    "stable",
+			"arch":     "amd64",
+			"package":  "tool",
+		},
+	}
+
+	checker := NewChecker(client, "")
+	result := checker.CheckVersion(src, filepath.Join(tmpDir, "Tool"))
+
+	if !strings.HasSuffix(requested, "/dists/stable/main/binary-amd64/Packages.gz") {
+		t.Errorf("Unexpected index URL %s", requested)
+	}
+	if result.Status != StatusNewer {
+		t.Fatalf("Expected status %v, got %v (Message: %s)", StatusNewer, result.Status, result.Message)
+	}
+	if result.Current != "1.2.0-1" || result.Latest != "1.10.0~rc1-1" {
+		t.Errorf("Expected 1.2.0-1 -> 1.10.0~rc1-1, got %s -> %s", result.Current, result.Latest)
+	}
+	if result.ResolvedURL != "https://apt.example.com/pool/main/t/tool/tool_1.10.0~rc1-1_amd64.deb" {
+		t.Errorf("Unexpected resolved URL %s", result.ResolvedURL)
+	}
+	if result.Checksum != "sha256:bbbb" {
+		t.Errorf("Expected checksum sha256:bbbb, got %s", result.Checksum)
+	}
+}
+
+func TestCheckDebRepoBzip2Index(t *testing.T) {
+	// No Packages.gz is recorded, so the index comes from Packages.bz2
+	fixtures := useFixtures(t, map[string]string{
+		"bz2.example.com/dists/stable/main/binary-amd64/Packages.bz2": "deb_Packages.bz2",
+	})
+
+	src := config.Source{
+		Name:     "Tool",
+		Strategy: "deb_repo",
+		Params: map[string]string{
+			"base_url": "https://bz2.example.com/",
+			"suite":    "stable",
+			"arch":     "amd64",
+			"package":  "tool",
+		},
+	}
+	result := NewChecker(nil, "").CheckVersion(src, filepath.Join(t.TempDir(), "Tool"))
+
+	if result.Latest != "2.0.0-1" || result.Checksum != "sha256:dddd" {
+		t.Errorf("Expected 2.0.0-1 from the bzip2 index, got %+v", result)
+	}
+	if got := fixtures.Requests(); len(got) != 2 || !strings.HasSuffix(got[0], "Packages.gz") {
+		t.Errorf("Expected Packages.gz to be tried before Packages.bz2, got %v", got)
+	}
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"lamp/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveDirect(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	version := "2.0"
+	lastModified := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="tool.zip"`)
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		if r.URL.Path == "/get" {
+			w.Header().Set("X-Version", version)
+		}
+		w.Write(make([]byte, 4096))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "Tool")
+	src := config.Source{Name: "Tool", Strategy: "direct", Params: map[string]string{
+		"url":                 server.URL + "/get",
+		"version_from_header": "X-Version",
+	}}
+	checker := NewChecker(nil, "")
+
+	result := checker.CheckVersion(src, localPath)
+	if result.Status != StatusNotFound || result.Latest != "2.0" || result.RemoteFilename != "tool.zip" || result.ResolvedURL != server.URL+"/get" {
+		t.Fatalf("Expected tool.zip 2.0 to be missing, got %+v", result)
+	}
+
+	// A copy from before the history is judged by Last-Modified
+	local := filepath.Join(dir, "tool.zip")
+	if err := os.WriteFile(local, make([]byte, 4096), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(local, lastModified, lastModified); err != nil {
+		t.Fatal(err)
+	}
+	if result := checker.CheckVersion(src, localPath); result.Status != StatusUpToDate || result.Latest != "2.0" || result.Current != "" {
+		t.Errorf("Expected an unversioned copy to be up to date by Last-Modified, got %+v", result)
+	}
+
+	// Once downloaded, the recorded version is compared with the header
+	if err := RecordDownload(HistoryEntry{Key: src.Key(), Version: "2.0", Path: local}); err != nil {
+		t.Fatal(err)
+	}
+	if result := checker.CheckVersion(src, localPath); result.Status != StatusUpToDate || result.Current != "2.0" {
+		t.Errorf("Expected 2.0 to be up to date, got %+v", result)
+	}
+	version = "2.1"
+	if result := checker.CheckVersion(src, localPath); result.Status != StatusNewer || result.Current != "2.0" || result.Latest != "2.1" {
+		t.Errorf("Expected 2.0 -> 2.1, got %+v", result)
+	}
+
+	src.Params["url"] = server.URL + "/plain"
+	if result := checker.CheckVersion(src, localPath); result.Status != StatusError || !strings.Contains(result.Message, "X-Version") {
+		t.Errorf("Expected an error for a response without the header, got %+v", result)
+	}
+
+	// Without version_from_header the direct strategy checks Last-Modified
+	delete(src.Params, "version_from_header")
+	if result := checker.CheckVersion(src, localPath); result.Status != StatusUpToDate || result.Latest != lastModified.Format(http.TimeFormat) {
+		t.Errorf("Expected Last-Modified to decide, got %+v", result)
+	}
+}
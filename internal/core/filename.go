@@ -0,0 +1,74 @@
+package core
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// RemoteFilename picks the filename to save a download of downloadURL as.
+// The URL's last path segment is used unless it is non-descriptive (no extension
+// or query-like), in which case the Content-Disposition filename and then the
+// final URL after redirects (both from resp, which may be nil) are preferred.
+// Release assets resolved to their GitHub API URL keep the asset's name.
+func RemoteFilename(downloadURL string, resp *http.Response) string {
+	// A private release asset's API URL ends in its id; the release names it
+	if name, ok := githubAssetName(downloadURL); ok {
+		return name
+	}
+	name := urlFilename(downloadURL)
+	if isDescriptiveFilename(name) || resp == nil {
+		return name
+	}
+
+	if cd := contentDispositionFilename(resp.Header.Get("Content-Disposition")); cd != "" {
+		return cd
+	}
+
+	if resp.Request == nil {
+		return name
+	}
+	if final := urlFilename(resp.Request.URL.String()); final != "" && (isDescriptiveFilename(final) || name == "") {
+		return final
+	}
+	return name
+}
+
+// contentDispositionFilename returns the sanitized filename from a Content-Disposition
+// header, decoding RFC 5987 filename* values. An unsafe or missing filename yields "".
+func contentDispositionFilename(header string) string {
+	if header == "" {
+		return ""
+	}
+	// mime.ParseMediaType prefers filename* over filename and decodes its charset
+	_, params, err := mime.ParseMediaType(header)
+	if err != nil {
+		return ""
+	}
+	name, err := SanitizeFilename(params["filename"])
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// isDescriptiveFilename reports whether name looks like a real filename rather
+// than an endpoint such as "download" or "get.php=123"
+func isDescriptiveFilename(name string) bool {
+	return path.Ext(name) != "" && !strings.ContainsAny(name, "?&=")
+}
+
+// urlFilename returns the unescaped last path segment of rawURL, ignoring the query string
+func urlFilename(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		return ""
+	}
+	return name
+}
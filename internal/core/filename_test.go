@@ -0,0 +1,65 @@
+package core
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestRemoteFilename(t *testing.T) {
+	redirected := func(finalURL string) *http.Response {
+		u, _ := url.Parse(finalURL)
+		return &http.Response{Request: &http.Request{URL: u}, Header: http.Header{}}
+	}
+
+	tests := []struct {
+		name        string
+		downloadURL string
+		resp        *http.Response
+		expected    string
+	}{
+		{"plain file", "https://example.com/files/app-1.0.zip", nil, "app-1.0.zip"},
+		{"query ignored", "https://example.com/files/app-1.0.zip?mirror=1", nil, "app-1.0.zip"},
+		{"original wins when it has an extension", "https://example.com/app.iso", redirected("https://cdn.example.com/x/app-eu.iso"), "app.iso"},
+		{"redirect supplies filename", "https://sourceforge.net/projects/foo/files/latest/download", redirected("https://downloads.sourceforge.net/project/foo/foo-2.3.tar.gz"), "foo-2.3.tar.gz"},
+		{"no better name", "https://example.com/download", redirected("https://cdn.example.com/blob"), "download"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RemoteFilename(tt.downloadURL, tt.resp); got != tt.expected {
+				t.Errorf("RemoteFilename() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRemoteFilenameContentDisposition(t *testing.T) {
+	withDisposition := func(cd string) *http.Response {
+		u, _ := url.Parse("https://example.com/download")
+		return &http.Response{
+			Request: &http.Request{URL: u},
+			Header:  http.Header{"Content-Disposition": []string{cd}},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		downloadURL string
+		cd          string
+		expected    string
+	}{
+		{"ascii filename", "https://example.com/download?id=123", `attachment; filename="report-2024.pdf"`, "report-2024.pdf"},
+		{"utf-8 filename*", "https://example.com/download?id=123", `attachment; filename="fallback.pdf"; filename*=UTF-8''%E2%82%AC%20rates.pdf`, "€ rates.pdf"},
+		{"descriptive URL wins", "https://example.com/files/app.zip", `attachment; filename="other.zip"`, "app.zip"},
+		{"path traversal rejected", "https://example.com/download", `attachment; filename="../../etc/passwd"`, "download"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RemoteFilename(tt.downloadURL, withDisposition(tt.cd)); got != tt.expected {
+				t.Errorf("RemoteFilename() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
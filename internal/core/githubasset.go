@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/go-github/v69/github"
+)
+
+// githubAssetPath matches the API URL of a release asset, which serves the file
+// itself when asked for application/octet-stream
+var githubAssetPath = regexp.MustCompile(`^/repos/[^/]+/[^/]+/releases/assets/\d+$`)
+
+var (
+	// gitHubToken is sent with requests for release assets through the API
+	gitHubToken atomic.Pointer[string]
+
+	// githubAssetNames maps the API URL of an asset to its filename, which the
+	// URL, ending in the asset id, doesn't carry
+	githubAssetNames sync.Map // map[string]string
+
+	repoPrivate sync.Map // map[string]bool, by owner/repo
+)
+
+// SetGitHubToken sets the token sent with downloads of private release assets,
+// which go through the GitHub API
+func SetGitHubToken(token string) {
+	gitHubToken.Store(&token)
+}
+
+// githubAssetToken returns the token from SetGitHubToken
+func githubAssetToken() string {
+	if t := gitHubToken.Load(); t != nil {
+		return *t
+	}
+	return ""
+}
+
+// isGitHubAssetRequest reports whether req fetches a release asset through the
+// GitHub API
+func isGitHubAssetRequest(req *http.Request) bool {
+	return isGitHubAssetURL(req.URL)
+}
+
+// IsGitHubAssetURL reports whether rawURL fetches a release asset through the
+// GitHub API, as assets of private repositories are. Downloading it outside LAMP
+// takes the token and Accept: application/octet-stream.
+func IsGitHubAssetURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && isGitHubAssetURL(u)
+}
+
+func isGitHubAssetURL(u *url.URL) bool {
+	return u.Scheme == "https" && strings.EqualFold(u.Host, "api.github.com") && githubAssetPath.MatchString(u.Path)
+}
+
+// githubAssetURL returns where to download asset from. Browser download URLs of
+// private repos only answer signed-in browsers, so with a token their assets are
+// fetched through the API instead; the token and Accept header are added by
+// authTransport.
+func githubAssetURL(asset *github.ReleaseAsset, private bool) string {
+	if !private || asset.GetURL() == "" {
+		return asset.GetBrowserDownloadURL()
+	}
+	u := asset.GetURL()
+	githubAssetNames.Store(u, asset.GetName())
+	return u
+}
+
+// githubAssetName returns the filename of an asset resolved to its API URL
+func githubAssetName(rawURL string) (string, bool) {
+	name, ok := githubAssetNames.Load(rawURL)
+	if !ok {
+		return "", false
+	}
+	return name.(string), true
+}
+
+// repoIsPrivate reports whether owner/repo is only visible with a token, in which
+// case its assets are downloaded through the API. Without the checker's token, or
+// when GitHub can't be asked, the repo is taken as public.
+func (c *Checker) repoIsPrivate(owner, repoName string) bool {
+	if c.githubToken == "" || IsOffline() {
+		return false
+	}
+	key := owner + "/" + repoName
+	if private, ok := repoPrivate.Load(key); ok {
+		return private.(bool)
+	}
+	repo, _, err := c.githubClient().Repositories.Get(context.Background(), owner, repoName)
+	if err != nil {
+		return false
+	}
+	private := repo.GetPrivate() || (repo.GetVisibility() != "" && repo.GetVisibility() != "public")
+	repoPrivate.Store(key, private)
+	return private
+}
+
+// applyGitHubAssetHeaders asks the API for the asset's bytes, with the token
+// unless the caller set its own Authorization. The Accept header is always
+// replaced, even one a source sets for the host, since any other answers with
+// the asset's JSON. The API answers with a redirect to signed storage, which the
+// client follows without these headers.
+func applyGitHubAssetHeaders(req *http.Request) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept", "application/octet-stream")
+	if token := githubAssetToken(); token != "" && req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"io"
+	"lamp/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v69/github"
+)
+
+func TestPrivateReleaseAssetsUseAPI(t *testing.T) {
+	t.Cleanup(func() {
+		for _, repo := range []string{"acme/internal", "acme/public"} {
+			githubCache.Delete(repo)
+			repoPrivate.Delete(repo)
+		}
+	})
+	release := `{"tag_name": "v1.2.0", "assets": [
+		{"name": "tool-1.2.0-linux.tar.gz", "url": "https://api.github.com/repos/acme/REPO/releases/assets/101",
+		 "browser_download_url": "https://github.com/acme/REPO/releases/download/v1.2.0/tool-1.2.0-linux.tar.gz"},
+		{"name": "SHA256SUMS", "url": "https://api.github.com/repos/acme/REPO/releases/assets/102",
+		 "browser_download_url": "https://github.com/acme/REPO/releases/download/v1.2.0/SHA256SUMS"}]}`
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/repos/"), "/")
+		switch {
+		case len(parts) == 2:
+			w.Write([]byte(`{"private": ` + map[string]string{"internal": "true", "public": "false"}[parts[1]] + `}`))
+		case strings.HasSuffix(r.URL.Path, "/releases/latest"):
+			w.Write([]byte(strings.ReplaceAll(release, "REPO", parts[1])))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	gc := github.NewClient(srv.Client()).WithAuthToken("secret")
+	gc.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	check := func(repo, token string) CheckResult {
+		checker := NewChecker(&MockHTTPClient{}, token)
+		checker.SetGithubClient(gc)
+		src := config.Source{Name: "Tool", Strategy: "github_release", Params: map[string]string{
+			"repo": repo, "asset_pattern": `tool-.*-linux\.tar\.gz`, "extra_assets": `^SHA256SUMS$`,
+		}}
+		return checker.CheckVersion(src, filepath.Join(t.TempDir(), "tool"))
+	}
+
+	result := check("acme/internal", "secret")
+	if result.ResolvedURL != "https://api.github.com/repos/acme/internal/releases/assets/101" {
+		t.Errorf("Expected the asset's API URL for a private repo, got %+v", result)
+	}
+	if result.RemoteFilename != "tool-1.2.0-linux.tar.gz" || RemoteFilename(result.ResolvedURL, nil) != "tool-1.2.0-linux.tar.gz" {
+		t.Errorf("Expected the asset's name for its API URL, got %q", result.RemoteFilename)
+	}
+	if len(result.ExtraURLs) != 1 || RemoteFilename(result.ExtraURLs[0], nil) != "SHA256SUMS" {
+		t.Errorf("Expected the extra asset by API URL and named, got %v", result.ExtraURLs)
+	}
+	for _, a := range auth {
+		if a != "Bearer secret" {
+			t.Errorf("Expected every API call to carry the token, got %q", a)
+		}
+	}
+
+	if result := check("acme/public", "secret"); !strings.HasPrefix(result.ResolvedURL, "https://github.com/acme/public/releases/download/") {
+		t.Errorf("Expected the browser URL for a public repo, got %+v", result)
+	}
+	// Without a token the repo isn't looked up and the browser URL is all there is
+	repoPrivate.Delete("acme/internal")
+	auth = nil
+	if result := check("acme/internal", ""); !strings.HasPrefix(result.ResolvedURL, "https://github.com/") || len(auth) != 0 {
+		t.Errorf("Expected the browser URL and no repo lookup without a token, got %+v (%d API calls)", result, len(auth))
+	}
+}
+
+// assetTransport answers every request itself, redirecting the GitHub API to
+// storage, and keeps the headers of each
+type assetTransport struct {
+	headers map[string]http.Header
+}
+
+func (rt *assetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.headers[req.URL.Host] = req.Header.Clone()
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}
+	if req.URL.Host == "api.github.com" {
+		resp.StatusCode = http.StatusFound
+		resp.Header.Set("Location", "https://objects.githubusercontent.com/asset-101?signature=abc")
+	}
+	return resp, nil
+}
+
+func TestGitHubAssetTransport(t *testing.T) {
+	SetGitHubToken("secret")
+	defer SetGitHubToken("")
+	// A source's Accept for the host must not turn the asset into its JSON
+	SetHeaders(&config.Config{Categories: map[string]config.Category{"Tools": {Sources: []config.Source{
+		{Name: "API", URL: "https://api.github.com/repos/acme/tool", Headers: map[string]string{"Accept": "application/vnd.github+json"}},
+	}}}})
+	defer SetHeaders(&config.Config{})
+
+	rt := &assetTransport{headers: make(map[string]http.Header)}
+	client := &http.Client{Transport: AuthTransport(rt)}
+	resp, err := client.Get("https://api.github.com/repos/acme/internal/releases/assets/101")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	api := rt.headers["api.github.com"]
+	if api.Get("Authorization") != "Bearer secret" || api.Get("Accept") != "application/octet-stream" {
+		t.Errorf("Expected the token and octet-stream Accept on the API request, got %v", api)
+	}
+	// The signed storage URL must not see the token
+	if storage, ok := rt.headers["objects.githubusercontent.com"]; !ok || storage.Get("Authorization") != "" {
+		t.Errorf("Expected the redirect followed without the token, got %v (followed %v)", storage, ok)
+	}
+
+	// Other API calls and other hosts are left alone
+	SetHeaders(&config.Config{})
+	rt.headers = make(map[string]http.Header)
+	for _, u := range []string{"https://api.github.com/repos/acme/internal/releases/latest", "https://github.com/acme/internal/releases/download/v1/tool.tar.gz"} {
+		resp, err := client.Get(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	for host, h := range rt.headers {
+		if h.Get("Authorization") != "" || h.Get("Accept") != "" {
+			t.Errorf("Expected no GitHub asset headers for %s, got %v", host, h)
+		}
+	}
+}
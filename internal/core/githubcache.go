@@ -0,0 +1,112 @@
+package core
+
+import (
+	"encoding/json"
+	"lamp/internal/config"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/go-github/v69/github"
+)
+
+// DefaultGitHubCacheTTL is how long a cached GitHub release is used before the
+// API is asked again
+const DefaultGitHubCacheTTL = time.Hour
+
+var gitHubCacheTTL atomic.Int64
+
+func init() {
+	gitHubCacheTTL.Store(int64(DefaultGitHubCacheTTL))
+}
+
+// SetGitHubCacheTTL sets how long cached GitHub releases are used without asking
+// the API. A non-positive ttl restores the default.
+func SetGitHubCacheTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultGitHubCacheTTL
+	}
+	gitHubCacheTTL.Store(int64(ttl))
+}
+
+// releaseCache keeps GitHub releases between runs. It is nil until
+// OpenGitHubCache is called, in which case every run asks the API.
+var releaseCache atomic.Pointer[ReleaseCache]
+
+// cachedRelease is a release as stored in the GitHub cache. ETag lets an expired
+// entry be revalidated with a conditional request, which GitHub answers with a
+// 304 that does not count against the rate limit.
+type cachedRelease struct {
+	Release   *github.RepositoryRelease `json:"release"`
+	ETag      string                    `json:"etag,omitempty"`
+	FetchedAt time.Time                 `json:"fetched_at"`
+}
+
+// ReleaseCache persists GitHub release responses, keyed by repo and tag
+type ReleaseCache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]cachedRelease
+}
+
+// OpenGitHubCache loads the GitHub release cache from the lamp config directory.
+// From then on release lookups are served from it while fresh and recorded in it.
+func OpenGitHubCache() {
+	releaseCache.Store(loadReleaseCache(getGitHubCachePath()))
+}
+
+func loadReleaseCache(path string) *ReleaseCache {
+	rc := &ReleaseCache{path: path, entries: make(map[string]cachedRelease)}
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(data, &rc.entries)
+		}
+	}
+	return rc
+}
+
+func (rc *ReleaseCache) get(key string) (cachedRelease, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	entry, ok := rc.entries[key]
+	return entry, ok && entry.Release != nil
+}
+
+// fresh reports whether entry can be used without asking the API
+func (entry cachedRelease) fresh() bool {
+	return time.Since(entry.FetchedAt) < time.Duration(gitHubCacheTTL.Load())
+}
+
+// reset forgets every release without touching the cache file
+func (rc *ReleaseCache) reset() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.entries = make(map[string]cachedRelease)
+}
+
+// put records a release and writes the cache file
+func (rc *ReleaseCache) put(key string, release *github.RepositoryRelease, etag string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.entries[key] = cachedRelease{Release: release, ETag: etag, FetchedAt: time.Now()}
+	if rc.path == "" {
+		return
+	}
+	data, err := json.Marshal(rc.entries)
+	if err != nil {
+		return
+	}
+	os.WriteFile(rc.path, data, 0600)
+}
+
+func getGitHubCachePath() string {
+	lampDir, err := config.GetConfigDir()
+	if err != nil {
+		return ""
+	}
+	os.MkdirAll(lampDir, 0755)
+	return filepath.Join(lampDir, "github_cache.json")
+}
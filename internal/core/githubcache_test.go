@@ -0,0 +1,76 @@
+package core
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// etagTransport serves one GitHub release with an ETag and answers a matching
+// If-None-Match with 304
+type etagTransport struct {
+	requests    int
+	conditional int
+}
+
+func (e *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	e.requests++
+	header := make(http.Header)
+	header.Set("ETag", `"v1"`)
+	if req.Header.Get("If-None-Match") == `"v1"` {
+		e.conditional++
+		return &http.Response{StatusCode: http.StatusNotModified, Status: "304 Not Modified", Header: header, Body: http.NoBody, Request: req}, nil
+	}
+	header.Set("Content-Type", "application/json")
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(`{"tag_name": "v1.2.3", "assets": []}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestGitHubReleaseDiskCache(t *testing.T) {
+	et := &etagTransport{}
+	SetTransport(et)
+	path := filepath.Join(t.TempDir(), "github_cache.json")
+	releaseCache.Store(loadReleaseCache(path))
+	t.Cleanup(func() {
+		SetTransport(nil)
+		releaseCache.Store(nil)
+		SetGitHubCacheTTL(0)
+		githubCache.Delete("example/cached")
+	})
+
+	fetch := func() string {
+		t.Helper()
+		// Each run starts with an empty in-memory cache
+		githubCache.Delete("example/cached")
+		release, err := NewChecker(nil, "").githubRelease("example", "cached", "")
+		if err != nil {
+			t.Fatalf("githubRelease failed: %v", err)
+		}
+		return release.GetTagName()
+	}
+
+	if tag := fetch(); tag != "v1.2.3" || et.requests != 1 {
+		t.Fatalf("Expected one API request for v1.2.3, got %d requests and %q", et.requests, tag)
+	}
+
+	// A later run reads the fresh entry from disk without asking the API
+	releaseCache.Store(loadReleaseCache(path))
+	if tag := fetch(); tag != "v1.2.3" || et.requests != 1 {
+		t.Errorf("Expected the cached release without a request, got %d requests and %q", et.requests, tag)
+	}
+
+	// Once expired, the entry is revalidated with its ETag
+	SetGitHubCacheTTL(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if tag := fetch(); tag != "v1.2.3" || et.requests != 2 || et.conditional != 1 {
+		t.Errorf("Expected a conditional request answered with 304, got %d requests (%d conditional) and %q", et.requests, et.conditional, tag)
+	}
+}
@@ -0,0 +1,393 @@
+package core
+
+import (
+	"fmt"
+	"lamp/internal/config"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// importTargets are the OS/arch combinations a generated github_release entry tries to cover
+var (
+	importOSes  = []string{"linux", "macos", "windows"}
+	importArchs = []string{"amd64", "arm64"}
+)
+
+// Fragments recognised in asset names, checked in order so that longer tokens win
+var (
+	importOSFragments = []struct{ os, frag string }{
+		{"linux", "linux"},
+		{"macos", "macos"}, {"macos", "darwin"}, {"macos", "osx"}, {"macos", "mac"}, {"macos", "apple"},
+		{"windows", "windows"}, {"windows", "win64"}, {"windows", "win32"}, {"windows", "win"},
+	}
+	importArchFragments = []struct{ arch, frag string }{
+		{"amd64", "x86_64"}, {"amd64", "amd64"}, {"amd64", "x64"}, {"amd64", "64bit"},
+		{"arm64", "aarch64"}, {"arm64", "arm64"},
+		{"universal", "universal"},
+	}
+	// Extensions that identify the OS on their own
+	importExtOS = map[string]string{
+		"appimage": "linux", "deb": "linux", "rpm": "linux",
+		"dmg": "macos", "pkg": "macos",
+		"exe": "windows", "msi": "windows",
+	}
+	// Preferred download formats per OS, best first
+	importExtPreference = map[string][]string{
+		"linux":   {"AppImage", "tar.gz", "tar.xz", "zip", "deb", "rpm"},
+		"macos":   {"dmg", "pkg", "zip", "tar.gz"},
+		"windows": {"exe", "msi", "zip"},
+	}
+	importIgnoredExts = map[string]bool{
+		"asc": true, "sig": true, "sha256": true, "sha512": true, "sha256sum": true, "md5": true,
+		"txt": true, "json": true, "yml": true, "yaml": true, "blockmap": true, "sbom": true, "pem": true,
+	}
+)
+
+// importAsset is a release asset with the OS/arch/version fragments located in its name
+type importAsset struct {
+	name    string
+	os      string
+	osFrag  [2]int // byte span of the OS fragment, -1 if absent
+	arch    string
+	archFrg [2]int
+	verSpan [2]int
+	ext     string
+}
+
+// GithubImport is a suggested catalog entry for a GitHub repository
+type GithubImport struct {
+	Source  config.Source
+	Matched map[string]string // "os/arch" (or "os", or "") to the asset name the pattern selects
+	Skipped []string          // Targets that could not be covered by the pattern
+}
+
+// GithubReleaseAssets returns the latest release tag and asset names of owner/repo
+func (c *Checker) GithubReleaseAssets(repo string) (string, []string, error) {
+	owner, repoName, err := parseRepo(repo)
+	if err != nil {
+		return "", nil, err
+	}
+	release, err := c.githubRelease(owner, repoName, "")
+	if err != nil {
+		return "", nil, err
+	}
+	var assets []string
+	for _, a := range release.Assets {
+		assets = append(assets, a.GetName())
+	}
+	return release.GetTagName(), assets, nil
+}
+
+// SuggestGithubSource builds a github_release catalog entry for repo from the
+// asset names of its latest release. OS/arch fragments in the chosen assets are
+// replaced with {{os_map}}/{{arch_map}}, the version with ".*" and the
+// extension with {{ext}}, and the maps are filled from what was found.
+func SuggestGithubSource(repo, tag string, assets []string) (GithubImport, error) {
+	var parsed []importAsset
+	for _, name := range assets {
+		if a, ok := parseImportAsset(name, tag); ok {
+			parsed = append(parsed, a)
+		}
+	}
+	if len(parsed) == 0 {
+		return GithubImport{}, fmt.Errorf("release %s has no downloadable assets", tag)
+	}
+
+	repoName := repo[strings.LastIndex(repo, "/")+1:]
+	src := config.Source{
+		ID:       strings.ToLower(repoName),
+		Name:     repoName,
+		Strategy: "github_release",
+		Params:   map[string]string{"repo": repo},
+	}
+	result := GithubImport{Matched: make(map[string]string)}
+
+	// Pick the preferred asset for every target the release ships
+	chosen := make(map[string]importAsset)
+	usesArch := false
+	for _, osName := range importOSes {
+		for _, arch := range importArchs {
+			if a, ok := pickImportAsset(parsed, osName, arch); ok {
+				chosen[osName+"/"+arch] = a
+				usesArch = usesArch || a.archFrg[0] >= 0
+			}
+		}
+	}
+
+	// A release without any recognisable platform builds (an ISO, a single
+	// archive) gets a plain pattern for its first asset
+	if len(chosen) == 0 {
+		src.Params["asset_pattern"] = importTemplate(parsed[0], false)
+		result.Source = src
+		result.Matched[""] = parsed[0].name
+		return result, nil
+	}
+
+	// Use the template shared by most targets; the rest are excluded
+	counts := make(map[string]int)
+	for _, a := range chosen {
+		counts[importTemplate(a, true)]++
+	}
+	pattern := ""
+	for t, n := range counts {
+		if n > counts[pattern] || (n == counts[pattern] && t < pattern) {
+			pattern = t
+		}
+	}
+	src.Params["asset_pattern"] = pattern
+
+	osMap := make(map[string]string)
+	archMap := make(map[string]string)
+	extMap := make(map[string]string)
+	covered := make(map[string]bool)
+	for key, a := range chosen {
+		if importTemplate(a, true) != pattern {
+			continue
+		}
+		osName, _, _ := strings.Cut(key, "/")
+		osMap[osName] = importFragment(a.name, a.osFrag)
+		extMap[osName] = a.ext
+		if usesArch {
+			archMap[key] = importFragment(a.name, a.archFrg)
+		}
+		covered[key] = true
+	}
+	if strings.Contains(pattern, "{{os_map}}") {
+		src.OSMap = osMap
+	}
+	if strings.Contains(pattern, "{{arch_map}}") {
+		src.ArchMap = archMap
+	}
+	src.ExtMap = extMap
+
+	// Confirm each target resolves to the asset it was generated from, since
+	// the resolver takes the first asset matching the expanded pattern
+	for _, osName := range importOSes {
+		osCovered := false
+		for _, arch := range importArchs {
+			key := osName + "/" + arch
+			if !covered[key] {
+				continue
+			}
+			if first := firstMatchingAsset(expandImportPattern(src, osName, arch), assets); first != chosen[key].name {
+				covered[key] = false
+				continue
+			}
+			osCovered = true
+			if usesArch {
+				result.Matched[key] = chosen[key].name
+			} else {
+				result.Matched[osName] = chosen[key].name
+			}
+		}
+		if !usesArch || !osCovered {
+			if !osCovered {
+				src.Exclude = append(src.Exclude, osName)
+				result.Skipped = append(result.Skipped, osName)
+			}
+			continue
+		}
+		for _, arch := range importArchs {
+			if key := osName + "/" + arch; !covered[key] {
+				src.Exclude = append(src.Exclude, key)
+				result.Skipped = append(result.Skipped, key)
+			}
+		}
+	}
+	if len(result.Matched) == 0 {
+		return GithubImport{}, fmt.Errorf("could not build an asset_pattern that selects the release assets")
+	}
+
+	result.Source = src
+	return result, nil
+}
+
+// parseImportAsset locates the OS, arch, version and extension in an asset name.
+// Checksums, signatures and metadata files are rejected.
+func parseImportAsset(name, tag string) (importAsset, bool) {
+	a := importAsset{name: name, osFrag: [2]int{-1, -1}, archFrg: [2]int{-1, -1}, verSpan: [2]int{-1, -1}}
+
+	lower := strings.ToLower(name)
+	a.ext = strings.TrimPrefix(path.Ext(name), ".")
+	for _, multi := range []string{".tar.gz", ".tar.xz", ".tar.bz2", ".tar.zst"} {
+		if strings.HasSuffix(lower, multi) {
+			a.ext = name[len(name)-len(multi)+1:]
+		}
+	}
+	if a.ext == "" || importIgnoredExts[strings.ToLower(a.ext)] ||
+		strings.Contains(lower, "checksum") || strings.Contains(lower, "sha256sum") {
+		return a, false
+	}
+	stem := name[:len(name)-len(a.ext)-1]
+
+	for _, f := range importOSFragments {
+		if span := findFragment(stem, f.frag); span[0] >= 0 {
+			a.os, a.osFrag = f.os, span
+			break
+		}
+	}
+	for _, f := range importArchFragments {
+		if span := findFragment(stem, f.frag); span[0] >= 0 && !overlaps(span, a.osFrag) {
+			a.arch, a.archFrg = f.arch, span
+			break
+		}
+	}
+	// "win64" names both the OS and the arch
+	if a.arch == "" && strings.EqualFold(importFragment(name, a.osFrag), "win64") {
+		a.arch = "amd64"
+	}
+	if a.os == "" {
+		a.os = importExtOS[strings.ToLower(a.ext)]
+	}
+
+	for _, v := range []string{tag, strings.TrimPrefix(tag, "v")} {
+		if v == "" {
+			continue
+		}
+		if i := strings.Index(stem, v); i >= 0 && !overlaps([2]int{i, i + len(v)}, a.osFrag) && !overlaps([2]int{i, i + len(v)}, a.archFrg) {
+			a.verSpan = [2]int{i, i + len(v)}
+			break
+		}
+	}
+	return a, true
+}
+
+// pickImportAsset chooses the asset for osName/arch, preferring an explicit arch
+// match, then universal builds, then (for amd64) builds without an arch. Ties
+// go to the OS's preferred extension and then the shortest name.
+func pickImportAsset(assets []importAsset, osName, arch string) (importAsset, bool) {
+	rank := func(a importAsset) int {
+		switch {
+		case a.arch == arch:
+			return 0
+		case a.arch == "universal":
+			return 1
+		case a.arch == "" && arch == "amd64":
+			return 2
+		}
+		return -1
+	}
+	extRank := func(a importAsset) int {
+		for i, e := range importExtPreference[osName] {
+			if strings.EqualFold(e, a.ext) {
+				return i
+			}
+		}
+		return len(importExtPreference[osName])
+	}
+
+	var candidates []importAsset
+	for _, a := range assets {
+		if a.os == osName && rank(a) >= 0 {
+			candidates = append(candidates, a)
+		}
+	}
+	if len(candidates) == 0 {
+		return importAsset{}, false
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		if extRank(a) != extRank(b) {
+			return extRank(a) < extRank(b)
+		}
+		return len(a.name) < len(b.name)
+	})
+	return candidates[0], true
+}
+
+// importTemplate turns an asset name into an asset_pattern, optionally
+// substituting the platform fragments and extension with template variables
+func importTemplate(a importAsset, platform bool) string {
+	type span struct {
+		start, end int
+		repl       string
+	}
+	var spans []span
+	if a.verSpan[0] >= 0 {
+		spans = append(spans, span{a.verSpan[0], a.verSpan[1], ".*"})
+	}
+	if platform && a.osFrag[0] >= 0 {
+		spans = append(spans, span{a.osFrag[0], a.osFrag[1], "{{os_map}}"})
+	}
+	if platform && a.archFrg[0] >= 0 {
+		spans = append(spans, span{a.archFrg[0], a.archFrg[1], "{{arch_map}}"})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	stem := a.name[:len(a.name)-len(a.ext)-1]
+	var b strings.Builder
+	pos := 0
+	for _, s := range spans {
+		b.WriteString(regexp.QuoteMeta(stem[pos:s.start]))
+		b.WriteString(s.repl)
+		pos = s.end
+	}
+	b.WriteString(regexp.QuoteMeta(stem[pos:]))
+	if platform {
+		b.WriteString(`\.{{ext}}$`)
+	} else {
+		b.WriteString(regexp.QuoteMeta("."+a.ext) + "$")
+	}
+	return "^" + b.String()
+}
+
+// expandImportPattern substitutes the template variables the way config expansion does
+func expandImportPattern(src config.Source, osName, arch string) string {
+	p := src.Params["asset_pattern"]
+	p = strings.ReplaceAll(p, "{{os_map}}", src.OSMap[osName])
+	p = strings.ReplaceAll(p, "{{arch_map}}", src.ArchMap[osName+"/"+arch])
+	p = strings.ReplaceAll(p, "{{ext}}", src.ExtMap[osName])
+	return p
+}
+
+func firstMatchingAsset(pattern string, assets []string) string {
+	re, err := SafeCompileRegex(pattern)
+	if err != nil {
+		return ""
+	}
+	for _, name := range assets {
+		if re.MatchString(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// findFragment finds frag (case-insensitively) as a whole token of name,
+// delimited by '-', '_', '.', ' ' or the ends of the string
+func findFragment(name, frag string) [2]int {
+	lower := strings.ToLower(name)
+	for from := 0; from < len(lower); {
+		i := strings.Index(lower[from:], frag)
+		if i < 0 {
+			break
+		}
+		i += from
+		end := i + len(frag)
+		if (i == 0 || isImportDelim(lower[i-1])) && (end == len(lower) || isImportDelim(lower[end])) {
+			return [2]int{i, end}
+		}
+		from = i + 1
+	}
+	return [2]int{-1, -1}
+}
+
+func isImportDelim(c byte) bool {
+	return c == '-' || c == '_' || c == '.' || c == ' '
+}
+
+func importFragment(name string, span [2]int) string {
+	if span[0] < 0 {
+		return ""
+	}
+	return regexp.QuoteMeta(name[span[0]:span[1]])
+}
+
+func overlaps(a, b [2]int) bool {
+	return a[0] >= 0 && b[0] >= 0 && a[0] < b[1] && b[0] < a[1]
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSuggestGithubSource(t *testing.T) {
+	assets := []string{
+		"tool-1.4.2-linux-x86_64.tar.gz",
+		"tool-1.4.2-linux-aarch64.tar.gz",
+		"tool-1.4.2-linux-x86_64.tar.gz.sha256",
+		"tool-1.4.2-macos-universal.dmg",
+		"tool-1.4.2-windows-x86_64.zip",
+		"checksums.txt",
+	}
+
+	imp, err := SuggestGithubSource("example/tool", "v1.4.2", assets)
+	if err != nil {
+		t.Fatalf("SuggestGithubSource failed: %v", err)
+	}
+
+	src := imp.Source
+	if src.ID != "tool" || src.Strategy != "github_release" || src.Params["repo"] != "example/tool" {
+		t.Errorf("Unexpected entry: %+v", src)
+	}
+	wantPattern := `^tool-.*-{{os_map}}-{{arch_map}}\.{{ext}}$`
+	if got := src.Params["asset_pattern"]; got != wantPattern {
+		t.Errorf("Expected pattern %q, got %q", wantPattern, got)
+	}
+
+	want := map[string]string{
+		"linux/amd64":   "tool-1.4.2-linux-x86_64.tar.gz",
+		"linux/arm64":   "tool-1.4.2-linux-aarch64.tar.gz",
+		"macos/amd64":   "tool-1.4.2-macos-universal.dmg",
+		"macos/arm64":   "tool-1.4.2-macos-universal.dmg",
+		"windows/amd64": "tool-1.4.2-windows-x86_64.zip",
+	}
+	for target, asset := range want {
+		if imp.Matched[target] != asset {
+			t.Errorf("%s: expected %s, got %q", target, asset, imp.Matched[target])
+		}
+		osName, arch, _ := strings.Cut(target, "/")
+		re := regexp.MustCompile(expandImportPattern(src, osName, arch))
+		if !re.MatchString(asset) {
+			t.Errorf("%s: expanded pattern does not match %s", target, asset)
+		}
+	}
+	if len(src.Exclude) != 1 || src.Exclude[0] != "windows/arm64" {
+		t.Errorf("Expected only windows/arm64 excluded, got %v", src.Exclude)
+	}
+}
+
+func TestSuggestGithubSourceWithoutPlatforms(t *testing.T) {
+	imp, err := SuggestGithubSource("example/distro", "2024.1", []string{"distro-2024.1.iso", "distro-2024.1.iso.sig"})
+	if err != nil {
+		t.Fatalf("SuggestGithubSource failed: %v", err)
+	}
+	if got := imp.Source.Params["asset_pattern"]; got != `^distro-.*\.iso$` {
+		t.Errorf("Unexpected pattern %q", got)
+	}
+	if len(imp.Source.OSMap) != 0 || len(imp.Source.Exclude) != 0 {
+		t.Errorf("Expected no platform maps, got %+v", imp.Source)
+	}
+}
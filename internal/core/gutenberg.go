@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -15,14 +16,20 @@ import (
 )
 
 const (
-	gutendexBaseURL = "https://gutendex.com/books"
-	defaultLimit    = 100
-	cacheTTL        = 24 * time.Hour
+	defaultLimit = 100
+
+	maxTopBooksPages = 20 // Hard cap on pages fetched, in case the API keeps returning "next"
+	pageAttempts     = 3  // Tries per page before giving up
 )
 
 var (
+	gutendexBaseURL = "https://gutendex.com/books"
+
 	// Global rate limiter for Gutenberg API: 5 requests burst, refill 1 per second
 	gutenbergRateLimiter = NewRateLimiter(5, time.Second)
+
+	// Delay before the first retry of a failed page; doubled for each further retry
+	pageRetryDelay = time.Second
 )
 
 // gutenbergCache represents the structure of the local cache file
@@ -60,46 +67,46 @@ type GutenbergResponse struct {
 	Results  []GutenbergBook `json:"results"`
 }
 
-// FetchTopBooks fetches the most popular books from Gutendex (sorted by download count)
-func FetchTopBooks(language string, limit int) ([]GutenbergBook, error) {
+// FetchTopBooks fetches the most popular books from Gutendex (sorted by download count).
+// Each page is retried with backoff on network errors and 5xx/429 responses, and at
+// most maxTopBooksPages pages are requested.
+func FetchTopBooks(ctx context.Context, language string, limit int) ([]GutenbergBook, error) {
+	return fetchTopBooks(ctx, language, limit, true)
+}
+
+// RefreshTopBooks is FetchTopBooks without reading the cache; the result is still cached
+func RefreshTopBooks(ctx context.Context, language string, limit int) ([]GutenbergBook, error) {
+	if IsOffline() {
+		return nil, fmt.Errorf("%w: cannot refresh the Gutenberg catalog", ErrOffline)
+	}
+	return fetchTopBooks(ctx, language, limit, false)
+}
+
+func fetchTopBooks(ctx context.Context, language string, limit int, useCache bool) ([]GutenbergBook, error) {
 	if limit <= 0 {
 		limit = defaultLimit
 	}
 
 	// Try loading from cache first
-	if cachedBooks, ok := loadCache(limit); ok {
-		return cachedBooks, nil
+	if useCache {
+		if cachedBooks, ok := loadCache(limit); ok {
+			return cachedBooks, nil
+		}
+		if IsOffline() {
+			return nil, fmt.Errorf("%w: no cached Gutenberg catalog", ErrOffline)
+		}
 	}
 
 	// Gutendex returns 32 books per page by default, need multiple requests for 100
 	var allBooks []GutenbergBook
 	nextURL := fmt.Sprintf("%s?languages=%s&sort=popular", gutendexBaseURL, language)
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient()
 
-	for len(allBooks) < limit && nextURL != "" {
-		// Rate limit API calls
-		gutenbergRateLimiter.Wait()
-
-		req, err := http.NewRequest("GET", nextURL, nil)
+	for page := 0; len(allBooks) < limit && nextURL != "" && page < maxTopBooksPages; page++ {
+		gutResp, err := fetchBooksPage(ctx, client, nextURL)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
-		}
-		req.Header.Set("User-Agent", "lamp/1.0")
-
-		resp, err := client.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch books: %w", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("gutendex API returned status %d", resp.StatusCode)
-		}
-
-		var gutResp GutenbergResponse
-		if err := json.NewDecoder(resp.Body).Decode(&gutResp); err != nil {
-			return nil, fmt.Errorf("failed to decode response: %w", err)
+			return nil, err
 		}
 
 		allBooks = append(allBooks, gutResp.Results...)
@@ -122,12 +129,71 @@ func FetchTopBooks(language string, limit int) ([]GutenbergBook, error) {
 	return allBooks, nil
 }
 
+// fetchBooksPage requests one page of Gutendex results, retrying transient failures
+func fetchBooksPage(ctx context.Context, client *http.Client, pageURL string) (*GutenbergResponse, error) {
+	delay := pageRetryDelay
+	var lastErr error
+	for attempt := 0; attempt < pageAttempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+			delay *= 2
+		}
+
+		// Rate limit API calls
+		if err := gutenbergRateLimiter.WaitContext(ctx); err != nil {
+			return nil, err
+		}
+
+		gutResp, retry, err := getBooksPage(ctx, client, pageURL)
+		if err == nil {
+			return gutResp, nil
+		}
+		if !retry {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("%w (after %d attempts)", lastErr, pageAttempts)
+}
+
+// getBooksPage makes a single page request. retry reports whether a failure is
+// worth retrying.
+func getBooksPage(ctx context.Context, client *http.Client, pageURL string) (gutResp *GutenbergResponse, retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "lamp/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, fmt.Errorf("failed to fetch books: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		retry = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf("gutendex API returned status %d", resp.StatusCode)
+	}
+
+	gutResp = &GutenbergResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(gutResp); err != nil {
+		return nil, true, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return gutResp, false, nil
+}
+
 func getCachePath() string {
-	configDir, err := os.UserConfigDir()
+	lampDir, err := config.GetConfigDir()
 	if err != nil {
 		return ""
 	}
-	lampDir := filepath.Join(configDir, "lamp")
 	// Ensure directory exists
 	os.MkdirAll(lampDir, 0755)
 	return filepath.Join(lampDir, "gutenberg_cache.json")
@@ -149,8 +215,13 @@ func loadCache(limit int) ([]GutenbergBook, bool) {
 		return nil, false
 	}
 
+	// Offline mode takes whatever was cached, however old or short
+	if IsOffline() {
+		return cache.Books[:min(limit, len(cache.Books))], len(cache.Books) > 0
+	}
+
 	// Check if cache is expired
-	if time.Since(cache.Timestamp) > cacheTTL {
+	if time.Since(cache.Timestamp) > cacheTTL() {
 		return nil, false
 	}
 
@@ -181,17 +252,28 @@ func saveCache(books []GutenbergBook) {
 	os.WriteFile(path, data, 0600)
 }
 
-// SearchBooks searches for books by title or author
+// SearchBooks searches for books by title or author. In offline mode only the
+// cached catalog is searched.
 func SearchBooks(query string, language string) ([]GutenbergBook, error) {
+	if IsOffline() {
+		books, ok := loadCache(defaultLimit)
+		if !ok {
+			return nil, fmt.Errorf("%w: no cached Gutenberg catalog to search", ErrOffline)
+		}
+		return filterBooks(books, query), nil
+	}
+
 	// Rate limit API calls
-	gutenbergRateLimiter.Wait()
+	if err := gutenbergRateLimiter.WaitContext(apiCtx); err != nil {
+		return nil, err
+	}
 
 	encodedQuery := url.QueryEscape(query)
 	apiURL := fmt.Sprintf("%s?search=%s&languages=%s", gutendexBaseURL, encodedQuery, language)
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := newHTTPClient()
 
-	req, err := http.NewRequest("GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(apiCtx, "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -215,6 +297,22 @@ func SearchBooks(query string, language string) ([]GutenbergBook, error) {
 	return gutResp.Results, nil
 }
 
+// filterBooks returns the books whose title or an author contains query, ignoring case
+func filterBooks(books []GutenbergBook, query string) []GutenbergBook {
+	query = strings.ToLower(query)
+	var matches []GutenbergBook
+	for _, book := range books {
+		match := strings.Contains(strings.ToLower(book.Title), query)
+		for _, a := range book.Authors {
+			match = match || strings.Contains(strings.ToLower(a.Name), query)
+		}
+		if match {
+			matches = append(matches, book)
+		}
+	}
+	return matches
+}
+
 // GetEPUB3URL extracts the EPUB3 download URL from a book's formats
 func GetEPUB3URL(book GutenbergBook) string {
 	// Try EPUB with images first (preferred)
@@ -298,3 +396,15 @@ func BookToSource(book GutenbergBook, cfg *config.Config) config.Source {
 		URL:      GetEPUB3URL(book),
 	}
 }
+
+// BookDirectSource returns a direct source for the EPUB of book, so it can be
+// kept in a regular category. Gutenberg strategy sources would turn the category
+// into a library tab instead.
+func BookDirectSource(book GutenbergBook) config.Source {
+	return config.Source{
+		ID:       fmt.Sprintf("gutenberg-%d", book.ID),
+		Name:     fmt.Sprintf("%s (%s)", book.Title, GetPrimaryAuthor(book)),
+		Strategy: "direct",
+		Params:   map[string]string{"url": GetEPUB3URL(book)},
+	}
+}
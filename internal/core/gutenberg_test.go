@@ -0,0 +1,49 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchTopBooksRetriesPage(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // Keep the cache out of the real config dir
+
+	var srv *httptest.Server
+	var page2Calls atomic.Int32
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp GutenbergResponse
+		if r.URL.Query().Get("page") == "2" {
+			if page2Calls.Add(1) == 1 {
+				http.Error(w, "bad gateway", http.StatusBadGateway)
+				return
+			}
+			resp.Results = []GutenbergBook{{ID: 3, Title: "Three"}, {ID: 4, Title: "Four"}}
+		} else {
+			next := srv.URL + "/books?page=2"
+			resp.Next = &next
+			resp.Results = []GutenbergBook{{ID: 1, Title: "One"}, {ID: 2, Title: "Two"}}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	oldURL, oldDelay := gutendexBaseURL, pageRetryDelay
+	gutendexBaseURL, pageRetryDelay = srv.URL+"/books", time.Millisecond
+	t.Cleanup(func() { gutendexBaseURL, pageRetryDelay = oldURL, oldDelay })
+
+	books, err := FetchTopBooks(context.Background(), "en", 4)
+	if err != nil {
+		t.Fatalf("FetchTopBooks failed: %v", err)
+	}
+	if len(books) != 4 || books[3].ID != 4 {
+		t.Errorf("Expected 4 books across both pages, got %+v", books)
+	}
+	if got := page2Calls.Load(); got != 2 {
+		t.Errorf("Expected page 2 to be requested twice, got %d", got)
+	}
+}
@@ -0,0 +1,182 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"lamp/internal/config"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const hashicorpReleasesURL = "https://releases.hashicorp.com"
+
+// HashicorpIndex is the per-product index.json on releases.hashicorp.com
+type HashicorpIndex struct {
+	Name     string                      `json:"name"`
+	Versions map[string]HashicorpVersion `json:"versions"`
+}
+
+type HashicorpVersion struct {
+	Version string           `json:"version"`
+	Shasums string           `json:"shasums"`
+	Builds  []HashicorpBuild `json:"builds"`
+}
+
+type HashicorpBuild struct {
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Filename string `json:"filename"`
+	URL      string `json:"url"`
+}
+
+// hashicorpOS and hashicorpArch map our OS/arch names to HashiCorp's
+var (
+	hashicorpOS   = map[string]string{"macos": "darwin"}
+	hashicorpArch = map[string]string{"x86_64": "amd64", "aarch64": "arm64", "x86": "386"}
+)
+
+func (c *Checker) resolveHashicorp(src config.Source, localPath string) CheckResult {
+	product := src.Params["product"]
+	osName := src.Params["os"]
+	arch := src.Params["arch"]
+
+	if product == "" || osName == "" || arch == "" {
+		return CheckResult{Status: StatusError, Message: "Missing product, os or arch for hashicorp"}
+	}
+	if mapped, ok := hashicorpOS[osName]; ok {
+		osName = mapped
+	}
+	if mapped, ok := hashicorpArch[arch]; ok {
+		arch = mapped
+	}
+
+	resp, err := c.client.Get(fmt.Sprintf("%s/%s/index.json", hashicorpReleasesURL, product))
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: "Failed to fetch HashiCorp index: " + err.Error()}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return CheckResult{Status: StatusError, Message: fmt.Sprintf("HashiCorp index returned HTTP %d", resp.StatusCode)}
+	}
+
+	var index HashicorpIndex
+	body, err := readMaybeCompressed(resp)
+	if err != nil {
+		return CheckResult{Status: StatusError, Message: "Failed to read HashiCorp index: " + err.Error()}
+	}
+	if err := json.NewDecoder(body).Decode(&index); err != nil {
+		return CheckResult{Status: StatusError, Message: "Failed to parse HashiCorp index"}
+	}
+
+	// Newest stable release; pre-releases (1.6.0-rc1) and enterprise builds (1.6.0+ent) are skipped
+	// unless one is pinned
+	var latest *HashicorpVersion
+	for v := range index.Versions {
+		if src.PinVersion != "" {
+			if v != src.PinVersion {
+				continue
+			}
+		} else if strings.ContainsAny(v, "-+") {
+			continue
+		}
+		if latest == nil || CompareVersions(v, latest.Version) > 0 {
+			ver := index.Versions[v]
+			ver.Version = v
+			latest = &ver
+		}
+	}
+	if latest == nil && src.PinVersion != "" {
+		return CheckResult{Status: StatusError, Message: fmt.Sprintf("Pinned version %s of %s not found", src.PinVersion, product)}
+	}
+	if latest == nil {
+		return CheckResult{Status: StatusError, Message: "No stable releases found for " + product}
+	}
+
+	var build *HashicorpBuild
+	for i := range latest.Builds {
+		if latest.Builds[i].OS == osName && latest.Builds[i].Arch == arch {
+			build = &latest.Builds[i]
+			break
+		}
+	}
+	if build == nil {
+		return CheckResult{Status: StatusError, Message: fmt.Sprintf("No %s/%s build of %s %s", osName, arch, product, latest.Version)}
+	}
+
+	checksum := ""
+	if latest.Shasums != "" {
+		sumsURL := fmt.Sprintf("%s/%s/%s/%s", hashicorpReleasesURL, product, latest.Version, latest.Shasums)
+		if sum, err := c.fetchSHA256Sum(sumsURL, build.Filename); err == nil {
+			checksum = "sha256:" + sum
+		}
+	}
+
+	targetDir := filepath.Dir(localPath)
+	if _, err := os.Stat(filepath.Join(targetDir, build.Filename)); err == nil {
+		return CheckResult{Status: StatusUpToDate, Current: latest.Version, Latest: latest.Version, ResolvedURL: build.URL, Checksum: checksum}
+	}
+
+	// Local version detection from <product>_<version>_<os>_<arch>.zip filenames
+	var currentVersion, localFilename string
+	reVer := regexp.MustCompile(fmt.Sprintf(`^%s_(.+)_%s_%s\.zip$`,
+		regexp.QuoteMeta(product), regexp.QuoteMeta(osName), regexp.QuoteMeta(arch)))
+	entries, _ := os.ReadDir(targetDir)
+	for _, entry := range entries {
+		if m := reVer.FindStringSubmatch(entry.Name()); len(m) > 1 && !entry.IsDir() {
+			if currentVersion == "" || CompareVersions(m[1], currentVersion) > 0 {
+				currentVersion = m[1]
+				localFilename = entry.Name()
+			}
+		}
+	}
+
+	if currentVersion != "" {
+		return CheckResult{
+			Status:        StatusNewer,
+			Current:       currentVersion,
+			Latest:        latest.Version,
+			ResolvedURL:   build.URL,
+			Checksum:      checksum,
+			LocalFilename: localFilename,
+		}
+	}
+
+	return CheckResult{
+		Status:      StatusNotFound,
+		Latest:      latest.Version,
+		ResolvedURL: build.URL,
+		Checksum:    checksum,
+	}
+}
+
+// fetchSHA256Sum looks up filename in a sha256sum-style file ("<hash>  <filename>" per line)
+func (c *Checker) fetchSHA256Sum(sumsURL, filename string) (string, error) {
+	resp, err := c.client.Get(sumsURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body, err := readMaybeCompressed(resp)
+	if err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == filename {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s not listed in %s", filename, sumsURL)
+}
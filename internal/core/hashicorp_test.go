@@ -0,0 +1,103 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"lamp/internal/config"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckHashicorp(t *testing.T) {
+	indexJSON := `{
+		"name": "terraform",
+		"versions": {
+			"1.9.0": {
+				"version": "1.9.0",
+				"shasums": "terraform_1.9.0_SHA256SUMS",
+				"builds": [
+					{"os": "darwin", "arch": "arm64", "filename": "terraform_1.9.0_darwin_arm64.zip", "url": "https://releases.hashicorp.com/terraform/1.9.0/terraform_1.9.0_darwin_arm64.zip"}
+				]
+			},
+			"1.10.1": {
+				"version": "1.10.1",
+				"shasums": "terraform_1.10.1_SHA256SUMS",
+				"builds": [
+					{"os": "linux", "arch": "amd64", "filename": "terraform_1.10.1_linux_amd64.zip", "url": "https://releases.hashicorp.com/terraform/1.10.1/terraform_1.10.1_linux_amd64.zip"},
+					{"os": "darwin", "arch": "arm64", "filename": "terraform_1.10.1_darwin_arm64.zip", "url": "https://releases.hashicorp.com/terraform/1.10.1/terraform_1.10.1_darwin_arm64.zip"}
+				]
+			},
+			"1.11.0-rc1": {
+				"version": "1.11.0-rc1",
+				"builds": [
+					{"os": "darwin", "arch": "arm64", "filename": "terraform_1.11.0-rc1_darwin_arm64.zip", "url": "https://releases.hashicorp.com/terraform/1.11.0-rc1/terraform_1.11.0-rc1_darwin_arm64.zip"}
+				]
+			}
+		}
+	}`
+	sums := "1111  terraform_1.10.1_linux_amd64.zip\n2222  terraform_1.10.1_darwin_arm64.zip\n"
+
+	client := &MockHTTPClient{
+		GetFunc: func(url string) (*http.Response, error) {
+			body := indexJSON
+			if strings.HasSuffix(url, "_SHA256SUMS") {
+				body = sums
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(body)),
+			}, nil
+		},
+	}
+
+	src := config.Source{
+		Name:     "Terraform",
+		Strategy: "hashicorp",
+		Params: map[string]string{
+			"product": "terraform",
+			"os":      "macos",
+			"arch":    "aarch64",
+		},
+	}
+
+	checker := NewChecker(client, "")
+	result := checker.CheckVersion(src, filepath.Join(t.TempDir(), "Terraform"))
+
+	if result.Status != StatusNotFound {
+		t.Errorf("Expected status %v, got %v (Message: %s)", StatusNotFound, result.Status, result.Message)
+	}
+	if result.Latest != "1.10.1" {
+		t.Errorf("Expected latest stable 1.10.1, got %s", result.Latest)
+	}
+	if !strings.HasSuffix(result.ResolvedURL, "terraform_1.10.1_darwin_arm64.zip") {
+		t.Errorf("Unexpected resolved URL %s", result.ResolvedURL)
+	}
+	if result.Checksum != "sha256:2222" {
+		t.Errorf("Expected checksum sha256:2222, got %s", result.Checksum)
+	}
+
+	// A pin targets that release even with a newer one on disk
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "terraform_1.10.1_darwin_arm64.zip"), []byte("zip"), 0644)
+	src.PinVersion = "1.9.0"
+	result = checker.CheckVersion(src, filepath.Join(dir, "Terraform"))
+	if result.Status != StatusNewer || result.Latest != "1.9.0" || result.Message != "Pinned to 1.9.0" {
+		t.Errorf("Expected the pinned 1.9.0 to be offered, got %+v", result)
+	}
+	if result.LocalFilename != "terraform_1.10.1_darwin_arm64.zip" || result.RemoteFilename != "terraform_1.9.0_darwin_arm64.zip" {
+		t.Errorf("Expected 1.10.1 to be replaced by the 1.9.0 zip, got %q -> %q", result.LocalFilename, result.RemoteFilename)
+	}
+
+	os.WriteFile(filepath.Join(dir, "terraform_1.9.0_darwin_arm64.zip"), make([]byte, 4096), 0644)
+	if result = checker.CheckVersion(src, filepath.Join(dir, "Terraform")); result.Status != StatusUpToDate {
+		t.Errorf("Expected up to date once the pinned version is present, got %+v", result)
+	}
+
+	src.PinVersion = "0.1.0"
+	if result = checker.CheckVersion(src, filepath.Join(dir, "Terraform")); result.Status != StatusError {
+		t.Errorf("Expected an error for a pin that does not exist, got %+v", result)
+	}
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"fmt"
+	"lamp/internal/config"
+	"sort"
+	"sync/atomic"
+)
+
+// sourceHeaders maps a lowercase host to the extra headers sources give for it
+var sourceHeaders atomic.Pointer[map[string]map[string]string]
+
+// SetHeaders makes requests to the hosts of sources with headers carry them,
+// replacing those set before. It returns a warning for each header two sources
+// give different values for on the same host; the first source's is used.
+func SetHeaders(cfg *config.Config) []string {
+	hosts := make(map[string]map[string]string)
+	owner := make(map[string]string)
+	warned := make(map[string]bool) // Expanded variants of a source repeat its headers
+	var warnings []string
+	names := make([]string, 0, len(cfg.Categories))
+	for name := range cfg.Categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, src := range cfg.Categories[name].Sources {
+			for _, host := range src.HeaderHosts() {
+				if hosts[host] == nil {
+					hosts[host] = make(map[string]string)
+				}
+				for header, value := range src.Headers {
+					key := host + " " + header
+					prev, ok := hosts[host][header]
+					if !ok {
+						hosts[host][header] = value
+						owner[key] = src.Name
+						continue
+					}
+					if prev != value && !warned[key+" "+src.Name] {
+						warned[key+" "+src.Name] = true
+						warnings = append(warnings, fmt.Sprintf("Sources '%s' and '%s' set different %s headers for %s; using the first", owner[key], src.Name, header, host))
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(warnings)
+	sourceHeaders.Store(&hosts)
+	return warnings
+}
+
+// headersFor returns the extra headers registered for host
+func headersFor(host string) map[string]string {
+	hosts := sourceHeaders.Load()
+	if hosts == nil {
+		return nil
+	}
+	return (*hosts)[host]
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"lamp/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSourceHeadersReachServer(t *testing.T) {
+	defer sourceHeaders.Store(nil)
+	var referer, userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		referer, userAgent = r.Header.Get("Referer"), r.Header.Get("User-Agent")
+		http.Redirect(w, r, "/app-1.2.0.iso", http.StatusFound)
+	}))
+	defer server.Close()
+
+	src := config.Source{Name: "App", Strategy: "redirect",
+		Params:  map[string]string{"url": server.URL + "/latest", "version_pattern": `app-(\d+\.\d+\.\d+)\.iso`},
+		Headers: map[string]string{"Referer": "https://example.org/downloads", "User-Agent": "Mozilla/5.0"},
+	}
+	other := config.Source{Name: "Other", URL: server.URL + "/other.iso", Headers: map[string]string{"Referer": "https://example.org/other"}}
+	cfg := &config.Config{Categories: map[string]config.Category{
+		"Apps": {Sources: []config.Source{src, other}},
+	}}
+	warnings := SetHeaders(cfg)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "Referer") {
+		t.Errorf("Expected a warning about the conflicting Referer, got %v", warnings)
+	}
+
+	result := NewChecker(nil, "").CheckVersion(src, filepath.Join(t.TempDir(), "app.iso"))
+	if result.Latest != "1.2.0" {
+		t.Fatalf("Expected version 1.2.0, got %+v", result)
+	}
+	if referer != "https://example.org/downloads" {
+		t.Errorf("Expected the first source's Referer, got %q", referer)
+	}
+	if userAgent != "Mozilla/5.0" {
+		t.Errorf("Expected the source's User-Agent to replace lamp's, got %q", userAgent)
+	}
+
+	// The same server under another host name gets none of them
+	referer = ""
+	client := &http.Client{Transport: AuthTransport(nil), CheckRedirect: noFollow}
+	resp, err := client.Get(strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if referer != "" {
+		t.Errorf("Expected no Referer for another host, got %q", referer)
+	}
+}
+
+func noFollow(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
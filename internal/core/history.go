@@ -0,0 +1,129 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"lamp/internal/config"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Checksum states recorded in the download history
+const (
+	HistoryVerified   = "verified"   // The download matched a configured or published checksum
+	HistoryUnverified = "unverified" // No checksum was known for the download
+)
+
+// HistoryEntry is one completed download in history.jsonl
+type HistoryEntry struct {
+	Time      time.Time `json:"time"`
+	Category  string    `json:"category"`
+	Name      string    `json:"name"`
+	ID        string    `json:"id,omitempty"`
+	Key       string    `json:"key"` // config.Source.Key of the downloaded source
+	Version   string    `json:"version,omitempty"`
+	URL       string    `json:"url"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	Checksum  string    `json:"checksum"` // HistoryVerified or HistoryUnverified
+	Signature bool      `json:"signature_verified,omitempty"`
+}
+
+// historyMu serializes appends from concurrent downloads within this process
+var historyMu sync.Mutex
+
+// RecordDownload appends e to history.jsonl in the lamp config directory. Each
+// entry is written with a single append, so lines from concurrent downloads, and
+// from other LAMP processes, never interleave.
+func RecordDownload(e HistoryEntry) error {
+	return appendHistory(getHistoryPath(), e)
+}
+
+// ReadHistory returns the last limit entries of the download history, oldest
+// first, or all of them when limit is 0. A missing history is empty.
+func ReadHistory(limit int) ([]HistoryEntry, error) {
+	return readHistory(getHistoryPath(), limit)
+}
+
+// downloadedVersion returns the version the newest download of the source with
+// key to path was recorded as, or "" when the history has none
+func downloadedVersion(key, path string) string {
+	entries, err := ReadHistory(0)
+	if err != nil {
+		return ""
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if e := entries[i]; e.Key == key && filepath.Clean(e.Path) == filepath.Clean(path) {
+			return e.Version
+		}
+	}
+	return ""
+}
+
+func appendHistory(path string, e HistoryEntry) error {
+	if path == "" {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func readHistory(path string, limit int) ([]HistoryEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e HistoryEntry
+		// A line cut short by a crash is skipped rather than failing the whole history
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+func getHistoryPath() string {
+	lampDir, err := config.GetConfigDir()
+	if err != nil {
+		return ""
+	}
+	os.MkdirAll(lampDir, 0755)
+	return filepath.Join(lampDir, "history.jsonl")
+}
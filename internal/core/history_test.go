@@ -0,0 +1,67 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestDownloadHistory(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if entries, err := ReadHistory(0); err != nil || len(entries) != 0 {
+		t.Fatalf("Expected an empty history before the first download, got %v, %v", entries, err)
+	}
+
+	// Concurrent downloads finishing together must each get a whole line
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := RecordDownload(HistoryEntry{
+				Category: "Apps",
+				Name:     fmt.Sprintf("Tool %d", i),
+				Version:  "1.0",
+				URL:      "https://example.com/tool.zip",
+				Path:     "/srv/apps/tool.zip",
+				Size:     1024,
+				Checksum: HistoryVerified,
+			})
+			if err != nil {
+				t.Errorf("RecordDownload failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// A line cut short by a crash is skipped
+	f, err := os.OpenFile(getHistoryPath(), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":"2024-01-01T00:00:00Z","name":"Cut`)
+	f.Close()
+
+	entries, err := ReadHistory(0)
+	if err != nil {
+		t.Fatalf("ReadHistory failed: %v", err)
+	}
+	if len(entries) != 20 {
+		t.Fatalf("Expected 20 entries, got %d", len(entries))
+	}
+	for _, e := range entries {
+		if e.Time.IsZero() || e.Checksum != HistoryVerified || e.Size != 1024 {
+			t.Errorf("Unexpected entry %+v", e)
+		}
+	}
+
+	if recent, _ := ReadHistory(5); len(recent) != 5 || recent[4] != entries[19] {
+		t.Errorf("Expected the 5 most recent entries, got %+v", recent)
+	}
+	if filepath.Base(getHistoryPath()) != "history.jsonl" {
+		t.Errorf("Unexpected history path %s", getHistoryPath())
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"lamp/internal/config"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// allowHooks lets post_download commands run. Catalogs can define them, so they
+// stay off unless general.allow_hooks is set.
+var allowHooks atomic.Bool
+
+// ErrHooksDisabled is returned for a post_download command while hooks are off
+var ErrHooksDisabled = errors.New("hooks are disabled (set general.allow_hooks to run post_download)")
+
+// SetAllowHooks turns post_download commands on or off
+func SetAllowHooks(enabled bool) {
+	allowHooks.Store(enabled)
+}
+
+// HookResult is the outcome of a post_download command
+type HookResult struct {
+	ExitCode int
+	Output   string // Combined stdout and stderr, trimmed
+}
+
+// hookLogMu serializes writes to the hook log from concurrent downloads
+var hookLogMu sync.Mutex
+
+// RunPostDownload runs the post_download command of src for file, a finished and
+// verified download, in the file's directory with LAMP_FILE, LAMP_NAME, LAMP_VERSION
+// and LAMP_CATEGORY set. Every run is appended to hooks.log in the lamp config directory.
+func RunPostDownload(src config.Source, category, file, version string) (HookResult, error) {
+	if src.PostDownload == "" {
+		return HookResult{}, nil
+	}
+	if !allowHooks.Load() {
+		return HookResult{}, ErrHooksDisabled
+	}
+
+	cmd := shellCommand(src.PostDownload)
+	cmd.Dir = filepath.Dir(file)
+	cmd.Env = append(os.Environ(),
+		"LAMP_FILE="+file,
+		"LAMP_NAME="+src.Name,
+		"LAMP_VERSION="+version,
+		"LAMP_CATEGORY="+category,
+	)
+	out, err := cmd.CombinedOutput()
+	result := HookResult{Output: strings.TrimSpace(string(out))}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+		err = fmt.Errorf("post_download exited with code %d", result.ExitCode)
+	} else if err != nil {
+		result.ExitCode = -1
+		err = fmt.Errorf("post_download failed to start: %w", err)
+	}
+
+	logHookRun(getHookLogPath(), src, file, result)
+	return result, err
+}
+
+func logHookRun(path string, src config.Source, file string, result HookResult) {
+	if path == "" {
+		return
+	}
+	hookLogMu.Lock()
+	defer hookLogMu.Unlock()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "%s [%s] %s\n  file: %s\n  exit: %d\n", time.Now().Format(time.RFC3339), src.Name, src.PostDownload, file, result.ExitCode)
+	if result.Output != "" {
+		fmt.Fprintf(f, "  output:\n    %s\n", strings.ReplaceAll(result.Output, "\n", "\n    "))
+	}
+}
+
+func getHookLogPath() string {
+	lampDir, err := config.GetConfigDir()
+	if err != nil {
+		return ""
+	}
+	os.MkdirAll(lampDir, 0755)
+	return filepath.Join(lampDir, "hooks.log")
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"errors"
+	"lamp/internal/config"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunPostDownload(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	defer SetAllowHooks(false)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tool-1.1.iso")
+	src := config.Source{
+		Name:         "Tool",
+		PostDownload: `echo "$LAMP_CATEGORY|$LAMP_NAME|$LAMP_VERSION|$LAMP_FILE" > done.txt; echo flashed`,
+	}
+
+	SetAllowHooks(false)
+	if _, err := RunPostDownload(src, "ISOs", file, "1.1"); !errors.Is(err, ErrHooksDisabled) {
+		t.Fatalf("Expected hooks to be disabled by default, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "done.txt")); err == nil {
+		t.Fatal("The command ran although hooks are disabled")
+	}
+
+	SetAllowHooks(true)
+	result, err := RunPostDownload(src, "ISOs", file, "1.1")
+	if err != nil {
+		t.Fatalf("RunPostDownload failed: %v", err)
+	}
+	if result.ExitCode != 0 || result.Output != "flashed" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	// The command runs in the download's directory
+	data, err := os.ReadFile(filepath.Join(dir, "done.txt"))
+	if err != nil {
+		t.Fatalf("Command did not run in %s: %v", dir, err)
+	}
+	if got, want := strings.TrimSpace(string(data)), "ISOs|Tool|1.1|"+file; got != want {
+		t.Errorf("Expected env %q, got %q", want, got)
+	}
+
+	src.PostDownload = "echo broken >&2; exit 3"
+	result, err = RunPostDownload(src, "ISOs", file, "1.1")
+	if err == nil || result.ExitCode != 3 || result.Output != "broken" {
+		t.Errorf("Expected exit code 3 with output, got %+v, %v", result, err)
+	}
+
+	log, err := os.ReadFile(filepath.Join(configHome, "lamp", "hooks.log"))
+	if err != nil {
+		t.Fatalf("Expected a hook log: %v", err)
+	}
+	if !strings.Contains(string(log), "exit: 0") || !strings.Contains(string(log), "exit: 3") || !strings.Contains(string(log), "broken") {
+		t.Errorf("Expected both runs in the log, got:\n%s", log)
+	}
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// minCompleteSize is the smallest a disk image, archive or installer can plausibly
+// be; a smaller one is an error page or the start of an interrupted download
+const minCompleteSize = 1024
+
+// bulkyExts are the file types minCompleteSize applies to
+var bulkyExts = map[string]bool{
+	".iso": true, ".img": true, ".zim": true, ".dmg": true, ".pkg": true,
+	".appimage": true, ".msi": true, ".exe": true, ".deb": true, ".rpm": true,
+	".apk": true, ".zip": true, ".7z": true, ".tar": true, ".gz": true,
+	".xz": true, ".bz2": true, ".zst": true, ".epub": true,
+}
+
+// partialSuffixes mark a file another download tool is still writing or left
+// behind when interrupted: browsers' .part/.crdownload/.download and aria2's
+// control file, which sits next to the file being downloaded under its final name
+var partialSuffixes = []string{".part", ".crdownload", ".download", ".aria2"}
+
+// incompleteReason explains why the file at path looks like an interrupted
+// download rather than a complete one, or returns "" when it looks complete or
+// does not exist. expectedSize is the published size of the file, 0 if unknown.
+// LAMP's own downloads only appear under their final name once complete, but
+// files fetched by other tools or an exported script may not.
+func incompleteReason(path string, expectedSize int64) string {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return ""
+	}
+	name := filepath.Base(path)
+	for _, suffix := range partialSuffixes {
+		if _, err := os.Stat(path + suffix); err == nil {
+			return fmt.Sprintf("%s has a %s file next to it, so its download did not finish", name, suffix)
+		}
+	}
+
+	size := info.Size()
+	switch {
+	case expectedSize > 0 && size < expectedSize:
+		return fmt.Sprintf("%s is %d of %d bytes", name, size, expectedSize)
+	case size == 0:
+		return name + " is empty"
+	case size < minCompleteSize && bulkyExts[strings.ToLower(filepath.Ext(name))]:
+		return fmt.Sprintf("%s is only %d bytes", name, size)
+	}
+	return ""
+}
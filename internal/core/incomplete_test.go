@@ -0,0 +1,77 @@
+package core
+
+import (
+	"lamp/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIncompleteReason(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, size int) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int64
+		want     bool
+	}{
+		{"complete image", write("full.iso", 4096), 0, false},
+		{"empty file", write("empty.txt", 0), 0, true},
+		{"tiny image", write("tiny.iso", 200), 0, true},
+		{"small text file", write("notes.txt", 200), 0, false},
+		{"shorter than published", write("short.zim", 4096), 8192, true},
+		{"matches published", write("exact.zim", 4096), 4096, false},
+		{"missing file", filepath.Join(dir, "absent.iso"), 0, false},
+	}
+	for _, tt := range tests {
+		if got := incompleteReason(tt.path, tt.expected); (got != "") != tt.want {
+			t.Errorf("%s: incompleteReason = %q, want incomplete %v", tt.name, got, tt.want)
+		}
+	}
+
+	p := write("resumed.iso", 4096)
+	write("resumed.iso.aria2", 10)
+	if incompleteReason(p, 0) == "" {
+		t.Error("Expected a file with an aria2 control file next to it to be incomplete")
+	}
+}
+
+func TestCheckVersionIncomplete(t *testing.T) {
+	useFixtures(t, map[string]string{
+		"api.github.com/repos/example/sized/releases/latest": "github_sized_latest.json",
+	})
+	t.Cleanup(func() { githubCache.Delete("example/sized") })
+
+	dir := t.TempDir()
+	src := config.Source{
+		Name:     "Tool",
+		Strategy: "github_release",
+		Params:   map[string]string{"repo": "example/sized", "asset_pattern": `tool-.*\.tar\.gz`},
+	}
+	local := filepath.Join(dir, "tool-3.0.0.tar.gz")
+
+	os.WriteFile(local, make([]byte, 4096), 0644)
+	result := NewChecker(nil, "").CheckVersion(src, filepath.Join(dir, "tool"))
+	if result.Status != StatusIncomplete || result.Size != 8192 || result.ResolvedURL == "" {
+		t.Errorf("Expected a truncated release asset to be incomplete, got %+v", result)
+	}
+
+	os.WriteFile(local, make([]byte, 8192), 0644)
+	os.WriteFile(local+".part", nil, 0644)
+	if result := NewChecker(nil, "").CheckVersion(src, filepath.Join(dir, "tool")); result.Status != StatusIncomplete {
+		t.Errorf("Expected a leftover .part file to mark the download incomplete, got %+v", result)
+	}
+
+	os.Remove(local + ".part")
+	if result := NewChecker(nil, "").CheckVersion(src, filepath.Join(dir, "tool")); result.Status != StatusUpToDate {
+		t.Errorf("Expected the complete asset to be up to date, got %+v", result)
+	}
+}
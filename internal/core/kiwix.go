@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"lamp/internal/config"
 	"net/http"
 	"net/url"
 	"os"
@@ -15,7 +16,6 @@ import (
 const (
 	kiwixBaseURL     = "https://library.kiwix.org/catalog/v2/entries"
 	kiwixDefaultLang = "eng"
-	kiwixCacheTTL    = 24 * time.Hour
 )
 
 var (
@@ -84,6 +84,39 @@ func (e KiwixEntry) GetDownloadURL() string {
 	return ""
 }
 
+// GetMetalinkURL returns the entry's .meta4 link, which lists mirrors and checksums
+func (e KiwixEntry) GetMetalinkURL() string {
+	for _, link := range e.Links {
+		if link.Rel == "http://opds-spec.org/acquisition/open-access" && strings.HasSuffix(link.Href, ".meta4") {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// metalink is the part of a Metalink 4 (RFC 5854) file LAMP reads
+type metalink struct {
+	Files []struct {
+		Hashes []struct {
+			Type  string `xml:"type,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"hash"`
+	} `xml:"file"`
+}
+
+// sha256 returns the sha-256 hash of the first file, or "" if it has none
+func (m metalink) sha256() string {
+	if len(m.Files) == 0 {
+		return ""
+	}
+	for _, h := range m.Files[0].Hashes {
+		if strings.EqualFold(h.Type, "sha-256") {
+			return strings.TrimSpace(h.Value)
+		}
+	}
+	return ""
+}
+
 // GetFileSize returns the file size in bytes
 func (e KiwixEntry) GetFileSize() int64 {
 	for _, link := range e.Links {
@@ -94,27 +127,60 @@ func (e KiwixEntry) GetFileSize() int64 {
 	return 0
 }
 
-// GetIssuedDate parses the issued date
+// kiwixDateLayouts are the formats Kiwix feeds use for issued and updated dates
+var kiwixDateLayouts = []string{time.RFC3339, "2006-01-02"}
+
+// GetIssuedDate parses the issued date, or returns the zero time if there is none
 func (e KiwixEntry) GetIssuedDate() time.Time {
-	// Try parsing from Issued first (dc:issued format)
-	if e.Issued != "" {
-		t, err := time.Parse(time.RFC3339, e.Issued)
-		if err == nil {
-			return t
+	return e.issuedDate("")
+}
+
+// issuedDate parses the issued date, falling back to the updated one. A layout,
+// from a kiwix_feed date_format, is tried before the formats Kiwix uses.
+func (e KiwixEntry) issuedDate(layout string) time.Time {
+	layouts := kiwixDateLayouts
+	if layout != "" {
+		layouts = append([]string{layout}, layouts...)
+	}
+	for _, value := range []string{e.Issued, e.Updated} {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
 		}
-	}
-	// Fallback to Updated
-	if e.Updated != "" {
-		t, err := time.Parse(time.RFC3339, e.Updated)
-		if err == nil {
-			return t
+		for _, l := range layouts {
+			if t, err := time.Parse(l, value); err == nil {
+				return t
+			}
 		}
 	}
 	return time.Time{}
 }
 
+// matchesLanguage reports whether one of the entry's languages (ISO 639-3,
+// comma-separated for multilingual ZIMs) is lang
+func (e KiwixEntry) matchesLanguage(lang string) bool {
+	for _, l := range strings.Split(e.Language, ",") {
+		if strings.EqualFold(strings.TrimSpace(l), lang) {
+			return true
+		}
+	}
+	return false
+}
+
 // FetchKiwixEntries fetches entries from the Kiwix library
 func FetchKiwixEntries(language string, category string, limit int) ([]KiwixEntry, error) {
+	return fetchKiwixEntries(language, category, limit, true)
+}
+
+// RefreshKiwixEntries is FetchKiwixEntries without reading the cache; the result is still cached
+func RefreshKiwixEntries(language string, category string, limit int) ([]KiwixEntry, error) {
+	if IsOffline() {
+		return nil, fmt.Errorf("%w: cannot refresh the Kiwix catalog", ErrOffline)
+	}
+	return fetchKiwixEntries(language, category, limit, false)
+}
+
+func fetchKiwixEntries(language string, category string, limit int, useCache bool) ([]KiwixEntry, error) {
 	if language == "" {
 		language = kiwixDefaultLang
 	}
@@ -123,8 +189,13 @@ func FetchKiwixEntries(language string, category string, limit int) ([]KiwixEntr
 	}
 
 	// Try loading from cache first
-	if cachedEntries, ok := loadKiwixCache(language, category, limit); ok {
-		return cachedEntries, nil
+	if useCache {
+		if cachedEntries, ok := loadKiwixCache(language, category, limit); ok {
+			return cachedEntries, nil
+		}
+		if IsOffline() {
+			return nil, fmt.Errorf("%w: no cached Kiwix catalog for this language and category", ErrOffline)
+		}
 	}
 
 	// Build URL with query parameters
@@ -140,10 +211,12 @@ func FetchKiwixEntries(language string, category string, limit int) ([]KiwixEntr
 	apiURL := fmt.Sprintf("%s?%s", kiwixBaseURL, params.Encode())
 
 	// Rate limit API calls
-	kiwixRateLimiter.Wait()
+	if err := kiwixRateLimiter.WaitContext(apiCtx); err != nil {
+		return nil, err
+	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	req, err := http.NewRequest("GET", apiURL, nil)
+	client := newHTTPClient()
+	req, err := http.NewRequestWithContext(apiCtx, "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -170,12 +243,21 @@ func FetchKiwixEntries(language string, category string, limit int) ([]KiwixEntr
 	return feed.Entries, nil
 }
 
-// SearchKiwixEntries searches for entries matching a query
+// SearchKiwixEntries searches for entries matching a query. In offline mode only
+// the cached catalog is searched.
 func SearchKiwixEntries(query string, language string, limit int) ([]KiwixEntry, error) {
 	if limit <= 0 {
 		limit = 100
 	}
 
+	if IsOffline() {
+		entries, ok := loadCachedKiwixEntries()
+		if !ok {
+			return nil, fmt.Errorf("%w: no cached Kiwix catalog to search", ErrOffline)
+		}
+		return filterKiwixEntries(entries, query, limit), nil
+	}
+
 	params := url.Values{}
 	params.Set("count", fmt.Sprintf("%d", limit))
 	params.Set("q", query)
@@ -186,10 +268,12 @@ func SearchKiwixEntries(query string, language string, limit int) ([]KiwixEntry,
 	apiURL := fmt.Sprintf("%s?%s", kiwixBaseURL, params.Encode())
 
 	// Rate limit API calls
-	kiwixRateLimiter.Wait()
+	if err := kiwixRateLimiter.WaitContext(apiCtx); err != nil {
+		return nil, err
+	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	req, err := http.NewRequest("GET", apiURL, nil)
+	client := newHTTPClient()
+	req, err := http.NewRequestWithContext(apiCtx, "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -233,11 +317,10 @@ func GetKiwixCategories() []string {
 }
 
 func getKiwixCachePath() string {
-	configDir, err := os.UserConfigDir()
+	lampDir, err := config.GetConfigDir()
 	if err != nil {
 		return ""
 	}
-	lampDir := filepath.Join(configDir, "lamp")
 	os.MkdirAll(lampDir, 0755)
 	return filepath.Join(lampDir, "kiwix_cache.json")
 }
@@ -258,13 +341,18 @@ func loadKiwixCache(language string, category string, limit int) ([]KiwixEntry,
 		return nil, false
 	}
 
-	// Check if cache is expired
-	if time.Since(cache.Timestamp) > kiwixCacheTTL {
+	// Check if cache matches the requested filters
+	if cache.Language != language || cache.Category != category {
 		return nil, false
 	}
 
-	// Check if cache matches the requested filters
-	if cache.Language != language || cache.Category != category {
+	// Offline mode takes whatever was cached, however old or short
+	if IsOffline() {
+		return cache.Entries[:min(limit, len(cache.Entries))], len(cache.Entries) > 0
+	}
+
+	// Check if cache is expired
+	if time.Since(cache.Timestamp) > cacheTTL() {
 		return nil, false
 	}
 
@@ -276,6 +364,37 @@ func loadKiwixCache(language string, category string, limit int) ([]KiwixEntry,
 	return cache.Entries[:limit], true
 }
 
+// loadCachedKiwixEntries returns the cached entries whatever their filters or age
+func loadCachedKiwixEntries() ([]KiwixEntry, bool) {
+	data, err := os.ReadFile(getKiwixCachePath())
+	if err != nil {
+		return nil, false
+	}
+	var cache kiwixCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, false
+	}
+	return cache.Entries, len(cache.Entries) > 0
+}
+
+// filterKiwixEntries returns up to limit entries whose title, name or summary
+// contains query, ignoring case
+func filterKiwixEntries(entries []KiwixEntry, query string, limit int) []KiwixEntry {
+	query = strings.ToLower(query)
+	var matches []KiwixEntry
+	for _, e := range entries {
+		if len(matches) == limit {
+			break
+		}
+		if strings.Contains(strings.ToLower(e.Title), query) ||
+			strings.Contains(strings.ToLower(e.Name), query) ||
+			strings.Contains(strings.ToLower(e.Summary), query) {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
+
 func saveKiwixCache(entries []KiwixEntry, language string, category string) {
 	path := getKiwixCachePath()
 	if path == "" {
@@ -297,6 +416,30 @@ func saveKiwixCache(entries []KiwixEntry, language string, category string) {
 	os.WriteFile(path, data, 0600)
 }
 
+// KiwixFeedSource returns a kiwix_feed source that keeps following the series of
+// entry: its name, flavour and, for a single-language ZIM, its language
+func KiwixFeedSource(entry KiwixEntry) config.Source {
+	id := "kiwix-" + entry.Name
+	name := entry.Title
+	if name == "" {
+		name = entry.Name
+	}
+	params := map[string]string{
+		"feed_url": kiwixBaseURL,
+		"series":   entry.Name,
+	}
+	if entry.Flavour != "" {
+		id += "-" + entry.Flavour
+		name += " (" + entry.Flavour + ")"
+		params["flavour"] = entry.Flavour
+	}
+	// The language param names one language, so multilingual ZIMs go without
+	if lang := strings.TrimSpace(entry.Language); lang != "" && !strings.Contains(lang, ",") {
+		params["language"] = lang
+	}
+	return config.Source{ID: id, Name: name, Strategy: "kiwix_feed", Params: params}
+}
+
 // GetExpectedKiwixPath generates the local file path for a Kiwix ZIM file
 func GetExpectedKiwixPath(entry KiwixEntry, basePath string) string {
 	if basePath == "" {
@@ -0,0 +1,39 @@
+package core
+
+import "testing"
+
+func TestSearchKiwixEntries(t *testing.T) {
+	fixtures := useFixtures(t, map[string]string{
+		"library.kiwix.org/catalog/v2/entries?count=5&lang=eng&q=wikipedia+100": "kiwix_wikipedia_en_100.xml",
+	})
+
+	entries, err := SearchKiwixEntries("wikipedia 100", "eng", 5)
+	if err != nil {
+		t.Fatalf("SearchKiwixEntries failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Name != "wikipedia_en_100" {
+		t.Errorf("Expected the two recorded wikipedia_en_100 entries, got %+v", entries)
+	}
+	if got := fixtures.Requests(); len(got) != 1 {
+		t.Errorf("Expected one catalog request, got %v", got)
+	}
+}
+
+func TestKiwixFeedSource(t *testing.T) {
+	src := KiwixFeedSource(KiwixEntry{Name: "wikipedia_en_100", Title: "Wikipedia 100", Flavour: "mini", Language: "eng"})
+	if src.ID != "kiwix-wikipedia_en_100-mini" || src.Name != "Wikipedia 100 (mini)" || src.Strategy != "kiwix_feed" {
+		t.Errorf("Unexpected source %+v", src)
+	}
+	if src.Params["series"] != "wikipedia_en_100" || src.Params["flavour"] != "mini" || src.Params["language"] != "eng" || src.Params["feed_url"] != kiwixBaseURL {
+		t.Errorf("Expected the series, flavour and language of the entry, got %v", src.Params)
+	}
+	if err := ValidateSource(src); err != nil {
+		t.Errorf("Expected a valid source, got %v", err)
+	}
+
+	// A multilingual ZIM can't be matched by one language
+	src = KiwixFeedSource(KiwixEntry{Name: "wikivoyage_mul_all", Language: "eng,fra"})
+	if _, ok := src.Params["language"]; ok || src.ID != "kiwix-wikivoyage_mul_all" || src.Name != "wikivoyage_mul_all" {
+		t.Errorf("Expected no language and the name as title, got %+v", src)
+	}
+}
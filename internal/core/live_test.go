@@ -0,0 +1,77 @@
+//go:build live
+
+// Tests against the real upstream services. They depend on the network and on
+// whatever those services currently publish, so they only run with -tags=live.
+
+package core
+
+import (
+	"lamp/internal/config"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLiveKiwixFeed(t *testing.T) {
+	src := config.Source{
+		Name:     "Wikipedia 100",
+		Strategy: "kiwix_feed",
+		Params: map[string]string{
+			"series":   "wikipedia_en_100",
+			"feed_url": "https://library.kiwix.org/catalog/v2/entries",
+			"flavour":  "mini",
+		},
+	}
+	result := NewChecker(nil, "").CheckVersion(src, filepath.Join(t.TempDir(), "wikipedia.zim"))
+	if result.Status != StatusNotFound || !strings.HasSuffix(result.ResolvedURL, ".zim") {
+		t.Errorf("Expected a downloadable ZIM, got %+v", result)
+	}
+}
+
+func TestLiveKiwixSearch(t *testing.T) {
+	entries, err := SearchKiwixEntries("wikipedia", "eng", 5)
+	if err != nil {
+		t.Fatalf("SearchKiwixEntries failed: %v", err)
+	}
+	if len(entries) == 0 {
+		t.Error("Expected at least one entry")
+	}
+}
+
+func TestLiveUbuntuReleases(t *testing.T) {
+	src := config.Source{
+		Name:     "Ubuntu MATE",
+		Strategy: "web_scrape",
+		Params: map[string]string{
+			"base_url":        "https://cdimage.ubuntu.com/ubuntu-mate/releases/",
+			"version_pattern": `(\d+\.\d+)/`,
+			"file_template":   "{{version}}/release/ubuntu-mate-{{version}}-desktop-amd64.iso",
+		},
+	}
+	result := NewChecker(nil, "").CheckVersion(src, filepath.Join(t.TempDir(), "ubuntu-mate.iso"))
+	if result.Status != StatusNotFound || result.Latest == "" {
+		t.Errorf("Expected the latest Ubuntu MATE release, got %+v", result)
+	}
+}
+
+func TestLiveFedoraCoreOS(t *testing.T) {
+	src := config.Source{
+		Name:     "Fedora CoreOS",
+		Strategy: "fedora_coreos",
+		Params:   map[string]string{"stream": "stable", "arch": "x86_64"},
+	}
+	result := NewChecker(nil, "").CheckVersion(src, filepath.Join(t.TempDir(), "fcos.iso"))
+	if result.Status != StatusNotFound || result.Latest == "" || result.Checksum == "" {
+		t.Errorf("Expected the latest stable release with a checksum, got %+v", result)
+	}
+}
+
+func TestLiveSelfUpdate(t *testing.T) {
+	update, err := NewChecker(nil, "").CheckSelfUpdate("v0.0.1")
+	if err != nil {
+		t.Fatalf("CheckSelfUpdate failed: %v", err)
+	}
+	if update.Latest == "" {
+		t.Errorf("Expected a latest release, got %+v", update)
+	}
+}
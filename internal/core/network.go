@@ -0,0 +1,109 @@
+package core
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+var (
+	// ipNetwork is "tcp4" or "tcp6" to restrict connections to one IP version, or "tcp"
+	ipNetwork atomic.Value
+	// dnsServer is the host:port of the DNS server used instead of the system resolver, or ""
+	dnsServer atomic.Value
+
+	installDialer sync.Once
+
+	// transport carries the requests of every client made by newHTTPClient; nil
+	// means http.DefaultTransport
+	transport http.RoundTripper
+)
+
+// SetTransport sends the requests of the checker, the catalog searches and the
+// notifiers through rt instead of the network, or restores the default transport
+// when rt is nil. Tests use it to replay recorded responses. It must be called
+// before any requests are made.
+func SetTransport(rt http.RoundTripper) {
+	transport = rt
+}
+
+// newHTTPClient returns the client resolvers use when none is injected. Its
+// requests carry the credentials from SetCredentials and the headers from
+// SetHeaders.
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second, CheckRedirect: checkRedirectAddress, Transport: authTransport{}}
+}
+
+// SetNetwork restricts outgoing connections to IPv4 ("4") or IPv6 ("6"), with
+// "" or "auto" allowing both, and resolves host names through dns (host or
+// host:port) when it is set. It applies to every HTTP client that uses the default
+// transport, which covers the checker, the downloader and the catalog fetches.
+func SetNetwork(ipVersion, dns string) {
+	useDialContext()
+
+	switch ipVersion {
+	case "4":
+		ipNetwork.Store("tcp4")
+	case "6":
+		ipNetwork.Store("tcp6")
+	default:
+		ipNetwork.Store("tcp")
+	}
+	if dns != "" {
+		if _, _, err := net.SplitHostPort(dns); err != nil {
+			dns = net.JoinHostPort(dns, "53")
+		}
+	}
+	dnsServer.Store(dns)
+}
+
+// resolver returns the resolver for the DNS server from SetNetwork, or the system
+// resolver when none is set
+func resolver() *net.Resolver {
+	server, _ := dnsServer.Load().(string)
+	if server == "" {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// useDialContext makes the default transport dial through dialContext
+func useDialContext() {
+	installDialer.Do(func() {
+		if t, ok := http.DefaultTransport.(*http.Transport); ok {
+			t.DialContext = dialContext
+		}
+	})
+}
+
+// dialContext dials like the default transport, honouring SetNetwork and, for
+// hosts other than the localhost testing exceptions, SetBlockPrivateAddresses on
+// the address actually connected to
+func dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if host, _, err := net.SplitHostPort(addr); err == nil && blockPrivateAddresses.Load() && !isLocalhost(host) {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			ip, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return checkIP(net.ParseIP(ip), host)
+		}
+	}
+	dialer.Resolver = resolver()
+	// Only plain "tcp" is narrowed; a caller asking for a specific version keeps it
+	if restricted, _ := ipNetwork.Load().(string); network == "tcp" && restricted != "" {
+		network = restricted
+	}
+	return dialer.DialContext(ctx, network, addr)
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestSetNetwork(t *testing.T) {
+	defer SetNetwork("", "")
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("IPv4 loopback unavailable: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	addr := ln.Addr().String()
+
+	for _, version := range []string{"", "auto", "4"} {
+		SetNetwork(version, "")
+		conn, err := dialContext(context.Background(), "tcp", addr)
+		if err != nil {
+			t.Errorf("ip_version %q: expected to reach %s, got %v", version, addr, err)
+			continue
+		}
+		conn.Close()
+	}
+
+	// An IPv4 literal has no IPv6 address to connect to
+	SetNetwork("6", "")
+	if conn, err := dialContext(context.Background(), "tcp", addr); err == nil {
+		conn.Close()
+		t.Errorf("ip_version 6: expected dialing %s to fail", addr)
+	}
+
+	SetNetwork("", "9.9.9.9")
+	if got := dnsServer.Load(); got != "9.9.9.9:53" {
+		t.Errorf("Expected the DNS server to default to port 53, got %v", got)
+	}
+	SetNetwork("", "[2620:fe::fe]:5353")
+	if got := dnsServer.Load(); got != "[2620:fe::fe]:5353" {
+		t.Errorf("Expected the DNS server port to be kept, got %v", got)
+	}
+}
+
+func TestDialBlocksPrivateAddresses(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("IPv4 loopback unavailable: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	SetBlockPrivateAddresses(true)
+	defer SetBlockPrivateAddresses(false)
+
+	// The address connected to is checked, whatever the name resolved to before
+	if conn, err := dialContext(context.Background(), "tcp", net.JoinHostPort("127.0.0.2", port)); err == nil || !strings.Contains(err.Error(), "blocked private address") {
+		if conn != nil {
+			conn.Close()
+		}
+		t.Errorf("Expected the dial to a loopback address to be blocked, got %v", err)
+	}
+	conn, err := dialContext(context.Background(), "tcp", net.JoinHostPort("127.0.0.1", port))
+	if err != nil {
+		t.Fatalf("Expected the localhost testing exception to dial, got %v", err)
+	}
+	conn.Close()
+}
+
+// serveDNS answers every A query on a local UDP port with ip, and AAAA queries
+// with no records, returning the server's address
+func serveDNS(t *testing.T, ip net.IP) string {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("UDP loopback unavailable: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < 12 {
+				continue
+			}
+			// The question is the name's labels, a zero byte, the type and the class
+			end := 12
+			for end < n && buf[end] != 0 {
+				end += int(buf[end]) + 1
+			}
+			if end+5 > n {
+				continue
+			}
+			question := buf[12 : end+5]
+			qtype := binary.BigEndian.Uint16(buf[end+1:])
+			resp := append([]byte{buf[0], buf[1], 0x81, 0x80, 0, 1, 0, 0, 0, 0, 0, 0}, question...)
+			if qtype == 1 {
+				resp[7] = 1
+				resp = append(resp, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4)
+				resp = append(resp, ip.To4()...)
+			}
+			conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestAddressCheckUsesDNSServer(t *testing.T) {
+	SetBlockPrivateAddresses(true)
+	defer SetBlockPrivateAddresses(false)
+	defer SetNetwork("", "")
+
+	// The system resolver knows nothing of the name; the configured server maps it to a private address
+	SetNetwork("", serveDNS(t, net.ParseIP("10.0.0.5")))
+	err := checkResolvedAddress("mirror.example.test")
+	if err == nil || !strings.Contains(err.Error(), "10.0.0.5") {
+		t.Errorf("Expected the address from dns_server to be blocked, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"lamp/internal/config"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Notification describes a source that needs downloading
+type Notification struct {
+	Name     string        `json:"name"`
+	Category string        `json:"category"`
+	Status   VersionStatus `json:"status"`
+	Current  string        `json:"current"`
+	Latest   string        `json:"latest"`
+	URL      string        `json:"url"`
+	Key      string        `json:"-"` // config.Source.Key of the source; announcements are remembered by it
+}
+
+// Notifier runs the configured notify hooks, remembering which versions were
+// already announced so repeated runs stay quiet until something changes
+type Notifier struct {
+	cfg       config.NotifyConfig
+	client    HTTPClient
+	statePath string
+
+	mu       sync.Mutex
+	notified map[string]string // Notification key -> announced Latest version
+}
+
+// NewNotifier loads the announced versions from the lamp config directory
+func NewNotifier(cfg config.NotifyConfig) *Notifier {
+	return newNotifier(cfg, newHTTPClient(), getNotifyStatePath())
+}
+
+func newNotifier(cfg config.NotifyConfig, client HTTPClient, statePath string) *Notifier {
+	n := &Notifier{cfg: cfg, client: client, statePath: statePath, notified: make(map[string]string)}
+	if statePath != "" {
+		if data, err := os.ReadFile(statePath); err == nil {
+			json.Unmarshal(data, &n.notified)
+		}
+	}
+	return n
+}
+
+// Enabled reports whether any hook is configured
+func (n *Notifier) Enabled() bool {
+	return n.cfg.Command != "" || n.cfg.Webhook != ""
+}
+
+// Notify announces a source through every configured hook unless the same
+// version was announced before. Only sources that are missing, incomplete or
+// have a newer version are announced.
+func (n *Notifier) Notify(note Notification) error {
+	if !n.Enabled() || (note.Status != StatusNewer && note.Status != StatusNotFound && note.Status != StatusIncomplete) {
+		return nil
+	}
+
+	key := note.key()
+	n.mu.Lock()
+	seen := n.notified[key] == note.Latest
+	n.mu.Unlock()
+	if seen {
+		return nil
+	}
+
+	if n.cfg.Command != "" {
+		if err := n.runCommand(note); err != nil {
+			return fmt.Errorf("notify_cmd failed: %w", err)
+		}
+	}
+	if n.cfg.Webhook != "" {
+		if err := n.postWebhook(note); err != nil {
+			return fmt.Errorf("notify_webhook failed: %w", err)
+		}
+	}
+
+	n.mu.Lock()
+	n.notified[key] = note.Latest
+	n.mu.Unlock()
+	return nil
+}
+
+// key identifies the announced source, falling back to its category and name
+// when the caller did not set Key
+func (note Notification) key() string {
+	if note.Key != "" {
+		return note.Key
+	}
+	return note.Category + "/" + note.Name
+}
+
+// Save persists the announced versions
+func (n *Notifier) Save() error {
+	if n.statePath == "" {
+		return nil
+	}
+	n.mu.Lock()
+	data, err := json.MarshalIndent(n.notified, "", "  ")
+	n.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(n.statePath, data, 0644)
+}
+
+func (n *Notifier) runCommand(note Notification) error {
+	cmd := shellCommand(n.cfg.Command)
+	cmd.Env = append(os.Environ(),
+		"LAMP_NAME="+note.Name,
+		"LAMP_CATEGORY="+note.Category,
+		"LAMP_STATUS="+string(note.Status),
+		"LAMP_CURRENT="+note.Current,
+		"LAMP_LATEST="+note.Latest,
+		"LAMP_URL="+note.URL,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if len(out) > 0 {
+			return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+		}
+		return err
+	}
+	return nil
+}
+
+func (n *Notifier) postWebhook(note Notification) error {
+	body, err := json.Marshal(note)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", n.cfg.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "lamp/1.0")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func getNotifyStatePath() string {
+	lampDir, err := config.GetConfigDir()
+	if err != nil {
+		return ""
+	}
+	os.MkdirAll(lampDir, 0755)
+	return filepath.Join(lampDir, "notified.json")
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"encoding/json"
+	"io"
+	"lamp/internal/config"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNotifierWebhookDeduplicates(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "notified.json")
+
+	var posts []Notification
+	client := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if req.Method != "POST" || req.URL.String() != "https://hooks.example.com/lamp" {
+				t.Errorf("Unexpected request %s %s", req.Method, req.URL)
+			}
+			var n Notification
+			if err := json.NewDecoder(req.Body).Decode(&n); err != nil {
+				t.Errorf("Invalid webhook body: %v", err)
+			}
+			posts = append(posts, n)
+			return &http.Response{StatusCode: http.StatusNoContent, Body: io.NopCloser(strings.NewReader(""))}, nil
+		},
+	}
+	cfg := config.NotifyConfig{Webhook: "https://hooks.example.com/lamp"}
+	note := Notification{Name: "Tool", Category: "Apps", Status: StatusNewer, Current: "1.0", Latest: "1.1", URL: "https://example.com/tool-1.1.zip", Key: "tool-key"}
+
+	n := newNotifier(cfg, client, statePath)
+	if err := n.Notify(note); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if err := n.Notify(Notification{Name: "Other", Category: "Apps", Status: StatusUpToDate, Latest: "2.0"}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if err := n.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// A later run must not announce the same version again, even after the source
+	// was renamed, but does announce a newer one
+	n = newNotifier(cfg, client, statePath)
+	note.Name = "Tool [renamed]"
+	n.Notify(note)
+	note.Latest = "1.2"
+	n.Notify(note)
+
+	if len(posts) != 2 {
+		t.Fatalf("Expected 2 webhook posts, got %d: %+v", len(posts), posts)
+	}
+	if posts[0].Latest != "1.1" || posts[1].Latest != "1.2" || posts[0].URL != "https://example.com/tool-1.1.zip" {
+		t.Errorf("Unexpected posts: %+v", posts)
+	}
+}
+
+func TestNotifierCommandEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	out := filepath.Join(t.TempDir(), "out.txt")
+	cfg := config.NotifyConfig{Command: `echo "$LAMP_CATEGORY|$LAMP_NAME|$LAMP_CURRENT|$LAMP_LATEST|$LAMP_URL" > ` + out}
+
+	n := newNotifier(cfg, nil, "")
+	err := n.Notify(Notification{Name: "Tool", Category: "Apps", Status: StatusNotFound, Latest: "1.1", URL: "https://example.com/t.zip"})
+	if err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Command did not run: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "Apps|Tool||1.1|https://example.com/t.zip" {
+		t.Errorf("Unexpected command output %q", got)
+	}
+}
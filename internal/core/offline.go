@@ -0,0 +1,107 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"lamp/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// offline makes version checks and catalog fetches serve cached results only
+var offline atomic.Bool
+
+// ErrOffline is returned when offline mode needs something that was never cached
+// or that requires the network, such as a download
+var ErrOffline = errors.New("offline mode")
+
+// SetOffline turns offline mode on or off
+func SetOffline(enabled bool) {
+	offline.Store(enabled)
+}
+
+// IsOffline reports whether offline mode is on
+func IsOffline() bool {
+	return offline.Load()
+}
+
+// statusCache holds the last successful check of every source. It is nil until
+// OpenStatusCache is called, in which case nothing is recorded.
+var statusCache atomic.Pointer[StatusCache]
+
+// cachedStatus is a check result as stored in the status cache
+type cachedStatus struct {
+	Result    CheckResult `json:"result"`
+	CheckedAt time.Time   `json:"checked_at"`
+}
+
+// StatusCache persists check results so offline mode can serve them later
+type StatusCache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]cachedStatus
+}
+
+// OpenStatusCache loads the status cache from the lamp config directory.
+// From then on CheckVersion records its results there.
+func OpenStatusCache() {
+	statusCache.Store(loadStatusCache(getStatusCachePath()))
+}
+
+func loadStatusCache(path string) *StatusCache {
+	sc := &StatusCache{path: path, entries: make(map[string]cachedStatus)}
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(data, &sc.entries)
+		}
+	}
+	return sc
+}
+
+func (sc *StatusCache) get(key string) (cachedStatus, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	entry, ok := sc.entries[key]
+	return entry, ok
+}
+
+// reset forgets every recorded result without touching the cache file
+func (sc *StatusCache) reset() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.entries = make(map[string]cachedStatus)
+}
+
+// put records a result and writes the cache file
+func (sc *StatusCache) put(key string, result CheckResult) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.entries[key] = cachedStatus{Result: result, CheckedAt: time.Now()}
+	if sc.path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(sc.entries, "", "  ")
+	if err != nil {
+		return
+	}
+	os.WriteFile(sc.path, data, 0600)
+}
+
+// statusCacheKey identifies an expanded source, and its pin, checked against a local path
+func statusCacheKey(src config.Source, localPath string) string {
+	return strings.Join([]string{src.Key(), src.PinVersion, localPath}, "|")
+}
+
+func getStatusCachePath() string {
+	lampDir, err := config.GetConfigDir()
+	if err != nil {
+		return ""
+	}
+	os.MkdirAll(lampDir, 0755)
+	return filepath.Join(lampDir, "status_cache.json")
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"lamp/internal/config"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCheckVersionOfflineServesCache(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "status_cache.json")
+	statusCache.Store(loadStatusCache(cachePath))
+	t.Cleanup(func() {
+		statusCache.Store(nil)
+		SetOffline(false)
+	})
+
+	var requests atomic.Int32
+	client := &MockHTTPClient{
+		GetFunc: func(url string) (*http.Response, error) {
+			requests.Add(1)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(bytes.NewBufferString(`{"architectures": {"x86_64": {"artifacts": {"metal": {
+					"release": "40.0.0",
+					"formats": {"iso": {"disk": {"location": "https://example.com/fedora-coreos-40.iso"}}}}}}}}`)),
+			}, nil
+		},
+	}
+	src := config.Source{
+		Name:     "Fedora Test",
+		Strategy: "fedora_coreos",
+		Params:   map[string]string{"stream": "stable", "arch": "x86_64"},
+	}
+	localPath := filepath.Join(t.TempDir(), "fedora-coreos.iso")
+
+	online := NewChecker(client, "").CheckVersion(src, localPath)
+	if online.Cached || online.Latest == "" {
+		t.Fatalf("Expected a fresh online result, got %+v", online)
+	}
+
+	// A new process would load the cache from disk
+	statusCache.Store(loadStatusCache(cachePath))
+	SetOffline(true)
+
+	before := requests.Load()
+	cached := NewChecker(client, "").CheckVersion(src, localPath)
+	if requests.Load() != before {
+		t.Error("Expected no requests in offline mode")
+	}
+	if !cached.Cached || cached.Status != online.Status || cached.Latest != online.Latest || cached.ResolvedURL != online.ResolvedURL {
+		t.Errorf("Expected cached copy of %+v, got %+v", online, cached)
+	}
+
+	other := NewChecker(client, "").CheckVersion(config.Source{Name: "Never Checked", Strategy: "fedora_coreos"}, localPath)
+	if other.Status != StatusError {
+		t.Errorf("Expected an error for an uncached source, got %+v", other)
+	}
+}
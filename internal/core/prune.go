@@ -0,0 +1,129 @@
+package core
+
+import (
+	"lamp/internal/config"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// fileVersionRe finds a dotted version in a filename whose pattern captured none
+	fileVersionRe = regexp.MustCompile(`[_\-]v?(\d+\.\d+(?:\.\d+)*)`)
+	// fileDateRe finds the YYYY-MM date Kiwix and similar sources version files by
+	fileDateRe = regexp.MustCompile(`[_\-](\d{4}-\d{2})(?:[_\-.]|$)`)
+	// dateVersionRe matches a captured version that is a YYYY-MM date
+	dateVersionRe = regexp.MustCompile(`^\d{4}-\d{2}$`)
+)
+
+// PruneVersions deletes older versions of src next to kept, the file that was just
+// downloaded, so that no more than keep versions remain, kept's included. Files are
+// recognised by the same patterns as ScanLocalStatus and ordered by CompareVersions
+// on the release part of the version in their names; files sharing a release, like
+// a signature next to its download, count once and go together. Only files older than kept are ever
+// removed. A keep below 1, a source without filename patterns, a kept file without
+// a readable version, and files whose version cannot be read are left alone; only
+// regular files directly in kept's directory are removed. It returns the paths deleted.
+func PruneVersions(src config.Source, kept string, keep int) ([]string, error) {
+	if keep < 1 {
+		return nil, nil
+	}
+	var patterns []*regexp.Regexp
+	for _, pat := range localFilePatterns(src) {
+		if re, err := compileLocalPattern(pat); err == nil {
+			patterns = append(patterns, re)
+		}
+	}
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	dir := filepath.Dir(kept)
+	keptName := filepath.Base(kept)
+	keptVersion := pruneRelease(matchFileVersion(patterns, keptName))
+	if keptVersion == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files by version, other than kept's
+	byVersion := make(map[string][]string)
+	var versions []string
+	for _, entry := range entries {
+		// ReadDir does not follow symlinks, so a link to a file elsewhere is never a candidate
+		if !entry.Type().IsRegular() || entry.Name() == keptName {
+			continue
+		}
+		v := pruneRelease(matchFileVersion(patterns, entry.Name()))
+		if v == "" || CompareVersions(v, keptVersion) == 0 {
+			continue
+		}
+		if _, ok := byVersion[v]; !ok {
+			versions = append(versions, v)
+		}
+		byVersion[v] = append(byVersion[v], entry.Name())
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return CompareVersions(versions[i], versions[j]) > 0
+	})
+
+	// The new download is always one of the versions kept
+	if len(versions) < keep {
+		return nil, nil
+	}
+	var removed []string
+	for _, v := range versions[keep-1:] {
+		if CompareVersions(v, keptVersion) > 0 {
+			continue
+		}
+		for _, name := range byVersion[v] {
+			path := filepath.Join(dir, name)
+			if err := os.Remove(path); err != nil {
+				return removed, err
+			}
+			removed = append(removed, path)
+		}
+	}
+	return removed, nil
+}
+
+// pruneRelease drops the suffix of a version, which for a loose pattern like
+// "mGBA-.*" is the rest of the filename and differs between a download and its
+// companions
+func pruneRelease(version string) string {
+	release, _ := splitVersion(version)
+	return release
+}
+
+// matchFileVersion returns the version in name if it matches one of patterns, or ""
+func matchFileVersion(patterns []*regexp.Regexp, name string) string {
+	for _, re := range patterns {
+		m := re.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+		if len(m) > 1 {
+			v := strings.Trim(strings.TrimPrefix(m[1], "v"), "-_ .")
+			if v != "" && strings.ContainsAny(v[:1], "0123456789") {
+				if dateVersionRe.MatchString(v) {
+					// As a pre-release, "-05" would sort below the bare year
+					v = strings.ReplaceAll(v, "-", ".")
+				}
+				return v
+			}
+		}
+		if m := fileVersionRe.FindStringSubmatch(name); m != nil {
+			return m[1]
+		}
+		if m := fileDateRe.FindStringSubmatch(name); m != nil {
+			return strings.ReplaceAll(m[1], "-", ".")
+		}
+		return ""
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"lamp/internal/config"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPruneVersions(t *testing.T) {
+	src := config.Source{
+		Name:     "Tool",
+		Strategy: "github_release",
+		Params:   map[string]string{"repo": "example/tool", "asset_pattern": `tool-.*\.tar\.gz`},
+	}
+	setup := func() string {
+		dir := t.TempDir()
+		for _, name := range []string{"tool-1.2.0.tar.gz", "tool-1.10.0.tar.gz", "tool-1.9.0.tar.gz", "tool-2.0.0.tar.gz", "tool-1.9.0.tar.gz.asc", "notes.txt"} {
+			os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644)
+		}
+		return dir
+	}
+	remaining := func(dir string) string {
+		entries, _ := os.ReadDir(dir)
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		sort.Strings(names)
+		return strings.Join(names, " ")
+	}
+
+	dir := setup()
+	removed, err := PruneVersions(src, filepath.Join(dir, "tool-2.0.0.tar.gz"), 2)
+	if err != nil || len(removed) != 2 {
+		t.Fatalf("Expected two files removed, got %v (%v)", removed, err)
+	}
+	// 1.10.0 sorts above 1.9.0 numerically; companions and unrelated files stay
+	if got, want := remaining(dir), "notes.txt tool-1.10.0.tar.gz tool-1.9.0.tar.gz.asc tool-2.0.0.tar.gz"; got != want {
+		t.Errorf("Expected %q left, got %q", want, got)
+	}
+
+	dir = setup()
+	if _, err := PruneVersions(src, filepath.Join(dir, "tool-2.0.0.tar.gz"), 1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := remaining(dir), "notes.txt tool-1.9.0.tar.gz.asc tool-2.0.0.tar.gz"; got != want {
+		t.Errorf("Expected only the latest kept, got %q", got)
+	}
+
+	dir = setup()
+	if removed, _ := PruneVersions(src, filepath.Join(dir, "tool-2.0.0.tar.gz"), 0); len(removed) != 0 {
+		t.Errorf("Expected keep_versions 0 to keep everything, removed %v", removed)
+	}
+
+	// Links are never followed or removed, and sources without patterns are left alone
+	dir = setup()
+	outside := filepath.Join(t.TempDir(), "tool-0.1.0.tar.gz")
+	os.WriteFile(outside, []byte("x"), 0644)
+	if err := os.Symlink(outside, filepath.Join(dir, "tool-0.1.0.tar.gz")); err == nil {
+		PruneVersions(src, filepath.Join(dir, "tool-2.0.0.tar.gz"), 1)
+		if _, err := os.Lstat(filepath.Join(dir, "tool-0.1.0.tar.gz")); err != nil {
+			t.Error("Expected a symlink to be left in place")
+		}
+		if _, err := os.Stat(outside); err != nil {
+			t.Error("Expected the link target to survive")
+		}
+	}
+	dir = setup()
+	if removed, _ := PruneVersions(config.Source{Name: "Tool", Strategy: "http_redirect"}, filepath.Join(dir, "tool-2.0.0.tar.gz"), 1); len(removed) != 0 {
+		t.Errorf("Expected no pruning without filename patterns, removed %v", removed)
+	}
+}
+
+func TestPruneVersionsKeepsCompanions(t *testing.T) {
+	src := config.Source{
+		Name:     "mGBA",
+		Strategy: "github_release",
+		Params:   map[string]string{"repo": "mgba-emu/mgba", "asset_pattern": `mGBA-.*`},
+	}
+	dir := t.TempDir()
+	for _, name := range []string{
+		"mGBA-0.10.3-linux64.AppImage", "mGBA-0.10.3-linux64.AppImage.sig", "mGBA-0.10.3-linux64.AppImage-SHA256SUMS",
+		"mGBA-0.10.2-linux64.AppImage", "mGBA-0.10.2-linux64.AppImage.sig",
+		"mGBA-0.10.1-linux64.AppImage",
+		"mGBA-0.11.0-linux64.AppImage",
+	} {
+		os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644)
+	}
+
+	removed, err := PruneVersions(src, filepath.Join(dir, "mGBA-0.10.3-linux64.AppImage"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two versions are kept, 0.11.0 counting as one, so 0.10.2 and 0.10.1 go
+	// with the signature of 0.10.2; the newer 0.11.0 and kept's companions stay
+	for _, name := range []string{"mGBA-0.10.3-linux64.AppImage.sig", "mGBA-0.10.3-linux64.AppImage-SHA256SUMS", "mGBA-0.11.0-linux64.AppImage"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s kept, got %v", name, err)
+		}
+	}
+	if len(removed) != 3 {
+		t.Errorf("Expected the files of 0.10.2 and 0.10.1 removed, got %v", removed)
+	}
+}
+
+func TestMatchFileVersion(t *testing.T) {
+	re, _ := compileLocalPattern(`wikipedia_en_all_maxi_.*\.zim`)
+	for name, want := range map[string]string{
+		"wikipedia_en_all_maxi_2024-05.zim": "2024.05",
+		"wikipedia_en_all_mini_2024-05.zim": "",
+	} {
+		if got := matchFileVersion([]*regexp.Regexp{re}, name); got != want {
+			t.Errorf("matchFileVersion(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
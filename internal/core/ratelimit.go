@@ -1,10 +1,26 @@
 package core
 
 import (
+	"context"
 	"sync"
 	"time"
 )
 
+// apiCtx is shared by the Gutenberg and Kiwix API calls so that pending
+// rate-limited requests can be abandoned when the application exits
+var apiCtx, cancelAPI = context.WithCancel(context.Background())
+
+// CancelAPIRequests cancels all in-flight and rate-limited API requests
+func CancelAPIRequests() {
+	cancelAPI()
+}
+
+// APIContext returns the context cancelled by CancelAPIRequests, for callers
+// of API functions that take a context
+func APIContext() context.Context {
+	return apiCtx
+}
+
 // RateLimiter implements a simple token bucket rate limiter
 type RateLimiter struct {
 	tokens     int
@@ -12,6 +28,10 @@ type RateLimiter struct {
 	refillRate time.Duration
 	mu         sync.Mutex
 	lastRefill time.Time
+
+	// Clock, replaced by tests to run without sleeping
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
 }
 
 // NewRateLimiter creates a new rate limiter
@@ -23,49 +43,60 @@ func NewRateLimiter(maxTokens int, refillRate time.Duration) *RateLimiter {
 		maxTokens:  maxTokens,
 		refillRate: refillRate,
 		lastRefill: time.Now(),
+		now:        time.Now,
+		after:      time.After,
 	}
 }
 
 // Wait blocks until a token is available
 func (rl *RateLimiter) Wait() {
+	rl.WaitContext(context.Background())
+}
+
+// WaitContext blocks until a token is available or ctx is cancelled,
+// in which case ctx.Err() is returned and no token is consumed
+func (rl *RateLimiter) WaitContext(ctx context.Context) error {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	// Refill tokens based on time elapsed
-	now := time.Now()
-	elapsed := now.Sub(rl.lastRefill)
-	tokensToAdd := int(elapsed / rl.refillRate)
-
-	if tokensToAdd > 0 {
-		rl.tokens += tokensToAdd
-		if rl.tokens > rl.maxTokens {
-			rl.tokens = rl.maxTokens
-		}
-		rl.lastRefill = now
-	}
+	rl.refill(rl.now())
 
-	// Wait if no tokens available
+	// Wait if no tokens available, sleeping only until the next token is due
 	for rl.tokens <= 0 {
+		wait := rl.lastRefill.Add(rl.refillRate).Sub(rl.now())
 		rl.mu.Unlock()
-		time.Sleep(rl.refillRate)
+		select {
+		case <-ctx.Done():
+			rl.mu.Lock()
+			return ctx.Err()
+		case <-rl.after(wait):
+		}
 		rl.mu.Lock()
 
-		// Refill after sleep
-		now = time.Now()
-		elapsed = now.Sub(rl.lastRefill)
-		tokensToAdd = int(elapsed / rl.refillRate)
-
-		if tokensToAdd > 0 {
-			rl.tokens += tokensToAdd
-			if rl.tokens > rl.maxTokens {
-				rl.tokens = rl.maxTokens
-			}
-			rl.lastRefill = now
-		}
+		rl.refill(rl.now())
 	}
 
 	// Consume a token
 	rl.tokens--
+	return nil
+}
+
+// refill adds the tokens earned since lastRefill. lastRefill only advances by
+// whole refill periods so the fractional remainder counts toward the next token.
+// Caller must hold rl.mu.
+func (rl *RateLimiter) refill(now time.Time) {
+	tokensToAdd := int(now.Sub(rl.lastRefill) / rl.refillRate)
+	if tokensToAdd <= 0 {
+		return
+	}
+
+	rl.tokens += tokensToAdd
+	rl.lastRefill = rl.lastRefill.Add(time.Duration(tokensToAdd) * rl.refillRate)
+	if rl.tokens >= rl.maxTokens {
+		// A full bucket doesn't bank time toward further tokens
+		rl.tokens = rl.maxTokens
+		rl.lastRefill = now
+	}
 }
 
 // Update updates the rate limiter settings
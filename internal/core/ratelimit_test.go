@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitContextCancelled(t *testing.T) {
+	// One token, refilled only after an hour: the second waiter would block
+	rl := NewRateLimiter(1, time.Hour)
+	if err := rl.WaitContext(context.Background()); err != nil {
+		t.Fatalf("First wait failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- rl.WaitContext(ctx)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("WaitContext did not return after cancellation")
+	}
+}
+
+func TestWaitThroughputMatchesRate(t *testing.T) {
+	const (
+		refill = 5 * time.Millisecond
+		calls  = 100
+	)
+	// A fake clock where every sleep overshoots by half a refill period, as a
+	// loaded machine's timers do; the overshoot must count toward the next token
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := start
+	// A burst of 2 keeps the bucket from filling, which would drop the overshoot by design
+	rl := NewRateLimiter(2, refill)
+	rl.lastRefill = start
+	rl.now = func() time.Time { return clock }
+	rl.after = func(d time.Duration) <-chan time.Time {
+		clock = clock.Add(d + refill/2)
+		ch := make(chan time.Time, 1)
+		ch <- clock
+		return ch
+	}
+
+	for i := 0; i < calls; i++ {
+		rl.Wait()
+	}
+	elapsed := clock.Sub(start)
+
+	// The first calls use the initial tokens; each later call needs one refill,
+	// and only the last overshoot is lost
+	expected := time.Duration(calls-2) * refill
+	if elapsed < expected || elapsed > expected+refill {
+		t.Errorf("Expected %d calls to take %v to %v, took %v", calls, expected, expected+refill, elapsed)
+	}
+}
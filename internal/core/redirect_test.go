@@ -0,0 +1,62 @@
+package core
+
+import (
+	"lamp/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestResolveRedirect(t *testing.T) {
+	var cdnHits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/download/latest":
+			http.Redirect(w, r, "/releases/app-2.5.1.iso", http.StatusFound)
+		case "/releases/app-2.5.1.iso":
+			// The versioned URL hands off to an unversioned CDN link
+			http.Redirect(w, r, "/cdn/blob", http.StatusFound)
+		case "/cdn/blob":
+			cdnHits.Add(1)
+			w.Write([]byte("iso"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app-2.4.0.iso"), make([]byte, 4096), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := config.Source{Name: "App", Strategy: "redirect", Params: map[string]string{
+		"url":             server.URL + "/download/latest",
+		"version_pattern": `app-(\d+\.\d+\.\d+)\.iso`,
+	}}
+
+	checker := NewChecker(nil, "")
+	result := checker.CheckVersion(src, filepath.Join(dir, "app.iso"))
+	if result.Status != StatusNewer || result.Current != "2.4.0" || result.Latest != "2.5.1" {
+		t.Fatalf("Expected 2.4.0 -> 2.5.1, got %+v", result)
+	}
+	if want := server.URL + "/releases/app-2.5.1.iso"; result.ResolvedURL != want {
+		t.Errorf("Expected the first Location %s as the resolved URL, got %s", want, result.ResolvedURL)
+	}
+	if n := cdnHits.Load(); n != 0 {
+		t.Errorf("Expected the redirect not to be followed, the CDN was hit %d times", n)
+	}
+
+	src.Params["url"] = server.URL + "/cdn/blob"
+	if result := checker.CheckVersion(src, filepath.Join(dir, "app.iso")); result.Status != StatusError || !strings.Contains(result.Message, "did not redirect") {
+		t.Errorf("Expected an error for a URL that does not redirect, got %+v", result)
+	}
+
+	delete(src.Params, "version_pattern")
+	if result := checker.CheckVersion(src, filepath.Join(dir, "app.iso")); result.Status != StatusError {
+		t.Errorf("Expected an error without version_pattern, got %+v", result)
+	}
+}
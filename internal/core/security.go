@@ -1,12 +1,32 @@
 package core
 
 import (
+	"context"
 	"fmt"
+	"lamp/internal/config"
+	"net"
+	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
+	"sync/atomic"
 )
 
+// blockPrivateAddresses enables the strict SSRF check in ValidateDownloadURL
+var blockPrivateAddresses atomic.Bool
+
+func init() {
+	// Remote catalogs are fetched by config, which cannot import core
+	config.SetURLPolicy(ValidateDownloadURL, ValidateRedirect)
+}
+
+// SetBlockPrivateAddresses turns rejection of URLs resolving to private,
+// loopback and link-local addresses on or off
+func SetBlockPrivateAddresses(enabled bool) {
+	useDialContext()
+	blockPrivateAddresses.Store(enabled)
+}
+
 // ValidateRegexPattern checks if a regex pattern is safe to compile and use
 // Returns an error if the pattern appears to be potentially dangerous (ReDoS)
 func ValidateRegexPattern(pattern string) error {
@@ -105,13 +125,13 @@ func ValidateDownloadURL(downloadURL string) error {
 
 	// Check if it's HTTPS
 	if parsedURL.Scheme == "https" {
-		return nil
+		return checkHostAddress(parsedURL.Hostname())
 	}
 
 	// Allow HTTP for localhost/127.0.0.1 (for testing)
 	if parsedURL.Scheme == "http" {
 		host := parsedURL.Hostname()
-		if host == "localhost" || host == "127.0.0.1" || host == "::1" {
+		if isLocalhost(host) {
 			return nil
 		}
 		return fmt.Errorf("insecure download URL: HTTP is not allowed (use HTTPS): %s", downloadURL)
@@ -120,3 +140,79 @@ func ValidateDownloadURL(downloadURL string) error {
 	// Reject other schemes
 	return fmt.Errorf("invalid URL scheme '%s': only HTTPS is allowed", parsedURL.Scheme)
 }
+
+// ValidateRedirect is an http.Client CheckRedirect policy for downloads. Every
+// hop must stay on HTTPS, apart from plain HTTP between localhost test servers,
+// and is held to the private address check without the localhost exception, which
+// only applies to the URL the user gave.
+func ValidateRedirect(req *http.Request, via []*http.Request) error {
+	if req.URL.Scheme != "https" && (via[len(via)-1].URL.Scheme == "https" || !isLocalhost(req.URL.Hostname())) {
+		return fmt.Errorf("redirect to %s: insecure scheme %s", req.URL.Host, req.URL.Scheme)
+	}
+	return checkRedirectAddress(req, via)
+}
+
+// checkRedirectAddress is the CheckRedirect policy of the checker's client: it
+// applies the private address check to every redirect hop
+func checkRedirectAddress(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after 10 redirects")
+	}
+	if err := checkResolvedAddress(req.URL.Hostname()); err != nil {
+		return fmt.Errorf("redirect to %s: %w", req.URL.Host, err)
+	}
+	return nil
+}
+
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// checkHostAddress applies checkResolvedAddress to a host the user gave, with the
+// explicit localhost testing exceptions still allowed
+func checkHostAddress(host string) error {
+	if isLocalhost(host) {
+		return nil
+	}
+	return checkResolvedAddress(host)
+}
+
+// checkResolvedAddress rejects hosts resolving to private (RFC 1918 / fc00::/7),
+// loopback, link-local or unspecified addresses when strict mode is enabled. The
+// check is repeated on the address actually dialed, see dialContext, so a name
+// that resolves differently the second time is still caught.
+func checkResolvedAddress(host string) error {
+	if !blockPrivateAddresses.Load() {
+		return nil
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		// The same resolver the connection will use, so dns_server is honoured
+		addrs, err := resolver().LookupIPAddr(context.Background(), host)
+		if err != nil {
+			return fmt.Errorf("failed to resolve host %s: %w", host, err)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	for _, ip := range ips {
+		if err := checkIP(ip, host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkIP rejects ip, an address of host, if it is private, loopback, link-local or unspecified
+func checkIP(ip net.IP, host string) error {
+	if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("blocked private address %s for host %s", ip, host)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"net/http"
 	"testing"
 )
 
@@ -196,3 +197,71 @@ func TestValidateDownloadURL(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateDownloadURLBlockPrivate(t *testing.T) {
+	SetBlockPrivateAddresses(true)
+	defer SetBlockPrivateAddresses(false)
+
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://169.254.169.254/latest/meta-data/", true},
+		{"https://10.0.0.5/file.iso", true},
+		{"https://192.168.1.1/file.iso", true},
+		{"https://[fd00::1]/file.iso", true},
+		{"https://[fe80::1]/file.iso", true},
+		{"https://[::ffff:172.16.0.1]/file.iso", true},
+		{"https://0.0.0.0/file.iso", true},
+		{"https://93.184.216.34/file.iso", false},
+		{"http://localhost:8080/file.zip", false},
+		{"http://127.0.0.1/file.zip", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateDownloadURL(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateDownloadURL(%s) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+	}
+
+	// Strict mode is opt-in
+	SetBlockPrivateAddresses(false)
+	if err := ValidateDownloadURL("https://10.0.0.5/file.iso"); err != nil {
+		t.Errorf("Expected private address to be allowed when strict mode is off, got %v", err)
+	}
+}
+
+func TestValidateRedirect(t *testing.T) {
+	SetBlockPrivateAddresses(true)
+	defer SetBlockPrivateAddresses(false)
+
+	hop := func(from, to string) error {
+		prev, _ := http.NewRequest("GET", from, nil)
+		req, _ := http.NewRequest("GET", to, nil)
+		return ValidateRedirect(req, []*http.Request{prev})
+	}
+	tests := []struct {
+		from, to string
+		wantErr  bool
+	}{
+		{"https://example.com/a", "https://93.184.216.34/b", false},
+		{"https://example.com/a", "http://93.184.216.34/b", true},
+		{"https://example.com/a", "http://localhost/b", true},
+		// Only the URL the user gave may be localhost
+		{"https://example.com/a", "https://127.0.0.1/b", true},
+		{"http://localhost:8080/a", "http://localhost:8080/b", true},
+		{"https://example.com/a", "https://10.0.0.5/b", true},
+	}
+	for _, tt := range tests {
+		if err := hop(tt.from, tt.to); (err != nil) != tt.wantErr {
+			t.Errorf("redirect %s -> %s: error = %v, wantErr %v", tt.from, tt.to, err, tt.wantErr)
+		}
+	}
+
+	// Plain HTTP between localhost test servers is fine when private addresses are allowed
+	SetBlockPrivateAddresses(false)
+	if err := hop("http://localhost:8080/a", "http://localhost:8080/b"); err != nil {
+		t.Errorf("Expected a localhost redirect allowed outside strict mode, got %v", err)
+	}
+}
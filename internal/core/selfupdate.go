@@ -0,0 +1,74 @@
+package core
+
+import (
+	"fmt"
+	"lamp/internal/config"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// SelfRepo is the GitHub repository LAMP itself is released from
+const SelfRepo = "acdop100/lamp"
+
+// SelfUpdate is the result of checking for a newer LAMP release
+type SelfUpdate struct {
+	Current    string
+	Latest     string
+	Available  bool   // Latest is newer than Current
+	ReleaseURL string // Release page of Latest
+	AssetURL   string // Archive for this OS/arch, empty if the release has none
+}
+
+// CheckSelfUpdate compares current, the running LAMP version, with the latest release.
+// Development builds (no numeric version) are never reported as outdated.
+func (c *Checker) CheckSelfUpdate(current string) (SelfUpdate, error) {
+	if IsOffline() {
+		return SelfUpdate{}, ErrOffline
+	}
+
+	src := config.Source{
+		Name:     "LAMP",
+		Strategy: "github_release",
+		Params:   map[string]string{"repo": SelfRepo, "asset_pattern": selfAssetPattern(runtime.GOOS, runtime.GOARCH)},
+	}
+	// The target only decides the local status, which is not used here
+	result := c.resolveGithubRelease(src, filepath.Join(os.TempDir(), "lamp-self-update", "lamp"))
+	if result.Latest == "" {
+		return SelfUpdate{}, fmt.Errorf("%s", result.Message)
+	}
+
+	update := SelfUpdate{
+		Current:    current,
+		Latest:     result.Latest,
+		ReleaseURL: fmt.Sprintf("https://github.com/%s/releases/tag/%s", SelfRepo, result.Latest),
+		AssetURL:   result.ResolvedURL,
+	}
+	if isReleaseVersion(current) {
+		update.Available = CompareVersions(strings.TrimPrefix(result.Latest, "v"), strings.TrimPrefix(current, "v")) > 0
+	}
+	return update, nil
+}
+
+// selfAssetPattern matches the release archive built for goos/goarch, named as in
+// .goreleaser.yaml (lamp_Linux_x86_64.tar.gz, lamp_Darwin_universal.tar.gz, ...)
+func selfAssetPattern(goos, goarch string) string {
+	osName := strings.ToUpper(goos[:1]) + goos[1:]
+	arch := goarch
+	switch {
+	case goos == "darwin":
+		arch = "universal"
+	case goarch == "amd64":
+		arch = "x86_64"
+	case goarch == "386":
+		arch = "i386"
+	}
+	return fmt.Sprintf(`^lamp_%s_%s\.(tar\.gz|zip)$`, osName, arch)
+}
+
+// isReleaseVersion reports whether v looks like a tagged version rather than "dev"
+func isReleaseVersion(v string) bool {
+	v = strings.TrimPrefix(v, "v")
+	return v != "" && v[0] >= '0' && v[0] <= '9'
+}
@@ -0,0 +1,53 @@
+package core
+
+import "testing"
+
+func TestCheckSelfUpdate(t *testing.T) {
+	useFixtures(t, map[string]string{
+		"api.github.com/repos/" + SelfRepo + "/releases/latest": "github_lamp_latest.json",
+	})
+	t.Cleanup(func() { githubCache.Delete(SelfRepo) })
+
+	tests := []struct {
+		current   string
+		available bool
+	}{
+		{"v1.0.0", true},
+		{"1.1.9", true},
+		{"v1.2.0", false},
+		{"1.3.0", false},
+		{"dev", false},
+	}
+	for _, tt := range tests {
+		update, err := NewChecker(nil, "").CheckSelfUpdate(tt.current)
+		if err != nil {
+			t.Fatalf("CheckSelfUpdate(%q) failed: %v", tt.current, err)
+		}
+		if update.Available != tt.available || update.Latest != "v1.2.0" {
+			t.Errorf("CheckSelfUpdate(%q) = %+v, want available=%v", tt.current, update, tt.available)
+		}
+		if update.ReleaseURL != "https://github.com/acdop100/lamp/releases/tag/v1.2.0" {
+			t.Errorf("Unexpected release URL %q", update.ReleaseURL)
+		}
+	}
+}
+
+func TestSelfAssetPattern(t *testing.T) {
+	tests := []struct {
+		goos, goarch, asset string
+	}{
+		{"linux", "amd64", "lamp_Linux_x86_64.tar.gz"},
+		{"linux", "arm64", "lamp_Linux_arm64.tar.gz"},
+		{"darwin", "arm64", "lamp_Darwin_universal.tar.gz"},
+		{"windows", "amd64", "lamp_Windows_x86_64.zip"},
+	}
+	for _, tt := range tests {
+		re, err := SafeCompileRegex(selfAssetPattern(tt.goos, tt.goarch))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !re.MatchString(tt.asset) {
+			t.Errorf("Pattern for %s/%s does not match %s", tt.goos, tt.goarch, tt.asset)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"fmt"
+	"lamp/internal/config"
+	"regexp"
+	"strings"
+)
+
+// StrategyParam describes one param a strategy reads
+type StrategyParam struct {
+	Name     string
+	Required bool
+	Regex    bool   // Must compile as a regular expression
+	Screened bool   // The resolver compiles it with SafeCompileRegex, so ValidateRegexPattern must accept it
+	List     bool   // Holds several comma-separated values
+	Hint     string // Example value, shown as the placeholder in the source form
+}
+
+// StrategyInfo describes a strategy for ValidateSource and the TUI source form
+type StrategyInfo struct {
+	Name        string
+	Description string
+	Catalog     bool // Browsed in its own tab instead of checked, so not offered in the form
+	Params      []StrategyParam
+}
+
+// Strategies lists every strategy checkVersion dispatches on, with params in the
+// order the source form asks for them
+var Strategies = []StrategyInfo{
+	{Name: "github_release", Description: "Latest release of a GitHub repository", Params: []StrategyParam{
+		{Name: "repo", Required: true, Hint: "owner/repo"},
+		{Name: "asset_pattern", Required: true, Regex: true, Screened: true, Hint: `tool-.*-linux-amd64\.tar\.gz$`},
+		{Name: "extra_assets", Regex: true, Screened: true, List: true, Hint: `^SHA256SUMS$`},
+	}},
+	{Name: "web_scrape", Description: "Versions scraped from a directory listing", Params: []StrategyParam{
+		{Name: "base_url", Required: true, Hint: "https://example.org/releases/"},
+		{Name: "version_pattern", Required: true, Regex: true, Hint: `href="(\d+\.\d+)/"`},
+		{Name: "file_template", Required: true, Hint: "{{version}}/tool-{{version}}.tar.gz"},
+		{Name: "version_regex", Regex: true, Hint: `r\d+[a-z]?`},
+		{Name: "page_pattern", Regex: true, Hint: `href="(\?page=\d+)"`},
+		{Name: "follow_latest", Hint: "true"},
+	}},
+	{Name: "rss_feed", Description: "Newest matching item of an RSS feed", Params: []StrategyParam{
+		{Name: "feed_url", Required: true, Hint: "https://example.org/releases.rss"},
+		{Name: "item_pattern", Required: true, Regex: true, Screened: true, Hint: `tool_.*\.zip`},
+		{Name: "version_pattern", Required: true, Regex: true, Screened: true, Hint: `tool_(\d+\.\d+\.\d+)`},
+	}},
+	{Name: "kiwix_feed", Description: "Newest ZIM of a series in a Kiwix OPDS catalog", Params: []StrategyParam{
+		{Name: "feed_url", Required: true, Hint: "https://library.kiwix.org/catalog/v2/entries"},
+		{Name: "series", Required: true, Hint: "wikipedia_en_all"},
+		{Name: "flavour", Hint: "maxi"},
+		{Name: "language", Hint: "eng"},
+		{Name: "date_format", Hint: "02 Jan 2006"},
+		{Name: "version_granularity", Hint: "day"},
+	}},
+	{Name: "http_redirect", Description: "File a \"latest\" URL redirects to", Params: []StrategyParam{
+		{Name: "url", Required: true, Hint: "https://example.org/download/latest"},
+		{Name: "version_pattern", Regex: true, Hint: `tool-(\d+\.\d+)`},
+	}},
+	{Name: "redirect", Description: "Version in the Location a \"latest\" URL redirects to", Params: []StrategyParam{
+		{Name: "url", Required: true, Hint: "https://example.org/download/latest"},
+		{Name: "version_pattern", Required: true, Regex: true, Screened: true, Hint: `/v(\d+\.\d+\.\d+)/`},
+	}},
+	{Name: "direct", Description: "File at a fixed URL", Params: []StrategyParam{
+		{Name: "url", Required: true, Hint: "https://example.org/download/tool.zip"},
+		{Name: "version_from_header", Hint: "X-Version"},
+	}},
+	{Name: "chromium_rss", Description: "Chromium builds announced in an RSS feed", Params: []StrategyParam{
+		{Name: "feed_url", Required: true},
+		{Name: "asset_pattern", Required: true, Regex: true, Screened: true},
+		{Name: "item_pattern", Regex: true, Screened: true},
+	}},
+	{Name: "chromium_gcs", Description: "Newest Chromium snapshot in Google Cloud Storage", Params: []StrategyParam{
+		{Name: "prefix", Required: true, Hint: "Mac_Arm"},
+		{Name: "filename", Required: true, Hint: "chrome-mac.zip"},
+	}},
+	{Name: "deb_repo", Description: "Package in an apt repository's Packages index", Params: []StrategyParam{
+		{Name: "base_url", Required: true, Hint: "https://deb.example.org/debian"},
+		{Name: "suite", Required: true, Hint: "stable"},
+		{Name: "package", Required: true, Hint: "tool"},
+		{Name: "component", Hint: "main"},
+		{Name: "arch", Hint: "amd64"},
+	}},
+	{Name: "hashicorp", Description: "Product on releases.hashicorp.com", Params: []StrategyParam{
+		{Name: "product", Required: true, Hint: "terraform"},
+		{Name: "os", Required: true, Hint: "{{os}}"},
+		{Name: "arch", Required: true, Hint: "{{arch}}"},
+	}},
+	{Name: "fedora_coreos", Description: "Fedora CoreOS stream metadata", Params: []StrategyParam{
+		{Name: "stream", Hint: "stable"},
+		{Name: "arch", Hint: "x86_64"},
+		{Name: "artifact", Hint: "metal"},
+		{Name: "format", Hint: "iso"},
+	}},
+	{Name: "gutenberg", Description: "Project Gutenberg library", Catalog: true},
+	{Name: "kiwix", Description: "Kiwix library", Catalog: true},
+}
+
+// LookupStrategy returns the description of the named strategy
+func LookupStrategy(name string) (StrategyInfo, bool) {
+	for _, s := range Strategies {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return StrategyInfo{}, false
+}
+
+// ValidateSource reports the first problem that would keep src from being checked:
+// an unknown strategy, a missing required param or an invalid regex param. A
+// source without a strategy needs a url instead.
+func ValidateSource(src config.Source) error {
+	if src.Strategy == "" {
+		if src.URL == "" {
+			return fmt.Errorf("no strategy or url")
+		}
+		return nil
+	}
+	info, ok := LookupStrategy(src.Strategy)
+	if !ok {
+		return fmt.Errorf("unknown strategy '%s'", src.Strategy)
+	}
+	for _, p := range info.Params {
+		if err := ValidateParam(p, src.Params[p.Name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateParam checks one value of param p
+func ValidateParam(p StrategyParam, value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if p.Required {
+			return fmt.Errorf("%s is required", p.Name)
+		}
+		return nil
+	}
+	if !p.Regex {
+		return nil
+	}
+	values := []string{value}
+	if p.List {
+		values = strings.Split(value, ",")
+	}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		var err error
+		if p.Screened {
+			err = ValidateRegexPattern(v)
+		} else {
+			_, err = regexp.Compile(v)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+	}
+	return nil
+}
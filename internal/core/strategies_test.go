@@ -0,0 +1,59 @@
+package core
+
+import (
+	"lamp/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidateSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     config.Source
+		wantErr string
+	}{
+		{"valid", config.Source{Strategy: "github_release", Params: map[string]string{"repo": "a/b", "asset_pattern": `b-.*\.zip`}}, ""},
+		{"missing param", config.Source{Strategy: "github_release", Params: map[string]string{"repo": "a/b"}}, "asset_pattern is required"},
+		{"bad regex", config.Source{Strategy: "rss_feed", Params: map[string]string{"feed_url": "x", "item_pattern": "(", "version_pattern": "v"}}, "item_pattern"},
+		{"bad list entry", config.Source{Strategy: "github_release", Params: map[string]string{"repo": "a/b", "asset_pattern": "b", "extra_assets": "ok, ("}}, "extra_assets"},
+		{"unknown strategy", config.Source{Strategy: "ftp"}, "unknown strategy"},
+		{"direct url", config.Source{URL: "https://example.com/f.iso"}, ""},
+		{"nothing", config.Source{}, "no strategy or url"},
+	}
+	for _, tt := range tests {
+		err := ValidateSource(tt.src)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+// The metadata has to agree with the shipped catalogs, which are known to work
+func TestBundledCatalogsValidate(t *testing.T) {
+	files, _ := filepath.Glob("../../catalogs/*.yaml")
+	if len(files) == 0 {
+		t.Fatal("no bundled catalogs found")
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var catalog config.Catalog
+		if err := yaml.Unmarshal(data, &catalog); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		for _, src := range catalog.Sources {
+			if err := ValidateSource(src); err != nil {
+				t.Errorf("%s: %s: %v", filepath.Base(file), src.ID, err)
+			}
+		}
+	}
+}
@@ -6,6 +6,14 @@ import (
 	"runtime"
 )
 
+// shellCommand runs command through the platform shell: sh -c, or cmd /C on Windows
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
 // OpenDir opens the specified directory in the default file manager
 func OpenDir(path string) error {
 	var cmd string
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CompareVersions compares two dotted version strings such as "1.10.2" or "v2.0.0-rc1".
+// Numeric components compare numerically, a missing component counts as zero, and a
+// pre-release suffix (after '-') sorts before the release itself. Build metadata
+// (after '+') is ignored. It returns -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	coreA, preA := splitVersion(a)
+	coreB, preB := splitVersion(b)
+
+	partsA := strings.Split(coreA, ".")
+	partsB := strings.Split(coreB, ".")
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		var pa, pb string
+		if i < len(partsA) {
+			pa = partsA[i]
+		}
+		if i < len(partsB) {
+			pb = partsB[i]
+		}
+		if c := compareVersionPart(pa, pb); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return compareVersionPart(preA, preB)
+}
+
+// splitVersion strips a leading "v" and build metadata, returning the
+// release part and the pre-release suffix
+func splitVersion(v string) (string, string) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "+")
+	release, pre, _ := strings.Cut(v, "-")
+	return release, pre
+}
+
+// compareVersionPart compares numerically when both parts are numbers (empty
+// counts as zero) and lexically otherwise
+func compareVersionPart(a, b string) int {
+	na, errA := strconv.Atoi(orZero(a))
+	nb, errB := strconv.Atoi(orZero(b))
+	if errA == nil && errB == nil {
+		switch {
+		case na < nb:
+			return -1
+		case na > nb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+func orZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
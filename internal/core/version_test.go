@@ -0,0 +1,24 @@
+package core
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.2.0", "1.10.0", -1},
+		{"v2.0", "1.9.9", 1},
+		{"1.0", "1.0.0", 0},
+		{"1.5.0-rc1", "1.5.0", -1},
+		{"1.5.0-alpha", "1.5.0-beta", -1},
+		{"1.5.0+ent", "1.5.0", 0},
+	}
+
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.expected {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
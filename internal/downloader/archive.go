@@ -0,0 +1,181 @@
+package downloader
+
+import (
+	"bufio"
+	"lamp/internal/core"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// IntegrityStatus is the outcome of verifying one downloaded file
+type IntegrityStatus string
+
+const (
+	IntegrityOK         IntegrityStatus = "OK"
+	IntegrityCorrupted  IntegrityStatus = "Corrupted"
+	IntegrityNoChecksum IntegrityStatus = "NoChecksum"
+	IntegrityMissing    IntegrityStatus = "Missing"
+)
+
+// ArchiveFile is a downloaded file to verify against the checksum known for it
+type ArchiveFile struct {
+	Path         string
+	Checksum     string // Empty to look for a sidecar checksum file
+	ChecksumFrom string // "configured", "published" or "sidecar"
+}
+
+// ArchiveResult is the outcome of verifying an ArchiveFile
+type ArchiveResult struct {
+	ArchiveFile
+	Status IntegrityStatus
+	Err    error // Why a file is Corrupted, e.g. the checksum mismatch
+}
+
+// ArchiveFileFor locates the local file of a checked source and the checksum to
+// verify it with. target is the source's target path; localFilename, status and
+// published come from its last check, configured from the source's checksum setting.
+// A published checksum belongs to the newest release, so it is only used when the
+// local copy is that release.
+func ArchiveFileFor(target, localFilename string, status core.VersionStatus, configured, published string) ArchiveFile {
+	f := ArchiveFile{Path: target}
+	if localFilename != "" {
+		f.Path = filepath.Join(filepath.Dir(target), localFilename)
+	}
+	switch {
+	case configured != "":
+		f.Checksum, f.ChecksumFrom = configured, "configured"
+	case published != "" && status == core.StatusUpToDate:
+		f.Checksum, f.ChecksumFrom = published, "published"
+	}
+	return f
+}
+
+// VerifyArchive verifies files using up to workers files at a time, returning
+// results in the order of files. onDone, if non-nil, is called once before the
+// first file and after each file finishes; calls are serialized.
+func VerifyArchive(files []ArchiveFile, workers int, onDone func(done, total int)) []ArchiveResult {
+	results := make([]ArchiveResult, len(files))
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	var (
+		mu   sync.Mutex
+		done int
+	)
+	if onDone != nil {
+		onDone(0, len(files))
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i] = verifyArchiveFile(files[i])
+				if onDone != nil {
+					mu.Lock()
+					done++
+					onDone(done, len(files))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range files {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	return results
+}
+
+func verifyArchiveFile(f ArchiveFile) ArchiveResult {
+	r := ArchiveResult{ArchiveFile: f}
+	if info, err := os.Stat(f.Path); err != nil || info.IsDir() {
+		r.Status = IntegrityMissing
+		return r
+	}
+	if r.Checksum == "" {
+		if sum := SidecarChecksum(f.Path); sum != "" {
+			r.Checksum, r.ChecksumFrom = sum, "sidecar"
+		}
+	}
+	if r.Checksum == "" {
+		r.Status = IntegrityNoChecksum
+		return r
+	}
+
+	if err := VerifyFile(f.Path, r.Checksum); err != nil {
+		r.Status = IntegrityCorrupted
+		r.Err = err
+		return r
+	}
+	r.Status = IntegrityOK
+	return r
+}
+
+// sidecarAlgos are the checksum files looked for next to a download, strongest first
+var sidecarAlgos = []string{"sha512", "sha256", "sha1", "md5"}
+
+// SidecarChecksum returns the checksum for path recorded in a sidecar file: either
+// path plus an algorithm extension (file.iso.sha256) or a checksum list such as
+// SHA256SUMS in the same folder. It returns "" when there is none.
+func SidecarChecksum(path string) string {
+	name := filepath.Base(path)
+	dir := filepath.Dir(path)
+	for _, algo := range sidecarAlgos {
+		for _, sidecar := range []string{path + "." + algo, path + "." + algo + "sum"} {
+			if sum := checksumFromList(sidecar, name, true); sum != "" {
+				return algo + ":" + sum
+			}
+		}
+	}
+	for _, algo := range sidecarAlgos {
+		if sum := checksumFromList(filepath.Join(dir, strings.ToUpper(algo)+"SUMS"), name, false); sum != "" {
+			return algo + ":" + sum
+		}
+	}
+	return ""
+}
+
+// checksumFromList reads a "<hex>  <name>" checksum list and returns the hex for
+// name. With single set, a file holding one bare checksum also counts.
+func checksumFromList(listPath, name string, single bool) string {
+	f, err := os.Open(listPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var lines int
+	var only string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		lines++
+		if len(fields) == 1 {
+			only = fields[0]
+			continue
+		}
+		// Binary-mode entries mark the name with a leading '*'
+		if strings.TrimPrefix(fields[1], "*") == name {
+			return fields[0]
+		}
+	}
+	if single && lines == 1 {
+		return only
+	}
+	return ""
+}
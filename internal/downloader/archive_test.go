@@ -0,0 +1,83 @@
+package downloader
+
+import (
+	"lamp/internal/core"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyArchive(t *testing.T) {
+	dir := t.TempDir()
+	// sha256 of "hello world"
+	const sum = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	ok := write("ok.iso", "hello world")
+	bad := write("bad.iso", "hello wor1d")
+	sidecar := write("sidecar.iso", "hello world")
+	write("sidecar.iso.sha256", sum+"  sidecar.iso\n")
+	listed := write("listed.iso", "hello world")
+	write("SHA256SUMS", "0000  other.iso\n"+sum+" *listed.iso\n")
+	bare := write("bare.iso", "hello world")
+
+	files := []ArchiveFile{
+		{Path: ok, Checksum: "sha256:" + sum},
+		{Path: bad, Checksum: "sha256:" + sum},
+		{Path: sidecar},
+		{Path: listed},
+		{Path: bare},
+		{Path: filepath.Join(dir, "missing.iso"), Checksum: "sha256:" + sum},
+	}
+	want := []IntegrityStatus{IntegrityOK, IntegrityCorrupted, IntegrityOK, IntegrityOK, IntegrityNoChecksum, IntegrityMissing}
+
+	var calls, last int
+	results := VerifyArchive(files, 3, func(done, total int) {
+		calls++
+		last = done
+		if total != len(files) {
+			t.Errorf("Expected total %d, got %d", len(files), total)
+		}
+	})
+	if calls != len(files)+1 || last != len(files) {
+		t.Errorf("Expected %d progress calls ending at %d, got %d ending at %d", len(files)+1, len(files), calls, last)
+	}
+
+	for i, r := range results {
+		if r.Path != files[i].Path {
+			t.Errorf("Result %d is for %s, expected %s", i, r.Path, files[i].Path)
+		}
+		if r.Status != want[i] {
+			t.Errorf("%s: expected %s, got %s (%v)", filepath.Base(r.Path), want[i], r.Status, r.Err)
+		}
+	}
+	if results[1].Err == nil {
+		t.Error("Expected the corrupted file to carry the mismatch")
+	}
+	if results[2].ChecksumFrom != "sidecar" || results[3].Checksum != "sha256:"+sum {
+		t.Errorf("Expected checksums from sidecar files, got %+v and %+v", results[2].ArchiveFile, results[3].ArchiveFile)
+	}
+}
+
+func TestArchiveFileFor(t *testing.T) {
+	target := filepath.Join("data", "app.zip")
+
+	f := ArchiveFileFor(target, "app-1.2.zip", core.StatusNewer, "", "sha256:new")
+	if f.Path != filepath.Join("data", "app-1.2.zip") || f.Checksum != "" {
+		t.Errorf("Expected the local file without the newer release's checksum, got %+v", f)
+	}
+	f = ArchiveFileFor(target, "", core.StatusUpToDate, "", "sha256:new")
+	if f.Path != target || f.Checksum != "sha256:new" || f.ChecksumFrom != "published" {
+		t.Errorf("Expected the published checksum for an up-to-date file, got %+v", f)
+	}
+	f = ArchiveFileFor(target, "", core.StatusUpToDate, "md5:abc", "sha256:new")
+	if f.Checksum != "md5:abc" || f.ChecksumFrom != "configured" {
+		t.Errorf("Expected the configured checksum to win, got %+v", f)
+	}
+}
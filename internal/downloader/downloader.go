@@ -7,13 +7,38 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
+)
+
+// downloadClient re-validates every redirect hop so a redirect can't bypass the
+// private address check applied to the original URL, and adds the credentials of
+// sources with auth
+var downloadClient = &http.Client{CheckRedirect: core.ValidateRedirect, Transport: core.AuthTransport(nil)}
+
+// Phase is the step of a download a Progress reports
+type Phase int
+
+const (
+	PhaseDownloading Phase = iota // Downloaded of Total bytes transferred
+	PhaseVerifying                // Downloaded of Total bytes checksummed
+	PhaseResolving                // Looking up the download URL
+	PhaseResolved                 // The URL was found; the resolution results are set
+	PhaseSpaceCheck               // Checking free space for the download
+	PhaseSpaceOK                  // There is room for the download
+	PhaseNoSpace                  // Not enough free space; Available says how much there is
+	PhaseSkipped                  // The local copy is already current, so nothing is downloaded
 )
 
 type Progress struct {
-	Total      int64
+	Phase      Phase
+	Total      int64 // Size in bytes, or 0 when the server did not send a length
 	Downloaded int64
+	Available  int64 // Free bytes at the destination, sent with PhaseNoSpace
+	Threads    int   // Connections in use, sent once the transfer starts
 	Error      error
 
 	// Results from auto-resolution
@@ -21,6 +46,9 @@ type Progress struct {
 	Current     string
 	Latest      string
 	ResolvedURL string
+	Checksum    string   // Checksum published alongside ResolvedURL
+	ExtraURLs   []string // Companion files published alongside ResolvedURL
+	Dest        string   // Final local path, sent once the filename is decided
 }
 
 type ProgressWriter struct {
@@ -32,17 +60,67 @@ type ProgressWriter struct {
 func (pw *ProgressWriter) Write(p []byte) (int, error) {
 	n := len(p)
 	pw.Downloaded += int64(n)
-	pw.onProgress(Progress{Total: pw.Total, Downloaded: pw.Downloaded})
+	pw.onProgress(Progress{Phase: PhaseDownloading, Total: pw.Total, Downloaded: pw.Downloaded})
 	return n, nil
 }
 
+// Servers that throttle connections answer 429 or 503; the request is retried after
+// the Retry-After delay, or an exponential backoff from retryBackoff without one
+const maxRateLimitRetries = 4
+
+var (
+	retryBackoff  = 2 * time.Second
+	maxRetryDelay = time.Minute
+)
+
+// rename moves files into place; tests replace it to make a rename fail
+var rename = os.Rename
+
+// rateLimitDelay reports whether resp is a throttling response and how long to wait
+// before the next attempt (0-based) at the same request
+func rateLimitDelay(resp *http.Response, attempt int) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	delay := retryBackoff << attempt
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			delay = time.Duration(secs) * time.Second
+		} else if at, err := http.ParseTime(v); err == nil {
+			delay = time.Until(at)
+		}
+	}
+	return min(max(delay, 0), maxRetryDelay), true
+}
+
 // DownloadFile downloads a file from url to dest, supporting parallel segments and resumption.
 func DownloadFile(url, dest string, threads int, progressChan chan<- Progress) error {
-	defer close(progressChan)
+	return DownloadFileVerified(url, dest, "", threads, 0, false, progressChan)
+}
+
+// DownloadFileVerified downloads url to a temporary file next to dest and renames it
+// into place only once the transfer (and the checksum, when one is given) succeeds,
+// so an interrupted download never leaves a partial file at dest.
+// A file over maxSize bytes fails with ErrTooLarge, before anything is written
+// when the server sends its size and as soon as the limit is crossed otherwise;
+// 0 allows any size.
+// With backup, a file already at dest is renamed to dest.bak just before the new
+// one takes its place, so a failed download leaves it untouched.
+// A failure is also sent on progressChan before it is closed.
+func DownloadFileVerified(url, dest, checksum string, threads int, maxSize int64, backup bool, progressChan chan<- Progress) (err error) {
+	defer func() {
+		if err != nil {
+			progressChan <- Progress{Error: err}
+		}
+		close(progressChan)
+	}()
 
 	if url == "" {
 		return fmt.Errorf("empty download URL")
 	}
+	if core.IsOffline() {
+		return fmt.Errorf("%w: downloads are disabled", core.ErrOffline)
+	}
 
 	// Validate that the URL uses HTTPS
 	if err := core.ValidateDownloadURL(url); err != nil {
@@ -54,27 +132,102 @@ func DownloadFile(url, dest string, threads int, progressChan chan<- Progress) e
 	}
 
 	// 1. Get file info and check for range support
-	client := &http.Client{}
-	req, err := http.NewRequest("HEAD", url, nil)
+	remote, err := probeDownload(url)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("User-Agent", "lamp/1.0")
+	contentLength := remote.Size
+	acceptRanges := remote.Ranges
+	if err := CheckMaxSize(contentLength, maxSize); err != nil {
+		return err
+	}
 
-	resp, err := client.Do(req)
+	tmp, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+".tmp-*")
 	if err != nil {
-		return fmt.Errorf("HEAD request failed: %w", err)
+		return fmt.Errorf("failed to create temp file: %w", err)
 	}
-	defer resp.Body.Close()
-
-	contentLength := resp.ContentLength
-	acceptRanges := resp.Header.Get("Accept-Ranges") == "bytes"
+	tmpPath := tmp.Name()
+	tmp.Close()
 
 	// Fallback to single-threaded if no range support or unknown size or small file
 	if !acceptRanges || contentLength <= 0 || threads <= 1 || contentLength < 1024*1024 {
-		return downloadSingle(url, dest, progressChan)
+		progressChan <- Progress{Phase: PhaseDownloading, Total: max(contentLength, 0), Threads: 1}
+		err = downloadSingle(url, tmpPath, maxSize, progressChan)
+	} else {
+		progressChan <- Progress{Phase: PhaseDownloading, Total: contentLength, Threads: threads}
+		err = downloadSegments(url, tmpPath, contentLength, threads, progressChan)
+	}
+	if err == nil {
+		err = VerifyFileProgress(tmpPath, checksum, func(done, total int64) {
+			select {
+			case progressChan <- Progress{Phase: PhaseVerifying, Total: total, Downloaded: done}:
+			default:
+			}
+		})
+	}
+	if err == nil {
+		// Catches HTML error pages saved under an .epub or .zim name when no checksum is known
+		err = ValidateFileType(tmpPath, ExpectedFileKind("", dest))
+	}
+	backedUp := false
+	if err == nil && backup {
+		if _, statErr := os.Stat(dest); statErr == nil {
+			if err = rename(dest, dest+".bak"); err != nil {
+				err = fmt.Errorf("failed to back up existing file: %w", err)
+			} else {
+				backedUp = true
+			}
+		}
+	}
+	if err == nil {
+		if err = rename(tmpPath, dest); err != nil && backedUp {
+			// Put the previous file back so a failed download leaves it untouched
+			rename(dest+".bak", dest)
+		}
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Stamp the file with the remote modification time so later Last-Modified checks compare correctly
+	if lastMod, err := http.ParseTime(remote.LastModified); err == nil {
+		os.Chtimes(dest, lastMod, lastMod)
+	}
+	return nil
+}
+
+// DownloadCompanions downloads each of urls into dir under its remote filename,
+// e.g. the signature and checksum files published with a release asset. Progress
+// of every file is forwarded to progressChan, which is left open.
+func DownloadCompanions(urls []string, dir string, threads int, progressChan chan<- Progress) error {
+	for _, u := range urls {
+		name, err := core.SanitizeFilename(core.RemoteFilename(u, nil))
+		if err != nil {
+			return fmt.Errorf("invalid companion filename in %s: %w", u, err)
+		}
+
+		fileChan := make(chan Progress, 10)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for p := range fileChan {
+				if p.Error == nil {
+					progressChan <- p
+				}
+			}
+		}()
+		err = DownloadFile(u, filepath.Join(dir, name), threads, fileChan)
+		<-done
+		if err != nil {
+			return fmt.Errorf("companion %s: %w", name, err)
+		}
 	}
+	return nil
+}
 
+// downloadSegments downloads url into dest using parallel range requests
+func downloadSegments(url, dest string, contentLength int64, threads int, progressChan chan<- Progress) error {
 	// 2. Prepare file
 	out, err := os.OpenFile(dest, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
@@ -92,6 +245,8 @@ func DownloadFile(url, dest string, threads int, progressChan chan<- Progress) e
 	var downloaded int64
 	var errOnce sync.Once
 	var firstErr error
+	ranges := make([]byteRange, threads)
+	written := make([]int64, threads) // Bytes each segment actually wrote
 
 	for i := 0; i < threads; i++ {
 		start := int64(i) * chunkSize
@@ -99,36 +254,75 @@ func DownloadFile(url, dest string, threads int, progressChan chan<- Progress) e
 		if i == threads-1 {
 			end = contentLength - 1
 		}
+		ranges[i] = byteRange{Start: start, End: end}
 
 		wg.Add(1)
-		go func(s, e int64) {
+		go func(i int, s, e int64) {
 			defer wg.Done()
-			err := downloadSegment(url, out, s, e, &downloaded, contentLength, progressChan)
+			n, err := downloadSegment(url, out, s, e, &downloaded, contentLength, progressChan)
+			written[i] = n
 			if err != nil {
 				errOnce.Do(func() {
 					firstErr = err
 				})
 			}
-		}(start, end)
+		}(i, start, end)
 	}
 
 	wg.Wait()
-	return firstErr
+	if firstErr != nil {
+		return firstErr
+	}
+	return verifySegments(ranges, written)
 }
 
-func downloadSingle(url, dest string, progressChan chan<- Progress) error {
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("User-Agent", "lamp/1.0")
+// byteRange is an inclusive range of file offsets
+type byteRange struct {
+	Start, End int64
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
+// verifySegments confirms every segment wrote its whole range. A server that closes
+// a segment early without an error would otherwise leave a zero-filled hole.
+func verifySegments(ranges []byteRange, written []int64) error {
+	var incomplete []string
+	for i, r := range ranges {
+		if expected := r.End - r.Start + 1; written[i] != expected {
+			incomplete = append(incomplete, fmt.Sprintf("%d-%d (%d of %d bytes)", r.Start, r.End, written[i], expected))
+		}
+	}
+	if len(incomplete) > 0 {
+		return fmt.Errorf("incomplete download, ranges not fully written: %s", strings.Join(incomplete, ", "))
+	}
+	return nil
+}
+
+func downloadSingle(url, dest string, maxSize int64, progressChan chan<- Progress) error {
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
+		req, _ := http.NewRequest("GET", url, nil)
+		req.Header.Set("User-Agent", "lamp/1.0")
+
+		var err error
+		resp, err = downloadClient.Do(req)
+		if err != nil {
+			return err
+		}
+		delay, throttled := rateLimitDelay(resp, attempt)
+		if !throttled || attempt == maxRateLimitRetries {
+			break
+		}
+		resp.Body.Close()
+		time.Sleep(delay)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("HTTP %d", resp.StatusCode)
 	}
+	// The probe may not have learned the size, but the response can still carry it
+	if err := CheckMaxSize(resp.ContentLength, maxSize); err != nil {
+		return err
+	}
 
 	out, err := os.Create(dest)
 	if err != nil {
@@ -136,10 +330,14 @@ func downloadSingle(url, dest string, progressChan chan<- Progress) error {
 	}
 	defer out.Close()
 
-	var downloaded int64
-	_ = downloaded // Reserved for future use if we want per-segment in single too
+	// Chunked responses report a length of -1; progress reports an unknown size as 0
+	total := resp.ContentLength
+	if total < 0 {
+		total = 0
+	}
+
 	pw := &ProgressWriter{
-		Total:      resp.ContentLength,
+		Total:      total,
 		Downloaded: 0,
 		onProgress: func(p Progress) {
 			select {
@@ -149,23 +347,46 @@ func downloadSingle(url, dest string, progressChan chan<- Progress) error {
 		},
 	}
 
-	_, err = io.Copy(out, io.TeeReader(resp.Body, pw))
+	var w io.Writer = out
+	if maxSize > 0 {
+		w = &limitWriter{w: out, limit: maxSize}
+	}
+	_, err = io.Copy(w, io.TeeReader(resp.Body, pw))
 	return err
 }
 
-func downloadSegment(url string, out *os.File, start, end int64, totalDownloaded *int64, totalSize int64, progressChan chan<- Progress) error {
+// downloadSegment fetches bytes start-end into out, returning how many bytes were written.
+// A throttled segment backs off and resumes where it stopped, leaving the others running.
+func downloadSegment(url string, out *os.File, start, end int64, totalDownloaded *int64, totalSize int64, progressChan chan<- Progress) (int64, error) {
+	var written int64
+	for attempt := 0; ; attempt++ {
+		n, delay, err := fetchSegment(url, out, start+written, end, totalDownloaded, totalSize, progressChan, attempt)
+		written += n
+		if delay < 0 || attempt == maxRateLimitRetries {
+			return written, err
+		}
+		time.Sleep(delay)
+	}
+}
+
+// fetchSegment makes one range request for start-end. When the server throttles it,
+// the returned delay is how long to wait before retrying; otherwise it is -1.
+func fetchSegment(url string, out *os.File, start, end int64, totalDownloaded *int64, totalSize int64, progressChan chan<- Progress, attempt int) (int64, time.Duration, error) {
 	req, _ := http.NewRequest("GET", url, nil)
 	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
 	req.Header.Set("User-Agent", "lamp/1.0")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := downloadClient.Do(req)
 	if err != nil {
-		return err
+		return 0, -1, err
 	}
 	defer resp.Body.Close()
 
+	if delay, throttled := rateLimitDelay(resp, attempt); throttled {
+		return 0, delay, fmt.Errorf("segment HTTP %d", resp.StatusCode)
+	}
 	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("segment HTTP %d", resp.StatusCode)
+		return 0, -1, fmt.Errorf("segment HTTP %d", resp.StatusCode)
 	}
 
 	buffer := make([]byte, 32*1024)
@@ -173,9 +394,13 @@ func downloadSegment(url string, out *os.File, start, end int64, totalDownloaded
 	for {
 		n, readErr := resp.Body.Read(buffer)
 		if n > 0 {
+			if offset+int64(n) > end+1 {
+				// The server ignored the Range header and is sending the whole file
+				return offset - start, -1, fmt.Errorf("server sent more than the requested range %d-%d", start, end)
+			}
 			_, writeErr := out.WriteAt(buffer[:n], offset)
 			if writeErr != nil {
-				return writeErr
+				return offset - start, -1, writeErr
 			}
 			offset += int64(n)
 			atomic.AddInt64(totalDownloaded, int64(n))
@@ -183,6 +408,7 @@ func downloadSegment(url string, out *os.File, start, end int64, totalDownloaded
 			// Report progress
 			select {
 			case progressChan <- Progress{
+				Phase:      PhaseDownloading,
 				Total:      totalSize,
 				Downloaded: atomic.LoadInt64(totalDownloaded),
 			}:
@@ -193,8 +419,8 @@ func downloadSegment(url string, out *os.File, start, end int64, totalDownloaded
 			break
 		}
 		if readErr != nil {
-			return readErr
+			return offset - start, -1, readErr
 		}
 	}
-	return nil
+	return offset - start, -1, nil
 }
@@ -0,0 +1,534 @@
+package downloader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"lamp/internal/config"
+	"lamp/internal/core"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownloadFileSetsLastModified(t *testing.T) {
+	lastMod := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", lastMod.Format(http.TimeFormat))
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	progressChan := make(chan Progress, 10)
+	go func() {
+		for range progressChan {
+		}
+	}()
+
+	if err := DownloadFile(server.URL, dest, 1, progressChan); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+
+	info, err := os.Stat(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(lastMod) {
+		t.Errorf("Expected mtime %v, got %v", lastMod, info.ModTime().UTC())
+	}
+}
+
+func TestDownloadFileVerifiedChecksumMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "file.bin")
+	progressChan := make(chan Progress, 10)
+	var reported error
+	done := make(chan struct{})
+	go func() {
+		for p := range progressChan {
+			if p.Error != nil {
+				reported = p.Error
+			}
+		}
+		close(done)
+	}()
+
+	err := DownloadFileVerified(server.URL, dest, "sha256:"+strings.Repeat("0", 64), 1, 0, false, progressChan)
+	<-done
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Expected checksum mismatch, got %v", err)
+	}
+	if !errors.Is(reported, ErrChecksumMismatch) {
+		t.Errorf("Expected mismatch to be sent on the progress channel, got %v", reported)
+	}
+
+	// Neither the final file nor the temp file should be left behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected empty directory after failed download, found %d entries", len(entries))
+	}
+}
+
+func TestDownloadFileRejectsErrorPageAsZIM(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>503 Service Unavailable</body></html>"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	progressChan := make(chan Progress, 10)
+	go func() {
+		for range progressChan {
+		}
+	}()
+
+	err := DownloadFile(server.URL, filepath.Join(dir, "wikipedia_en_2024-01.zim"), 1, progressChan)
+	if !errors.Is(err, ErrInvalidFileType) {
+		t.Fatalf("Expected ErrInvalidFileType, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected the error page to be discarded, found %d entries", len(entries))
+	}
+}
+
+func TestDownloadFileDetectsShortSegment(t *testing.T) {
+	const size = 2 * 1024 * 1024
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Accept-Ranges", "bytes")
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", strconv.Itoa(size))
+			return
+		}
+		var start, end int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end)
+		n := end - start + 1
+		if start == 0 {
+			n /= 2 // Close the first segment early without signalling an error
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(bytes.Repeat([]byte("x"), n))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "file.bin")
+	progressChan := make(chan Progress, 10)
+	go func() {
+		for range progressChan {
+		}
+	}()
+
+	err := DownloadFile(server.URL, dest, 2, progressChan)
+	if err == nil || !strings.Contains(err.Error(), "0-1048575") {
+		t.Fatalf("Expected incomplete range error for 0-1048575, got %v", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("Expected no file at destination after incomplete download")
+	}
+}
+
+func TestDownloadFileChunkedUnknownSize(t *testing.T) {
+	chunk := bytes.Repeat([]byte("x"), 64*1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		// Flushing before the handler returns forces chunked transfer encoding
+		for i := 0; i < 4; i++ {
+			w.Write(chunk)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	progressChan := make(chan Progress, 100)
+	var updates []Progress
+	done := make(chan struct{})
+	go func() {
+		for p := range progressChan {
+			updates = append(updates, p)
+		}
+		close(done)
+	}()
+
+	if err := DownloadFile(server.URL, dest, 4, progressChan); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	<-done
+
+	info, err := os.Stat(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != int64(4*len(chunk)) {
+		t.Errorf("Expected %d bytes, got %d", 4*len(chunk), info.Size())
+	}
+	if len(updates) == 0 {
+		t.Fatal("Expected progress updates")
+	}
+	for _, p := range updates {
+		if p.Phase != PhaseDownloading && p.Phase != PhaseVerifying {
+			t.Fatalf("Expected only transfer and verification updates, got %+v", p)
+		}
+		if p.Phase == PhaseDownloading && p.Total != 0 {
+			t.Fatalf("Expected an unknown total of 0 for a chunked response, got %d", p.Total)
+		}
+	}
+	if last := updates[len(updates)-1]; last.Downloaded <= 0 {
+		t.Errorf("Expected the byte count to grow, last update was %+v", last)
+	}
+}
+
+func TestDownloadFileMaxSize(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 64*1024)
+	var gets atomic.Int32
+	sized := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		if r.Method == http.MethodGet {
+			gets.Add(1)
+			w.Write(payload)
+		}
+	}))
+	defer sized.Close()
+	chunked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		for i := 0; i < 4; i++ {
+			w.Write(payload)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer chunked.Close()
+
+	drain := func() chan Progress {
+		progressChan := make(chan Progress, 100)
+		go func() {
+			for range progressChan {
+			}
+		}()
+		return progressChan
+	}
+
+	dir := t.TempDir()
+	err := DownloadFileVerified(sized.URL, filepath.Join(dir, "sized.bin"), "", 1, 1024, false, drain())
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected ErrTooLarge from the advertised size, got %v", err)
+	}
+	if n := gets.Load(); n != 0 {
+		t.Errorf("Expected the download to stop before any GET, got %d", n)
+	}
+
+	err = DownloadFileVerified(chunked.URL, filepath.Join(dir, "chunked.bin"), "", 1, int64(len(payload)*2), false, drain())
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected ErrTooLarge once the stream crossed the limit, got %v", err)
+	}
+
+	// Neither the final files nor the temp files should be left behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected empty directory after refused downloads, found %d entries", len(entries))
+	}
+
+	if err := DownloadFileVerified(sized.URL, filepath.Join(dir, "ok.bin"), "", 1, int64(len(payload)), false, drain()); err != nil {
+		t.Errorf("Expected a file exactly at the limit to download, got %v", err)
+	}
+}
+
+func TestDownloadFileVerifiedBackup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("new"))
+	}))
+	defer server.Close()
+	drain := func() chan Progress {
+		progressChan := make(chan Progress, 100)
+		go func() {
+			for range progressChan {
+			}
+		}()
+		return progressChan
+	}
+
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	os.WriteFile(dest, []byte("old"), 0644)
+
+	// A failed download leaves the existing file where it was
+	if err := DownloadFileVerified(server.URL, dest, "sha256:"+strings.Repeat("0", 64), 1, 0, true, drain()); err == nil {
+		t.Fatal("Expected a checksum mismatch")
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "old" {
+		t.Errorf("Expected the existing file kept after a failure, got %q", data)
+	}
+	if _, err := os.Stat(dest + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("Expected no backup after a failure, got %v", err)
+	}
+
+	if err := DownloadFileVerified(server.URL, dest, "", 1, 0, true, drain()); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "new" {
+		t.Errorf("Expected the new file in place, got %q", data)
+	}
+	if data, _ := os.ReadFile(dest + ".bak"); string(data) != "old" {
+		t.Errorf("Expected the previous file kept as .bak, got %q", data)
+	}
+
+	// When the new file can't be moved into place, the backup is restored
+	os.Remove(dest + ".bak")
+	rename = func(oldpath, newpath string) error {
+		if strings.Contains(oldpath, ".tmp-") {
+			return fmt.Errorf("rename %s: read-only file system", oldpath)
+		}
+		return os.Rename(oldpath, newpath)
+	}
+	defer func() { rename = os.Rename }()
+	os.WriteFile(dest, []byte("old"), 0644)
+	if err := DownloadFileVerified(server.URL, dest, "", 1, 0, true, drain()); err == nil {
+		t.Fatal("Expected the final rename to fail")
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "old" {
+		t.Errorf("Expected the existing file restored after a failed rename, got %q", data)
+	}
+	if _, err := os.Stat(dest + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("Expected the backup moved back, got %v", err)
+	}
+}
+
+func TestDownloadCompanions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("contents of " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	progressChan := make(chan Progress, 100)
+	go func() {
+		for range progressChan {
+		}
+	}()
+	defer close(progressChan)
+
+	urls := []string{server.URL + "/v1/tool.tar.gz.asc", server.URL + "/v1/SHA256SUMS"}
+	if err := DownloadCompanions(urls, dir, 1, progressChan); err != nil {
+		t.Fatalf("DownloadCompanions failed: %v", err)
+	}
+
+	for name, want := range map[string]string{"tool.tar.gz.asc": "/v1/tool.tar.gz.asc", "SHA256SUMS": "/v1/SHA256SUMS"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Expected %s to be downloaded: %v", name, err)
+		}
+		if string(data) != "contents of "+want {
+			t.Errorf("Unexpected contents of %s: %q", name, data)
+		}
+	}
+
+	if err := DownloadCompanions([]string{server.URL + "/"}, dir, 1, progressChan); err == nil {
+		t.Error("Expected an error for a URL without a filename")
+	}
+}
+
+func TestDownloadFileBacksOffThrottledSegment(t *testing.T) {
+	const size = 2 * 1024 * 1024
+	payload := bytes.Repeat([]byte("0123456789abcdef"), size/16)
+	var throttled atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Accept-Ranges", "bytes")
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", strconv.Itoa(size))
+			return
+		}
+		var start, end int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end)
+		// Throttle the second segment twice before serving it
+		if start > 0 && throttled.Add(1) <= 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(payload[start : end+1])
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	progressChan := make(chan Progress, 100)
+	threads := 0
+	done := make(chan struct{})
+	go func() {
+		for p := range progressChan {
+			if p.Threads > 0 {
+				threads = p.Threads
+			}
+		}
+		close(done)
+	}()
+
+	if err := DownloadFile(server.URL, dest, 2, progressChan); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	<-done
+
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, payload) {
+		t.Error("Downloaded file does not match the payload")
+	}
+	if threads != 2 {
+		t.Errorf("Expected 2 threads to be reported, got %d", threads)
+	}
+}
+
+func TestDownloadFileProbesRangesWhenHeadIsRefused(t *testing.T) {
+	const size = 2 * 1024 * 1024
+	payload := bytes.Repeat([]byte("fedcba9876543210"), size/16)
+	var heads, probes, segments atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads.Add(1)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var start, end int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err != nil {
+			w.Write(payload)
+			return
+		}
+		if start == 0 && end == 0 {
+			probes.Add(1)
+		} else {
+			segments.Add(1)
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(payload[start : end+1])
+	}))
+	defer server.Close()
+
+	download := func() int {
+		dest := filepath.Join(t.TempDir(), "file.bin")
+		progressChan := make(chan Progress, 100)
+		threads := 0
+		done := make(chan struct{})
+		go func() {
+			for p := range progressChan {
+				if p.Threads > 0 {
+					threads = p.Threads
+				}
+			}
+			close(done)
+		}()
+		if err := DownloadFile(server.URL, dest, 4, progressChan); err != nil {
+			t.Fatalf("DownloadFile failed: %v", err)
+		}
+		<-done
+		if data, _ := os.ReadFile(dest); !bytes.Equal(data, payload) {
+			t.Error("Downloaded file does not match the payload")
+		}
+		return threads
+	}
+
+	if threads := download(); threads != 4 {
+		t.Errorf("Expected a segmented download with 4 threads, got %d", threads)
+	}
+	if heads.Load() != 1 || probes.Load() != 1 || segments.Load() != 4 {
+		t.Errorf("Expected 1 HEAD, 1 probe and 4 segments, got %d, %d and %d", heads.Load(), probes.Load(), segments.Load())
+	}
+
+	// The refusal is remembered for the host, so the next download skips HEAD
+	download()
+	if heads.Load() != 1 || probes.Load() != 2 {
+		t.Errorf("Expected no further HEAD requests, got %d HEADs and %d probes", heads.Load(), probes.Load())
+	}
+}
+
+func TestContentRangeTotal(t *testing.T) {
+	for header, want := range map[string]int64{
+		"bytes 0-0/1234": 1234,
+		"bytes 0-0/*":    -1,
+		"":               -1,
+		"items 0-0/10":   -1,
+	} {
+		if got := contentRangeTotal(header); got != want {
+			t.Errorf("contentRangeTotal(%q) = %d, want %d", header, got, want)
+		}
+	}
+}
+
+func TestRateLimitDelay(t *testing.T) {
+	defer func(b time.Duration) { retryBackoff = b }(retryBackoff)
+	retryBackoff = time.Second
+
+	tests := []struct {
+		status     int
+		retryAfter string
+		attempt    int
+		want       time.Duration
+		throttled  bool
+	}{
+		{http.StatusOK, "", 0, 0, false},
+		{http.StatusTooManyRequests, "7", 0, 7 * time.Second, true},
+		{http.StatusServiceUnavailable, "", 2, 4 * time.Second, true},
+		{http.StatusTooManyRequests, "3600", 0, maxRetryDelay, true},
+		{http.StatusTooManyRequests, "Mon, 02 Jan 2006 15:04:05 GMT", 0, 0, true},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		if tt.retryAfter != "" {
+			resp.Header.Set("Retry-After", tt.retryAfter)
+		}
+		got, throttled := rateLimitDelay(resp, tt.attempt)
+		if got != tt.want || throttled != tt.throttled {
+			t.Errorf("rateLimitDelay(%d, %q, %d) = %v, %v; want %v, %v",
+				tt.status, tt.retryAfter, tt.attempt, got, throttled, tt.want, tt.throttled)
+		}
+	}
+}
+
+func TestDownloadFileSendsSourceHeaders(t *testing.T) {
+	var missing atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hotlink protection: only requests from the download page are served
+		if r.Header.Get("Referer") != "https://example.org/downloads" {
+			missing.Add(1)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		http.ServeContent(w, r, "app.iso", time.Time{}, bytes.NewReader(bytes.Repeat([]byte("x"), 4096)))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{Categories: map[string]config.Category{
+		"Apps": {Sources: []config.Source{{Name: "App", URL: server.URL + "/app.iso", Headers: map[string]string{"Referer": "https://example.org/downloads"}}}},
+	}}
+	core.SetHeaders(cfg)
+	defer core.SetHeaders(&config.Config{})
+
+	dest := filepath.Join(t.TempDir(), "app.iso")
+	progressChan := make(chan Progress, 10)
+	go func() {
+		for range progressChan {
+		}
+	}()
+	if err := DownloadFile(server.URL+"/app.iso", dest, 4, progressChan); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if n := missing.Load(); n != 0 {
+		t.Errorf("Expected every request, segments included, to carry the Referer; %d did not", n)
+	}
+}
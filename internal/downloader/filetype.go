@@ -0,0 +1,110 @@
+package downloader
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidFileType is returned when a downloaded file is not the kind of file
+// that was expected, such as an HTML error page saved in place of an EPUB
+var ErrInvalidFileType = errors.New("invalid file")
+
+// ErrUnexpectedExtension is returned when a resolved download's filename does not
+// have one of the extensions its source allows
+var ErrUnexpectedExtension = errors.New("unexpected file type")
+
+var (
+	zipMagic = []byte("PK\x03\x04")
+	zimMagic = []byte{0x5A, 0x49, 0x4D, 0x04}
+)
+
+// ExpectedFileKind returns the kind of file ValidateFileType should check for a
+// download, from its strategy or, failing that, the file extension. An empty
+// result means the file type is not checked.
+func ExpectedFileKind(strategy, path string) string {
+	switch strategy {
+	case "gutenberg":
+		return "epub"
+	case "kiwix", "kiwix_feed":
+		return "zim"
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".epub":
+		return "epub"
+	case ".zim":
+		return "zim"
+	}
+	return ""
+}
+
+// CheckExtension reports ErrUnexpectedExtension unless filename ends in one of
+// allowed, which are compared case-insensitively with or without a leading dot.
+// Multi-part extensions such as "tar.gz" are supported. An empty list allows any file.
+func CheckExtension(filename string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	lower := strings.ToLower(filename)
+	names := make([]string, len(allowed))
+	for i, ext := range allowed {
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+		if strings.HasSuffix(lower, "."+ext) {
+			return nil
+		}
+		names[i] = "." + ext
+	}
+	return fmt.Errorf("%w: %s is not %s", ErrUnexpectedExtension, filename, strings.Join(names, ", "))
+}
+
+// ValidateFileType checks the magic bytes of the file at path against
+// expectedKind ("epub" or "zim"). An empty kind is not checked.
+func ValidateFileType(path, expectedKind string) error {
+	if expectedKind == "" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file for type check: %w", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return fmt.Errorf("%w: file too short to be %s", ErrInvalidFileType, expectedKind)
+	}
+
+	switch expectedKind {
+	case "zim":
+		if !bytes.Equal(header, zimMagic) {
+			return fmt.Errorf("%w: not a ZIM file", ErrInvalidFileType)
+		}
+	case "epub":
+		if !bytes.Equal(header, zipMagic) {
+			return fmt.Errorf("%w: not an EPUB (ZIP) file", ErrInvalidFileType)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to stat file for type check: %w", err)
+		}
+		zr, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return fmt.Errorf("%w: corrupt EPUB archive: %v", ErrInvalidFileType, err)
+		}
+		for _, entry := range zr.File {
+			if entry.Name == "mimetype" {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: EPUB has no mimetype entry", ErrInvalidFileType)
+	default:
+		return fmt.Errorf("unsupported file kind: %s", expectedKind)
+	}
+	return nil
+}
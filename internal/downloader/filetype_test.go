@@ -0,0 +1,113 @@
+package downloader
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateFileType(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	zipWith := func(names ...string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, n := range names {
+			w, err := zw.Create(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte("application/epub+zip"))
+		}
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	html := write("error.epub", []byte("<html><body>502 Bad Gateway</body></html>"))
+	epub := write("book.epub", zipWith("mimetype", "content.opf"))
+	plainZip := write("plain.epub", zipWith("readme.txt"))
+	zim := write("wiki.zim", append([]byte{0x5A, 0x49, 0x4D, 0x04}, make([]byte, 76)...))
+
+	tests := []struct {
+		name    string
+		path    string
+		kind    string
+		wantErr bool
+	}{
+		{"No kind", html, "", false},
+		{"Valid EPUB", epub, "epub", false},
+		{"HTML as EPUB", html, "epub", true},
+		{"ZIP without mimetype", plainZip, "epub", true},
+		{"Valid ZIM", zim, "zim", false},
+		{"HTML as ZIM", html, "zim", true},
+		{"EPUB as ZIM", epub, "zim", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFileType(tt.path, tt.kind)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateFileType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidFileType) {
+				t.Errorf("Expected ErrInvalidFileType, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExpectedFileKind(t *testing.T) {
+	tests := []struct {
+		strategy, path, want string
+	}{
+		{"gutenberg", "/books/title.epub", "epub"},
+		{"kiwix", "/zims/wikipedia", "zim"},
+		{"kiwix_feed", "/zims/wikipedia_en_100_mini", "zim"},
+		{"direct", "/files/Book.EPUB", "epub"},
+		{"", "/files/wiki.zim", "zim"},
+		{"github_release", "/apps/app.zip", ""},
+	}
+	for _, tt := range tests {
+		if got := ExpectedFileKind(tt.strategy, tt.path); got != tt.want {
+			t.Errorf("ExpectedFileKind(%q, %q) = %q, want %q", tt.strategy, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCheckExtension(t *testing.T) {
+	tests := []struct {
+		filename string
+		allowed  []string
+		ok       bool
+	}{
+		{"ubuntu-24.04-desktop-amd64.iso", []string{"iso"}, true},
+		{"UBUNTU.ISO", []string{".iso"}, true},
+		{"tool-1.2-linux.tar.gz", []string{"zip", "tar.gz"}, true},
+		{"tool-1.2-linux.gz", []string{"tar.gz"}, false},
+		{"index.html", []string{"iso"}, false},
+		{"setup.exe", []string{"iso", "img"}, false},
+		{"anything.bin", nil, true},
+	}
+	for _, tt := range tests {
+		err := CheckExtension(tt.filename, tt.allowed)
+		if tt.ok && err != nil {
+			t.Errorf("CheckExtension(%q, %v) = %v, want nil", tt.filename, tt.allowed, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrUnexpectedExtension) {
+			t.Errorf("CheckExtension(%q, %v) = %v, want ErrUnexpectedExtension", tt.filename, tt.allowed, err)
+		}
+	}
+
+	err := CheckExtension("index.html", []string{"iso", ".img"})
+	if want := "unexpected file type: index.html is not .iso, .img"; err == nil || err.Error() != want {
+		t.Errorf("Expected %q, got %v", want, err)
+	}
+}
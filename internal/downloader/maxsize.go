@@ -0,0 +1,41 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dustin/go-humanize"
+)
+
+// ErrTooLarge is returned when a download is bigger than its max_size or
+// general.max_download_size
+var ErrTooLarge = errors.New("exceeds max size")
+
+// CheckMaxSize reports ErrTooLarge if size is known and over limit. A limit of 0
+// allows any size.
+func CheckMaxSize(size, limit int64) error {
+	if limit > 0 && size > limit {
+		return fmt.Errorf("%w: %s is larger than the %s limit", ErrTooLarge,
+			humanize.Bytes(uint64(size)), humanize.Bytes(uint64(limit)))
+	}
+	return nil
+}
+
+// limitWriter fails a write that would take it past limit bytes, stopping a
+// download whose size wasn't known up front
+type limitWriter struct {
+	w       io.Writer
+	written int64
+	limit   int64
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if l.written+int64(len(p)) > l.limit {
+		return 0, fmt.Errorf("%w: stopped after %s, over the %s limit", ErrTooLarge,
+			humanize.Bytes(uint64(l.written+int64(len(p)))), humanize.Bytes(uint64(l.limit)))
+	}
+	n, err := l.w.Write(p)
+	l.written += int64(n)
+	return n, err
+}
@@ -0,0 +1,100 @@
+package downloader
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// remoteFile is what a download learns about the file before fetching it
+type remoteFile struct {
+	Size         int64 // -1 when unknown
+	Ranges       bool  // The server honours byte ranges
+	LastModified string
+}
+
+// headUnsupported holds the hosts that refused a HEAD request, so later downloads
+// from them go straight to the ranged GET probe
+var headUnsupported sync.Map
+
+// probeDownload finds the size of rawURL and whether it can be fetched in
+// segments. It asks with HEAD; when the server refuses HEAD (405, 501 or another
+// error status) it requests the first byte instead, where a 206 with a
+// Content-Range proves range support and carries the total size. If neither
+// tells anything, the file is fetched in one piece and that request reports
+// the actual error.
+func probeDownload(rawURL string) (remoteFile, error) {
+	host := ""
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+	if _, refused := headUnsupported.Load(host); !refused {
+		req, err := http.NewRequest("HEAD", rawURL, nil)
+		if err != nil {
+			return remoteFile{}, err
+		}
+		req.Header.Set("User-Agent", "lamp/1.0")
+		resp, err := downloadClient.Do(req)
+		if err != nil {
+			return remoteFile{}, fmt.Errorf("HEAD request failed: %w", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 400 {
+			return remoteFile{
+				Size:         resp.ContentLength,
+				Ranges:       resp.Header.Get("Accept-Ranges") == "bytes",
+				LastModified: resp.Header.Get("Last-Modified"),
+			}, nil
+		}
+		// A missing file is missing for GET too; only a refused method is worth remembering
+		if resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented {
+			headUnsupported.Store(host, true)
+		}
+	}
+	return probeRange(rawURL), nil
+}
+
+// probeRange requests the first byte of rawURL
+func probeRange(rawURL string) remoteFile {
+	info := remoteFile{Size: -1}
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return info
+	}
+	req.Header.Set("User-Agent", "lamp/1.0")
+	req.Header.Set("Range", "bytes=0-0")
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return info
+	}
+	// Closing without reading drops the connection, which is cheaper than reading a
+	// whole file from a server that ignored the range
+	defer resp.Body.Close()
+
+	info.LastModified = resp.Header.Get("Last-Modified")
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		info.Size = contentRangeTotal(resp.Header.Get("Content-Range"))
+		info.Ranges = info.Size > 0
+	case http.StatusOK:
+		info.Size = resp.ContentLength
+	}
+	return info
+}
+
+// contentRangeTotal returns the complete length from a "bytes 0-0/1234" header,
+// or -1 when it is missing or "*"
+func contentRangeTotal(header string) int64 {
+	_, total, ok := strings.Cut(header, "/")
+	if !ok || !strings.HasPrefix(header, "bytes ") {
+		return -1
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(total), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,47 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"lamp/internal/config"
+	"path/filepath"
+
+	"github.com/dustin/go-humanize"
+)
+
+// ErrQuotaExceeded is returned when a download would take a folder past its max_bytes
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// DirSize sums the sizes of regular files below dir, skipping unreadable entries
+func DirSize(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// CheckQuotas reports ErrQuotaExceeded if adding incoming bytes, after freeing
+// freed bytes of files the download replaces, would exceed any of quotas
+func CheckQuotas(quotas []config.Quota, incoming, freed int64) error {
+	for _, q := range quotas {
+		var used int64
+		for _, dir := range q.Dirs {
+			used += DirSize(dir)
+		}
+		if after := used - freed + incoming; after > q.MaxBytes {
+			return fmt.Errorf("%w: %s would use %s of %s", ErrQuotaExceeded, q.Name,
+				humanize.Bytes(uint64(after)), humanize.Bytes(uint64(q.MaxBytes)))
+		}
+	}
+	return nil
+}
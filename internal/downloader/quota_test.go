@@ -0,0 +1,41 @@
+package downloader
+
+import (
+	"errors"
+	"lamp/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckQuotas(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "old.iso"), make([]byte, 600), 0644)
+	os.WriteFile(filepath.Join(dir, "sub", "other.zip"), make([]byte, 300), 0644)
+
+	if got := DirSize(dir); got != 900 {
+		t.Fatalf("Expected 900 bytes in use, got %d", got)
+	}
+
+	quotas := []config.Quota{{Name: "ISOs", MaxBytes: 1000, Dirs: []string{dir}}}
+	if err := CheckQuotas(quotas, 100, 0); err != nil {
+		t.Errorf("Expected 1000 of 1000 bytes to fit, got %v", err)
+	}
+	err := CheckQuotas(quotas, 500, 0)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Expected the quota to be exceeded, got %v", err)
+	}
+	if want := "storage quota exceeded: ISOs would use 1.4 kB of 1.0 kB"; err.Error() != want {
+		t.Errorf("Expected %q, got %q", want, err.Error())
+	}
+	// Replacing the old version frees its space
+	if err := CheckQuotas(quotas, 500, 600); err != nil {
+		t.Errorf("Expected the download to fit once old.iso is freed, got %v", err)
+	}
+	if err := CheckQuotas(nil, 1<<40, 0); err != nil {
+		t.Errorf("Expected no limit without quotas, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package downloader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// VerifySignature checks a detached OpenPGP signature (.sig or .asc) for the file at path
+// against the public key(s) in keyPath. Any key in keyPath is accepted as a signer.
+func VerifySignature(path, sigPath, keyPath string) error {
+	return VerifyTrustedSignature(path, sigPath, keyPath, "")
+}
+
+// VerifyTrustedSignature behaves like VerifySignature but additionally requires the
+// signing key to match the given fingerprint, so a substituted keyring is rejected.
+// An empty fingerprint disables the check.
+func VerifyTrustedSignature(path, sigPath, keyPath, fingerprint string) error {
+	if keyPath == "" {
+		return fmt.Errorf("no public key configured for signature verification")
+	}
+
+	keyFile, err := os.Open(keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read public key: %w", err)
+	}
+	defer keyFile.Close()
+	keyData, err := dearmor(keyFile, openpgp.PublicKeyType)
+	if err != nil {
+		return fmt.Errorf("failed to read public key: %w", err)
+	}
+	keyring, err := openpgp.ReadKeyRing(keyData)
+	if err != nil {
+		return fmt.Errorf("failed to import public key: %w", err)
+	}
+
+	sigFile, err := os.Open(sigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+	defer sigFile.Close()
+	sigData, err := dearmor(sigFile, openpgp.SignatureType)
+	if err != nil {
+		return fmt.Errorf("signature invalid for %s: %w", path, err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	sig, signer, err := openpgp.VerifyDetachedSignature(keyring, file, sigData, nil)
+	if err != nil {
+		return fmt.Errorf("signature invalid for %s", path)
+	}
+
+	if fingerprint != "" {
+		want := normalizeFingerprint(fingerprint)
+		trusted := false
+		signers := signerFingerprints(signer, sig.IssuerKeyId)
+		for _, fp := range signers {
+			if fp == want {
+				trusted = true
+				break
+			}
+		}
+		if !trusted {
+			return fmt.Errorf("signature made by untrusted key %s (expected %s)", signers[0], want)
+		}
+	}
+
+	return nil
+}
+
+// dearmor returns the binary packets of r, decoding ASCII armor of blockType when
+// r starts with it
+func dearmor(r io.Reader, blockType string) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(64)
+	if !bytes.Contains(head, []byte("-----BEGIN ")) {
+		return br, nil
+	}
+	block, err := armor.Decode(br)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type != blockType {
+		return nil, fmt.Errorf("expected %s, got %s", blockType, block.Type)
+	}
+	return block.Body, nil
+}
+
+// signerFingerprints returns the fingerprints of the key that made a signature:
+// first the (sub)key with keyID, then its primary key
+func signerFingerprints(signer *openpgp.Entity, keyID *uint64) []string {
+	primary := strings.ToUpper(hex.EncodeToString(signer.PrimaryKey.Fingerprint))
+	if keyID != nil && *keyID != signer.PrimaryKey.KeyId {
+		for _, sub := range signer.Subkeys {
+			if sub.PublicKey.KeyId == *keyID {
+				return []string{strings.ToUpper(hex.EncodeToString(sub.PublicKey.Fingerprint)), primary}
+			}
+		}
+	}
+	return []string{primary}
+}
+
+func normalizeFingerprint(fp string) string {
+	fp = strings.ReplaceAll(fp, " ", "")
+	fp = strings.TrimPrefix(strings.ToLower(fp), "0x")
+	return strings.ToUpper(fp)
+}
@@ -0,0 +1,71 @@
+package downloader
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+func TestVerifySignature(t *testing.T) {
+	entity, err := openpgp.NewEntity("LAMP Test", "", "test@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fingerprint := hex.EncodeToString(entity.PrimaryKey.Fingerprint)
+
+	tmpDir := t.TempDir()
+	var key bytes.Buffer
+	w, err := armor.Encode(&key, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	keyPath := filepath.Join(tmpDir, "key.asc")
+	if err := os.WriteFile(keyPath, key.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	contents := []byte("iso contents")
+	filePath := filepath.Join(tmpDir, "image.iso")
+	if err := os.WriteFile(filePath, contents, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Both an armored .asc and a binary .sig are accepted
+	var asc, sig bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&asc, entity, bytes.NewReader(contents), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := openpgp.DetachSign(&sig, entity, bytes.NewReader(contents), nil); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filePath+".asc", asc.Bytes(), 0644)
+	os.WriteFile(filePath+".sig", sig.Bytes(), 0644)
+
+	for _, sigPath := range []string{filePath + ".asc", filePath + ".sig"} {
+		if err := VerifySignature(filePath, sigPath, keyPath); err != nil {
+			t.Errorf("Expected valid signature in %s, got %v", filepath.Base(sigPath), err)
+		}
+		if err := VerifyTrustedSignature(filePath, sigPath, keyPath, "0x"+fingerprint); err != nil {
+			t.Errorf("Expected trusted signature in %s, got %v", filepath.Base(sigPath), err)
+		}
+	}
+	if err := VerifyTrustedSignature(filePath, filePath+".asc", keyPath, strings.Repeat("AB", 20)); err == nil {
+		t.Error("Expected error for untrusted fingerprint")
+	}
+
+	if err := os.WriteFile(filePath, []byte("tampered contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySignature(filePath, filePath+".asc", keyPath); err == nil {
+		t.Error("Expected error for tampered file")
+	}
+}
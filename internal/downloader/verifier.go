@@ -4,18 +4,35 @@ import (
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
 	"os"
 	"strings"
+
+	"golang.org/x/crypto/blake2b"
 )
 
+// ErrChecksumMismatch is returned when a file's hash does not match the expected checksum
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// verifyChunkSize is how much is hashed between progress reports
+const verifyChunkSize = 4 * 1024 * 1024
+
 // VerifyFile checks if the file at path matches the expected checksum.
-// The expectedChecksum can be prefixed with "sha256:", "md5:", or "sha1:".
+// The expectedChecksum can be prefixed with "sha256:", "sha512:", "blake2b:", "md5:", or "sha1:".
 // If no prefix is provided, it attempts to guess based on length, defaulting to sha256.
+// A 128-character checksum is taken as sha512; blake2b needs the prefix.
 func VerifyFile(path string, expectedChecksum string) error {
+	return VerifyFileProgress(path, expectedChecksum, nil)
+}
+
+// VerifyFileProgress is VerifyFile for large files: onProgress, if non-nil, is called
+// with the bytes hashed so far and the file size after every chunk.
+func VerifyFileProgress(path string, expectedChecksum string, onProgress func(done, total int64)) error {
 	if expectedChecksum == "" {
 		return nil
 	}
@@ -39,6 +56,8 @@ func VerifyFile(path string, expectedChecksum string) error {
 			algo = "md5"
 		} else if l == 40 {
 			algo = "sha1"
+		} else if l == 128 {
+			algo = "sha512"
 		}
 	}
 
@@ -52,17 +71,38 @@ func VerifyFile(path string, expectedChecksum string) error {
 		hasher = sha1.New()
 	case "sha256":
 		hasher = sha256.New()
+	case "sha512":
+		hasher = sha512.New()
+	case "blake2b":
+		hasher, _ = blake2b.New512(nil) // Only fails for keys over 64 bytes
 	default:
 		return fmt.Errorf("unsupported hash algorithm: %s", algo)
 	}
 
-	if _, err := io.Copy(hasher, f); err != nil {
-		return fmt.Errorf("failed to calculate hash: %w", err)
+	var total int64
+	if info, err := f.Stat(); err == nil {
+		total = info.Size()
+	}
+	buf := make([]byte, verifyChunkSize)
+	var done int64
+	for {
+		n, err := io.ReadFull(f, buf)
+		hasher.Write(buf[:n])
+		done += int64(n)
+		if onProgress != nil && n > 0 {
+			onProgress(done, total)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to calculate hash: %w", err)
+		}
 	}
 
 	calculated := hex.EncodeToString(hasher.Sum(nil))
 	if !strings.EqualFold(calculated, hashStr) {
-		return fmt.Errorf("checksum mismatch: expected %s, got %s", hashStr, calculated)
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, hashStr, calculated)
 	}
 
 	return nil
@@ -3,10 +3,15 @@ package downloader
 import (
 	"crypto/md5"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
+	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
+
+	"golang.org/x/crypto/blake2b"
 )
 
 func TestVerifyFile(t *testing.T) {
@@ -32,6 +37,13 @@ func TestVerifyFile(t *testing.T) {
 	io.WriteString(hMd5, content)
 	sumMd5 := hex.EncodeToString(hMd5.Sum(nil))
 
+	h512 := sha512.New()
+	io.WriteString(h512, content)
+	sum512 := hex.EncodeToString(h512.Sum(nil))
+
+	sumBlake2b := blake2b.Sum512([]byte(content))
+	sumB2 := hex.EncodeToString(sumBlake2b[:])
+
 	tests := []struct {
 		name     string
 		checksum string
@@ -42,6 +54,12 @@ func TestVerifyFile(t *testing.T) {
 		{"Valid SHA256 explicit", "sha256:" + sum256, false},
 		{"Valid MD5 explicit", "md5:" + sumMd5, false},
 		{"Valid MD5 implicit", sumMd5, false}, // 32 chars
+		{"Valid SHA512 explicit", "sha512:" + sum512, false},
+		{"Valid SHA512 implicit", sum512, false}, // 128 chars
+		{"Valid BLAKE2b explicit", "blake2b:" + sumB2, false},
+		{"BLAKE2b without prefix", sumB2, true}, // Same length as sha512
+		{"Known SHA512 vector", "sha512:309ecc489c12d6eb4cc40f50c902f2b4d0ed77ee511a7c7a9bcd3ca86d4cd86f989dd35bc5ff499670da34255b45b0cfd830e81f605dcf7dc5542e93ae9cd76f", false},
+		{"Known BLAKE2b vector", "blake2b:021ced8799296ceca557832ab941a50b4a11f83478cf141f51f933f653ab9fbcc05a037cddbed06e309bf334942c4e58cdf1a46e237911ccd7fcf9787cbc7fd0", false},
 		{"Invalid Checksum", "badchecksum", true},
 		{"Mismatch Checksum", "sha256:0000000000000000000000000000000000000000000000000000000000000000", true},
 	}
@@ -55,3 +73,39 @@ func TestVerifyFile(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyFileProgress(t *testing.T) {
+	// Just over two chunks, so the last report covers a partial chunk
+	content := make([]byte, 2*verifyChunkSize+100)
+	for i := range content {
+		content[i] = byte(i)
+	}
+	path := filepath.Join(t.TempDir(), "large.iso")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+
+	var reports [][2]int64
+	err := VerifyFileProgress(path, "sha256:"+hex.EncodeToString(sum[:]), func(done, total int64) {
+		reports = append(reports, [2]int64{done, total})
+	})
+	if err != nil {
+		t.Fatalf("VerifyFileProgress failed: %v", err)
+	}
+	size := int64(len(content))
+	want := [][2]int64{{verifyChunkSize, size}, {2 * verifyChunkSize, size}, {size, size}}
+	if len(reports) != len(want) {
+		t.Fatalf("Expected %d progress reports, got %v", len(want), reports)
+	}
+	for i := range want {
+		if reports[i] != want[i] {
+			t.Errorf("Report %d: expected %v, got %v", i, want[i], reports[i])
+		}
+	}
+
+	err = VerifyFileProgress(path, "sha256:"+hex.EncodeToString(make([]byte, 32)), nil)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected a checksum mismatch, got %v", err)
+	}
+}
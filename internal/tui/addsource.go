@@ -0,0 +1,210 @@
+package tui
+
+import (
+	"fmt"
+	"lamp/internal/config"
+	"lamp/internal/core"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sourceForm is the "new source" form opened with n: a strategy is picked from a
+// list, then the name and the strategy's params are typed into one input each
+type sourceForm struct {
+	Category   string
+	strategies []core.StrategyInfo // Strategies offered, without the catalog tabs
+	cursor     int                 // Highlighted strategy
+	chosen     bool                // A strategy was picked and the inputs are shown
+	params     []core.StrategyParam
+	inputs     []textinput.Model // The name, then one per param
+	focus      int
+	err        string // Why the last save was refused
+}
+
+func newSourceForm(category string) *sourceForm {
+	f := &sourceForm{Category: category}
+	for _, s := range core.Strategies {
+		if !s.Catalog {
+			f.strategies = append(f.strategies, s)
+		}
+	}
+	return f
+}
+
+// choose switches to the inputs for the highlighted strategy
+func (f *sourceForm) choose() tea.Cmd {
+	strategy := f.strategies[f.cursor]
+	f.chosen = true
+	f.params = strategy.Params
+	f.inputs = []textinput.Model{newFormInput("Name shown in the list")}
+	for _, p := range strategy.Params {
+		f.inputs = append(f.inputs, newFormInput(p.Hint))
+	}
+	f.focus = 0
+	return f.inputs[0].Focus()
+}
+
+func newFormInput(placeholder string) textinput.Model {
+	in := textinput.New()
+	in.Placeholder = placeholder
+	in.CharLimit = 500
+	in.Width = 50
+	in.Prompt = ""
+	return in
+}
+
+// setFocus moves the cursor to input i
+func (f *sourceForm) setFocus(i int) tea.Cmd {
+	f.inputs[f.focus].Blur()
+	f.focus = (i + len(f.inputs)) % len(f.inputs)
+	return f.inputs[f.focus].Focus()
+}
+
+// paramError is the inline problem with input i, which is checked as it is typed.
+// Missing required values are only reported on save.
+func (f *sourceForm) paramError(i int) string {
+	if i == 0 {
+		return ""
+	}
+	p := f.params[i-1]
+	p.Required = false
+	if err := core.ValidateParam(p, f.inputs[i].Value()); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// source builds the source the form describes, or explains why it can't be saved
+func (f *sourceForm) source() (config.Source, error) {
+	src := config.Source{
+		Name:     strings.TrimSpace(f.inputs[0].Value()),
+		Strategy: f.strategies[f.cursor].Name,
+	}
+	if src.Name == "" {
+		return src, fmt.Errorf("name is required")
+	}
+	for i, p := range f.params {
+		if v := strings.TrimSpace(f.inputs[i+1].Value()); v != "" {
+			if src.Params == nil {
+				src.Params = make(map[string]string)
+			}
+			src.Params[p.Name] = v
+		}
+	}
+	return src, core.ValidateSource(src)
+}
+
+// updateSourceForm handles a key while the form is open
+func (m Model) updateSourceForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f := m.SourceForm
+	if msg.String() == "esc" {
+		m.SourceForm = nil
+		m.State = stateList
+		return m, nil
+	}
+
+	if !f.chosen {
+		switch msg.String() {
+		case "up", "k":
+			if f.cursor > 0 {
+				f.cursor--
+			}
+		case "down", "j":
+			if f.cursor < len(f.strategies)-1 {
+				f.cursor++
+			}
+		case "enter":
+			return m, f.choose()
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "tab", "down":
+		return m, f.setFocus(f.focus + 1)
+	case "shift+tab", "up":
+		return m, f.setFocus(f.focus - 1)
+	case "enter", "ctrl+s":
+		if msg.String() == "enter" && f.focus < len(f.inputs)-1 {
+			return m, f.setFocus(f.focus + 1)
+		}
+		src, err := f.source()
+		if err != nil {
+			f.err = err.Error()
+			return m, nil
+		}
+		m.SourceForm = nil
+		m.State = stateList
+		m.StatusMessage = fmt.Sprintf("Adding %s to %s...", src.Name, f.Category)
+		return m, addSourceCmd(m.ConfigPath, f.Category, src, m.LoadConfig)
+	}
+
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	f.err = ""
+	return m, cmd
+}
+
+// addSourceCmd writes src into category of the config file and reloads the config
+// so it shows up at once
+func addSourceCmd(configPath, category string, src config.Source, load func() (*config.Config, error)) tea.Cmd {
+	return func() tea.Msg {
+		if err := config.AddSource(configPath, category, src); err != nil {
+			return ConfigReloadedMsg{Err: fmt.Errorf("could not add %s: %w", src.Name, err)}
+		}
+		cfg, err := load()
+		return ConfigReloadedMsg{Config: cfg, Err: err, Added: src.Name}
+	}
+}
+
+// sourceFormView renders the open form
+func (m Model) sourceFormView() string {
+	f := m.SourceForm
+	accent := lipgloss.NewStyle().Foreground(m.Theme.Accent).Bold(true)
+	secondary := lipgloss.NewStyle().Foreground(m.Theme.Secondary)
+	errStyle := lipgloss.NewStyle().Foreground(m.Theme.Error)
+
+	var b strings.Builder
+	b.WriteString(accent.Render("New source in "+f.Category) + "\n\n")
+
+	if !f.chosen {
+		b.WriteString("Strategy:\n")
+		for i, s := range f.strategies {
+			line := fmt.Sprintf("  %-16s %s", s.Name, s.Description)
+			if i == f.cursor {
+				line = accent.Render("> " + line[2:])
+			}
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("\n" + secondary.Render("j/k: choose | enter: select | esc: cancel"))
+		return b.String()
+	}
+
+	b.WriteString(secondary.Render("strategy: "+f.strategies[f.cursor].Name) + "\n\n")
+	for i, in := range f.inputs {
+		label := "name *"
+		if i > 0 {
+			label = f.params[i-1].Name
+			if f.params[i-1].Required {
+				label += " *"
+			}
+		}
+		label = fmt.Sprintf("%-18s", label)
+		if i == f.focus {
+			label = accent.Render(label)
+		}
+		line := label + " " + in.View()
+		if e := f.paramError(i); e != "" {
+			line += "  " + errStyle.Render(e)
+		}
+		b.WriteString(line + "\n")
+	}
+	if f.err != "" {
+		b.WriteString("\n" + errStyle.Render("Not saved: "+f.err) + "\n")
+	}
+	b.WriteString("\n" + secondary.Render("* required | tab/shift-tab: move | enter: next, saves on the last field | ctrl+s: save | esc: cancel"))
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"errors"
+	"lamp/internal/config"
+	"lamp/internal/core"
+	"lamp/internal/downloader"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// An HTML error page saved in place of a book or ZIM is reported as an invalid file
+func TestCatalogDownloadRejectsHTML(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body>503 Service Unavailable</body></html>"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	cfg := &config.Config{Categories: map[string]config.Category{
+		"Books": {Path: dir},
+		"Wikis": {Path: dir},
+	}}
+
+	book := core.GutenbergBook{ID: 84, Title: "Frankenstein", Formats: map[string]string{"application/epub+zip": srv.URL + "/84.epub"}}
+	msg := DownloadGutenbergCmd("Books", 0, book, cfg)().(GutenbergDownloadMsg)
+	if !errors.Is(msg.Err, downloader.ErrInvalidFileType) {
+		t.Errorf("Expected ErrInvalidFileType for the book, got %v", msg.Err)
+	}
+	if _, err := os.Stat(core.GetExpectedPath(book, dir, "by_author")); err == nil {
+		t.Error("Expected the HTML page not to be saved as the book")
+	}
+
+	entry := core.KiwixEntry{Name: "wikipedia_en_all", Issued: "2024-01-01T00:00:00Z", Links: []core.KiwixLink{{
+		Rel: "http://opds-spec.org/acquisition/open-access", Type: "application/x-zim", Href: srv.URL + "/wikipedia_en_all.zim.meta4",
+	}}}
+	kmsg := DownloadKiwixCmd("Wikis", 0, entry, cfg)().(KiwixDownloadMsg)
+	if !errors.Is(kmsg.Err, downloader.ErrInvalidFileType) {
+		t.Errorf("Expected ErrInvalidFileType for the ZIM, got %v", kmsg.Err)
+	}
+	if _, err := os.Stat(core.GetExpectedKiwixPath(entry, dir)); err == nil {
+		t.Error("Expected the HTML page not to be saved as the ZIM")
+	}
+}
@@ -7,19 +7,18 @@ import (
 	"lamp/internal/downloader"
 	"math"
 	"net/http"
-	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/filepicker"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 type state int
@@ -28,9 +27,13 @@ const (
 	stateSplash state = iota
 	stateList
 	stateChecking
-	stateDownloading
+	stateDownloading // Following the steps of one source's download
 	stateFolderSelect
 	stateSearch // New state for search input mode
+	stateConfirm
+	statePin       // Typing a version to pin the highlighted source to
+	stateAddSource // Filling in the new source form
+	stateSnippet   // Showing the catalog entry for a library item
 )
 
 type Item struct {
@@ -40,8 +43,24 @@ type Item struct {
 	CurrentVersion string
 	LatestVersion  string
 	LocalMessage   string // Store error or info messages from checking
+	Cached         bool   // The status came from the status cache in offline mode
 	Downloaded     int64
 	Total          int64
+	InFlight       bool              // A download or verification is running for this item
+	DownloadPath   string            // Where the last download was written, which may differ from the target path
+	ServedFrom     string            // Final URL of the last download after redirects, when it differs
+	Threads        int               // Connections the running download uses
+	Verifying      bool              // Downloaded and Total track checksum progress instead of the transfer
+	HookStatus     string            // Outcome of the last post_download command
+	Rate           transferRate      // Speed of the running download
+	Phase          downloadPhase     // Step the running or last download reached
+	PhaseNotes     [phaseDone]string // What each step reported, e.g. the resolved version
+	PhaseFailed    bool              // The last download stopped at Phase
+
+	ResolvedChecksum string   // Checksum the source published for the resolved Source.URL
+	ExtraURLs        []string // Companion files to download next to the resolved Source.URL
+	LocalFilename    string   // Local file the last check read the status from
+	RemoteFilename   string   // File the resolved Source.URL downloads
 }
 
 // GutenbergItem represents a book in the Gutenberg tab
@@ -69,24 +88,46 @@ type DynamicCatalog struct {
 	Category       string          // For Kiwix: selected category filter
 }
 
+// checksum returns the checksum to verify downloads against. A configured
+// checksum wins over one published by the source.
+func (i Item) checksum() string {
+	if i.Source.Checksum != "" {
+		return i.Source.Checksum
+	}
+	return i.ResolvedChecksum
+}
+
 func (i Item) normalizeVer(v string) string {
 	return strings.TrimLeft(v, "v")
 }
 
+// defaultStatusWidth is the progress bar width used before the table has been sized
+const defaultStatusWidth = 20
+
 func (i Item) ToRow() table.Row {
+	return i.ToRowWithWidth(defaultStatusWidth)
+}
+
+// ToRowWithWidth renders the item with a progress bar filling a STATUS column
+// statusWidth cells wide
+func (i Item) ToRowWithWidth(statusWidth int) table.Row {
 	status := string(i.LocalStatus)
 
 	// Check if this looks like a download status and we have progress info
 	// The status string from update.go often starts with "Downloading..." or has "Enough space"
 	// But we can rely on Total > 0 and Downloaded to be sure we are tracking progress
-	if i.Total > 0 {
+	if i.Verifying && i.Total > 0 {
+		status = fmt.Sprintf("Verifying... %.0f%%", float64(i.Downloaded)/float64(i.Total)*100)
+	} else if i.Total > 0 {
 		percent := float64(i.Downloaded) / float64(i.Total)
-		// Use a fixed width for the bar within the column, e.g. 20 chars
-		// Ideally this would be dynamic based on column width, but ToRow doesn't know context width easily
-		// We'll trust the renderer to truncate or we use a safe default
-		status = progressBar(percent, 20)
+		status = progressBar(percent, statusWidth)
 	} else if i.LocalStatus == core.StatusError {
 		status = "Error: " + i.LocalMessage
+	} else if i.Cached {
+		status += " (cached)"
+	}
+	if sym := i.LocalStatus.Symbol(); sym != "" && i.Total <= 0 {
+		status = sym + " " + status
 	}
 
 	current := i.normalizeVer(i.CurrentVersion)
@@ -125,6 +166,49 @@ type Model struct {
 	SearchInput     textinput.Model            // Shared text input for search
 	SearchActive    bool                       // Whether search mode is active
 	FilterQuery     string                     // Current filter query for static tabs
+	StatusFilter    statusFilter               // Restricts static tabs to sources in some statuses
+
+	LoadConfig    func() (*config.Config, error) // Re-reads the configuration for live reload (optional)
+	ConfigPath    string                         // Config file the new source form writes to (optional)
+	Version       string                         // Running LAMP version, compared with the latest release at startup (optional)
+	SelfUpdate    *core.SelfUpdate               // Newer LAMP release, once found
+	StatusMessage string                         // Transient message shown in the footer
+	Theme         *Theme                         // Palette in use
+	baseTheme     *Theme                         // Palette from the config, restored when leaving the mono theme
+
+	PathOverrides map[string]string // Per-session target directories chosen with the folder picker, by overrideKey
+	folderTarget  string            // overrideKey of the item the open folder picker is choosing a directory for
+	PinOverrides  map[string]string // Per-session pinned versions by overrideKey; an empty version clears a configured pin
+	PinInput      textinput.Model   // Text input for the pinned version
+	pinTarget     QueueItem         // Item the open pin input is for
+	SourceForm    *sourceForm       // Open new source form
+	Snippet       *snippetView      // Catalog entry shown for a library item
+	detailTarget  QueueItem         // Item the download detail view is open for
+
+	Planning    bool             // A "download all" size check is running
+	PendingPlan *DownloadPlanMsg // Sized "download all" awaiting confirmation
+
+	batch     map[QueueItem]bool // Items of the running all-categories download
+	batchDone int                // Items of batch that have finished
+
+	VerifyingAll bool // A "verify all" run is re-hashing downloaded files
+
+	SessionBytes int64 // Bytes downloaded since LAMP started, for the status bar
+	statsTicking bool  // A statsTickMsg is pending
+
+	HideForeign  bool    // Hide sources built for another OS/arch from static tabs
+	ShowDisabled bool    // Include categories and sources with enabled: false for this session
+	rowIndex     [][]int // TableData index of each visible table row, per tab
+
+	layout *viewLayout // Screen positions recorded by View for mouse hit-testing
+}
+
+// viewLayout records where the list view drew its tabs and table. View has a
+// value receiver, so it is shared by pointer across model copies.
+type viewLayout struct {
+	tabY      int   // Screen row of the tab labels
+	tabStarts []int // Screen column where each tab starts, plus the end of the last tab
+	tableTop  int   // Screen row of the table's first visible data row
 }
 
 func progressBar(percent float64, width int) string {
@@ -140,8 +224,9 @@ func progressBar(percent float64, width int) string {
 	}
 
 	barWidth := width - 8 // Reserve space for percentage text " 100.0%"
-	if barWidth < 5 {
-		barWidth = 5
+	if barWidth < 3 {
+		// Too narrow for a useful bar
+		return fmt.Sprintf("%.0f%%", percent*100)
 	}
 
 	full := int(math.Round(percent * float64(barWidth)))
@@ -156,11 +241,29 @@ func progressBar(percent float64, width int) string {
 }
 
 func NewModel(cfg *config.Config, warnings []string) Model {
+	return newModel(cfg, warnings, false)
+}
+
+// newModel builds the model, including categories and sources that set
+// enabled: false only when showDisabled is set
+func newModel(cfg *config.Config, warnings []string, showDisabled bool) Model {
 	tabs := make([]string, 0, len(cfg.Categories))
-	for name := range cfg.Categories {
-		tabs = append(tabs, name)
+	for name, cat := range cfg.Categories {
+		if showDisabled || cat.IsEnabled() {
+			tabs = append(tabs, name)
+		}
 	}
 	sort.Strings(tabs)
+	if len(tabs) == 0 && !showDisabled && len(cfg.Categories) > 0 {
+		// Every category is disabled; show them rather than an empty screen
+		return newModel(cfg, warnings, true)
+	}
+
+	theme, err := LoadTheme(cfg.General.Theme)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("%v; using the earthy theme", err))
+		theme = earthyTheme
+	}
 
 	columns := []table.Column{
 		{Title: "NAME", Width: 40},
@@ -203,18 +306,7 @@ func NewModel(cfg *config.Config, warnings []string) Model {
 				table.WithHeight(10),
 			)
 
-			s := table.DefaultStyles()
-			s.Header = s.Header.
-				BorderStyle(lipgloss.NormalBorder()).
-				BorderForeground(lipgloss.Color("240")).
-				BorderBottom(true).
-				Bold(false).
-				Foreground(lipgloss.AdaptiveColor{Light: "#A0522D", Dark: "#CD853F"})
-			s.Selected = s.Selected.
-				Foreground(lipgloss.AdaptiveColor{Light: "#2D5A27", Dark: "#78B159"}).
-				Background(lipgloss.AdaptiveColor{Light: "#E1C699", Dark: "#2D5A27"}).
-				Bold(true)
-			t.SetStyles(s)
+			t.SetStyles(theme.tableStyles())
 
 			tables[i] = t
 			tableData[i] = []Item{} // Empty for Gutenberg (uses DynamicCatalogs)
@@ -244,18 +336,7 @@ func NewModel(cfg *config.Config, warnings []string) Model {
 				table.WithHeight(10),
 			)
 
-			s := table.DefaultStyles()
-			s.Header = s.Header.
-				BorderStyle(lipgloss.NormalBorder()).
-				BorderForeground(lipgloss.Color("240")).
-				BorderBottom(true).
-				Bold(false).
-				Foreground(lipgloss.AdaptiveColor{Light: "#A0522D", Dark: "#CD853F"})
-			s.Selected = s.Selected.
-				Foreground(lipgloss.AdaptiveColor{Light: "#2D5A27", Dark: "#78B159"}).
-				Background(lipgloss.AdaptiveColor{Light: "#E1C699", Dark: "#2D5A27"}).
-				Bold(true)
-			t.SetStyles(s)
+			t.SetStyles(theme.tableStyles())
 
 			tables[i] = t
 			tableData[i] = []Item{} // Empty for Kiwix (uses DynamicCatalogs)
@@ -271,9 +352,11 @@ func NewModel(cfg *config.Config, warnings []string) Model {
 		} else {
 			// Standard category setup
 			cat := cfg.Categories[catName]
-			var rows []table.Row
 			var items []Item
 			for _, src := range cat.Sources {
+				if !showDisabled && !src.IsEnabled() {
+					continue
+				}
 				path := cfg.GetTargetPath(catName, src)
 				res := core.ScanLocalStatus(src, path)
 
@@ -285,29 +368,18 @@ func NewModel(cfg *config.Config, warnings []string) Model {
 					LatestVersion:  "---",
 				}
 				items = append(items, it)
-				rows = append(rows, it.ToRow())
 			}
 			tableData[i] = items
 
+			// Rows are filled in by syncTableRows once the model exists
 			t := table.New(
 				table.WithColumns(columns),
-				table.WithRows(rows),
+				table.WithRows([]table.Row{}),
 				table.WithFocused(true),
 				table.WithHeight(10),
 			)
 
-			s := table.DefaultStyles()
-			s.Header = s.Header.
-				BorderStyle(lipgloss.NormalBorder()).
-				BorderForeground(lipgloss.Color("240")).
-				BorderBottom(true).
-				Bold(false).
-				Foreground(lipgloss.AdaptiveColor{Light: "#A0522D", Dark: "#CD853F"})
-			s.Selected = s.Selected.
-				Foreground(lipgloss.AdaptiveColor{Light: "#2D5A27", Dark: "#78B159"}).
-				Background(lipgloss.AdaptiveColor{Light: "#E1C699", Dark: "#2D5A27"}).
-				Bold(true)
-			t.SetStyles(s)
+			t.SetStyles(theme.tableStyles())
 
 			tables[i] = t
 		}
@@ -316,6 +388,7 @@ func NewModel(cfg *config.Config, warnings []string) Model {
 	fp := filepicker.New()
 	fp.DirAllowed = true
 	fp.FileAllowed = false
+	fp.Styles = theme.filepickerStyles()
 	fp.CurrentDirectory, _ = os.Getwd()
 
 	// Initialize search input
@@ -324,12 +397,17 @@ func NewModel(cfg *config.Config, warnings []string) Model {
 	ti.CharLimit = 100
 	ti.Width = 40
 
+	pin := textinput.New()
+	pin.Placeholder = "Version or tag, empty to unpin"
+	pin.CharLimit = 100
+	pin.Width = 30
+
 	initialState := stateList
 	if len(warnings) > 0 {
 		initialState = stateSplash
 	}
 
-	return Model{
+	m := Model{
 		Config:          cfg,
 		State:           initialState,
 		Tabs:            tabs,
@@ -340,11 +418,86 @@ func NewModel(cfg *config.Config, warnings []string) Model {
 		Warnings:        warnings,
 		DynamicCatalogs: dynamicCatalogs,
 		SearchInput:     ti,
+		PinInput:        pin,
 		SearchActive:    false,
+		HideForeign:     cfg.General.HideForeign,
+		ShowDisabled:    showDisabled,
+		rowIndex:        make([][]int, len(tabs)),
+		layout:          &viewLayout{},
+		Theme:           theme,
+		baseTheme:       theme,
 	}
+	for i := range tabs {
+		if !m.isDynamicTab(i) {
+			m.syncTableRows(i)
+		}
+	}
+	return m
+}
+
+// selectedIndex returns the TableData index of the highlighted row in the
+// active tab, or -1 if there is none
+func (m Model) selectedIndex() int {
+	cursor := m.Tables[m.ActiveTab].Cursor()
+	if m.ActiveTab >= len(m.rowIndex) || cursor < 0 || cursor >= len(m.rowIndex[m.ActiveTab]) {
+		return -1
+	}
+	return m.rowIndex[m.ActiveTab][cursor]
+}
+
+// statusFilter selects which statuses static tabs show
+type statusFilter int
+
+const (
+	filterNone      statusFilter = iota
+	filterAttention              // Newer version available or not downloaded
+	filterErrors                 // Failed checks
+)
+
+func (f statusFilter) String() string {
+	switch f {
+	case filterAttention:
+		return "out of date or missing"
+	case filterErrors:
+		return "errors"
+	}
+	return "all"
+}
+
+// matches reports whether an item passes the filter. Items with a download or
+// verification running always pass so they don't vanish mid-transfer.
+func (f statusFilter) matches(it Item) bool {
+	switch f {
+	case filterAttention:
+		return it.InFlight || it.LocalStatus == core.StatusNewer || it.LocalStatus == core.StatusNotFound || it.LocalStatus == core.StatusIncomplete
+	case filterErrors:
+		return it.InFlight || it.LocalStatus == core.StatusError
+	}
+	return true
+}
+
+// visible reports whether a source is shown given the foreign-platform toggle
+func (m Model) visible(it Item) bool {
+	return !m.HideForeign || !it.Source.IsForeign()
 }
 
 // isDynamicTab returns true if the tab uses a dynamic catalog (Gutenberg or Kiwix)
+// overrideKey identifies src in category for the per-session overrides. Unlike a
+// position it survives re-sorting and reloads, for as long as Source.Key does.
+func overrideKey(category string, src config.Source) string {
+	return category + "/" + src.Key()
+}
+
+// targetPath returns where a source should be downloaded, honoring any
+// per-session directory override for it
+func (m Model) targetPath(category string, src config.Source) string {
+	target := m.Config.GetTargetPath(category, src)
+	if dir, ok := m.PathOverrides[overrideKey(category, src)]; ok {
+		return filepath.Join(dir, filepath.Base(target))
+	}
+	return target
+}
+
 func (m Model) isDynamicTab(tabIdx int) bool {
 	if tabIdx < 0 || tabIdx >= len(m.Tabs) {
 		return false
@@ -418,31 +571,21 @@ func (m *Model) resizeTableColumns(width int) {
 				{Title: "LATEST", Width: int(float64(usableWidth) * (0.12))},
 			}
 			m.Tables[i].SetColumns(columns)
+			// Redraw rows so progress bars fill the new STATUS width
+			m.syncTableRows(i)
 		}
 	}
 }
 
 func (m Model) Init() tea.Cmd {
 	var cmds []tea.Cmd
+	if m.Version != "" && !core.IsOffline() {
+		cmds = append(cmds, selfUpdateCmd(m.Version, m.Config.General.GitHubToken))
+	}
 	// Start fetching for all dynamic catalogs
-	for name, cat := range m.Config.Categories {
-		for _, src := range cat.Sources {
-			if src.Strategy == "gutenberg" {
-				lang := cat.Language
-				if lang == "" {
-					lang = "en"
-				}
-				cmds = append(cmds, FetchGutenbergCmd(name, lang, m.Config))
-				break
-			} else if src.Strategy == "kiwix" {
-				lang := cat.Language
-				if lang == "" {
-					lang = "eng"
-				}
-				category := src.Params["category"]
-				cmds = append(cmds, FetchKiwixCmd(name, lang, category, m.Config))
-				break
-			}
+	for _, name := range m.Tabs {
+		if cmd := m.fetchCatalogCmd(name, false); cmd != nil {
+			cmds = append(cmds, cmd)
 		}
 	}
 	if len(cmds) > 0 {
@@ -451,6 +594,42 @@ func (m Model) Init() tea.Cmd {
 	return nil
 }
 
+// SelfUpdateMsg carries the result of checking for a newer LAMP release
+type SelfUpdateMsg struct {
+	Update core.SelfUpdate
+	Err    error
+}
+
+func selfUpdateCmd(version, githubToken string) tea.Cmd {
+	return func() tea.Msg {
+		update, err := core.NewChecker(nil, githubToken).CheckSelfUpdate(version)
+		return SelfUpdateMsg{Update: update, Err: err}
+	}
+}
+
+// fetchCatalogCmd returns the command that loads the default listing of a dynamic catalog tab,
+// or nil if the category is not a dynamic catalog. refresh skips the catalog cache.
+func (m Model) fetchCatalogCmd(name string, refresh bool) tea.Cmd {
+	cat := m.Config.Categories[name]
+	for _, src := range cat.Sources {
+		if src.Strategy == "gutenberg" {
+			lang := cat.Language
+			if lang == "" {
+				lang = "en"
+			}
+			return FetchGutenbergCmd(name, lang, m.Config, refresh)
+		} else if src.Strategy == "kiwix" {
+			lang := cat.Language
+			if lang == "" {
+				lang = "eng"
+			}
+			category := src.Params["category"]
+			return FetchKiwixCmd(name, lang, category, m.Config, refresh)
+		}
+	}
+	return nil
+}
+
 // DynamicCatalogLoadedMsg is sent when dynamic catalog data is fetched
 type DynamicCatalogLoadedMsg struct {
 	TabName string
@@ -466,10 +645,14 @@ type DynamicCatalogSearchMsg struct {
 	Err     error
 }
 
-// FetchGutenbergCmd fetches top 100 books from Gutendex
-func FetchGutenbergCmd(tabName string, language string, cfg *config.Config) tea.Cmd {
+// FetchGutenbergCmd fetches top 100 books from Gutendex, bypassing the cache when refresh is set
+func FetchGutenbergCmd(tabName string, language string, cfg *config.Config, refresh bool) tea.Cmd {
 	return func() tea.Msg {
-		books, err := core.FetchTopBooks(language, 100)
+		fetch := core.FetchTopBooks
+		if refresh {
+			fetch = core.RefreshTopBooks
+		}
+		books, err := fetch(core.APIContext(), language, 100)
 		if err != nil {
 			return DynamicCatalogLoadedMsg{
 				TabName: tabName,
@@ -581,10 +764,14 @@ type KiwixCatalogSearchMsg struct {
 	Err     error
 }
 
-// FetchKiwixCmd fetches entries from the Kiwix library
-func FetchKiwixCmd(tabName string, language string, category string, cfg *config.Config) tea.Cmd {
+// FetchKiwixCmd fetches entries from the Kiwix library, bypassing the cache when refresh is set
+func FetchKiwixCmd(tabName string, language string, category string, cfg *config.Config, refresh bool) tea.Cmd {
 	return func() tea.Msg {
-		entries, err := core.FetchKiwixEntries(language, category, 100)
+		fetch := core.FetchKiwixEntries
+		if refresh {
+			fetch = core.RefreshKiwixEntries
+		}
+		entries, err := fetch(language, category, 100)
 		if err != nil {
 			return KiwixCatalogLoadedMsg{
 				TabName: tabName,
@@ -681,6 +868,7 @@ type DownloadMsg struct {
 	Category string
 	Index    int
 	Err      error
+	Skipped  bool // The local copy was already current, so nothing was downloaded
 }
 
 type StartDownloadMsg struct {
@@ -703,7 +891,46 @@ func DownloadCmdBatch(index int, category, downloadURL, dest string, threads int
 	}
 }
 
-func DownloadCmd(index int, category string, src config.Source, dest string, version string, githubToken string, threads int) tea.Cmd {
+// quotaPlan is what DownloadCmd needs to enforce storage quotas
+type quotaPlan struct {
+	Quotas      []config.Quota
+	EvictOldest bool   // storage.evict_oldest
+	Superseded  string // Local file of an older version the download replaces, if any
+}
+
+// quotaPlan collects the quotas for a download of it
+func (m Model) quotaPlan(it Item) quotaPlan {
+	plan := quotaPlan{Quotas: m.Config.QuotasFor(it.Category), EvictOldest: m.Config.Storage.EvictOldest}
+	if it.LocalStatus == core.StatusNewer {
+		plan.Superseded = it.LocalFilename
+	}
+	return plan
+}
+
+// check refuses a download of size bytes to dest that would go past a quota. With
+// evict_oldest, the superseded version counts as freed when that is needed to fit,
+// and is returned to be deleted once the download succeeds.
+func (p quotaPlan) check(dest string, size int64) (evict string, err error) {
+	if len(p.Quotas) == 0 {
+		return "", nil
+	}
+	size = max(size, 0)
+	err = downloader.CheckQuotas(p.Quotas, size, 0)
+	if err == nil || !p.EvictOldest || p.Superseded == "" {
+		return "", err
+	}
+	old := filepath.Join(filepath.Dir(dest), p.Superseded)
+	info, statErr := os.Stat(old)
+	if old == dest || statErr != nil {
+		return "", err
+	}
+	if err := downloader.CheckQuotas(p.Quotas, size, info.Size()); err != nil {
+		return "", err
+	}
+	return old, nil
+}
+
+func DownloadCmd(index int, category string, src config.Source, dest string, version string, checksum string, extraURLs []string, githubToken string, threads int, maxSize int64, backup bool, quota quotaPlan) tea.Cmd {
 	return func() tea.Msg {
 		progressChan := make(chan downloader.Progress, 10)
 
@@ -711,7 +938,7 @@ func DownloadCmd(index int, category string, src config.Source, dest string, ver
 			downloadURL := src.URL
 			if downloadURL == "" {
 				// 0. Auto-resolve
-				progressChan <- downloader.Progress{Downloaded: 0, Total: -2} // Special indicator for "Resolving..."
+				progressChan <- downloader.Progress{Phase: downloader.PhaseResolving}
 				checker := core.NewChecker(nil, githubToken)
 				res := checker.CheckVersion(src, dest)
 				if res.ResolvedURL == "" {
@@ -724,33 +951,60 @@ func DownloadCmd(index int, category string, src config.Source, dest string, ver
 				if version == "" {
 					version = res.Latest
 				}
+				if checksum == "" {
+					checksum = res.Checksum
+				}
+				extraURLs = res.ExtraURLs
+				if res.Status == core.StatusNewer {
+					quota.Superseded = res.LocalFilename
+				}
 				// Feedback the resolved info to TUI
 				progressChan <- downloader.Progress{
-					Downloaded:  1,
-					Total:       -2,
+					Phase:       downloader.PhaseResolved,
 					Status:      string(res.Status),
 					Current:     res.Current,
 					Latest:      res.Latest,
 					ResolvedURL: res.ResolvedURL,
+					Checksum:    res.Checksum,
+					ExtraURLs:   res.ExtraURLs,
 				}
 			}
 
-			// Apply Standardization if requested
-			if src.StandardizeName {
-				// Use path.Ext for URL to handle forward slashes correctly on all platforms
-				ext := ""
-				if u, err := url.Parse(downloadURL); err == nil {
-					ext = path.Ext(u.Path)
-				} else {
-					ext = filepath.Ext(downloadURL)
+			if err := core.ValidateDownloadURL(downloadURL); err != nil {
+				progressChan <- downloader.Progress{Error: err}
+				close(progressChan)
+				return
+			}
+
+			// Probe the URL once: the response gives the final URL after redirects,
+			// the size for the space check and Last-Modified for direct URLs
+			probe := &http.Client{Timeout: 30 * time.Second, CheckRedirect: core.ValidateRedirect, Transport: core.AuthTransport(nil)}
+			resp, err := probe.Head(downloadURL)
+			if err != nil {
+				resp = nil // Not fatal, we'll try to download anyway or it will fail later
+			} else {
+				defer resp.Body.Close()
+				// An error page says nothing about the file, e.g. when signed storage
+				// behind a GitHub asset URL only accepts GET; the download probes again
+				if resp.StatusCode >= 400 {
+					resp = nil
 				}
+			}
+			remoteFilename := core.RemoteFilename(downloadURL, resp)
+			// Catch an error page or the wrong asset before any bytes are written
+			if err := downloader.CheckExtension(remoteFilename, src.ExpectExt); err != nil {
+				progressChan <- downloader.Progress{Error: err}
+				close(progressChan)
+				return
+			}
 
-				newName := src.GetStandardizedFilename(version, ext)
+			// Apply Standardization if requested
+			if src.StandardizeName {
+				newName := src.GetStandardizedFilename(version, path.Ext(remoteFilename))
 				// Ensure we are in the right directory (category path)
 				dest = filepath.Join(filepath.Dir(dest), newName)
 			} else if downloadURL != "" && (filepath.Base(dest) == src.Name || strings.Contains(filepath.Base(dest), "[")) {
 				// Fallback generic name fix - sanitize the filename from URL
-				remoteFilename := filepath.Base(downloadURL)
 				sanitized, err := core.SanitizeFilename(remoteFilename)
 				if err != nil {
 					progressChan <- downloader.Progress{Error: fmt.Errorf("invalid filename from URL: %w", err)}
@@ -760,15 +1014,26 @@ func DownloadCmd(index int, category string, src config.Source, dest string, ver
 				dest = filepath.Join(filepath.Dir(dest), sanitized)
 			}
 
+			// Report where the bytes will actually come from when the URL redirects
+			servedFrom := ""
+			if resp != nil && resp.Request.URL.String() != downloadURL {
+				servedFrom = resp.Request.URL.String()
+			}
+
 			// 1. Log space check
-			progressChan <- downloader.Progress{Downloaded: 0, Total: -1} // Custom indicator for "Checking space"
+			progressChan <- downloader.Progress{Phase: downloader.PhaseSpaceCheck, Dest: dest, ResolvedURL: servedFrom}
+
+			if resp != nil {
+				// Direct URLs keep their filename across versions, so the download replaces the local copy
+				if src.Strategy == "" || src.Strategy == "direct" {
+					// Without a version, Last-Modified decides whether to re-download
+					if src.Params["version_from_header"] == "" && localIsCurrent(dest, resp.Header.Get("Last-Modified")) {
+						progressChan <- downloader.Progress{Phase: downloader.PhaseSkipped}
+						close(progressChan)
+						return
+					}
+				}
 
-			// 2. Perform HEAD to get size
-			resp, err := http.Head(downloadURL)
-			if err != nil {
-				// Not fatal, we'll try to download anyway or it will fail later
-			} else {
-				defer resp.Body.Close()
 				size := resp.ContentLength
 				if size > 0 {
 					// 3. Check space
@@ -776,16 +1041,42 @@ func DownloadCmd(index int, category string, src config.Source, dest string, ver
 					if err != nil {
 						// Error checking space
 					} else if !ok {
-						progressChan <- downloader.Progress{Downloaded: -1, Total: avail} // Custom indicator for "Not enough space"
+						progressChan <- downloader.Progress{Phase: downloader.PhaseNoSpace, Available: avail}
 						close(progressChan)
 						return
 					} else {
-						progressChan <- downloader.Progress{Downloaded: 1, Total: -1} // Custom indicator for "Space OK"
+						progressChan <- downloader.Progress{Phase: downloader.PhaseSpaceOK}
 					}
 				}
 			}
 
-			downloader.DownloadFile(downloadURL, dest, threads, progressChan)
+			size := int64(0)
+			if resp != nil {
+				size = resp.ContentLength
+			}
+			evict, err := quota.check(dest, size)
+			if err == nil {
+				// Checked before the companions so nothing is fetched for a file that would be refused
+				err = downloader.CheckMaxSize(size, maxSize)
+			}
+			if err != nil {
+				progressChan <- downloader.Progress{Error: err}
+				close(progressChan)
+				return
+			}
+
+			// Companions go first so the primary file, which decides the status, lands last
+			if err := downloader.DownloadCompanions(extraURLs, filepath.Dir(dest), threads, progressChan); err != nil {
+				progressChan <- downloader.Progress{Error: err}
+				close(progressChan)
+				return
+			}
+
+			// Only direct URLs download over the previous copy
+			backup = backup && (src.Strategy == "" || src.Strategy == "direct")
+			if err := downloader.DownloadFileVerified(downloadURL, dest, checksum, threads, maxSize, backup, progressChan); err == nil && evict != "" {
+				os.Remove(evict)
+			}
 		}()
 
 		return StartDownloadMsg{
@@ -796,6 +1087,20 @@ func DownloadCmd(index int, category string, src config.Source, dest string, ver
 	}
 }
 
+// localIsCurrent reports whether the file at dest exists and is not older than
+// the remote Last-Modified header value
+func localIsCurrent(dest, lastModified string) bool {
+	info, err := os.Stat(dest)
+	if err != nil || lastModified == "" {
+		return false
+	}
+	remote, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !remote.After(info.ModTime())
+}
+
 func WaitForProgress(index int, category string, progressChan chan downloader.Progress) tea.Cmd {
 	return func() tea.Msg {
 		p, ok := <-progressChan
@@ -805,63 +1110,223 @@ func WaitForProgress(index int, category string, progressChan chan downloader.Pr
 		if p.Error != nil {
 			return DownloadMsg{Category: category, Index: index, Err: p.Error}
 		}
+		if p.Phase == downloader.PhaseSkipped {
+			// Sent by the Last-Modified check in DownloadCmd, which closes the channel next
+			return DownloadMsg{Category: category, Index: index, Skipped: true}
+		}
 		return ProgressUpdateMsg{Category: category, Index: index, Progress: p, ProgressChan: progressChan}
 	}
 }
 
 type VerifyMsg struct {
+	Category     string
+	Index        int
+	Path         string
+	Err          error
+	SignatureErr error
+}
+
+// VerifyAllProgressMsg reports how many files a "verify all" run has checked
+type VerifyAllProgressMsg struct {
+	Done, Total int
+	updates     chan tea.Msg
+}
+
+// VerifyAllMsg carries the results of a "verify all" run, in the order of Items
+type VerifyAllMsg struct {
+	Items   []QueueItem
+	Results []downloader.ArchiveResult
+}
+
+// verifyAllCmd re-hashes files using up to workers at a time. It reports progress
+// with VerifyAllProgressMsg, to be followed by waitForVerifyAll, and ends with VerifyAllMsg.
+func verifyAllCmd(items []QueueItem, files []downloader.ArchiveFile, workers int) tea.Cmd {
+	return func() tea.Msg {
+		updates := make(chan tea.Msg, 1)
+		go func() {
+			results := downloader.VerifyArchive(files, workers, func(done, total int) {
+				// Progress is only for display, so drop updates the TUI hasn't caught up with
+				select {
+				case updates <- VerifyAllProgressMsg{Done: done, Total: total, updates: updates}:
+				default:
+				}
+			})
+			updates <- VerifyAllMsg{Items: items, Results: results}
+		}()
+		return <-updates
+	}
+}
+
+// waitForVerifyAll waits for the next message of a running "verify all"
+func waitForVerifyAll(updates chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+// VerifyCmd checks the downloaded file against checksum and, if configured, its detached signature
+func VerifyCmd(index int, category, path, checksum string, src config.Source) tea.Cmd {
+	return func() tea.Msg {
+		if err := downloader.VerifyFile(path, checksum); err != nil {
+			return VerifyMsg{Category: category, Index: index, Path: path, Err: err}
+		}
+
+		sigURL := src.SignatureURL(src.URL)
+		if sigURL == "" {
+			if src.Signature != "" {
+				return VerifyMsg{Category: category, Index: index, Path: path, SignatureErr: fmt.Errorf("could not resolve signature URL")}
+			}
+			return VerifyMsg{Category: category, Index: index, Path: path}
+		}
+
+		sigPath := path + filepath.Ext(sigURL)
+		progressChan := make(chan downloader.Progress, 10)
+		go downloader.DownloadFile(sigURL, sigPath, 1, progressChan)
+		// A failure arrives on the channel before it is closed
+		var dlErr error
+		for p := range progressChan {
+			if p.Error != nil {
+				dlErr = p.Error
+			}
+		}
+		if dlErr != nil {
+			return VerifyMsg{Category: category, Index: index, Path: path, SignatureErr: fmt.Errorf("failed to fetch signature: %w", dlErr)}
+		}
+
+		err := downloader.VerifyTrustedSignature(path, sigPath, src.SignatureKey, src.SignatureFpr)
+		return VerifyMsg{Category: category, Index: index, Path: path, SignatureErr: err}
+	}
+}
+
+// PostDownloadMsg reports the outcome of a source's post_download command
+type PostDownloadMsg struct {
 	Category string
 	Index    int
+	Result   core.HookResult
 	Err      error
 }
 
-func VerifyCmd(index int, category, path, checksum string) tea.Cmd {
+// PostDownloadCmd runs the post_download command of src for the finished download at path
+func PostDownloadCmd(index int, category string, src config.Source, path, version string) tea.Cmd {
 	return func() tea.Msg {
-		err := downloader.VerifyFile(path, checksum)
-		return VerifyMsg{Category: category, Index: index, Err: err}
+		result, err := core.RunPostDownload(src, category, path, version)
+		return PostDownloadMsg{Category: category, Index: index, Result: result, Err: err}
 	}
 }
 
+// postDownloadCmd starts the item's post_download command, if it has one
+func (it *Item) postDownloadCmd(index int, path string) tea.Cmd {
+	if it.Source.PostDownload == "" {
+		return nil
+	}
+	it.HookStatus = "running..."
+	return PostDownloadCmd(index, it.Category, it.Source, path, it.LatestVersion)
+}
+
+// recordHistoryCmd appends the item's finished download at path to the download
+// history; signature reports whether its detached signature was verified
+func (it *Item) recordHistoryCmd(path string, signature bool) tea.Cmd {
+	entry := core.HistoryEntry{
+		Time:      time.Now(),
+		Category:  it.Category,
+		Name:      it.Source.Name,
+		ID:        it.Source.ID,
+		Key:       it.Source.Key(),
+		Version:   it.LatestVersion,
+		URL:       it.Source.URL,
+		Path:      path,
+		Checksum:  core.HistoryUnverified,
+		Signature: signature,
+	}
+	if it.checksum() != "" {
+		entry.Checksum = core.HistoryVerified
+	}
+	return func() tea.Msg {
+		if info, err := os.Stat(path); err == nil {
+			entry.Size = info.Size()
+		}
+		// The history is an audit trail; failing to write it must not fail the download
+		core.RecordDownload(entry)
+		return nil
+	}
+}
+
+// PruneMsg reports the older versions deleted after a download because of keep_versions
+type PruneMsg struct {
+	Name    string
+	Removed []string
+	Err     error
+}
+
+// pruneVersionsCmd deletes versions of the item older than its keep_versions
+// newest, counting the download that just finished at path
+func (it *Item) pruneVersionsCmd(path string) tea.Cmd {
+	if it.Source.KeepVersions < 1 {
+		return nil
+	}
+	src, keep := it.Source, it.Source.KeepVersions
+	return func() tea.Msg {
+		removed, err := core.PruneVersions(src, path, keep)
+		if err == nil && len(removed) == 0 {
+			return nil
+		}
+		return PruneMsg{Name: src.Name, Removed: removed, Err: err}
+	}
+}
+
+// maxConcurrentDownloads is how many queued downloads run at once
+const maxConcurrentDownloads = 3
+
+// ProcessQueue starts queued downloads while fewer than maxConcurrentDownloads
+// are running. Every download of a source, including a manual one, goes through
+// the queue, so this is the only place ActiveDownloads counts them.
 func (m *Model) ProcessQueue() tea.Cmd {
-	var maxConcurrent = 3
 	var cmds []tea.Cmd
 
-	for len(m.DownloadQueue) > 0 && m.ActiveDownloads < maxConcurrent {
+	for len(m.DownloadQueue) > 0 && m.ActiveDownloads < maxConcurrentDownloads {
 		// Pop
 		item := m.DownloadQueue[0]
 		m.DownloadQueue = m.DownloadQueue[1:]
-		m.ActiveDownloads++
 
 		// Get latest item data to ensure correct source/path
 		// Find the item in table data
 		var src config.Source
-		found := false
+		found, inFlight := false, false
 		for tabIdx, name := range m.Tabs {
 			if name == item.Category {
 				if item.Index >= 0 && item.Index < len(m.TableData[tabIdx]) {
 					src = m.TableData[tabIdx][item.Index].Source
+					inFlight = m.TableData[tabIdx][item.Index].InFlight
 					found = true
 				}
 				break
 			}
 		}
+		if !found || inFlight {
+			// Gone after a reload, or queued twice
+			continue
+		}
+		m.ActiveDownloads++
 
-		if found {
-			target := m.Config.GetTargetPath(item.Category, src)
-
-			var version string
-			m.updateItemState(item.Category, item.Index, func(it *Item) {
-				it.LocalStatus = "Starting download..."
-				version = it.LatestVersion
-				if version == "" || version == "---" {
-					version = it.CurrentVersion
-				}
-			})
+		target := m.targetPath(item.Category, src)
+
+		var version, checksum string
+		var extraURLs []string
+		var quota quotaPlan
+		m.updateItemState(item.Category, item.Index, func(it *Item) {
+			quota = m.quotaPlan(*it)
+			it.LocalStatus = "Starting download..."
+			it.InFlight = true
+			it.startPhases()
+			checksum = it.checksum()
+			extraURLs = it.ExtraURLs
+			version = it.LatestVersion
+			if version == "" || version == "---" {
+				version = it.CurrentVersion
+			}
+		})
 
-			cmds = append(cmds, DownloadCmd(item.Index, item.Category, src, target, version, m.Config.General.GitHubToken, m.Config.General.Threads))
-		} else {
-			m.ActiveDownloads-- // Should not happen, but safety decrement
-		}
+		cmds = append(cmds, DownloadCmd(item.Index, item.Category, src, target, version, checksum, extraURLs, m.Config.General.GitHubToken, m.Config.ThreadsFor(src), m.Config.MaxSizeFor(src), m.Config.General.BackupOnUpdate, quota))
 	}
 
 	if len(cmds) > 0 {
@@ -869,3 +1334,106 @@ func (m *Model) ProcessQueue() tea.Cmd {
 	}
 	return nil
 }
+
+// ConfigReloadedMsg carries the result of re-reading the configuration
+type ConfigReloadedMsg struct {
+	Config *config.Config
+	Err    error
+	Added  string // Source added to the config just before the reload, if any
+}
+
+func reloadConfigCmd(load func() (*config.Config, error)) tea.Cmd {
+	return func() tea.Msg {
+		cfg, err := load()
+		return ConfigReloadedMsg{Config: cfg, Err: err}
+	}
+}
+
+// planJob is a candidate for "download all"
+type planJob struct {
+	Category string
+	Index    int
+	Source   config.Source
+	Target   string
+}
+
+// planEntry is the outcome of checking one planJob
+type planEntry struct {
+	Category string
+	Index    int
+	Result   core.CheckResult
+	Checked  bool // Result comes from a fresh version check
+}
+
+// DownloadPlanMsg summarizes a "download all" before it is confirmed
+type DownloadPlanMsg struct {
+	Category   string // Empty when the plan spans every category
+	Entries    []planEntry
+	Items      []QueueItem // Items to enqueue on confirmation
+	TotalBytes int64
+	Unknown    int // Items whose size could not be determined
+	SpaceOK    bool
+	Available  int64
+}
+
+// planDownloadsCmd checks unresolved sources, sums the download sizes with HEAD
+// requests, and checks the free space once per category for its total
+func planDownloadsCmd(category string, jobs []planJob, githubToken string) tea.Cmd {
+	return func() tea.Msg {
+		plan := DownloadPlanMsg{Category: category, SpaceOK: true}
+		checker := core.NewChecker(nil, githubToken)
+		client := &http.Client{Timeout: 30 * time.Second, CheckRedirect: core.ValidateRedirect, Transport: core.AuthTransport(nil)}
+
+		var categories []string
+		categoryBytes := make(map[string]int64)
+		categoryTarget := make(map[string]string)
+
+		for _, job := range jobs {
+			downloadURL := job.Source.URL
+			if downloadURL == "" {
+				res := checker.CheckVersion(job.Source, job.Target)
+				plan.Entries = append(plan.Entries, planEntry{Category: job.Category, Index: job.Index, Result: res, Checked: true})
+				if res.Status == core.StatusUpToDate {
+					continue
+				}
+				downloadURL = res.ResolvedURL
+			}
+			plan.Items = append(plan.Items, QueueItem{Category: job.Category, Index: job.Index})
+
+			size := int64(-1)
+			if req, err := http.NewRequest("HEAD", downloadURL, nil); err == nil {
+				req.Header.Set("User-Agent", "lamp/1.0")
+				if resp, err := client.Do(req); err == nil {
+					resp.Body.Close()
+					if resp.StatusCode < 400 {
+						size = resp.ContentLength
+					}
+				}
+			}
+			if size > 0 {
+				plan.TotalBytes += size
+				if _, ok := categoryTarget[job.Category]; !ok {
+					categories = append(categories, job.Category)
+					categoryTarget[job.Category] = job.Target
+				}
+				categoryBytes[job.Category] += size
+			} else {
+				plan.Unknown++
+			}
+		}
+
+		for _, name := range categories {
+			ok, avail, err := downloader.CheckAvailableSpace(categoryTarget[name], categoryBytes[name])
+			if err != nil {
+				continue
+			}
+			// Report the tightest volume that is short on space
+			if !ok && (plan.SpaceOK || avail < plan.Available) {
+				plan.Available = avail
+				plan.SpaceOK = false
+			}
+		}
+
+		return plan
+	}
+}
@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+)
+
+// downloadPhase is the step of the download pipeline an item last reported
+type downloadPhase int
+
+const (
+	phaseStarting downloadPhase = iota // Queued work picked up, nothing reported yet
+	phaseResolving
+	phaseSpaceCheck
+	phaseDownloading
+	phaseVerifying
+	phaseDone
+)
+
+// phaseNames labels the steps shown in the detail view, indexed by phase
+var phaseNames = [phaseDone]string{
+	phaseResolving:   "Resolve URL",
+	phaseSpaceCheck:  "Check free space",
+	phaseDownloading: "Download",
+	phaseVerifying:   "Verify",
+}
+
+// startPhases resets the pipeline steps for a new download
+func (i *Item) startPhases() {
+	i.Phase = phaseStarting
+	i.PhaseNotes = [phaseDone]string{}
+	i.PhaseFailed = false
+}
+
+// enterPhase records that the download reached p, with what the step reported
+func (i *Item) enterPhase(p downloadPhase, note string) {
+	i.Phase = p
+	if note != "" && p < phaseDone {
+		i.PhaseNotes[p] = note
+	}
+}
+
+// resolvedNote describes the versions auto-resolution found
+func resolvedNote(current, latest string) string {
+	switch {
+	case latest == "":
+		return ""
+	case current == "" || current == "---" || current == latest:
+		return latest
+	}
+	return current + " → " + latest
+}
+
+// openDownloadDetail shows the pipeline of the highlighted source
+func (m Model) openDownloadDetail() (tea.Model, tea.Cmd) {
+	if m.isDynamicTab(m.ActiveTab) {
+		return m, nil
+	}
+	idx := m.selectedIndex()
+	if idx < 0 {
+		return m, nil
+	}
+	it := m.TableData[m.ActiveTab][idx]
+	if !it.InFlight && it.Phase == phaseStarting {
+		m.StatusMessage = "No download of " + it.Source.Name + " this session"
+		return m, nil
+	}
+	m.detailTarget = QueueItem{Category: it.Category, Index: idx}
+	m.State = stateDownloading
+	return m, nil
+}
+
+// detailItem returns the item the detail view is open for
+func (m Model) detailItem() (Item, bool) {
+	for i, tab := range m.Tabs {
+		if tab == m.detailTarget.Category {
+			if idx := m.detailTarget.Index; idx >= 0 && idx < len(m.TableData[i]) {
+				return m.TableData[i][idx], true
+			}
+		}
+	}
+	return Item{}, false
+}
+
+// downloadDetailView renders every step of the selected item's download, which
+// the STATUS column can only show one at a time
+func (m Model) downloadDetailView() string {
+	accent := lipgloss.NewStyle().Foreground(m.Theme.Accent).Bold(true)
+	secondary := lipgloss.NewStyle().Foreground(m.Theme.Secondary)
+	errStyle := lipgloss.NewStyle().Foreground(m.Theme.Error)
+	done := lipgloss.NewStyle().Foreground(m.Theme.Accent)
+
+	it, ok := m.detailItem()
+	if !ok {
+		return "The source is no longer in the list.\n\n" + secondary.Render("esc: back to list")
+	}
+
+	var b strings.Builder
+	b.WriteString(accent.Render(fmt.Sprintf("%s (%s)", it.Source.Name, it.Category)) + "\n\n")
+
+	for p := phaseResolving; p < phaseDone; p++ {
+		var marker string
+		style := secondary
+		switch {
+		case p < it.Phase:
+			marker, style = "✓", done
+		case p == it.Phase && it.PhaseFailed:
+			marker, style = "✗", errStyle
+		case p == it.Phase:
+			marker, style = "▶", accent
+		default:
+			marker = "·"
+		}
+		line := style.Render(fmt.Sprintf(" %s %-18s", marker, phaseNames[p]))
+		if note := it.PhaseNotes[p]; note != "" {
+			line += " " + note
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if (it.Phase == phaseDownloading || it.Phase == phaseVerifying) && it.InFlight {
+		b.WriteString("\n")
+		if it.Total > 0 {
+			b.WriteString(" " + progressBar(float64(it.Downloaded)/float64(it.Total), max(m.Width-8, 20)) + "\n")
+		}
+		stats := []string{humanize.Bytes(uint64(it.Downloaded))}
+		if it.Total > 0 {
+			stats[0] += " of " + humanize.Bytes(uint64(it.Total))
+		}
+		if !it.Verifying {
+			stats = append(stats, humanize.Bytes(uint64(it.Rate.BytesPerSec))+"/s")
+			if it.Threads > 0 {
+				stats = append(stats, fmt.Sprintf("%d connections", it.Threads))
+			}
+			if it.Rate.stalled(time.Now()) {
+				stats = append(stats, lipgloss.NewStyle().Foreground(m.Theme.Warning).Render("stalled"))
+			}
+		}
+		b.WriteString(secondary.Render(" "+strings.Join(stats, " | ")) + "\n")
+	}
+
+	b.WriteString("\n Status: " + string(it.LocalStatus) + "\n")
+	if it.LocalMessage != "" && it.PhaseFailed {
+		b.WriteString(errStyle.Render(" "+it.LocalMessage) + "\n")
+	}
+	if it.DownloadPath != "" {
+		b.WriteString(secondary.Render(" Destination: "+it.DownloadPath) + "\n")
+	}
+	if it.ServedFrom != "" {
+		b.WriteString(secondary.Render(" Served from: "+it.ServedFrom) + "\n")
+	}
+	b.WriteString("\n" + secondary.Render("esc/enter: back to list | q: quit"))
+	return b.String()
+}
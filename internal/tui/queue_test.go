@@ -0,0 +1,273 @@
+package tui
+
+import (
+	"fmt"
+	"lamp/internal/config"
+	"lamp/internal/core"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fileServer serves /file-N.bin slowly enough for downloads to overlap, and
+// records how many run at once and how often each file is fetched
+type fileServer struct {
+	*httptest.Server
+	active, peak atomic.Int32
+
+	mu      sync.Mutex
+	fetches map[string]int
+}
+
+func newFileServer(t *testing.T) *fileServer {
+	fs := &fileServer{fetches: make(map[string]int)}
+	body := make([]byte, 4096)
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		if r.Method == http.MethodHead {
+			return
+		}
+		n := fs.active.Add(1)
+		defer fs.active.Add(-1)
+		for {
+			peak := fs.peak.Load()
+			if n <= peak || fs.peak.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		fs.mu.Lock()
+		fs.fetches[r.URL.Path]++
+		fs.mu.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+		w.Write(body)
+	}))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+// newQueueModel returns a model with n missing files served by fs
+func newQueueModel(t *testing.T, fs *fileServer, n int) Model {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // Download history
+	var sources []config.Source
+	for i := 0; i < n; i++ {
+		sources = append(sources, config.Source{Name: fmt.Sprintf("File %d", i), URL: fmt.Sprintf("%s/file-%d.bin", fs.URL, i)})
+	}
+	cfg := &config.Config{Categories: map[string]config.Category{
+		"Files": {Path: t.TempDir(), Sources: sources},
+	}}
+	m := NewModel(cfg, nil)
+	for i := range m.TableData[0] {
+		m.TableData[0][i].LocalStatus = core.StatusNotFound
+	}
+	m.syncTableRows(0)
+	return m
+}
+
+func press(m Model, key string) (Model, tea.Cmd) {
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	return next.(Model), cmd
+}
+
+// run executes cmd and the commands its messages lead to, concurrently as
+// tea.Program does, feeding every message back into m until none are left.
+// check is called after each update.
+func run(t *testing.T, m Model, cmd tea.Cmd, check func(Model)) Model {
+	t.Helper()
+	msgs := make(chan tea.Msg)
+	pending := 0
+	start := func(c tea.Cmd) {
+		if c == nil {
+			return
+		}
+		pending++
+		go func() { msgs <- c() }()
+	}
+	start(cmd)
+
+	timeout := time.After(30 * time.Second)
+	for pending > 0 {
+		select {
+		case msg := <-msgs:
+			pending--
+			switch msg := msg.(type) {
+			case nil:
+			case tea.BatchMsg:
+				for _, c := range msg {
+					start(c)
+				}
+			default:
+				next, c := m.Update(msg)
+				m = next.(Model)
+				check(m)
+				start(c)
+			}
+		case <-timeout:
+			t.Fatalf("Commands still running after 30s; %d downloads active, %d queued", m.ActiveDownloads, len(m.DownloadQueue))
+		}
+	}
+	return m
+}
+
+// checkLimit fails the test when more downloads are running than allowed
+func checkLimit(t *testing.T) func(Model) {
+	return func(m Model) {
+		t.Helper()
+		inFlight := 0
+		for _, it := range m.TableData[0] {
+			if it.InFlight {
+				inFlight++
+			}
+		}
+		if m.ActiveDownloads > maxConcurrentDownloads || inFlight > maxConcurrentDownloads {
+			t.Fatalf("%d downloads counted and %d in flight, over the limit of %d", m.ActiveDownloads, inFlight, maxConcurrentDownloads)
+		}
+		if m.ActiveDownloads < 0 {
+			t.Fatalf("ActiveDownloads went negative: %d", m.ActiveDownloads)
+		}
+	}
+}
+
+// checkDrained fails the test unless every file was fetched once and the
+// queue and counter are back to empty
+func checkDrained(t *testing.T, m Model, fs *fileServer) {
+	t.Helper()
+	if m.ActiveDownloads != 0 || len(m.DownloadQueue) != 0 {
+		t.Errorf("Expected no active or queued downloads, got %d active and %d queued", m.ActiveDownloads, len(m.DownloadQueue))
+	}
+	dir := m.Config.Categories["Files"].Path
+	for i, it := range m.TableData[0] {
+		if it.InFlight {
+			t.Errorf("%s is still in flight", it.Source.Name)
+		}
+		if _, err := os.Stat(filepath.Join(dir, fmt.Sprintf("file-%d.bin", i))); err != nil {
+			t.Errorf("%s was not downloaded: %v (status %q)", it.Source.Name, err, it.LocalStatus)
+		}
+		if n := fs.fetches[fmt.Sprintf("/file-%d.bin", i)]; n != 1 {
+			t.Errorf("Expected %s to be fetched once, got %d", it.Source.Name, n)
+		}
+	}
+	if peak := fs.peak.Load(); peak > maxConcurrentDownloads {
+		t.Errorf("The server saw %d downloads at once, over the limit of %d", peak, maxConcurrentDownloads)
+	}
+}
+
+func TestDownloadAllDrainsQueueWithinLimit(t *testing.T) {
+	fs := newFileServer(t)
+	m := newQueueModel(t, fs, 7)
+
+	m, cmd := press(m, "D")
+	m = run(t, m, cmd, checkLimit(t))
+	if m.State != stateConfirm {
+		t.Fatalf("Expected the download plan to await confirmation, state is %v", m.State)
+	}
+	m, cmd = press(m, "y")
+	if m.ActiveDownloads != maxConcurrentDownloads || len(m.DownloadQueue) != 7-maxConcurrentDownloads {
+		t.Fatalf("Expected %d started and the rest queued, got %d active and %d queued", maxConcurrentDownloads, m.ActiveDownloads, len(m.DownloadQueue))
+	}
+	m = run(t, m, cmd, checkLimit(t))
+
+	checkDrained(t, m, fs)
+	if fs.peak.Load() < 2 {
+		t.Errorf("Expected downloads to overlap, at most %d ran at once", fs.peak.Load())
+	}
+}
+
+func TestManualDownloadDuringBatch(t *testing.T) {
+	fs := newFileServer(t)
+	m := newQueueModel(t, fs, 5)
+
+	m, cmd := press(m, "D")
+	m = run(t, m, cmd, checkLimit(t))
+	m, batch := press(m, "y")
+
+	// d on a running download doesn't start it again
+	m.Tables[0].SetCursor(0)
+	m, cmd = press(m, "d")
+	if cmd != nil || m.ActiveDownloads != maxConcurrentDownloads {
+		t.Errorf("Expected d on a running download to do nothing, %d active", m.ActiveDownloads)
+	}
+
+	// d on a queued one moves it to the front but waits for a free slot
+	m.Tables[0].SetCursor(4)
+	m, cmd = press(m, "d")
+	if cmd != nil || m.ActiveDownloads != maxConcurrentDownloads {
+		t.Errorf("Expected d at the limit to wait, %d active", m.ActiveDownloads)
+	}
+	if len(m.DownloadQueue) != 2 || m.DownloadQueue[0].Index != 4 {
+		t.Errorf("Expected File 4 at the front of a queue of 2, got %+v", m.DownloadQueue)
+	}
+	checkLimit(t)(m)
+
+	m = run(t, m, batch, checkLimit(t))
+	checkDrained(t, m, fs)
+	if m.batch != nil {
+		t.Errorf("Expected the batch to be finished, %d items left", len(m.batch))
+	}
+}
+
+func TestManualDownloadWithFreeSlot(t *testing.T) {
+	fs := newFileServer(t)
+	m := newQueueModel(t, fs, 2)
+
+	m.Tables[0].SetCursor(1)
+	m, cmd := press(m, "d")
+	if m.ActiveDownloads != 1 || !m.TableData[0][1].InFlight || len(m.DownloadQueue) != 0 {
+		t.Fatalf("Expected d to start right away, got %d active and %d queued", m.ActiveDownloads, len(m.DownloadQueue))
+	}
+	m = run(t, m, cmd, checkLimit(t))
+
+	if m.ActiveDownloads != 0 {
+		t.Errorf("Expected ActiveDownloads back at 0, got %d", m.ActiveDownloads)
+	}
+	if n := fs.fetches["/file-1.bin"]; n != 1 {
+		t.Errorf("Expected File 1 to be fetched once, got %d", n)
+	}
+	if n := fs.fetches["/file-0.bin"]; n != 0 {
+		t.Errorf("Expected File 0 to be left alone, got %d fetches", n)
+	}
+}
+
+func TestDownloadSkipsCurrentFile(t *testing.T) {
+	var gets atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+		if r.Method == http.MethodGet {
+			gets.Add(1)
+			w.Write([]byte("new contents"))
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	// The local copy is newer than the remote Last-Modified
+	if err := os.WriteFile(filepath.Join(dir, "file.bin"), []byte("contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Categories: map[string]config.Category{
+		"Files": {Path: dir, Sources: []config.Source{{Name: "File", URL: srv.URL + "/file.bin"}}},
+	}}
+	m := NewModel(cfg, nil)
+
+	m, cmd := press(m, "d")
+	m = run(t, m, cmd, checkLimit(t))
+
+	it := m.TableData[0][0]
+	if it.LocalStatus != "Skipped (up to date)" || it.Phase != phaseDone {
+		t.Errorf("Expected the download to be skipped, got %q in phase %d", it.LocalStatus, it.Phase)
+	}
+	if n := gets.Load(); n != 0 {
+		t.Errorf("Expected nothing to be downloaded, got %d requests", n)
+	}
+	if m.ActiveDownloads != 0 || it.InFlight {
+		t.Errorf("Expected the slot to be free, %d active", m.ActiveDownloads)
+	}
+}
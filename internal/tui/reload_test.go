@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"lamp/internal/config"
+	"path/filepath"
+	"testing"
+)
+
+func TestReloadKeepsOverrides(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	app := config.Source{ID: "app", Name: "App", URL: "https://example.com/app.iso"}
+	tool := config.Source{ID: "tool", Name: "Tool", Strategy: "github_release", Params: map[string]string{"repo": "acme/tool"}}
+	configWith := func(sources ...config.Source) *config.Config {
+		return &config.Config{Categories: map[string]config.Category{"Files": {Path: dir, Sources: sources}}}
+	}
+	m := NewModel(configWith(app, tool), nil)
+
+	m.PathOverrides = map[string]string{overrideKey("Files", app): "/mnt/usb"}
+	m.setPin(QueueItem{Category: "Files", Index: 1}, "1.2.0")
+
+	// A new source ahead of both moves them down a row, and a renamed one keeps its overrides
+	tool.Name = "Tool (renamed)"
+	if _, err := m.applyReload(configWith(config.Source{ID: "new", Name: "New", URL: "https://example.com/new.iso"}, app, tool)); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.targetPath("Files", m.TableData[0][1].Source); got != filepath.Join("/mnt/usb", "app.iso") {
+		t.Errorf("Expected the folder override to follow App, got %s", got)
+	}
+	if got := m.TableData[0][2].Source.PinVersion; got != "1.2.0" {
+		t.Errorf("Expected the pin to follow the renamed Tool, got %q", got)
+	}
+	if got := m.TableData[0][0]; got.Source.PinVersion != "" || m.targetPath("Files", got.Source) != filepath.Join(dir, "new.iso") {
+		t.Errorf("Expected no overrides for the new source, got pin %q and target %s", got.Source.PinVersion, m.targetPath("Files", got.Source))
+	}
+}
+
+func TestReloadRemapsPendingPlan(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	a := config.Source{ID: "a", Name: "A", URL: "https://example.com/a.iso"}
+	b := config.Source{ID: "b", Name: "B", URL: "https://example.com/b.iso"}
+	configWith := func(sources ...config.Source) *config.Config {
+		return &config.Config{Categories: map[string]config.Category{"Files": {Path: dir, Sources: sources}}}
+	}
+	withPlan := func() Model {
+		m := NewModel(configWith(a, b), nil)
+		m.PendingPlan = &DownloadPlanMsg{Category: "Files", Items: []QueueItem{{Category: "Files", Index: 1}}, SpaceOK: true,
+			Entries: []planEntry{{Category: "Files", Index: 1}}}
+		m.State = stateConfirm
+		return m
+	}
+
+	// B moves down a row and the plan follows it
+	m := withPlan()
+	if _, err := m.applyReload(configWith(config.Source{ID: "c", Name: "C", URL: "https://example.com/c.iso"}, a, b)); err != nil {
+		t.Fatal(err)
+	}
+	if m.State != stateConfirm || m.PendingPlan == nil || m.PendingPlan.Items[0].Index != 2 || m.PendingPlan.Entries[0].Index != 2 {
+		t.Errorf("Expected the plan to follow B to row 2, got %+v (state %v)", m.PendingPlan, m.State)
+	}
+
+	// With B gone the plan's total is wrong, so it is dropped
+	m = withPlan()
+	if _, err := m.applyReload(configWith(a)); err != nil {
+		t.Fatal(err)
+	}
+	if m.State != stateList || m.PendingPlan != nil {
+		t.Errorf("Expected the plan dropped, got %+v (state %v)", m.PendingPlan, m.State)
+	}
+
+	// Sizing in progress reports on rows by index, so the reload waits for it
+	m = withPlan()
+	m.Planning = true
+	if _, err := m.applyReload(configWith(b, a)); err == nil {
+		t.Error("Expected the reload refused while planning")
+	}
+}
@@ -0,0 +1,163 @@
+package tui
+
+import (
+	"fmt"
+	"lamp/internal/config"
+	"lamp/internal/core"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// defaultTrackCatalog is the catalog file entries from the library tabs are
+// appended to unless another is typed
+const defaultTrackCatalog = "tracked.yaml"
+
+// snippetView shows the catalog entry that keeps following the highlighted
+// Kiwix or Gutenberg item, opened with n in a library tab. The entry can be
+// copied to the clipboard or appended to a catalog file.
+type snippetView struct {
+	Source  config.Source
+	Entry   string          // Source as a catalog "sources:" item
+	file    textinput.Model // Catalog file to append to; a bare name goes in the catalogs folder
+	writing bool            // The file is being typed
+	err     string          // Why the last write failed
+}
+
+// openSnippet builds the entry for the highlighted item of the active library tab
+func (m Model) openSnippet() (tea.Model, tea.Cmd) {
+	catalog, ok := m.DynamicCatalogs[m.Tabs[m.ActiveTab]]
+	idx := m.Tables[m.ActiveTab].Cursor()
+	if !ok || idx < 0 {
+		return m, nil
+	}
+	var src config.Source
+	switch catalog.CatalogType {
+	case "gutenberg":
+		if idx >= len(catalog.GutenbergItems) {
+			return m, nil
+		}
+		book := catalog.GutenbergItems[idx].Book
+		if core.GetEPUB3URL(book) == "" {
+			m.StatusMessage = book.Title + " has no EPUB to track"
+			return m, nil
+		}
+		src = core.BookDirectSource(book)
+	case "kiwix":
+		if idx >= len(catalog.KiwixItems) {
+			return m, nil
+		}
+		src = core.KiwixFeedSource(catalog.KiwixItems[idx].Entry)
+	default:
+		return m, nil
+	}
+
+	entry, err := config.MarshalCatalogEntry(src)
+	if err != nil {
+		m.StatusMessage = "Could not build the entry: " + err.Error()
+		return m, nil
+	}
+	file := newFormInput(defaultTrackCatalog)
+	file.SetValue(defaultTrackCatalog)
+	m.Snippet = &snippetView{Source: src, Entry: entry, file: file}
+	m.State = stateSnippet
+	return m, nil
+}
+
+// updateSnippet handles a key while the entry is shown
+func (m Model) updateSnippet(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.Snippet
+	if s.writing {
+		switch msg.String() {
+		case "esc":
+			s.writing = false
+			s.file.Blur()
+			return m, nil
+		case "enter":
+			name := strings.TrimSpace(s.file.Value())
+			if name == "" {
+				s.err = "a catalog file is required"
+				return m, nil
+			}
+			return m, appendCatalogEntryCmd(m.catalogPath(name), s.Source)
+		}
+		var cmd tea.Cmd
+		s.file, cmd = s.file.Update(msg)
+		s.err = ""
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		m.Snippet = nil
+		m.State = stateList
+	case "c", "y":
+		// OSC 52 asks the terminal to set the clipboard, which also works over SSH
+		termenv.Copy(s.Entry)
+		m.Snippet = nil
+		m.State = stateList
+		m.StatusMessage = fmt.Sprintf("Copied the entry for %s (terminals without OSC 52 support ignore it)", s.Source.Name)
+	case "w":
+		s.writing = true
+		s.file.CursorEnd()
+		return m, s.file.Focus()
+	}
+	return m, nil
+}
+
+// catalogsDir is the catalogs folder beside the config file
+func (m Model) catalogsDir() string {
+	configPath := m.ConfigPath
+	if configPath == "" {
+		configPath = config.DefaultConfigPath()
+	}
+	return config.CatalogsDir(configPath)
+}
+
+// catalogPath places a bare file name in the catalogs folder
+func (m Model) catalogPath(name string) string {
+	if filepath.Base(name) != name {
+		return name
+	}
+	return filepath.Join(m.catalogsDir(), name)
+}
+
+// CatalogEntryAddedMsg reports the result of appending a tracked item to a catalog
+type CatalogEntryAddedMsg struct {
+	Path   string
+	Source config.Source
+	Err    error
+}
+
+func appendCatalogEntryCmd(path string, src config.Source) tea.Cmd {
+	return func() tea.Msg {
+		return CatalogEntryAddedMsg{Path: path, Source: src, Err: config.AppendCatalogEntry(path, src)}
+	}
+}
+
+// snippetView renders the entry and what can be done with it
+func (m Model) snippetView() string {
+	s := m.Snippet
+	accent := lipgloss.NewStyle().Foreground(m.Theme.Accent).Bold(true)
+	secondary := lipgloss.NewStyle().Foreground(m.Theme.Secondary)
+	errStyle := lipgloss.NewStyle().Foreground(m.Theme.Error)
+
+	var b strings.Builder
+	b.WriteString(accent.Render("Track "+s.Source.Name) + "\n\n")
+	b.WriteString(s.Entry + "\n")
+	if !s.writing {
+		b.WriteString(secondary.Render("c: copy to clipboard | w: append to a catalog file | esc: close"))
+		return b.String()
+	}
+	b.WriteString(fmt.Sprintf("%-14s %s\n", "Catalog file", s.file.View()))
+	b.WriteString(secondary.Render("  A bare name is saved in "+m.catalogsDir()) + "\n")
+	if s.err != "" {
+		b.WriteString("\n" + errStyle.Render("Not written: "+s.err) + "\n")
+	}
+	b.WriteString("\n" + secondary.Render("enter: append | esc: back"))
+	return b.String()
+}
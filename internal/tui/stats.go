@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	rateWindow   = time.Second     // Shortest interval a transfer rate is measured over
+	stallTimeout = 5 * time.Second // A download without progress for this long counts as stalled
+)
+
+// transferRate tracks the speed of one item's running download
+type transferRate struct {
+	BytesPerSec float64   // Smoothed transfer speed
+	LastUpdate  time.Time // When the download last made progress
+	counted     int64     // Bytes of the running download already added to the session total
+	windowStart time.Time
+	windowBytes int64
+}
+
+// record notes that a download has reached downloaded bytes at now and returns
+// how many of them are new since the last call
+func (r *transferRate) record(downloaded int64, now time.Time) int64 {
+	if r.windowStart.IsZero() {
+		r.windowStart, r.windowBytes = now, downloaded
+	}
+	if elapsed := now.Sub(r.windowStart); elapsed >= rateWindow {
+		rate := float64(downloaded-r.windowBytes) / elapsed.Seconds()
+		if r.BytesPerSec == 0 {
+			r.BytesPerSec = rate
+		} else {
+			r.BytesPerSec = 0.5*r.BytesPerSec + 0.5*rate
+		}
+		r.windowStart, r.windowBytes = now, downloaded
+	}
+
+	delta := downloaded - r.counted
+	if delta < 0 {
+		delta = 0
+	}
+	r.counted = max(r.counted, downloaded)
+	if delta > 0 {
+		r.LastUpdate = now
+	}
+	return delta
+}
+
+// stalled reports whether a running download has gone stallTimeout without progress
+func (r transferRate) stalled(now time.Time) bool {
+	return !r.LastUpdate.IsZero() && now.Sub(r.LastUpdate) >= stallTimeout
+}
+
+// downloadStats sums the state of all running downloads for the status bar
+type downloadStats struct {
+	Active      int
+	Queued      int
+	BytesPerSec float64
+	Stalled     int
+	Session     int64 // Bytes downloaded since LAMP started
+}
+
+func (m Model) downloadStats() downloadStats {
+	now := time.Now()
+	stats := downloadStats{Active: m.ActiveDownloads, Queued: len(m.DownloadQueue), Session: m.SessionBytes}
+	for _, items := range m.TableData {
+		for _, it := range items {
+			if !it.InFlight || it.Verifying {
+				continue
+			}
+			if it.Rate.stalled(now) {
+				stats.Stalled++
+				continue
+			}
+			stats.BytesPerSec += it.Rate.BytesPerSec
+		}
+	}
+	return stats
+}
+
+// statsTickMsg redraws the status bar so speeds and stalls stay current when no
+// progress arrives
+type statsTickMsg struct{}
+
+func statsTickCmd() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return statsTickMsg{} })
+}
@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"errors"
+	"lamp/internal/config"
+	"lamp/internal/downloader"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDownloadStats(t *testing.T) {
+	progress := func(index int, phase downloader.Phase, done, total int64) tea.Msg {
+		return ProgressUpdateMsg{Category: "Files", Index: index, Progress: downloader.Progress{Phase: phase, Downloaded: done, Total: total}}
+	}
+	tests := []struct {
+		name      string
+		signature bool // Sources have a signature, so a finished download is verified next
+		queue     int  // Sources waiting in the queue while a catalog download holds the third slot
+		msgs      []tea.Msg
+
+		active, queued int
+		session        int64
+		batchDone      int
+		inFlight       int
+		status         string // Status message
+		itemStatus     []string
+	}{
+		{
+			name: "downloads finish",
+			msgs: []tea.Msg{
+				progress(0, downloader.PhaseDownloading, 1000, 4000),
+				progress(1, downloader.PhaseDownloading, 500, 500),
+				progress(0, downloader.PhaseDownloading, 4000, 4000),
+				DownloadMsg{Category: "Files", Index: 1},
+				DownloadMsg{Category: "Files", Index: 0},
+			},
+			session: 4500, batchDone: 2, status: "Download all finished (2 files)",
+			itemStatus: []string{"Finished", "Finished"},
+		},
+		{
+			name: "progress is counted once",
+			msgs: []tea.Msg{
+				progress(0, downloader.PhaseDownloading, 2000, 4000),
+				progress(0, downloader.PhaseDownloading, 2000, 4000),
+				// Hashing rereads the file, which is not downloading it again
+				progress(0, downloader.PhaseVerifying, 4000, 4000),
+				progress(1, downloader.PhaseDownloading, 300, 0),
+			},
+			active: 2, session: 2300, inFlight: 2,
+			itemStatus: []string{"Verifying checksum... 100.0% (4.0 kB/4.0 kB)", "Downloading... 300 B"},
+		},
+		{
+			name: "failures finish the batch",
+			msgs: []tea.Msg{
+				progress(0, downloader.PhaseDownloading, 1000, 4000),
+				DownloadMsg{Category: "Files", Index: 0, Err: downloader.ErrChecksumMismatch},
+				DownloadMsg{Category: "Files", Index: 1, Err: errors.New("connection reset")},
+			},
+			session: 1000, batchDone: 2, status: "Download all finished (2 files)",
+			itemStatus: []string{"Checksum Failed", "Error: connection reset"},
+		},
+		{
+			name:      "signatures are verified after the download",
+			signature: true,
+			msgs: []tea.Msg{
+				progress(0, downloader.PhaseDownloading, 4000, 4000),
+				DownloadMsg{Category: "Files", Index: 0},
+				DownloadMsg{Category: "Files", Index: 1},
+				VerifyMsg{Category: "Files", Index: 1, SignatureErr: errors.New("bad signature")},
+			},
+			// The download slots are free while item 0's signature is checked
+			session: 4000, batchDone: 2, inFlight: 1, status: "Download all finished (2 files)",
+			itemStatus: []string{"Verifying integrity...", "Signature Invalid"},
+		},
+		{
+			name: "skipped download",
+			msgs: []tea.Msg{
+				DownloadMsg{Category: "Files", Index: 0, Skipped: true},
+				DownloadMsg{Category: "Files", Index: 1},
+			},
+			batchDone: 2, status: "Download all finished (2 files)",
+			itemStatus: []string{"Skipped (up to date)", "Finished"},
+		},
+		{
+			// A second message for the same item neither frees another slot nor counts
+			// toward the batch twice
+			name:  "repeated finish",
+			queue: 2,
+			msgs: []tea.Msg{
+				DownloadMsg{Category: "Files", Index: 0},
+				DownloadMsg{Category: "Files", Index: 0},
+			},
+			// The first queued source took the freed slot; the second still waits
+			active: 3, queued: 1, batchDone: 1, inFlight: 2,
+			itemStatus: []string{"Finished", "Local File Not Found", "Starting download...", "Local File Not Found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			var sources []config.Source
+			for _, name := range []string{"a", "b", "c", "d"}[:2+tt.queue] {
+				src := config.Source{ID: name, Name: name, URL: "https://example.com/" + name + ".iso"}
+				if tt.signature {
+					src.Signature = ".sig"
+				}
+				sources = append(sources, src)
+			}
+			m := NewModel(&config.Config{Categories: map[string]config.Category{"Files": {Path: t.TempDir(), Sources: sources}}}, nil)
+			// Both are running as part of a "download all"
+			m.batch = make(map[QueueItem]bool)
+			for i := 0; i < 2; i++ {
+				m.TableData[0][i].InFlight = true
+				m.batch[QueueItem{Category: "Files", Index: i}] = true
+			}
+			m.ActiveDownloads = 2
+			for i := 2; i < 2+tt.queue; i++ {
+				m.ActiveDownloads = maxConcurrentDownloads
+				m.DownloadQueue = append(m.DownloadQueue, QueueItem{Category: "Files", Index: i})
+			}
+
+			for _, msg := range tt.msgs {
+				next, _ := m.Update(msg)
+				m = next.(Model)
+			}
+
+			stats := m.downloadStats()
+			if stats.Active != tt.active || stats.Queued != tt.queued || stats.Session != tt.session {
+				t.Errorf("Expected %d active, %d queued and %d session bytes, got %+v", tt.active, tt.queued, tt.session, stats)
+			}
+			if m.batchDone != tt.batchDone || m.StatusMessage != tt.status {
+				t.Errorf("Expected %d batch items done with status %q, got %d and %q", tt.batchDone, tt.status, m.batchDone, m.StatusMessage)
+			}
+			inFlight := 0
+			for i, it := range m.TableData[0] {
+				if it.InFlight {
+					inFlight++
+				}
+				if string(it.LocalStatus) != tt.itemStatus[i] {
+					t.Errorf("Expected item %d to be %q, got %q", i, tt.itemStatus[i], it.LocalStatus)
+				}
+			}
+			if inFlight != tt.inFlight {
+				t.Errorf("Expected %d items in flight, got %d", tt.inFlight, inFlight)
+			}
+		})
+	}
+}
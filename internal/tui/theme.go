@@ -0,0 +1,237 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/filepicker"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+	"gopkg.in/yaml.v3"
+)
+
+// Theme is the TUI palette; every color the view uses comes from it
+type Theme struct {
+	Name string
+	Mono bool // Uses no colors, only bold and reverse video, for NO_COLOR and color-blind users
+
+	Accent    lipgloss.TerminalColor // Active tab, selected row and progress
+	Secondary lipgloss.TerminalColor // Inactive tabs, headers and key hints
+	Border    lipgloss.TerminalColor // Tab underline, column titles and status messages
+	Selected  lipgloss.TerminalColor // Background of the selected row
+	Error     lipgloss.TerminalColor
+	Warning   lipgloss.TerminalColor
+}
+
+var (
+	earthyTheme = &Theme{
+		Name:      "earthy",
+		Accent:    lipgloss.AdaptiveColor{Light: "#2D5A27", Dark: "#78B159"}, // Forest green
+		Secondary: lipgloss.AdaptiveColor{Light: "#C2B280", Dark: "#E1C699"}, // Sand
+		Border:    lipgloss.AdaptiveColor{Light: "#A0522D", Dark: "#CD853F"}, // Clay
+		Selected:  lipgloss.AdaptiveColor{Light: "#E1C699", Dark: "#2D5A27"},
+		Error:     lipgloss.Color("9"),  // Red
+		Warning:   lipgloss.Color("11"), // Yellow
+	}
+
+	monoTheme = &Theme{
+		Name:      "mono",
+		Mono:      true,
+		Accent:    lipgloss.NoColor{},
+		Secondary: lipgloss.NoColor{},
+		Border:    lipgloss.NoColor{},
+		Selected:  lipgloss.NoColor{},
+		Error:     lipgloss.NoColor{},
+		Warning:   lipgloss.NoColor{},
+	}
+
+	draculaTheme = &Theme{
+		Name:      "dracula",
+		Accent:    lipgloss.Color("#BD93F9"), // Purple
+		Secondary: lipgloss.Color("#8BE9FD"), // Cyan
+		Border:    lipgloss.Color("#FF79C6"), // Pink
+		Selected:  lipgloss.Color("#44475A"), // Current line
+		Error:     lipgloss.Color("#FF5555"),
+		Warning:   lipgloss.Color("#F1FA8C"),
+	}
+
+	builtinThemes = map[string]*Theme{
+		"earthy":  earthyTheme,
+		"mono":    monoTheme,
+		"dracula": draculaTheme,
+	}
+)
+
+// LoadTheme returns the built-in theme called name (earthy, mono or dracula), or
+// reads a palette file when name is anything else. An empty name selects earthy.
+func LoadTheme(name string) (*Theme, error) {
+	if name == "" {
+		return earthyTheme, nil
+	}
+	if t, ok := builtinThemes[strings.ToLower(name)]; ok {
+		return t, nil
+	}
+	return loadPaletteFile(name)
+}
+
+// paletteFile is a custom theme. Each color is either a single color used on
+// light and dark terminals, or a {light, dark} pair; missing colors come from earthy.
+type paletteFile struct {
+	Accent    *paletteColor `yaml:"accent"`
+	Secondary *paletteColor `yaml:"secondary"`
+	Border    *paletteColor `yaml:"border"`
+	Selected  *paletteColor `yaml:"selected"`
+	Error     *paletteColor `yaml:"error"`
+	Warning   *paletteColor `yaml:"warning"`
+}
+
+type paletteColor struct {
+	Light string `yaml:"light"`
+	Dark  string `yaml:"dark"`
+}
+
+func (c *paletteColor) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		c.Light, c.Dark = value.Value, value.Value
+		return nil
+	}
+	type plain paletteColor
+	if err := value.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Light == "" {
+		c.Light = c.Dark
+	}
+	if c.Dark == "" {
+		c.Dark = c.Light
+	}
+	return nil
+}
+
+func loadPaletteFile(path string) (*Theme, error) {
+	if strings.HasPrefix(path, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unknown theme %q: %w", path, err)
+	}
+	var p paletteFile
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid theme file %s: %w", path, err)
+	}
+
+	t := *earthyTheme
+	t.Name = path
+	for _, c := range []struct {
+		from *paletteColor
+		to   *lipgloss.TerminalColor
+	}{
+		{p.Accent, &t.Accent},
+		{p.Secondary, &t.Secondary},
+		{p.Border, &t.Border},
+		{p.Selected, &t.Selected},
+		{p.Error, &t.Error},
+		{p.Warning, &t.Warning},
+	} {
+		if c.from != nil {
+			*c.to = lipgloss.AdaptiveColor{Light: c.from.Light, Dark: c.from.Dark}
+		}
+	}
+	return &t, nil
+}
+
+func (t *Theme) activeTabStyle() lipgloss.Style {
+	if t.Mono {
+		return lipgloss.NewStyle().Reverse(true).Padding(0, 1).Bold(true)
+	}
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FFFFFF")).
+		Background(t.Accent).
+		Padding(0, 1).
+		Bold(true)
+}
+
+func (t *Theme) inactiveTabStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(t.Secondary).
+		Padding(0, 1)
+}
+
+func (t *Theme) tabRowStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, true, false).
+		BorderForeground(t.Border)
+}
+
+func (t *Theme) tableStyles() table.Styles {
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderBottom(true).
+		Bold(false)
+	if t.Mono {
+		s.Header = s.Header.Bold(true)
+		s.Selected = lipgloss.NewStyle().Reverse(true).Bold(true)
+		return s
+	}
+	s.Header = s.Header.
+		BorderForeground(lipgloss.Color("240")).
+		Foreground(t.Border)
+	s.Selected = s.Selected.
+		Foreground(t.Accent).
+		Background(t.Selected).
+		Bold(true)
+	return s
+}
+
+func (t *Theme) filepickerStyles() filepicker.Styles {
+	if !t.Mono {
+		return filepicker.DefaultStyles()
+	}
+	plain := lipgloss.NewStyle()
+	return filepicker.Styles{
+		DisabledCursor:   plain,
+		Cursor:           plain.Bold(true),
+		Symlink:          plain,
+		Directory:        plain.Bold(true),
+		File:             plain,
+		DisabledFile:     plain.Faint(true),
+		Permission:       plain,
+		Selected:         plain.Reverse(true).Bold(true),
+		DisabledSelected: plain.Reverse(true),
+		FileSize:         plain.Width(7).Align(lipgloss.Right),
+		EmptyDirectory:   plain.PaddingLeft(2).SetString("Bummer. No Files Found."),
+	}
+}
+
+// applyTheme restyles every table and the folder picker with m.Theme
+func (m *Model) applyTheme() {
+	for i := range m.Tables {
+		m.Tables[i].SetStyles(m.Theme.tableStyles())
+	}
+	m.Filepicker.Styles = m.Theme.filepickerStyles()
+}
+
+// toggleTheme switches between the configured theme and the mono theme
+func (m *Model) toggleTheme() {
+	switch {
+	case !m.Theme.Mono:
+		m.Theme = monoTheme
+	case !m.baseTheme.Mono:
+		m.Theme = m.baseTheme
+	default:
+		m.Theme = earthyTheme
+	}
+	m.applyTheme()
+}
+
+// SetMonochrome starts the TUI in the mono theme, e.g. for --no-color or NO_COLOR
+func (m *Model) SetMonochrome() {
+	m.Theme = monoTheme
+	m.applyTheme()
+}
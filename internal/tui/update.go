@@ -1,14 +1,20 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"lamp/internal/config"
 	"lamp/internal/core"
 	"lamp/internal/downloader"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/dustin/go-humanize"
 )
 
@@ -19,6 +25,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		if m.State == stateSplash {
 			if msg.String() == "q" || msg.String() == "ctrl+c" {
+				core.CancelAPIRequests()
 				return m, tea.Quit
 			}
 			m.State = stateList
@@ -43,7 +50,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						if lang == "" {
 							lang = "en"
 						}
-						return m, FetchGutenbergCmd(m.Tabs[m.ActiveTab], lang, m.Config)
+						return m, FetchGutenbergCmd(m.Tabs[m.ActiveTab], lang, m.Config, false)
 					} else if catalog.CatalogType == "kiwix" {
 						cat := m.Config.Categories[m.Tabs[m.ActiveTab]]
 						lang := cat.Language
@@ -57,7 +64,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 								break
 							}
 						}
-						return m, FetchKiwixCmd(m.Tabs[m.ActiveTab], lang, category, m.Config)
+						return m, FetchKiwixCmd(m.Tabs[m.ActiveTab], lang, category, m.Config, false)
 					}
 				} else {
 					// Static tab - clear filter and restore full table
@@ -107,8 +114,88 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		}
 
+		if m.State == statePin {
+			switch msg.String() {
+			case "esc":
+				m.State = stateList
+				m.PinInput.Blur()
+				return m, nil
+			case "enter":
+				m.State = stateList
+				m.PinInput.Blur()
+				return m, m.setPin(m.pinTarget, strings.TrimSpace(m.PinInput.Value()))
+			}
+			m.PinInput, cmd = m.PinInput.Update(msg)
+			return m, cmd
+		}
+
+		if m.State == stateAddSource {
+			return m.updateSourceForm(msg)
+		}
+
+		if m.State == stateSnippet {
+			return m.updateSnippet(msg)
+		}
+
+		if m.State == stateDownloading {
+			switch msg.String() {
+			case "esc", "enter":
+				m.State = stateList
+			case "q", "ctrl+c":
+				core.CancelAPIRequests()
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		if m.State == stateConfirm {
+			plan := m.PendingPlan
+			m.PendingPlan = nil
+			m.State = stateList
+			if msg.String() != "y" && msg.String() != "Y" {
+				m.StatusMessage = "Download all cancelled"
+				return m, nil
+			}
+			// Add to queue instead of firing immediately
+			if plan.Category == "" {
+				m.batch = make(map[QueueItem]bool, len(plan.Items))
+				m.batchDone = 0
+			}
+			for _, q := range plan.Items {
+				m.updateItemState(q.Category, q.Index, func(it *Item) {
+					it.LocalStatus = "Queued"
+				})
+				m.DownloadQueue = append(m.DownloadQueue, q)
+				if plan.Category == "" {
+					m.batch[q] = true
+				}
+			}
+			return m, m.ProcessQueue()
+		}
+
+		if m.State == stateFolderSelect {
+			// The folder picker handles its own navigation keys (h/l, enter to select)
+			if msg.String() == "esc" {
+				m.State = stateList
+				return m, nil
+			}
+			break
+		}
+
+		// Footer messages last until the next keypress
+		m.StatusMessage = ""
+
+		switch msg.String() {
+		case "d", "D", "A", "U":
+			if core.IsOffline() {
+				m.StatusMessage = "Offline mode: downloads are disabled (restart without --offline to download)"
+				return m, nil
+			}
+		}
+
 		switch msg.String() {
 		case "q", "ctrl+c":
+			core.CancelAPIRequests()
 			return m, tea.Quit
 		case "right", "l", "]":
 			m.ActiveTab = (m.ActiveTab + 1) % len(m.Tabs)
@@ -127,13 +214,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.isDynamicTab(m.ActiveTab) {
 				return m, nil
 			}
-			var cmds []tea.Cmd
-			items := m.TableData[m.ActiveTab]
-			for i, it := range items {
-				target := m.Config.GetTargetPath(it.Category, it.Source)
-				cmds = append(cmds, checkSourceCmd(i, it.Category, it.Source, target, m.Config.General.GitHubToken))
-			}
-			return m, tea.Batch(cmds...)
+			return m, m.checkTabCmd(m.ActiveTab)
 		case "d":
 			// Download selected item
 			if m.isGutenbergTab(m.ActiveTab) {
@@ -142,39 +223,82 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.isKiwixTab(m.ActiveTab) {
 				return m.handleKiwixDownload()
 			}
-			idx := m.Tables[m.ActiveTab].Cursor()
-			if idx < 0 || idx >= len(m.TableData[m.ActiveTab]) {
+			idx := m.selectedIndex()
+			if idx < 0 {
 				return m, nil
 			}
 			it := m.TableData[m.ActiveTab][idx]
-			target := m.Config.GetTargetPath(it.Category, it.Source)
-
-			it.LocalStatus = "Starting download..."
-			m.TableData[m.ActiveTab][idx] = it
-			m.syncTableRows(m.ActiveTab)
-
-			m.ActiveDownloads++ // Manual download counts towards concurrency
-			version := it.LatestVersion
-			if version == "" || version == "---" {
-				version = it.CurrentVersion
+			if it.InFlight {
+				m.StatusMessage = it.Source.Name + " is already downloading"
+				return m, nil
+			}
+			// Jump the queue, but still wait for a free slot
+			q := QueueItem{Category: it.Category, Index: idx}
+			queue := []QueueItem{q}
+			for _, other := range m.DownloadQueue {
+				if other != q {
+					queue = append(queue, other)
+				}
 			}
-			return m, DownloadCmd(idx, it.Category, it.Source, target, version, m.Config.General.GitHubToken, m.Config.General.Threads)
+			m.DownloadQueue = queue
+			cmd := m.ProcessQueue()
+			if !m.TableData[m.ActiveTab][idx].InFlight {
+				m.updateItemState(it.Category, idx, func(it *Item) {
+					it.LocalStatus = "Queued"
+				})
+			}
+			return m, cmd
 		case "D":
 			// Download all missing files in current tab (not for dynamic catalogs)
 			if m.isDynamicTab(m.ActiveTab) {
 				return m, nil
 			}
-			// Add to queue instead of firing immediately
-			items := m.TableData[m.ActiveTab]
-			for i, it := range items {
-				if it.LocalStatus == "Local File Not Found" || it.LocalStatus == "Not Checked" {
-					it.LocalStatus = "Queued"
-					m.TableData[m.ActiveTab][i] = it
-					m.DownloadQueue = append(m.DownloadQueue, QueueItem{Category: it.Category, Index: i})
+			if m.Planning {
+				return m, nil
+			}
+			// Size everything first and ask for confirmation before queueing
+			var jobs []planJob
+			for i, it := range m.TableData[m.ActiveTab] {
+				if !m.visible(it) {
+					continue
+				}
+				if it.LocalStatus == "Local File Not Found" || it.LocalStatus == core.StatusIncomplete || it.LocalStatus == "Not Checked" {
+					jobs = append(jobs, planJob{Category: it.Category, Index: i, Source: it.Source, Target: m.targetPath(it.Category, it.Source)})
 				}
 			}
-			m.syncTableRows(m.ActiveTab)
-			return m, m.ProcessQueue()
+			if len(jobs) == 0 {
+				m.StatusMessage = "Nothing to download"
+				return m, nil
+			}
+			m.Planning = true
+			m.StatusMessage = fmt.Sprintf("Checking sizes of %d downloads...", len(jobs))
+			return m, planDownloadsCmd(m.Tabs[m.ActiveTab], jobs, m.Config.General.GitHubToken)
+		case "A":
+			// Download all missing files across every static category
+			if m.Planning || m.batch != nil {
+				return m, nil
+			}
+			var jobs []planJob
+			for tabIdx := range m.Tabs {
+				if m.isDynamicTab(tabIdx) {
+					continue
+				}
+				for i, it := range m.TableData[tabIdx] {
+					if !m.visible(it) {
+						continue
+					}
+					if it.LocalStatus == "Local File Not Found" || it.LocalStatus == core.StatusIncomplete || it.LocalStatus == "Not Checked" {
+						jobs = append(jobs, planJob{Category: it.Category, Index: i, Source: it.Source, Target: m.targetPath(it.Category, it.Source)})
+					}
+				}
+			}
+			if len(jobs) == 0 {
+				m.StatusMessage = "Nothing to download"
+				return m, nil
+			}
+			m.Planning = true
+			m.StatusMessage = fmt.Sprintf("Checking sizes of %d downloads across all categories...", len(jobs))
+			return m, planDownloadsCmd("", jobs, m.Config.General.GitHubToken)
 		case "U":
 			// Update all files with newer versions available in current tab (not for dynamic catalogs)
 			if m.isDynamicTab(m.ActiveTab) {
@@ -183,7 +307,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Add to queue instead of firing immediately
 			items := m.TableData[m.ActiveTab]
 			for i, it := range items {
-				if it.LocalStatus == core.StatusNewer {
+				if it.LocalStatus == core.StatusNewer && m.visible(it) {
 					it.LocalStatus = "Queued"
 					m.TableData[m.ActiveTab][i] = it
 					m.DownloadQueue = append(m.DownloadQueue, QueueItem{Category: it.Category, Index: i})
@@ -191,6 +315,119 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			m.syncTableRows(m.ActiveTab)
 			return m, m.ProcessQueue()
+		case "V":
+			// Re-hash every downloaded file across static categories against its known checksum
+			if m.VerifyingAll {
+				return m, nil
+			}
+			var items []QueueItem
+			var files []downloader.ArchiveFile
+			for tabIdx := range m.Tabs {
+				if m.isDynamicTab(tabIdx) {
+					continue
+				}
+				for i, it := range m.TableData[tabIdx] {
+					// Files being written would read as corrupted
+					if !m.visible(it) || it.InFlight {
+						continue
+					}
+					items = append(items, QueueItem{Category: it.Category, Index: i})
+					files = append(files, downloader.ArchiveFileFor(m.targetPath(it.Category, it.Source), it.LocalFilename, it.LocalStatus, it.Source.Checksum, it.ResolvedChecksum))
+				}
+			}
+			if len(files) == 0 {
+				m.StatusMessage = "Nothing to verify"
+				return m, nil
+			}
+			m.VerifyingAll = true
+			m.StatusMessage = fmt.Sprintf("Verifying 0/%d files...", len(files))
+			return m, verifyAllCmd(items, files, m.Config.General.CheckConcurrency)
+		case "p":
+			// Toggle hiding sources built for another OS/arch
+			m.HideForeign = !m.HideForeign
+			for i := range m.Tabs {
+				if !m.isDynamicTab(i) {
+					m.syncTableRows(i)
+				}
+			}
+			if m.HideForeign {
+				m.StatusMessage = "Showing only sources for this machine"
+			} else {
+				m.StatusMessage = "Showing sources for all platforms"
+			}
+			return m, nil
+		case "o", "x", "*":
+			// Filter static tabs by status; pressing the active filter's key again clears it
+			filter := map[string]statusFilter{"o": filterAttention, "x": filterErrors, "*": filterNone}[msg.String()]
+			if filter == m.StatusFilter {
+				filter = filterNone
+			}
+			m.StatusFilter = filter
+			for i := range m.Tabs {
+				if !m.isDynamicTab(i) {
+					m.syncTableRows(i)
+				}
+			}
+			return m, nil
+		case "e":
+			// Show or hide disabled categories and sources for this session
+			m.ShowDisabled = !m.ShowDisabled
+			cmd, err := m.applyReload(m.Config)
+			if err != nil {
+				m.ShowDisabled = !m.ShowDisabled
+				m.StatusMessage = "Toggle skipped: " + err.Error()
+				return m, nil
+			}
+			if m.ShowDisabled {
+				m.StatusMessage = "Showing disabled categories and sources"
+			} else {
+				m.StatusMessage = "Hiding disabled categories and sources"
+			}
+			return m, cmd
+		case "enter":
+			// Follow the download of the highlighted source step by step
+			return m.openDownloadDetail()
+		case "a":
+			// Add a source to the current category through a form, or in a library
+			// tab build the entry that keeps tracking the highlighted item
+			if m.isDynamicTab(m.ActiveTab) {
+				return m.openSnippet()
+			}
+			if m.ConfigPath == "" || m.LoadConfig == nil {
+				m.StatusMessage = "No config file to add sources to"
+				return m, nil
+			}
+			m.SourceForm = newSourceForm(m.Tabs[m.ActiveTab])
+			m.State = stateAddSource
+			return m, nil
+		case "r":
+			// Reload config and catalogs without restarting
+			if m.LoadConfig == nil {
+				return m, nil
+			}
+			m.StatusMessage = "Reloading config..."
+			return m, reloadConfigCmd(m.LoadConfig)
+		case "t":
+			// Switch between the default colors and the high-contrast mono theme
+			m.toggleTheme()
+			return m, nil
+		case "R":
+			// Refresh the current view without using cached data
+			if core.IsOffline() {
+				m.StatusMessage = "Offline mode: cannot refresh"
+				return m, nil
+			}
+			name := m.Tabs[m.ActiveTab]
+			if catalog, ok := m.DynamicCatalogs[name]; ok {
+				if catalog.Loading {
+					return m, nil
+				}
+				// Searches always go to the API, so refreshing reloads the default listing
+				catalog.Loading = true
+				catalog.SearchQuery = ""
+				return m, m.fetchCatalogCmd(name, true)
+			}
+			return m, m.checkTabCmd(m.ActiveTab)
 		case "c":
 			// Open config directory
 			if dir, err := config.GetConfigDir(); err == nil {
@@ -198,13 +435,56 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 		case "f":
+			// Choose a different target directory for the highlighted source
+			if m.isDynamicTab(m.ActiveTab) {
+				return m, nil
+			}
+			idx := m.selectedIndex()
+			if idx < 0 {
+				return m, nil
+			}
+			it := m.TableData[m.ActiveTab][idx]
+			m.folderTarget = overrideKey(it.Category, it.Source)
+			if dir := filepath.Dir(m.targetPath(it.Category, it.Source)); isDir(dir) {
+				m.Filepicker.CurrentDirectory = dir
+			}
 			m.State = stateFolderSelect
 			return m, m.Filepicker.Init()
-		case "esc":
-			if m.State == stateFolderSelect {
-				m.State = stateList
+		case "v":
+			// Pin the highlighted source to a version for this session
+			if m.isDynamicTab(m.ActiveTab) {
+				return m, nil
+			}
+			idx := m.selectedIndex()
+			if idx < 0 {
+				return m, nil
+			}
+			if m.TableData[m.ActiveTab][idx].InFlight {
+				m.StatusMessage = "Wait for the download to finish before pinning"
 				return m, nil
 			}
+			m.pinTarget = QueueItem{Category: m.Tabs[m.ActiveTab], Index: idx}
+			m.PinInput.SetValue(m.TableData[m.ActiveTab][idx].Source.PinVersion)
+			m.PinInput.CursorEnd()
+			m.State = statePin
+			return m, m.PinInput.Focus()
+		case "F":
+			// Clear the highlighted source's target directory override
+			if m.isDynamicTab(m.ActiveTab) {
+				return m, nil
+			}
+			idx := m.selectedIndex()
+			if idx < 0 {
+				return m, nil
+			}
+			it := m.TableData[m.ActiveTab][idx]
+			key := overrideKey(it.Category, it.Source)
+			if _, ok := m.PathOverrides[key]; ok {
+				delete(m.PathOverrides, key)
+				m.StatusMessage = "Target folder override cleared"
+			}
+			return m, nil
+		case "esc":
 			// Reset dynamic catalogs if searching
 			if m.isDynamicTab(m.ActiveTab) {
 				if catalog, ok := m.DynamicCatalogs[m.Tabs[m.ActiveTab]]; ok && catalog.SearchQuery != "" {
@@ -218,7 +498,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						if lang == "" {
 							lang = "en"
 						}
-						return m, FetchGutenbergCmd(m.Tabs[m.ActiveTab], lang, m.Config)
+						return m, FetchGutenbergCmd(m.Tabs[m.ActiveTab], lang, m.Config, false)
 					} else if catalogType == "kiwix" {
 						lang := cat.Language
 						if lang == "" {
@@ -231,7 +511,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 								break
 							}
 						}
-						return m, FetchKiwixCmd(m.Tabs[m.ActiveTab], lang, category, m.Config)
+						return m, FetchKiwixCmd(m.Tabs[m.ActiveTab], lang, category, m.Config, false)
 					}
 				}
 			}
@@ -271,7 +551,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		if catalog, ok := m.DynamicCatalogs[msg.TabName]; ok {
 			if msg.Index >= 0 && msg.Index < len(catalog.GutenbergItems) {
-				if msg.Err != nil {
+				if errors.Is(msg.Err, downloader.ErrInvalidFileType) {
+					catalog.GutenbergItems[msg.Index].Status = "Invalid file"
+				} else if msg.Err != nil {
 					catalog.GutenbergItems[msg.Index].Status = "Error: " + msg.Err.Error()
 				} else {
 					catalog.GutenbergItems[msg.Index].Status = "Downloaded"
@@ -316,7 +598,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		if catalog, ok := m.DynamicCatalogs[msg.TabName]; ok {
 			if msg.Index >= 0 && msg.Index < len(catalog.KiwixItems) {
-				if msg.Err != nil {
+				if errors.Is(msg.Err, downloader.ErrInvalidFileType) {
+					catalog.KiwixItems[msg.Index].Status = "Invalid file"
+				} else if msg.Err != nil {
 					catalog.KiwixItems[msg.Index].Status = "Error: " + msg.Err.Error()
 				} else {
 					catalog.KiwixItems[msg.Index].Status = "Downloaded"
@@ -327,87 +611,263 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case CatalogEntryAddedMsg:
+		if msg.Err != nil {
+			if m.Snippet != nil {
+				m.Snippet.err = msg.Err.Error()
+			}
+			return m, nil
+		}
+		m.Snippet = nil
+		if m.State == stateSnippet {
+			m.State = stateList
+		}
+		m.StatusMessage = fmt.Sprintf("Added '%s' to %s; list it under a category as - id: \"%s\"", msg.Source.ID, msg.Path, msg.Source.ID)
+		return m, nil
+
+	case ConfigReloadedMsg:
+		if msg.Err != nil {
+			m.StatusMessage = "Reload failed: " + msg.Err.Error()
+			return m, nil
+		}
+		cmd, err := m.applyReload(msg.Config)
+		if err != nil {
+			m.StatusMessage = "Reload skipped: " + err.Error()
+			return m, nil
+		}
+		core.ApplyRateLimitConfig(msg.Config.General.ApiRateLimit, msg.Config.General.ApiBurst)
+		core.SetBlockPrivateAddresses(msg.Config.General.BlockPrivateAddresses)
+		core.SetOffline(msg.Config.General.Offline)
+		core.SetCacheTTL(msg.Config.General.CacheTTL)
+		core.SetGitHubCacheTTL(msg.Config.General.GitHubCacheTTL)
+		core.SetAllowHooks(msg.Config.General.AllowHooks)
+		core.SetNetwork(msg.Config.General.IPVersion, msg.Config.General.DNSServer)
+		core.SetGitHubToken(msg.Config.General.GitHubToken)
+		core.SetCredentials(msg.Config)
+		core.SetHeaders(msg.Config)
+		m.StatusMessage = "Config reloaded"
+		if msg.Added != "" {
+			m.StatusMessage = "Added " + msg.Added + "; press u to check it"
+		}
+		if _, err := LoadTheme(msg.Config.General.Theme); err != nil {
+			m.StatusMessage += fmt.Sprintf(" (%v; using the earthy theme)", err)
+		}
+		return m, cmd
+
+	case SelfUpdateMsg:
+		// Failures are not worth interrupting for; the check runs again next start
+		if msg.Err == nil && msg.Update.Available {
+			m.SelfUpdate = &msg.Update
+		}
+		return m, nil
+
 	case CheckMsg:
 		m.updateItemState(msg.Category, msg.Index, func(it *Item) {
+			if it.InFlight {
+				// A download started since the check was sent; its progress owns the row
+				return
+			}
 			it.Total = 0
 			it.Downloaded = 0
 			it.LocalStatus = msg.Result.Status
 			it.CurrentVersion = msg.Result.Current
 			it.LatestVersion = msg.Result.Latest
 			it.LocalMessage = msg.Result.Message
+			it.Cached = msg.Result.Cached
+			it.LocalFilename = msg.Result.LocalFilename
+			it.RemoteFilename = msg.Result.RemoteFilename
 			if msg.Result.ResolvedURL != "" {
 				it.Source.URL = msg.Result.ResolvedURL
+				it.ResolvedChecksum = msg.Result.Checksum
+				it.ExtraURLs = msg.Result.ExtraURLs
 			}
 		})
 		return m, nil
 
+	case DownloadPlanMsg:
+		m.Planning = false
+		m.StatusMessage = ""
+		for _, e := range msg.Entries {
+			if !e.Checked {
+				continue
+			}
+			m.updateItemState(e.Category, e.Index, func(it *Item) {
+				it.LocalStatus = e.Result.Status
+				it.CurrentVersion = e.Result.Current
+				it.LatestVersion = e.Result.Latest
+				it.LocalMessage = e.Result.Message
+				it.Cached = e.Result.Cached
+				it.LocalFilename = e.Result.LocalFilename
+				it.RemoteFilename = e.Result.RemoteFilename
+				if e.Result.ResolvedURL != "" {
+					it.Source.URL = e.Result.ResolvedURL
+					it.ResolvedChecksum = e.Result.Checksum
+					it.ExtraURLs = e.Result.ExtraURLs
+				}
+			})
+		}
+		if len(msg.Items) == 0 {
+			m.StatusMessage = "Nothing to download"
+			return m, nil
+		}
+		m.PendingPlan = &msg
+		m.State = stateConfirm
+		return m, nil
+
 	case StartDownloadMsg:
 		return m, WaitForProgress(msg.Index, msg.Category, msg.ProgressChan)
 
 	case ProgressUpdateMsg:
 		m.updateItemState(msg.Category, msg.Index, func(it *Item) {
-			it.Downloaded = msg.Progress.Downloaded
-			it.Total = msg.Progress.Total
+			p := msg.Progress
+			if p.Dest != "" {
+				it.DownloadPath = p.Dest
+			}
+			if p.Threads > 0 {
+				it.Threads = p.Threads
+			}
+			it.Verifying = p.Phase == downloader.PhaseVerifying
+			// Only the transfer and verification count bytes
+			it.Downloaded, it.Total = 0, 0
+			if p.Phase == downloader.PhaseDownloading || p.Phase == downloader.PhaseVerifying {
+				it.Downloaded, it.Total = p.Downloaded, p.Total
+			}
 
-			// Special handling for space check and resolution statuses
-			if it.Total == -2 {
-				if msg.Progress.Downloaded == 0 {
-					it.LocalStatus = "Resolving URL..."
-				} else {
-					// Feedback from auto-resolution
-					it.LocalStatus = core.VersionStatus(msg.Progress.Status)
-					it.CurrentVersion = msg.Progress.Current
-					it.LatestVersion = msg.Progress.Latest
-					if msg.Progress.ResolvedURL != "" {
-						it.Source.URL = msg.Progress.ResolvedURL
-					}
+			switch p.Phase {
+			case downloader.PhaseResolving:
+				it.LocalStatus = "Resolving URL..."
+				it.enterPhase(phaseResolving, "")
+			case downloader.PhaseResolved:
+				// Feedback from auto-resolution
+				it.LocalStatus = core.VersionStatus(p.Status)
+				it.CurrentVersion = p.Current
+				it.LatestVersion = p.Latest
+				it.enterPhase(phaseResolving, resolvedNote(p.Current, p.Latest))
+				if p.ResolvedURL != "" {
+					it.Source.URL = p.ResolvedURL
+					it.ResolvedChecksum = p.Checksum
+					it.ExtraURLs = p.ExtraURLs
 				}
-			} else if it.Total == -1 {
-				if it.Downloaded == 0 {
-					it.LocalStatus = "Checking available space..."
-				} else if it.Downloaded == 1 {
-					it.LocalStatus = "Enough space available!"
-				}
-			} else if it.Downloaded == -1 {
+			case downloader.PhaseSpaceCheck:
+				it.LocalStatus = "Checking available space..."
+				it.ServedFrom = p.ResolvedURL
+				it.enterPhase(phaseSpaceCheck, "")
+			case downloader.PhaseSpaceOK:
+				it.LocalStatus = "Enough space available!"
+				it.enterPhase(phaseSpaceCheck, "enough space")
+			case downloader.PhaseNoSpace:
 				it.LocalStatus = core.VersionStatus(fmt.Sprintf("Error: Not enough space (%s available)",
-					humanize.Bytes(uint64(it.Total))))
-			} else if it.Total > 0 {
-				percent := float64(it.Downloaded) / float64(it.Total)
-				it.LocalStatus = core.VersionStatus(fmt.Sprintf("Downloading... %.1f%% (%s/%s)",
-					percent*100,
+					humanize.Bytes(uint64(p.Available))))
+				it.enterPhase(phaseSpaceCheck, humanize.Bytes(uint64(p.Available))+" available")
+				it.PhaseFailed = true
+			case downloader.PhaseVerifying:
+				it.enterPhase(phaseVerifying, "checksum")
+				it.LocalStatus = core.VersionStatus(fmt.Sprintf("Verifying checksum... %.1f%% (%s/%s)",
+					float64(it.Downloaded)/float64(max(it.Total, 1))*100,
 					humanize.Bytes(uint64(it.Downloaded)),
 					humanize.Bytes(uint64(it.Total))))
-			} else {
-				it.LocalStatus = core.VersionStatus(fmt.Sprintf("Downloading... %s",
-					humanize.Bytes(uint64(it.Downloaded))))
+			case downloader.PhaseDownloading:
+				m.SessionBytes += it.Rate.record(it.Downloaded, time.Now())
+				if it.Total > 0 {
+					it.enterPhase(phaseDownloading, "")
+					percent := float64(it.Downloaded) / float64(it.Total)
+					it.LocalStatus = core.VersionStatus(fmt.Sprintf("Downloading... %.1f%% (%s/%s)",
+						percent*100,
+						humanize.Bytes(uint64(it.Downloaded)),
+						humanize.Bytes(uint64(it.Total))))
+				} else {
+					// Unknown size, e.g. a chunked response: count bytes instead of a percentage
+					it.enterPhase(phaseDownloading, "size unknown")
+					it.LocalStatus = core.VersionStatus(fmt.Sprintf("Downloading... %s",
+						humanize.Bytes(uint64(it.Downloaded))))
+				}
 			}
 		})
+		if !m.statsTicking {
+			m.statsTicking = true
+			return m, tea.Batch(WaitForProgress(msg.Index, msg.Category, msg.ProgressChan), statsTickCmd())
+		}
 		return m, WaitForProgress(msg.Index, msg.Category, msg.ProgressChan)
 
+	case statsTickMsg:
+		// View redraws after every message, so ticking is all it takes to refresh speeds
+		if m.ActiveDownloads > 0 {
+			return m, statsTickCmd()
+		}
+		m.statsTicking = false
+		return m, nil
+
 	case DownloadMsg:
-		m.ActiveDownloads--
-		if m.ActiveDownloads < 0 {
-			m.ActiveDownloads = 0
+		// Only an in-flight item holds a slot, so a duplicate or stale message
+		// neither frees another one nor relabels the item
+		inFlight := false
+		m.updateItemState(msg.Category, msg.Index, func(it *Item) {
+			inFlight = it.InFlight
+		})
+		if !inFlight {
+			return m, nil
 		}
+		m.ActiveDownloads--
+		m.finishBatchItem(QueueItem{Category: msg.Category, Index: msg.Index})
 
 		var nextCmd tea.Cmd
 		m.updateItemState(msg.Category, msg.Index, func(it *Item) {
-			if msg.Err != nil {
+			it.InFlight = false
+			it.Verifying = false
+			it.Rate = transferRate{}
+			it.PhaseFailed = msg.Err != nil
+			if errors.Is(msg.Err, downloader.ErrChecksumMismatch) {
+				// The download was discarded rather than moved into place
+				it.LocalStatus = core.VersionStatus("Checksum Failed")
+				it.LocalMessage = msg.Err.Error()
+			} else if errors.Is(msg.Err, downloader.ErrQuotaExceeded) {
+				_, detail, _ := strings.Cut(msg.Err.Error(), ": ")
+				it.LocalStatus = core.VersionStatus("Over quota: " + detail)
+				it.LocalMessage = msg.Err.Error()
+			} else if errors.Is(msg.Err, downloader.ErrTooLarge) {
+				_, detail, _ := strings.Cut(msg.Err.Error(), ": ")
+				it.LocalStatus = core.VersionStatus("Exceeds max size: " + detail)
+				it.LocalMessage = msg.Err.Error()
+			} else if errors.Is(msg.Err, downloader.ErrInvalidFileType) {
+				it.LocalStatus = core.VersionStatus("Invalid file")
+				it.LocalMessage = msg.Err.Error()
+			} else if errors.Is(msg.Err, downloader.ErrUnexpectedExtension) {
+				it.LocalStatus = core.VersionStatus("Unexpected file type")
+				it.LocalMessage = msg.Err.Error()
+			} else if msg.Err != nil {
 				it.LocalStatus = core.VersionStatus("Error: " + msg.Err.Error())
+			} else if msg.Skipped {
+				it.LocalStatus = "Skipped (up to date)"
+				it.enterPhase(phaseDone, "")
 			} else {
-				if it.Source.Checksum != "" {
+				target := it.DownloadPath
+				if target == "" {
+					target = m.targetPath(it.Category, it.Source)
+				}
+				// The checksum was verified before the download was moved into place
+				if it.Source.Signature != "" {
 					it.LocalStatus = "Verifying integrity..."
-					target := m.Config.GetTargetPath(it.Category, it.Source)
-					nextCmd = VerifyCmd(msg.Index, msg.Category, target, it.Source.Checksum)
+					it.enterPhase(phaseVerifying, "signature")
+					nextCmd = VerifyCmd(msg.Index, msg.Category, target, "", it.Source)
+					it.InFlight = true
 				} else {
-					it.LocalStatus = "Finished"
+					if it.checksum() != "" {
+						it.LocalStatus = "Verified & Finished"
+						it.enterPhase(phaseVerifying, "checksum matches")
+					} else {
+						it.LocalStatus = "Finished"
+						it.enterPhase(phaseVerifying, "no checksum to check")
+					}
+					it.enterPhase(phaseDone, "")
 					it.Downloaded = 0
 					it.Total = 0
+					nextCmd = tea.Batch(it.recordHistoryCmd(target, false), it.pruneVersionsCmd(target), it.postDownloadCmd(msg.Index, target),
+						m.recheckCmd(it.Category, msg.Index, it.Source))
 				}
 			}
 		})
 
-		// Process queue and batch with nextCmd (verify or none)
+		// Process queue and batch with nextCmd (verify, post_download or none)
 		queueCmd := m.ProcessQueue()
 		if nextCmd != nil && queueCmd != nil {
 			return m, tea.Batch(nextCmd, queueCmd)
@@ -417,23 +877,86 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, queueCmd
 
 	case VerifyMsg:
+		var nextCmd tea.Cmd
 		m.updateItemState(msg.Category, msg.Index, func(it *Item) {
+			it.InFlight = false
+			it.PhaseFailed = msg.Err != nil || msg.SignatureErr != nil
 			if msg.Err != nil {
 				it.LocalStatus = core.VersionStatus("Checksum Failed")
 				it.LocalMessage = msg.Err.Error()
+			} else if msg.SignatureErr != nil {
+				it.LocalStatus = core.VersionStatus("Signature Invalid")
+				it.LocalMessage = msg.SignatureErr.Error()
 			} else {
 				it.LocalStatus = "Verified & Finished"
+				it.enterPhase(phaseVerifying, "signature valid")
+				it.enterPhase(phaseDone, "")
 				it.Downloaded = 0
 				it.Total = 0
+				nextCmd = tea.Batch(it.recordHistoryCmd(msg.Path, true), it.pruneVersionsCmd(msg.Path), it.postDownloadCmd(msg.Index, msg.Path),
+					m.recheckCmd(it.Category, msg.Index, it.Source))
 			}
 		})
+		return m, nextCmd
+
+	case VerifyAllProgressMsg:
+		m.StatusMessage = fmt.Sprintf("Verifying %d/%d files...", msg.Done, msg.Total)
+		return m, waitForVerifyAll(msg.updates)
+
+	case VerifyAllMsg:
+		m.VerifyingAll = false
+		counts := make(map[downloader.IntegrityStatus]int)
+		for i, r := range msg.Results {
+			counts[r.Status]++
+			if r.Status != downloader.IntegrityCorrupted {
+				continue
+			}
+			m.updateItemState(msg.Items[i].Category, msg.Items[i].Index, func(it *Item) {
+				it.LocalStatus = core.VersionStatus("Checksum Failed")
+				it.LocalMessage = r.Err.Error()
+			})
+		}
+		m.StatusMessage = fmt.Sprintf("Verified %d files: %d OK, %d corrupted, %d without a checksum, %d missing",
+			len(msg.Results), counts[downloader.IntegrityOK], counts[downloader.IntegrityCorrupted],
+			counts[downloader.IntegrityNoChecksum], counts[downloader.IntegrityMissing])
+		return m, nil
+
+	case PruneMsg:
+		if msg.Err != nil {
+			m.StatusMessage = fmt.Sprintf("Pruning old versions of %s failed: %v", msg.Name, msg.Err)
+		} else {
+			m.StatusMessage = fmt.Sprintf("Removed %d older version(s) of %s", len(msg.Removed), msg.Name)
+		}
+		return m, nil
+
+	case PostDownloadMsg:
+		m.updateItemState(msg.Category, msg.Index, func(it *Item) {
+			switch {
+			case errors.Is(msg.Err, core.ErrHooksDisabled):
+				it.HookStatus = "skipped, " + msg.Err.Error()
+			case msg.Err != nil:
+				it.HookStatus = msg.Err.Error()
+			default:
+				it.HookStatus = "exit 0"
+			}
+			if out := msg.Result.Output; out != "" {
+				// The full output is in hooks.log; the last line usually says what happened
+				it.HookStatus += ": " + out[strings.LastIndex(out, "\n")+1:]
+			}
+		})
+		return m, nil
+
+	case tea.MouseMsg:
+		if m.State == stateList {
+			m.handleMouse(msg)
+		}
 		return m, nil
 
 	case tea.WindowSizeMsg:
 		m.Width, m.Height = msg.Width, msg.Height
 		m.resizeTableColumns(msg.Width)
 		for i := range m.Tables {
-			m.Tables[i].SetHeight(msg.Height - 11) // Reserve space for tabs, headers, footer
+			m.Tables[i].SetHeight(msg.Height - 12) // Reserve space for tabs, headers, status bar, footer
 		}
 	}
 
@@ -445,16 +968,159 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if didSelect, _ := m.Filepicker.DidSelectDisabledFile(msg); didSelect {
 			m.State = stateList
 		}
-		if didSelect, _ := m.Filepicker.DidSelectFile(msg); didSelect {
+		if didSelect, dir := m.Filepicker.DidSelectFile(msg); didSelect {
+			if m.PathOverrides == nil {
+				m.PathOverrides = make(map[string]string)
+			}
+			m.PathOverrides[m.folderTarget] = dir
+			m.StatusMessage = "Target folder set to " + dir
 			m.State = stateList
 		}
 	case stateSearch:
 		m.SearchInput, cmd = m.SearchInput.Update(msg)
+	case statePin:
+		m.PinInput, cmd = m.PinInput.Update(msg)
+	case stateAddSource:
+		if f := m.SourceForm; f != nil && f.chosen {
+			f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+		}
+	case stateSnippet:
+		if s := m.Snippet; s != nil && s.writing {
+			s.file, cmd = s.file.Update(msg)
+		}
 	}
 
 	return m, cmd
 }
 
+// setPin pins the source at q to version for this session, or unpins it when
+// version is empty, and checks it again against the new target
+func (m *Model) setPin(q QueueItem, version string) tea.Cmd {
+	if m.PinOverrides == nil {
+		m.PinOverrides = make(map[string]string)
+	}
+
+	var src config.Source
+	m.updateItemState(q.Category, q.Index, func(it *Item) {
+		m.PinOverrides[overrideKey(it.Category, it.Source)] = version
+		it.Source.PinVersion = version
+		if it.Source.Strategy != "" {
+			// The resolved URL belongs to the previous target
+			it.Source.URL = ""
+			it.ResolvedChecksum = ""
+			it.ExtraURLs = nil
+		}
+		src = it.Source
+	})
+	if version == "" {
+		m.StatusMessage = src.Name + " unpinned"
+	} else {
+		m.StatusMessage = fmt.Sprintf("%s pinned to %s", src.Name, version)
+	}
+	target := m.targetPath(q.Category, src)
+	return checkSourceCmd(q.Index, q.Category, src, target, m.Config.General.GitHubToken)
+}
+
+// recheckCmd checks an item again after its download finished, so the row shows
+// the versions of the file now on disk instead of those from before the download
+func (m Model) recheckCmd(category string, index int, src config.Source) tea.Cmd {
+	return checkSourceCmd(index, category, src, m.targetPath(category, src), m.Config.General.GitHubToken)
+}
+
+// finishBatchItem counts a finished download towards the running all-categories
+// download and ends the batch once every item has finished
+func (m *Model) finishBatchItem(q QueueItem) {
+	if !m.batch[q] {
+		return
+	}
+	delete(m.batch, q)
+	m.batchDone++
+	if len(m.batch) == 0 {
+		m.batch = nil
+		m.StatusMessage = fmt.Sprintf("Download all finished (%d files)", m.batchDone)
+	}
+}
+
+// handleMouse switches tabs on a tab click, selects a clicked table row and
+// moves the cursor with the scroll wheel
+func (m *Model) handleMouse(msg tea.MouseMsg) {
+	t := &m.Tables[m.ActiveTab]
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		t.MoveUp(1)
+		return
+	case tea.MouseButtonWheelDown:
+		t.MoveDown(1)
+		return
+	case tea.MouseButtonLeft:
+	default:
+		return
+	}
+	if msg.Action != tea.MouseActionPress || m.layout == nil {
+		return
+	}
+
+	if msg.Y == m.layout.tabY {
+		for i := 0; i+1 < len(m.layout.tabStarts) && i < len(m.Tabs); i++ {
+			if msg.X >= m.layout.tabStarts[i] && msg.X < m.layout.tabStarts[i+1] {
+				m.ActiveTab = i
+				return
+			}
+		}
+		return
+	}
+
+	line := msg.Y - m.layout.tableTop
+	if line < 0 || line >= t.Height() {
+		return
+	}
+	if row := firstVisibleRow(*t) + line; row < len(t.Rows()) {
+		t.SetCursor(row)
+	}
+}
+
+// firstVisibleRow returns the index of the row drawn at the top of a table.
+// The table keeps its scroll offset private, so a copy is rendered with each
+// row's index as its first cell and the top line is read back.
+func firstVisibleRow(t table.Model) int {
+	rows := t.Rows()
+	cols := len(t.Columns())
+	if len(rows) == 0 || cols == 0 {
+		return 0
+	}
+	probe := make([]table.Row, len(rows))
+	for i := range rows {
+		probe[i] = make(table.Row, cols)
+		probe[i][0] = strconv.Itoa(i)
+	}
+	t.SetRows(probe)
+
+	lines := strings.Split(t.View(), "\n")
+	top := len(lines) - t.Height()
+	if top < 0 || top >= len(lines) {
+		return 0
+	}
+	fields := strings.Fields(ansi.Strip(lines[top]))
+	if len(fields) == 0 {
+		return 0
+	}
+	first, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0
+	}
+	return first
+}
+
+// checkTabCmd checks every source of a static tab against its latest release
+func (m Model) checkTabCmd(tabIdx int) tea.Cmd {
+	var cmds []tea.Cmd
+	for i, it := range m.TableData[tabIdx] {
+		target := m.targetPath(it.Category, it.Source)
+		cmds = append(cmds, checkSourceCmd(i, it.Category, it.Source, target, m.Config.General.GitHubToken))
+	}
+	return tea.Batch(cmds...)
+}
+
 // GutenbergDownloadMsg is sent when a Gutenberg book download completes
 type GutenbergDownloadMsg struct {
 	TabName string
@@ -506,12 +1172,19 @@ func DownloadGutenbergCmd(tabName string, index int, book core.GutenbergBook, cf
 		dest := core.GetExpectedPath(book, path, organization)
 
 		progressChan := make(chan downloader.Progress, 10)
-		go func() {
-			downloader.DownloadFile(url, dest, cfg.General.Threads, progressChan)
-		}()
+		go downloader.DownloadFile(url, dest, cfg.General.Threads, progressChan)
 
-		// Drain progress channel (simplified - doesn't show progress bar for Gutenberg)
-		for range progressChan {
+		// Drain progress channel (simplified - doesn't show progress bar for Gutenberg).
+		// A failure arrives on the channel before it is closed.
+		var dlErr error
+		for p := range progressChan {
+			if p.Error != nil {
+				dlErr = p.Error
+			}
+		}
+		// An HTML error page in place of the file is rejected before it is moved into place
+		if errors.Is(dlErr, downloader.ErrInvalidFileType) {
+			return GutenbergDownloadMsg{TabName: tabName, Index: index, Err: dlErr}
 		}
 
 		// Check if file exists after download
@@ -620,12 +1293,19 @@ func DownloadKiwixCmd(tabName string, index int, entry core.KiwixEntry, cfg *con
 		dest := core.GetExpectedKiwixPath(entry, path)
 
 		progressChan := make(chan downloader.Progress, 10)
-		go func() {
-			downloader.DownloadFile(url, dest, cfg.General.Threads, progressChan)
-		}()
+		go downloader.DownloadFile(url, dest, cfg.General.Threads, progressChan)
 
-		// Drain progress channel (simplified - doesn't show progress bar for Kiwix)
-		for range progressChan {
+		// Drain progress channel (simplified - doesn't show progress bar for Kiwix).
+		// A failure arrives on the channel before it is closed.
+		var dlErr error
+		for p := range progressChan {
+			if p.Error != nil {
+				dlErr = p.Error
+			}
+		}
+		// An HTML error page in place of the file is rejected before it is moved into place
+		if errors.Is(dlErr, downloader.ErrInvalidFileType) {
+			return KiwixDownloadMsg{TabName: tabName, Index: index, Err: dlErr}
 		}
 
 		// Check if file exists after download
@@ -648,27 +1328,263 @@ func (m *Model) updateItemState(category string, index int, updateFn func(*Item)
 	}
 }
 
+// syncTableRows rebuilds a static tab's rows from TableData, applying the search
+// filter (active tab only), the status filter and the foreign-platform toggle. Sources for another
+// OS/arch are marked so they aren't downloaded by mistake.
 func (m *Model) syncTableRows(tabIndex int) {
+	if tabIndex < 0 || tabIndex >= len(m.TableData) {
+		return
+	}
+
+	query := ""
+	if tabIndex == m.ActiveTab {
+		query = strings.ToLower(m.FilterQuery)
+	}
+
+	statusWidth := defaultStatusWidth
+	if cols := m.Tables[tabIndex].Columns(); len(cols) > 1 {
+		statusWidth = cols[1].Width
+	}
+
 	var rows []table.Row
-	for _, it := range m.TableData[tabIndex] {
-		rows = append(rows, it.ToRow())
+	var index []int
+	for i, it := range m.TableData[tabIndex] {
+		if !m.visible(it) {
+			continue
+		}
+		// Filter by Name (case-insensitive)
+		if query != "" && !strings.Contains(strings.ToLower(it.Source.Name), query) {
+			continue
+		}
+		if !m.StatusFilter.matches(it) {
+			continue
+		}
+		row := it.ToRowWithWidth(statusWidth)
+		if it.Source.IsForeign() {
+			row[0] += " [foreign]"
+		}
+		rows = append(rows, row)
+		index = append(index, i)
+	}
+	// Say why a category is empty instead of showing a blank table. The row has
+	// no entry in rowIndex, so it can't be selected as an item.
+	if len(m.TableData[tabIndex]) == 0 && tabIndex < len(m.Tabs) && m.Config.Categories[m.Tabs[tabIndex]].PlatformFiltered {
+		placeholder := make(table.Row, len(m.Tables[tabIndex].Columns()))
+		placeholder[0] = "No sources match current OS/Arch filters"
+		rows = append(rows, placeholder)
+	}
+
+	for len(m.rowIndex) < len(m.TableData) {
+		m.rowIndex = append(m.rowIndex, nil)
 	}
+	m.rowIndex[tabIndex] = index
 	m.Tables[tabIndex].SetRows(rows)
 }
 
 // applyTableFilter filters the table rows for static tabs based on FilterQuery
 func (m *Model) applyTableFilter(tabIndex int) {
-	if tabIndex < 0 || tabIndex >= len(m.TableData) {
-		return
+	m.syncTableRows(tabIndex)
+}
+
+// applyReload rebuilds tabs and tables from a freshly loaded config, preserving the
+// active tab, cursors, queued downloads, and per-item state matched by source identity.
+// In-flight progress messages address items by index, so the reload is refused if an
+// in-flight item would move or disappear, or while a "download all" is being sized.
+// A plan awaiting confirmation follows its items, and is dropped if one is gone.
+func (m *Model) applyReload(cfg *config.Config) (tea.Cmd, error) {
+	if m.Planning {
+		return nil, fmt.Errorf("download all is being sized; wait for it to finish")
 	}
+	fresh := newModel(cfg, nil, m.ShowDisabled)
 
-	query := strings.ToLower(m.FilterQuery)
-	var rows []table.Row
-	for _, it := range m.TableData[tabIndex] {
-		// Filter by Name (case-insensitive)
-		if query == "" || strings.Contains(strings.ToLower(it.Source.Name), query) {
-			rows = append(rows, it.ToRow())
+	keyOf := func(it Item) string {
+		return overrideKey(it.Category, it.Source)
+	}
+
+	oldItems := make(map[string]Item)
+	oldPos := make(map[string]int)
+	for _, items := range m.TableData {
+		for i, it := range items {
+			oldItems[keyOf(it)] = it
+			oldPos[keyOf(it)] = i
 		}
 	}
-	m.Tables[tabIndex].SetRows(rows)
+
+	newPos := make(map[string]int)
+	for tabIdx, items := range fresh.TableData {
+		for i, it := range items {
+			k := keyOf(it)
+			newPos[k] = i
+			prev, ok := oldItems[k]
+			if !ok {
+				continue
+			}
+			if prev.InFlight && oldPos[k] != i {
+				return nil, fmt.Errorf("download in progress for %s would move; wait for it to finish", prev.Source.Name)
+			}
+			it.LocalStatus = prev.LocalStatus
+			it.CurrentVersion = prev.CurrentVersion
+			it.LatestVersion = prev.LatestVersion
+			it.LocalMessage = prev.LocalMessage
+			it.Cached = prev.Cached
+			it.LocalFilename = prev.LocalFilename
+			it.RemoteFilename = prev.RemoteFilename
+			it.Downloaded = prev.Downloaded
+			it.Total = prev.Total
+			it.InFlight = prev.InFlight
+			it.DownloadPath = prev.DownloadPath
+			it.ServedFrom = prev.ServedFrom
+			it.Rate = prev.Rate
+			if it.Source.URL == "" && it.Source.PinVersion == prev.Source.PinVersion {
+				it.Source.URL = prev.Source.URL
+				it.ResolvedChecksum = prev.ResolvedChecksum
+				it.ExtraURLs = prev.ExtraURLs
+			}
+			fresh.TableData[tabIdx][i] = it
+		}
+	}
+	for k, it := range oldItems {
+		if _, ok := newPos[k]; !ok && it.InFlight {
+			return nil, fmt.Errorf("download in progress for %s, which is no longer configured", it.Source.Name)
+		}
+	}
+
+	// remap finds the new position of the item at q, if it is still configured
+	remap := func(q QueueItem) (QueueItem, bool) {
+		for tabIdx, name := range m.Tabs {
+			if name != q.Category || q.Index < 0 || q.Index >= len(m.TableData[tabIdx]) {
+				continue
+			}
+			idx, ok := newPos[keyOf(m.TableData[tabIdx][q.Index])]
+			return QueueItem{Category: q.Category, Index: idx}, ok
+		}
+		return QueueItem{}, false
+	}
+
+	// Remap queued downloads onto their new positions
+	var queue []QueueItem
+	for _, q := range m.DownloadQueue {
+		if q, ok := remap(q); ok {
+			queue = append(queue, q)
+		}
+	}
+
+	// Remap the all-categories batch; items that disappeared no longer count towards it
+	var batch map[QueueItem]bool
+	if m.batch != nil {
+		batch = make(map[QueueItem]bool)
+		for q := range m.batch {
+			if q, ok := remap(q); ok {
+				batch[q] = true
+			}
+		}
+		if len(batch) == 0 {
+			batch = nil
+		}
+	}
+
+	// Remap a plan awaiting confirmation; its sizes are no longer right if an item went away
+	var plan *DownloadPlanMsg
+	if m.PendingPlan != nil {
+		remapped := *m.PendingPlan
+		remapped.Items = nil
+		remapped.Entries = nil
+		for _, q := range m.PendingPlan.Items {
+			if q, ok := remap(q); ok {
+				remapped.Items = append(remapped.Items, q)
+			}
+		}
+		for _, e := range m.PendingPlan.Entries {
+			if q, ok := remap(QueueItem{Category: e.Category, Index: e.Index}); ok {
+				e.Index = q.Index
+				remapped.Entries = append(remapped.Entries, e)
+			}
+		}
+		if len(remapped.Items) == len(m.PendingPlan.Items) {
+			plan = &remapped
+		}
+	}
+
+	// Keep cursors and already-loaded dynamic catalogs for tabs that still exist
+	activeName := m.Tabs[m.ActiveTab]
+	cursors := make(map[string]int)
+	for i, name := range m.Tabs {
+		cursors[name] = m.Tables[i].Cursor()
+	}
+
+	var cmds []tea.Cmd
+	m.Config = cfg
+	m.Tabs = fresh.Tabs
+	m.Tables = fresh.Tables
+	m.TableData = fresh.TableData
+	m.rowIndex = fresh.rowIndex
+	m.DownloadQueue = queue
+	m.batch = batch
+	m.PendingPlan = plan
+	if plan == nil && m.State == stateConfirm {
+		m.State = stateList
+	}
+	// Overrides are keyed by source rather than position, so only pins need reapplying
+	for tabIdx := range m.TableData {
+		for i, it := range m.TableData[tabIdx] {
+			if version, ok := m.PinOverrides[keyOf(it)]; ok {
+				m.TableData[tabIdx][i].Source.PinVersion = version
+			}
+		}
+	}
+	m.ActiveTab = 0
+
+	catalogs := make(map[string]*DynamicCatalog)
+	for name, catalog := range fresh.DynamicCatalogs {
+		if prev, ok := m.DynamicCatalogs[name]; ok && prev.CatalogType == catalog.CatalogType {
+			catalogs[name] = prev
+			continue
+		}
+		catalogs[name] = catalog
+		if cmd := m.fetchCatalogCmd(name, false); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	}
+	m.DynamicCatalogs = catalogs
+
+	// Take the configured theme unless the mono theme was switched on in this session
+	if m.Theme == m.baseTheme {
+		m.Theme = fresh.Theme
+	}
+	m.baseTheme = fresh.baseTheme
+	m.applyTheme()
+
+	if m.Width > 0 {
+		m.resizeTableColumns(m.Width)
+	}
+	for i, name := range m.Tabs {
+		if m.Height > 0 {
+			m.Tables[i].SetHeight(m.Height - 12)
+		}
+		if name == activeName {
+			m.ActiveTab = i
+		}
+		if catalog, ok := m.DynamicCatalogs[name]; ok {
+			if catalog.CatalogType == "gutenberg" {
+				m.syncGutenbergTable(name)
+			} else if catalog.CatalogType == "kiwix" {
+				m.syncKiwixTable(name)
+			}
+		} else {
+			m.syncTableRows(i)
+		}
+		if c, ok := cursors[name]; ok {
+			m.Tables[i].SetCursor(c)
+		}
+	}
+	if m.FilterQuery != "" && !m.isDynamicTab(m.ActiveTab) {
+		m.applyTableFilter(m.ActiveTab)
+	}
+
+	return tea.Batch(cmds...), nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
 }
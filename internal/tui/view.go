@@ -2,43 +2,24 @@ package tui
 
 import (
 	"fmt"
+	"lamp/internal/core"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
 )
 
-var (
-	docStyle = lipgloss.NewStyle().Margin(1, 2)
-
-	// Earthy Palette
-	forestGreen = lipgloss.AdaptiveColor{Light: "#2D5A27", Dark: "#78B159"} // Active accents
-	sand        = lipgloss.AdaptiveColor{Light: "#C2B280", Dark: "#E1C699"} // Inactive/Secondary
-	clay        = lipgloss.AdaptiveColor{Light: "#A0522D", Dark: "#CD853F"} // Border/Warning
-
-	activeTabStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFFFFF")).
-			Background(forestGreen).
-			Padding(0, 1).
-			Bold(true)
-
-	inactiveTabStyle = lipgloss.NewStyle().
-				Foreground(sand).
-				Padding(0, 1)
-
-	tabRowStyle = lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder(), false, false, true, false).
-			BorderForeground(clay)
-)
+var docStyle = lipgloss.NewStyle().Margin(1, 2)
 
 func (m Model) View() string {
 	switch m.State {
 	case stateSplash:
 		warnStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("11")). // Yellow
+			Foreground(m.Theme.Warning).
 			Bold(true).
 			MarginBottom(1)
 
 		msgStyle := lipgloss.NewStyle().
-			Foreground(sand).
+			Foreground(m.Theme.Secondary).
 			MarginTop(2)
 
 		var warnings string
@@ -48,14 +29,14 @@ func (m Model) View() string {
 
 		content := lipgloss.JoinVertical(lipgloss.Center,
 			warnStyle.Render("Configuration Warning:"),
-			lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(warnings),
+			lipgloss.NewStyle().Foreground(m.Theme.Warning).Render(warnings),
 			msgStyle.Render("Press any key to continue..."),
 		)
 
 		// Center the content
 		return lipgloss.Place(m.Width, m.Height, lipgloss.Center, lipgloss.Center, docStyle.Render(content))
 
-	case stateList, stateSearch:
+	case stateList, stateSearch, stateConfirm, statePin:
 		catName := m.Tabs[m.ActiveTab]
 		cat := m.Config.Categories[m.Tabs[m.ActiveTab]]
 		catalogType := m.getCatalogType(m.ActiveTab)
@@ -71,13 +52,13 @@ func (m Model) View() string {
 					loadingText = "Loading Kiwix library..."
 				}
 				configHeader = lipgloss.NewStyle().
-					Foreground(sand).
+					Foreground(m.Theme.Secondary).
 					Width(m.Width - 4).
 					Align(lipgloss.Center).
 					Render(loadingText)
 			} else if catalog.Error != "" {
 				configHeader = lipgloss.NewStyle().
-					Foreground(lipgloss.Color("9")). // Red
+					Foreground(m.Theme.Error).
 					Width(m.Width - 4).
 					Align(lipgloss.Center).
 					Render(fmt.Sprintf("Error loading: %s", catalog.Error))
@@ -89,7 +70,7 @@ func (m Model) View() string {
 					itemCount = len(catalog.KiwixItems)
 				}
 				configHeader = lipgloss.NewStyle().
-					Foreground(sand).
+					Foreground(m.Theme.Secondary).
 					Width(m.Width - 4).
 					Align(lipgloss.Center).
 					Render(fmt.Sprintf("Search results for: \"%s\" (%d items) | Path: %s", catalog.SearchQuery, itemCount, cat.Path))
@@ -101,7 +82,7 @@ func (m Model) View() string {
 					defaultText = fmt.Sprintf("Kiwix Library (%d ZIMs) | Path: %s", len(catalog.KiwixItems), cat.Path)
 				}
 				configHeader = lipgloss.NewStyle().
-					Foreground(sand).
+					Foreground(m.Theme.Secondary).
 					Width(m.Width - 4).
 					Align(lipgloss.Center).
 					Render(defaultText)
@@ -113,7 +94,7 @@ func (m Model) View() string {
 				dlPath = m.Config.Storage.DefaultRoot
 			}
 			configHeader = lipgloss.NewStyle().
-				Foreground(sand).
+				Foreground(m.Theme.Secondary).
 				Width(m.Width - 4).
 				Align(lipgloss.Center).
 				Render(fmt.Sprintf("Targets: OS=%v Arch=%v | Path: %s", m.Config.General.OS, m.Config.General.Arch, dlPath))
@@ -122,44 +103,143 @@ func (m Model) View() string {
 		var tabs []string
 		for i, t := range m.Tabs {
 			if i == m.ActiveTab {
-				tabs = append(tabs, activeTabStyle.Render(t))
+				tabs = append(tabs, m.Theme.activeTabStyle().Render(t))
 			} else {
-				tabs = append(tabs, inactiveTabStyle.Render(t))
+				tabs = append(tabs, m.Theme.inactiveTabStyle().Render(t))
 			}
 		}
 
-		tabRow := tabRowStyle.
+		tabRow := m.Theme.tabRowStyle().
 			Width(m.Width - 4).
 			Align(lipgloss.Center).
 			Render(lipgloss.JoinHorizontal(lipgloss.Top, tabs...))
 
 		tableView := m.Tables[m.ActiveTab].View()
 
+		// Record tab and row positions for mouse clicks, offset by docStyle's margins
+		if m.layout != nil {
+			tabsWidth := 0
+			for _, t := range tabs {
+				tabsWidth += lipgloss.Width(t)
+			}
+			x := 2 + max(0, m.Width-4-tabsWidth)/2
+			m.layout.tabStarts = m.layout.tabStarts[:0]
+			for _, t := range tabs {
+				m.layout.tabStarts = append(m.layout.tabStarts, x)
+				x += lipgloss.Width(t)
+			}
+			m.layout.tabStarts = append(m.layout.tabStarts, x)
+			m.layout.tabY = 2
+			m.layout.tableTop = 2 + lipgloss.Height(tabRow) +
+				lipgloss.Height(tableView) - m.Tables[m.ActiveTab].Height()
+		}
+
 		// Footer - different for dynamic catalogs
 		var footer string
 		if m.isDynamicTab(m.ActiveTab) {
 			if m.State == stateSearch {
 				footer = lipgloss.NewStyle().
-					Foreground(sand).
+					Foreground(m.Theme.Secondary).
 					MarginTop(1).
 					Render(" Enter: search | Esc: cancel | Type to search...")
 			} else {
 				footer = lipgloss.NewStyle().
-					Foreground(sand).
+					Foreground(m.Theme.Secondary).
 					MarginTop(1).
-					Render(" h/l: tabs | /: search | d: download | Esc: back to list | c: open config | q: quit")
+					Render(" h/l: tabs | /: search | d: download | n: track in a catalog | Esc: back to list | shift-r: refresh | t: theme | c: open config | q: quit")
 			}
 		} else {
 			footer = lipgloss.NewStyle().
-				Foreground(sand).
+				Foreground(m.Theme.Secondary).
 				MarginTop(1).
-				Render(" h/l: tabs | d: download | shift-d: download all | u: check updates | shift-u: update all | c: open config | q: quit")
+				Render(" h/l: tabs | d: download | shift-d: download all | shift-a: all categories | u: check updates | shift-u: update all | shift-v: verify all | f: target folder | v: pin version | enter: download details | a: add source | p: this platform only | o/x/*: outdated/errors/all | e: show disabled | r: reload config | shift-r: refresh | t: theme | c: open config | q: quit")
+		}
+
+		// Details for the highlighted item
+		if !m.isDynamicTab(m.ActiveTab) {
+			if m.StatusFilter != filterNone {
+				footer = lipgloss.JoinVertical(lipgloss.Left, footer,
+					lipgloss.NewStyle().Foreground(m.Theme.Accent).Render(
+						fmt.Sprintf(" Status filter: %s (%d of %d shown, *: show all)",
+							m.StatusFilter, len(m.rowIndex[m.ActiveTab]), len(m.TableData[m.ActiveTab]))))
+			}
+			if idx := m.selectedIndex(); idx >= 0 {
+				it := m.TableData[m.ActiveTab][idx]
+				if dir, ok := m.PathOverrides[overrideKey(it.Category, it.Source)]; ok {
+					footer = lipgloss.JoinVertical(lipgloss.Left, footer,
+						lipgloss.NewStyle().Foreground(m.Theme.Secondary).Render(" Target folder: "+dir+" (shift-f: clear)"))
+				}
+				if files := core.FileChange(it.LocalFilename, it.RemoteFilename); files != "" {
+					footer = lipgloss.JoinVertical(lipgloss.Left, footer,
+						lipgloss.NewStyle().Foreground(m.Theme.Secondary).Render(" Files: "+files))
+				}
+				if it.Source.PinVersion != "" {
+					footer = lipgloss.JoinVertical(lipgloss.Left, footer,
+						lipgloss.NewStyle().Foreground(m.Theme.Secondary).Render(" Pinned to: "+it.Source.PinVersion+" (v: change)"))
+				}
+				if it.InFlight && it.Threads > 0 && it.Total > 0 {
+					footer = lipgloss.JoinVertical(lipgloss.Left, footer,
+						lipgloss.NewStyle().Foreground(m.Theme.Secondary).Render(fmt.Sprintf(" Connections: %d", it.Threads)))
+				}
+				if it.HookStatus != "" {
+					footer = lipgloss.JoinVertical(lipgloss.Left, footer,
+						lipgloss.NewStyle().Foreground(m.Theme.Secondary).Render(" Post-download: "+it.HookStatus))
+				}
+				if it.ServedFrom != "" {
+					footer = lipgloss.JoinVertical(lipgloss.Left, footer,
+						lipgloss.NewStyle().Foreground(m.Theme.Secondary).Render(" Served from: "+it.ServedFrom))
+				}
+			}
+		}
+
+		if m.State == statePin {
+			footer = lipgloss.JoinVertical(lipgloss.Left, footer,
+				lipgloss.NewStyle().Foreground(m.Theme.Accent).Bold(true).Render(" Pin version: ")+m.PinInput.View(),
+				lipgloss.NewStyle().Foreground(m.Theme.Secondary).Render(" Enter: pin (empty to unpin) | Esc: cancel"))
+		}
+
+		if m.State == stateConfirm && m.PendingPlan != nil {
+			plan := m.PendingPlan
+			prompt := fmt.Sprintf(" Download %d files (%s", len(plan.Items), humanize.Bytes(uint64(plan.TotalBytes)))
+			if plan.Unknown > 0 {
+				prompt += fmt.Sprintf(", %d of unknown size", plan.Unknown)
+			}
+			prompt += ")? [y/N]"
+			footer = lipgloss.NewStyle().Foreground(m.Theme.Secondary).MarginTop(1).Bold(true).Render(prompt)
+			if !plan.SpaceOK {
+				footer = lipgloss.JoinVertical(lipgloss.Left, footer,
+					lipgloss.NewStyle().Foreground(m.Theme.Error).Render(
+						fmt.Sprintf(" Warning: not enough space (%s available)", humanize.Bytes(uint64(plan.Available)))))
+			}
+		}
+
+		if m.batch != nil {
+			total := m.batchDone + len(m.batch)
+			footer = lipgloss.JoinVertical(lipgloss.Left, footer,
+				lipgloss.NewStyle().Foreground(m.Theme.Accent).Render(
+					fmt.Sprintf(" Downloading all categories: %d/%d files done", m.batchDone, total)))
+		}
+
+		if m.SelfUpdate != nil {
+			footer = lipgloss.JoinVertical(lipgloss.Left, footer,
+				lipgloss.NewStyle().Foreground(m.Theme.Accent).Render(
+					fmt.Sprintf(" LAMP %s is available (running %s): %s", m.SelfUpdate.Latest, m.SelfUpdate.Current, m.SelfUpdate.ReleaseURL)))
+		}
+
+		if m.Config.General.Offline {
+			footer = lipgloss.JoinVertical(lipgloss.Left, footer,
+				lipgloss.NewStyle().Foreground(m.Theme.Border).Render(" Offline: showing cached results, downloads disabled"))
+		}
+
+		if m.StatusMessage != "" {
+			footer = lipgloss.JoinVertical(lipgloss.Left, footer,
+				lipgloss.NewStyle().Foreground(m.Theme.Border).Render(" "+m.StatusMessage))
 		}
 
 		// Search bar - always visible, compact inline style (no border)
-		searchPrefix := lipgloss.NewStyle().Foreground(sand).Render("/:")
+		searchPrefix := lipgloss.NewStyle().Foreground(m.Theme.Secondary).Render("/:")
 		if m.State == stateSearch {
-			searchPrefix = lipgloss.NewStyle().Foreground(forestGreen).Bold(true).Render("/:")
+			searchPrefix = lipgloss.NewStyle().Foreground(m.Theme.Accent).Bold(true).Render("/:")
 		}
 		searchBar := searchPrefix + " " + m.SearchInput.View()
 
@@ -185,17 +265,39 @@ func (m Model) View() string {
 			topRow,
 			tabRow,
 			tableView,
+			m.statusBar(),
 			footer,
 		)
 
 		return docStyle.Render(content)
 
+	case stateAddSource:
+		return docStyle.Render(m.sourceFormView())
+
+	case stateSnippet:
+		return docStyle.Render(m.snippetView())
+
+	case stateDownloading:
+		return docStyle.Render(m.downloadDetailView())
+
 	case stateFolderSelect:
 		return docStyle.Render(fmt.Sprintf(
-			"Select Target Directory (ESC to cancel):\n\n%s",
+			"Select Target Directory (enter: select | h/l: navigate | esc: cancel):\n\n%s",
 			m.Filepicker.View(),
 		))
 	default:
 		return "Unknown state"
 	}
 }
+
+// statusBar summarizes all running downloads on one line above the footer
+func (m Model) statusBar() string {
+	stats := m.downloadStats()
+	style := lipgloss.NewStyle().Foreground(m.Theme.Secondary)
+	bar := fmt.Sprintf(" Downloads: %d active | %s/s | %s this session | %d queued",
+		stats.Active, humanize.Bytes(uint64(stats.BytesPerSec)), humanize.Bytes(uint64(stats.Session)), stats.Queued)
+	if stats.Stalled > 0 {
+		return style.Render(bar + " | " + lipgloss.NewStyle().Foreground(m.Theme.Warning).Render(fmt.Sprintf("%d stalled", stats.Stalled)))
+	}
+	return style.Render(bar)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"lamp/internal/config"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// listEntry is one expanded source as printed by -list
+type listEntry struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	ID       string `json:"id,omitempty"`
+	Strategy string `json:"strategy"`
+	OS       string `json:"os,omitempty"`
+	Arch     string `json:"arch,omitempty"`
+	Path     string `json:"path"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// runList prints every configured source matching filter with its strategy and
+// target path. Nothing is resolved, so no requests are made.
+func runList(cfg *config.Config, warnings []string, filter checkFilter, jsonOutput bool) int {
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	entries := listSources(cfg, filter)
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode sources: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No sources configured")
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CATEGORY\tNAME\tSTRATEGY\tPATH")
+	for _, e := range entries {
+		name := e.Name
+		if !e.Enabled {
+			name += " (disabled)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Category, name, e.Strategy, e.Path)
+	}
+	w.Flush()
+	return 0
+}
+
+// listSources returns the sources matching filter in sorted category order. Unlike
+// -check, disabled categories and sources are included and marked as such.
+func listSources(cfg *config.Config, filter checkFilter) []listEntry {
+	catNames := make([]string, 0, len(cfg.Categories))
+	for name := range cfg.Categories {
+		catNames = append(catNames, name)
+	}
+	sort.Strings(catNames)
+
+	entries := []listEntry{}
+	for _, catName := range catNames {
+		cat := cfg.Categories[catName]
+		for _, src := range cat.Sources {
+			if !filter.matches(catName, src) {
+				continue
+			}
+			entries = append(entries, listEntry{
+				Category: catName,
+				Name:     src.Name,
+				ID:       src.ID,
+				Strategy: src.Strategy,
+				OS:       src.OS,
+				Arch:     src.Arch,
+				Path:     cfg.GetTargetPath(catName, src),
+				Enabled:  cat.IsEnabled() && src.IsEnabled(),
+			})
+		}
+	}
+	return entries
+}
@@ -9,10 +9,10 @@ import (
 	"lamp/internal/tui"
 	"log"
 	"os"
-	"sort"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
 var (
@@ -28,8 +28,32 @@ var embeddedFiles embed.FS
 var defaultConfig []byte
 
 func main() {
+	configPath := flag.String("config", "", "Path to config.yaml (default: ./config.yaml, then $XDG_CONFIG_HOME/lamp or the user config directory)")
 	checkMode := flag.Bool("check", false, "Check status of all monitored applications")
+	listMode := flag.Bool("list", false, "List every configured source with its strategy and target path, without checking it")
 	versionMode := flag.Bool("version", false, "Print version information")
+	jsonOutput := flag.Bool("json", false, "With --check or --list, print results as JSON")
+	metricsMode := flag.Bool("metrics", false, "Print source status as Prometheus metrics")
+	categoryFilter := flag.String("category", "", "With --check/--list/--metrics/--verify-all/--debug-expand/--history, only check this category")
+	nameFilter := flag.String("name", "", "With --check/--list/--metrics/--verify-all/--debug-expand/--history, only check sources whose name contains this text")
+	failOn := flag.String("fail-on", "newer", "With --check, exit non-zero on: error, newer (errors or updates), none")
+	sizesMode := flag.Bool("sizes", false, "With --check, fetch download sizes of outdated sources for the summary")
+	verbose := flag.Bool("v", false, "With --check, show the local file and the remote file each source would download")
+	groupMode := flag.Bool("group", false, "With --check, show one line per source when all its OS/arch variants share a version")
+	debugExpand := flag.Bool("debug-expand", false, "Print every source as expanded for each OS/arch, with its substituted params, then exit")
+	historyMode := flag.Bool("history", false, "Print the most recent completed downloads from history.jsonl")
+	verifyAll := flag.Bool("verify-all", false, "Re-hash every downloaded file and compare it with its known checksum")
+	exportMode := flag.Bool("export-script", false, "Resolve all sources and print a script that downloads them")
+	exportFormat := flag.String("export-format", "sh", "With --export-script, output format: sh or aria2")
+	addGithub := flag.String("add-github", "", "Suggest a github_release catalog entry for owner/repo and append it to a catalog")
+	offlineMode := flag.Bool("offline", false, "Only use cached statuses and catalogs; no network requests or downloads")
+	noColor := flag.Bool("no-color", false, "Disable colors in output and start the TUI in the mono theme (also set by NO_COLOR)")
+	selfUpdateCheck := flag.Bool("self-update-check", false, "Check whether a newer LAMP release is available, then exit")
+	clearCache := flag.Bool("clear-cache", false, "Delete the cached Gutenberg and Kiwix catalogs, GitHub releases and check results, then exit")
+	initMode := flag.Bool("init", false, "Write the default config.yaml and catalogs to the config directory, then exit")
+	force := flag.Bool("force", false, "With --init, overwrite files that already exist")
+	completion := flag.String("completion", "", "Print a shell completion script for bash, zsh or fish")
+	catalogFile := flag.String("catalog", "custom.yaml", "With --add-github, catalog file to append to (bare names are placed in the catalogs directory)")
 	flag.Parse()
 
 	if *versionMode {
@@ -39,87 +63,147 @@ func main() {
 		os.Exit(0)
 	}
 
-	if err := config.EnsureConfigExists(defaultConfig, embeddedFiles); err != nil {
-		fmt.Printf("Warning: failed to ensure config exists: %v\n", err)
+	if *completion != "" {
+		if isCompletionShell(*completion) {
+			os.Exit(runCompletionScript(*completion))
+		}
+		// The scripts run "-completion category|name" to list words from the config
+		if !completionFromConfig[*completion] {
+			fmt.Fprintf(os.Stderr, "Invalid --completion value '%s' (expected bash, zsh or fish)\n", *completion)
+			os.Exit(2)
+		}
+		// Completing a word must not wait on remote catalogs
+		config.ForceOffline()
+	}
+
+	// https://no-color.org: any non-empty NO_COLOR disables color
+	monochrome := *noColor || os.Getenv("NO_COLOR") != ""
+	if monochrome {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+
+	if *initMode {
+		os.Exit(runInit(*force))
 	}
 
-	// Pass empty string to load from default location
-	cfg, err := config.LoadConfig("", defaultConfig, embeddedFiles)
+	if *clearCache {
+		removed, err := core.ClearCaches()
+		for _, path := range removed {
+			fmt.Printf("Removed %s\n", path)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to clear caches: %v\n", err)
+			os.Exit(1)
+		}
+		if len(removed) == 0 {
+			fmt.Println("No cache files to remove")
+		}
+		os.Exit(0)
+	}
+
+	// Listing sources must not fetch remote catalogs either
+	if *offlineMode || *listMode {
+		config.ForceOffline()
+	}
+
+	// The global config and catalogs are only seeded when no explicit config is given
+	if *configPath == "" {
+		if err := config.EnsureConfigExists(defaultConfig, embeddedFiles); err != nil {
+			fmt.Printf("Warning: failed to ensure config exists: %v\n", err)
+		}
+	}
+
+	// An empty path loads from the default location
+	cfg, err := config.LoadConfig(*configPath, defaultConfig, embeddedFiles)
 	if err != nil {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
+	if *completion != "" {
+		os.Exit(runCompletionWords(cfg, *completion))
+	}
+
 	// 1.5. Apply Rate Limits
 	core.ApplyRateLimitConfig(cfg.General.ApiRateLimit, cfg.General.ApiBurst)
+	core.SetBlockPrivateAddresses(cfg.General.BlockPrivateAddresses)
+	core.SetOffline(cfg.General.Offline)
+	core.SetCacheTTL(cfg.General.CacheTTL)
+	core.SetGitHubCacheTTL(cfg.General.GitHubCacheTTL)
+	core.SetAllowHooks(cfg.General.AllowHooks)
+	core.SetNetwork(cfg.General.IPVersion, cfg.General.DNSServer)
+	core.SetGitHubToken(cfg.General.GitHubToken)
+	authWarnings := core.SetCredentials(cfg)
+	authWarnings = append(authWarnings, core.SetHeaders(cfg)...)
+	core.OpenStatusCache()
+	core.OpenGitHubCache()
 
 	// Check system compatibility
-	warnings := config.CheckSystemCompatibility(cfg)
+	warnings := append(cfg.Warnings, config.CheckSystemCompatibility(cfg)...)
+	warnings = append(warnings, authWarnings...)
 
-	if *checkMode {
-		if len(warnings) > 0 {
-			warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11")) // Yellow
-			fmt.Println(warnStyle.Render("Configuration Warning:"))
-			for _, w := range warnings {
-				fmt.Println(warnStyle.Render("- " + w))
-			}
-			fmt.Println("") // Spacer
-		}
+	filter := checkFilter{Category: *categoryFilter, Name: *nameFilter}
 
-		fmt.Println("Checking status of all monitored applications...")
-		fmt.Println("--------------------------------------------------")
+	if *debugExpand {
+		os.Exit(runDebugExpand(cfg, warnings, filter))
+	}
 
-		// Define CLI Styles
-		red := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
-		yellow := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
-		green := lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
-		gray := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	if *listMode {
+		os.Exit(runList(cfg, warnings, filter, *jsonOutput))
+	}
 
-		tabs := make([]string, 0, len(cfg.Categories))
-		for name := range cfg.Categories {
-			tabs = append(tabs, name)
+	if *addGithub != "" {
+		if cfg.General.Offline {
+			fmt.Fprintln(os.Stderr, "--add-github needs network access and cannot run in offline mode")
+			os.Exit(1)
 		}
-		sort.Strings(tabs)
-
-		for _, catName := range tabs {
-			cat := cfg.Categories[catName]
-			for _, src := range cat.Sources {
-				target := cfg.GetTargetPath(catName, src)
-				checker := core.NewChecker(nil, cfg.General.GitHubToken)
-				result := checker.CheckVersion(src, target)
-
-				statusStr := string(result.Status)
-				style := gray // Default
-
-				switch result.Status {
-				case core.StatusUpToDate:
-					statusStr = green.Render(statusStr)
-					style = green
-				case core.StatusNewer:
-					statusStr = yellow.Render(statusStr)
-					style = yellow
-				case core.StatusNotFound:
-					statusStr = red.Render(statusStr)
-					style = red
-				case core.StatusError:
-					statusStr = red.Bold(true).Render(statusStr)
-					style = red
-				}
-
-				versionInfo := ""
-				if result.Current != "" && result.Latest != "" {
-					versionInfo = style.Render(fmt.Sprintf(" [%s -> %s]", result.Current, result.Latest))
-				} else if result.Latest != "" {
-					versionInfo = style.Render(fmt.Sprintf(" [Latest: %s]", result.Latest))
-				}
-
-				fmt.Printf("[%s] %s: %s%s\n", catName, src.Name, statusStr, versionInfo)
-			}
+		os.Exit(runAddGithub(cfg, *configPath, *addGithub, *catalogFile))
+	}
+
+	if *selfUpdateCheck {
+		if cfg.General.Offline {
+			fmt.Fprintln(os.Stderr, "--self-update-check needs network access and cannot run in offline mode")
+			os.Exit(1)
 		}
-		os.Exit(0)
+		os.Exit(runSelfUpdateCheck(cfg))
+	}
+
+	if *historyMode {
+		os.Exit(runHistory(filter))
+	}
+
+	if *exportMode {
+		os.Exit(runExport(cfg, filter, *exportFormat))
+	}
+
+	if *verifyAll {
+		os.Exit(runVerifyAll(cfg, filter))
+	}
+
+	if *metricsMode {
+		os.Exit(runMetrics(cfg, filter))
+	}
+
+	if *checkMode {
+		os.Exit(runCheck(cfg, warnings, filter, *jsonOutput, *failOn, *sizesMode, *verbose, *groupMode))
 	}
 
 	m := tui.NewModel(cfg, warnings)
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	if monochrome {
+		// The mono theme uses no colors but needs bold and reverse video to show the selection
+		lipgloss.SetColorProfile(termenv.ANSI)
+		m.SetMonochrome()
+	}
+	if version != "dev" {
+		m.Version = version
+	}
+	m.LoadConfig = func() (*config.Config, error) {
+		return config.LoadConfig(*configPath, defaultConfig, embeddedFiles)
+	}
+	m.ConfigPath = *configPath
+	if m.ConfigPath == "" {
+		m.ConfigPath = config.DefaultConfigPath()
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
 
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running program: %v", err)
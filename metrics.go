@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"lamp/internal/config"
+	"lamp/internal/core"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// runMetrics prints a Prometheus text exposition snapshot of all sources,
+// suitable for the node_exporter textfile collector
+func runMetrics(cfg *config.Config, filter checkFilter) int {
+	entries := collectChecks(cfg, filter, nil)
+	writeMetrics(os.Stdout, cfg, entries)
+	return 0
+}
+
+func writeMetrics(w io.Writer, cfg *config.Config, entries []checkEntry) {
+	fmt.Fprintln(w, "# HELP lamp_source_up_to_date Whether the local copy of a source is the latest version (1) or not (0).")
+	fmt.Fprintln(w, "# TYPE lamp_source_up_to_date gauge")
+	errors := 0
+	for _, e := range entries {
+		upToDate := 0
+		if e.Status == core.StatusUpToDate {
+			upToDate = 1
+		}
+		if e.Status == core.StatusError {
+			errors++
+		}
+		fmt.Fprintf(w, "lamp_source_up_to_date{category=\"%s\",name=\"%s\"} %d\n",
+			escapeLabel(e.Category), escapeLabel(e.Name), upToDate)
+	}
+
+	fmt.Fprintln(w, "# HELP lamp_source_check_errors_total Number of sources whose check failed.")
+	fmt.Fprintln(w, "# TYPE lamp_source_check_errors_total gauge")
+	fmt.Fprintf(w, "lamp_source_check_errors_total %d\n", errors)
+
+	fmt.Fprintln(w, "# HELP lamp_local_file_bytes Size of the local file for sources present on disk.")
+	fmt.Fprintln(w, "# TYPE lamp_local_file_bytes gauge")
+	for _, e := range entries {
+		size, ok := localFileSize(cfg, e)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "lamp_local_file_bytes{category=\"%s\",name=\"%s\"} %d\n",
+			escapeLabel(e.Category), escapeLabel(e.Name), size)
+	}
+}
+
+// localFileSize returns the size of the file backing an entry, checking the
+// configured target, then the local file the check found (an older version for
+// a Newer source) and the resolved remote filename in the target directory
+func localFileSize(cfg *config.Config, e checkEntry) (int64, bool) {
+	target := cfg.GetTargetPath(e.Category, e.source)
+	candidates := []string{target}
+	if e.LocalFilename != "" {
+		candidates = append(candidates, filepath.Join(filepath.Dir(target), e.LocalFilename))
+	}
+	if e.ResolvedURL != "" {
+		candidates = append(candidates, filepath.Join(filepath.Dir(target), path.Base(e.ResolvedURL)))
+	}
+	for _, c := range candidates {
+		if info, err := os.Stat(c); err == nil && !info.IsDir() {
+			return info.Size(), true
+		}
+	}
+	return 0, false
+}
+
+// escapeLabel escapes a label value per the Prometheus text format
+func escapeLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return v
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"lamp/internal/config"
+	"lamp/internal/core"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	dir := t.TempDir()
+	// An older release is on disk while a newer one is available
+	if err := os.WriteFile(filepath.Join(dir, "tool-1.0.tar.gz"), make([]byte, 1234), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Categories: map[string]config.Category{
+		`Tools "main"`: {Path: dir},
+	}}
+	tool := config.Source{Name: "tool", URL: "https://example.com/tool-1.1.tar.gz"}
+	entries := []checkEntry{
+		{
+			Category: `Tools "main"`, Name: "tool", Status: core.StatusNewer,
+			ResolvedURL: tool.URL, LocalFilename: "tool-1.0.tar.gz", source: tool,
+		},
+		{
+			Category: `Tools "main"`, Name: "C:\\path\nsecond line", Status: core.StatusError,
+			source: config.Source{Name: "broken", URL: "https://example.com/broken.iso"},
+		},
+		{
+			Category: `Tools "main"`, Name: "current", Status: core.StatusUpToDate,
+			source: config.Source{Name: "current", URL: "https://example.com/current.iso"},
+		},
+	}
+
+	var buf bytes.Buffer
+	writeMetrics(&buf, cfg, entries)
+	out := buf.String()
+
+	for _, want := range []string{
+		`lamp_source_up_to_date{category="Tools \"main\"",name="tool"} 0` + "\n",
+		`lamp_source_up_to_date{category="Tools \"main\"",name="C:\\path\nsecond line"} 0` + "\n",
+		`lamp_source_up_to_date{category="Tools \"main\"",name="current"} 1` + "\n",
+		"lamp_source_check_errors_total 1\n",
+		`lamp_local_file_bytes{category="Tools \"main\"",name="tool"} 1234` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "lamp_local_file_bytes{"); n != 1 {
+		t.Errorf("Expected a size only for the file on disk, got %d sizes", n)
+	}
+	// Every sample is on its own line, so no label value leaked a raw newline
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if !strings.HasPrefix(line, "# ") && !strings.HasPrefix(line, "lamp_") {
+			t.Errorf("Unexpected line %q", line)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"lamp/internal/config"
+	"lamp/internal/core"
+	"os"
+)
+
+// runSelfUpdateCheck reports whether a newer LAMP release than the running one is available
+func runSelfUpdateCheck(cfg *config.Config) int {
+	checker := core.NewChecker(nil, cfg.General.GitHubToken)
+	update, err := checker.CheckSelfUpdate(version)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to check for a newer LAMP release: %v\n", err)
+		return 1
+	}
+
+	switch {
+	case update.Available:
+		fmt.Printf("LAMP %s is available (running %s): %s\n", update.Latest, update.Current, update.ReleaseURL)
+		if update.AssetURL != "" {
+			fmt.Printf("Download for this platform: %s\n", update.AssetURL)
+		}
+	case version == "dev":
+		fmt.Printf("Running a development build; the latest release is %s: %s\n", update.Latest, update.ReleaseURL)
+	default:
+		fmt.Printf("LAMP %s is up to date\n", update.Current)
+	}
+	return 0
+}
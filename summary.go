@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"lamp/internal/config"
+	"lamp/internal/core"
+	"lamp/internal/downloader"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// checkSummary is the closing overview printed after --check results
+type checkSummary struct {
+	UpToDate int
+	Newer    int
+	Missing  int
+	Errors   int
+
+	Pending      int   // Sources that need downloading (newer or missing)
+	PendingBytes int64 // Known download size of pending sources, when sizes were fetched
+	UnknownSizes int   // Pending sources whose size could not be determined
+	SizesFetched bool
+
+	Categories []categoryUsage
+}
+
+// categoryUsage is the on-disk footprint of one category's storage directories
+type categoryUsage struct {
+	Name      string
+	UsedBytes int64
+	FreeBytes int64 // -1 when the volume could not be queried
+	Exists    bool  // Whether any of the category's directories exist yet
+}
+
+// summarizeChecks counts entries by status, measures category directories and,
+// when fetchSizes is set, sums download sizes of pending sources with HEAD requests
+func summarizeChecks(cfg *config.Config, entries []checkEntry, fetchSizes bool) checkSummary {
+	var s checkSummary
+	var pendingURLs []string
+	for _, e := range entries {
+		switch e.Status {
+		case core.StatusUpToDate:
+			s.UpToDate++
+		case core.StatusNewer:
+			s.Newer++
+		case core.StatusNotFound, core.StatusIncomplete:
+			s.Missing++
+		case core.StatusError:
+			s.Errors++
+		}
+		if e.Status == core.StatusNewer || e.Status == core.StatusNotFound || e.Status == core.StatusIncomplete {
+			s.Pending++
+			pendingURLs = append(pendingURLs, e.ResolvedURL)
+		}
+	}
+
+	if fetchSizes {
+		s.SizesFetched = true
+		for _, size := range headSizes(pendingURLs, cfg.General.CheckConcurrency) {
+			if size > 0 {
+				s.PendingBytes += size
+			} else {
+				s.UnknownSizes++
+			}
+		}
+	}
+
+	s.Categories = categoryUsages(cfg, entries)
+	return s
+}
+
+// headSizes returns the Content-Length of each URL using up to workers parallel
+// HEAD requests. Unknown sizes are reported as -1.
+func headSizes(urls []string, workers int) []int64 {
+	sizes := make([]int64, len(urls))
+	client := &http.Client{Timeout: 30 * time.Second, CheckRedirect: core.ValidateRedirect, Transport: core.AuthTransport(nil)}
+
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(urls) {
+		workers = len(urls)
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				sizes[i] = headSize(client, urls[i])
+			}
+		}()
+	}
+	for i := range urls {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	return sizes
+}
+
+func headSize(client *http.Client, url string) int64 {
+	if url == "" || core.ValidateDownloadURL(url) != nil {
+		return -1
+	}
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		return -1
+	}
+	req.Header.Set("User-Agent", "lamp/1.0")
+	resp, err := client.Do(req)
+	if err != nil {
+		return -1
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return -1
+	}
+	return resp.ContentLength
+}
+
+// categoryUsages measures the directories the checked sources of each category
+// are stored in. Nested directories are only counted once.
+func categoryUsages(cfg *config.Config, entries []checkEntry) []categoryUsage {
+	dirs := make(map[string]map[string]bool)
+	for _, e := range entries {
+		target := cfg.GetTargetPath(e.Category, e.source)
+		if target == "" {
+			continue
+		}
+		if dirs[e.Category] == nil {
+			dirs[e.Category] = make(map[string]bool)
+		}
+		dirs[e.Category][filepath.Dir(target)] = true
+	}
+
+	names := make([]string, 0, len(dirs))
+	for name := range dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	usages := make([]categoryUsage, 0, len(names))
+	for _, name := range names {
+		u := categoryUsage{Name: name, FreeBytes: -1}
+		for _, dir := range config.OutermostDirs(dirs[name]) {
+			if _, err := os.Stat(dir); err != nil {
+				continue
+			}
+			u.Exists = true
+			u.UsedBytes += downloader.DirSize(dir)
+			if u.FreeBytes < 0 {
+				// The probe file only selects the volume; it is never created
+				if _, avail, err := downloader.CheckAvailableSpace(filepath.Join(dir, ".lamp"), 0); err == nil {
+					u.FreeBytes = avail
+				}
+			}
+		}
+		usages = append(usages, u)
+	}
+	return usages
+}
+
+func printCheckSummary(w io.Writer, s checkSummary) {
+	fmt.Fprintln(w, "--------------------------------------------------")
+	fmt.Fprintf(w, "Up to date: %d, Newer: %d, Missing: %d, Errors: %d\n", s.UpToDate, s.Newer, s.Missing, s.Errors)
+
+	switch {
+	case s.Pending == 0:
+		fmt.Fprintln(w, "Nothing to download")
+	case !s.SizesFetched:
+		fmt.Fprintf(w, "To download: %d files (use -sizes to total their size)\n", s.Pending)
+	case s.UnknownSizes > 0:
+		fmt.Fprintf(w, "To download: %s across %d files (%d of unknown size)\n",
+			humanize.Bytes(uint64(s.PendingBytes)), s.Pending, s.UnknownSizes)
+	default:
+		fmt.Fprintf(w, "To download: %s across %d files\n", humanize.Bytes(uint64(s.PendingBytes)), s.Pending)
+	}
+
+	for _, u := range s.Categories {
+		if !u.Exists {
+			fmt.Fprintf(w, "[%s] no files yet\n", u.Name)
+			continue
+		}
+		free := "unknown"
+		if u.FreeBytes >= 0 {
+			free = humanize.Bytes(uint64(u.FreeBytes))
+		}
+		fmt.Fprintf(w, "[%s] %s used, %s free\n", u.Name, humanize.Bytes(uint64(u.UsedBytes)), free)
+	}
+}
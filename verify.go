@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"lamp/internal/config"
+	"lamp/internal/downloader"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// runVerifyAll re-hashes the downloaded file of every source matching filter and
+// compares it with the configured, published or sidecar checksum. It returns 1 if
+// any file is corrupted.
+func runVerifyAll(cfg *config.Config, filter checkFilter) int {
+	// The check finds which file on disk belongs to each source
+	progress := newCheckProgress("Checking")
+	entries := collectChecks(cfg, filter, progress.update)
+	progress.clear()
+
+	files := make([]downloader.ArchiveFile, len(entries))
+	for i, e := range entries {
+		target := cfg.GetTargetPath(e.Category, e.source)
+		files[i] = downloader.ArchiveFileFor(target, e.LocalFilename, e.Status, e.source.Checksum, e.Checksum)
+	}
+
+	progress = newCheckProgress("Verifying")
+	results := downloader.VerifyArchive(files, cfg.General.CheckConcurrency, progress.update)
+	progress.clear()
+
+	red := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	yellow := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	green := lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	gray := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+
+	counts := make(map[downloader.IntegrityStatus]int)
+	for i, r := range results {
+		e := entries[i]
+		counts[r.Status]++
+
+		style := gray
+		detail := r.Path
+		switch r.Status {
+		case downloader.IntegrityOK:
+			style = green
+			detail += fmt.Sprintf(" (%s checksum)", r.ChecksumFrom)
+		case downloader.IntegrityCorrupted:
+			style = red
+			detail += fmt.Sprintf(": %v", r.Err)
+		case downloader.IntegrityNoChecksum:
+			style = yellow
+		}
+		fmt.Printf("[%s] %-30s %s %s\n", e.Category, e.Name, style.Render(fmt.Sprintf("%-10s", r.Status)), gray.Render(detail))
+	}
+
+	fmt.Println("--------------------------------------------------")
+	fmt.Printf("%d OK, %d corrupted, %d without a checksum, %d missing\n",
+		counts[downloader.IntegrityOK], counts[downloader.IntegrityCorrupted],
+		counts[downloader.IntegrityNoChecksum], counts[downloader.IntegrityMissing])
+
+	if counts[downloader.IntegrityCorrupted] > 0 {
+		fmt.Fprintf(os.Stderr, "%d file(s) failed checksum verification\n", counts[downloader.IntegrityCorrupted])
+		return 1
+	}
+	return 0
+}